@@ -0,0 +1,132 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+)
+
+// eventAge is a person's age in whole years at an event
+type eventAge struct {
+	Years       int
+	Approximate bool // The birth or event date is partial or qualified ("abt.", "bef."), so the age may be off by a year
+}
+
+// String formats the age for display, e.g. "24" or "about 24"
+func (a eventAge) String() string {
+	if a.Approximate {
+		return fmt.Sprintf("about %d", a.Years)
+	}
+	return fmt.Sprintf("%d", a.Years)
+}
+
+// ageAt returns the age at date of a person born on birth. Without a month on either date
+// the age is the difference in years and approximate, as is an age on the birthday month
+// without both days. It reports false if either year is unknown or date is before birth.
+func ageAt(birth, date ancestry.ParsedDate) (eventAge, bool) {
+	if birth.Year == 0 || date.Year == 0 {
+		return eventAge{}, false
+	}
+
+	age := eventAge{
+		Years:       date.Year - birth.Year,
+		Approximate: birth.Qualifier != "" || date.Qualifier != "",
+	}
+	switch {
+	case birth.Month == 0 || date.Month == 0:
+		age.Approximate = true
+	case date.Month < birth.Month:
+		age.Years--
+	case date.Month == birth.Month && (birth.Day == 0 || date.Day == 0):
+		age.Approximate = true
+	case date.Month == birth.Month && date.Day < birth.Day:
+		age.Years--
+	}
+
+	if age.Years < 0 {
+		return eventAge{}, false
+	}
+	return age, true
+}
+
+// birthDate returns the parsed date of the person's first dated birth event
+func birthDate(events []ancestry.Event) (ancestry.ParsedDate, bool) {
+	for _, event := range events {
+		if event.Type != Birth {
+			continue
+		}
+		if date, err := ancestry.ParseGenealogyDate(event.Date); err == nil && date.Year != 0 {
+			return date, true
+		}
+	}
+	return ancestry.ParsedDate{}, false
+}
+
+// eventAgeAt returns the age at an event of a person born on birth; births themselves get none
+func eventAgeAt(birth ancestry.ParsedDate, eventType string, eventDate interface{}) (eventAge, bool) {
+	if eventType == Birth {
+		return eventAge{}, false
+	}
+	date, err := ancestry.ParseGenealogyDate(eventDate)
+	if err != nil {
+		return eventAge{}, false
+	}
+	return ageAt(birth, date)
+}
+
+// setAgeFields stores an age under key, with key+"Approximate" set when it is approximate
+func setAgeFields(m map[string]interface{}, key string, age eventAge) {
+	m[key] = age.Years
+	if age.Approximate {
+		m[key+"Approximate"] = true
+	} else {
+		delete(m, key+"Approximate")
+	}
+}
+
+// readableEventMaps returns the event maps of a readable person, whose events are a
+// []map[string]interface{} when built for export and a []interface{} when read back from people.json
+func readableEventMaps(readable map[string]interface{}) []map[string]interface{} {
+	switch events := readable["events"].(type) {
+	case []map[string]interface{}:
+		return events
+	case []interface{}:
+		maps := make([]map[string]interface{}, 0, len(events))
+		for _, item := range events {
+			if event, ok := item.(map[string]interface{}); ok {
+				maps = append(maps, event)
+			}
+		}
+		return maps
+	}
+	return nil
+}
+
+// addReadableAges adds ageAtEvent to each of a readable person's dated events after birth,
+// and ageAtDeath to the person, each with an ...Approximate flag for partial dates. Ages need
+// a dated birth event, so persons without one are left unchanged.
+func addReadableAges(readable map[string]interface{}) {
+	events := readableEventMaps(readable)
+	var birthEvents []ancestry.Event
+	for _, event := range events {
+		if eventType, _ := event["type"].(string); eventType == Birth {
+			birthEvents = append(birthEvents, ancestry.Event{Type: Birth, Date: event["date"]})
+		}
+	}
+	birth, ok := birthDate(birthEvents)
+	if !ok {
+		return
+	}
+
+	for _, event := range events {
+		eventType, _ := event["type"].(string)
+		age, ok := eventAgeAt(birth, eventType, event["date"])
+		if !ok {
+			continue
+		}
+		setAgeFields(event, "ageAtEvent", age)
+		if eventType == Death {
+			setAgeFields(readable, "ageAtDeath", age)
+		}
+	}
+}
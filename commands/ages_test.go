@@ -0,0 +1,103 @@
+package commands
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+)
+
+func TestAgeAt(t *testing.T) {
+	parse := func(raw string) ancestry.ParsedDate {
+		date, _ := ancestry.ParseGenealogyDate(raw)
+		return date
+	}
+	tests := []struct {
+		birth  string
+		date   string
+		want   eventAge
+		wantOK bool
+	}{
+		{"12 Mar 1850", "3 Feb 1920", eventAge{Years: 69}, true},
+		{"12 Mar 1850", "12 Mar 1920", eventAge{Years: 70}, true},
+		{"12 Mar 1850", "11 Mar 1875", eventAge{Years: 24}, true},
+		{"12 Mar 1850", "Mar 1875", eventAge{Years: 25, Approximate: true}, true},
+		{"12 Mar 1850", "1875", eventAge{Years: 25, Approximate: true}, true},
+		{"1850", "4 Jul 1875", eventAge{Years: 25, Approximate: true}, true},
+		{"Abt 1850", "4 Jul 1875", eventAge{Years: 25, Approximate: true}, true},
+		{"12 Mar 1850", "Bef 1900", eventAge{Years: 50, Approximate: true}, true},
+		{"12 Mar 1850", "1849", eventAge{}, false},
+		{"12 Mar 1850", "", eventAge{}, false},
+		{"", "1875", eventAge{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := ageAt(parse(tt.birth), parse(tt.date))
+		if ok != tt.wantOK || got != tt.want {
+			t.Errorf("ageAt(%q, %q) = %+v, %v; want %+v, %v", tt.birth, tt.date, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestEventAgeString(t *testing.T) {
+	if got := (eventAge{Years: 24}).String(); got != "24" {
+		t.Errorf("String() = %q, want %q", got, "24")
+	}
+	if got := (eventAge{Years: 24, Approximate: true}).String(); got != "about 24" {
+		t.Errorf("String() = %q, want %q", got, "about 24")
+	}
+}
+
+func TestConvertPersonToReadableFormatAddsAges(t *testing.T) {
+	person := ancestry.Person{
+		PID: "1",
+		Events: []ancestry.Event{
+			{Type: Birth, Date: "12 Mar 1850"},
+			{Type: "Marriage", Date: "1875"},
+			{Type: Death, Date: "3 Feb 1920"},
+			{Type: "Residence"},
+		},
+	}
+	readable := convertPersonToReadableFormat(person, nil, nil, nil)
+
+	events := readable["events"].([]map[string]interface{})
+	if _, ok := events[0]["ageAtEvent"]; ok {
+		t.Errorf("birth should have no age: %v", events[0])
+	}
+	if events[1]["ageAtEvent"] != 25 || events[1]["ageAtEventApproximate"] != true {
+		t.Errorf("unexpected marriage age: %v", events[1])
+	}
+	if events[2]["ageAtEvent"] != 69 || events[2]["ageAtEventApproximate"] != nil {
+		t.Errorf("unexpected death age: %v", events[2])
+	}
+	if _, ok := events[3]["ageAtEvent"]; ok {
+		t.Errorf("undated event should have no age: %v", events[3])
+	}
+	if readable["ageAtDeath"] != 69 {
+		t.Errorf("ageAtDeath = %v, want 69", readable["ageAtDeath"])
+	}
+}
+
+func TestAddReadableAgesToSavedEvents(t *testing.T) {
+	// Events read back from people.json, as retry-failed merges them
+	readable := map[string]interface{}{
+		"events": []interface{}{
+			map[string]interface{}{"type": Birth, "date": "1850"},
+			map[string]interface{}{"type": Death, "date": "1900", "ageAtEventApproximate": true},
+		},
+	}
+	addReadableAges(readable)
+
+	want := map[string]interface{}{"type": Death, "date": "1900", "ageAtEvent": 50, "ageAtEventApproximate": true}
+	if got := readable["events"].([]interface{})[1]; !reflect.DeepEqual(got, want) {
+		t.Errorf("death event = %v, want %v", got, want)
+	}
+	if readable["ageAtDeath"] != 50 || readable["ageAtDeathApproximate"] != true {
+		t.Errorf("unexpected age at death: %v", readable)
+	}
+
+	noBirth := map[string]interface{}{"events": []interface{}{map[string]interface{}{"type": Death, "date": "1900"}}}
+	addReadableAges(noBirth)
+	if _, ok := noBirth["ageAtDeath"]; ok {
+		t.Error("persons without a birth date should get no ages")
+	}
+}
@@ -0,0 +1,52 @@
+package commands
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+)
+
+// captureFilter selects the captured network requests worth showing
+type captureFilter struct {
+	URLContains string // Keep only URLs containing this, ignoring case; empty keeps all
+	APIOnly     bool   // Keep only JSON responses from Ancestry API paths
+}
+
+// isAncestryAPIRequest reports whether a request went to an Ancestry API path and got JSON back
+func isAncestryAPIRequest(req *ancestry.CapturedRequest) bool {
+	if !strings.HasPrefix(strings.ToLower(req.ContentType), "application/json") {
+		return false
+	}
+	u, err := url.Parse(req.URL)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(u.Hostname())
+	if host != "ancestry.com" && !strings.HasSuffix(host, ".ancestry.com") {
+		return false
+	}
+	return strings.Contains(strings.ToLower(u.Path), "/api/")
+}
+
+// matches reports whether a captured request passes the filter
+func (f captureFilter) matches(req *ancestry.CapturedRequest) bool {
+	if f.URLContains != "" && !strings.Contains(strings.ToLower(req.URL), strings.ToLower(f.URLContains)) {
+		return false
+	}
+	return !f.APIOnly || isAncestryAPIRequest(req)
+}
+
+// apply returns the requests that pass the filter, in order
+func (f captureFilter) apply(requests []*ancestry.CapturedRequest) []*ancestry.CapturedRequest {
+	if f.URLContains == "" && !f.APIOnly {
+		return requests
+	}
+	var kept []*ancestry.CapturedRequest
+	for _, req := range requests {
+		if f.matches(req) {
+			kept = append(kept, req)
+		}
+	}
+	return kept
+}
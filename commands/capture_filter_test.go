@@ -0,0 +1,41 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+)
+
+func TestCaptureFilter(t *testing.T) {
+	familyView := &ancestry.CapturedRequest{URL: "https://www.ancestry.com/api/treeviewer/tree/newfamilyview/1", ContentType: "application/json; charset=utf-8"}
+	script := &ancestry.CapturedRequest{URL: "https://www.ancestry.com/static/app.js", ContentType: "application/javascript"}
+	page := &ancestry.CapturedRequest{URL: "https://www.ancestry.com/family-tree/person/tree/1/person/2/facts", ContentType: "text/html"}
+	tracker := &ancestry.CapturedRequest{URL: "https://tracker.example.com/api/collect", ContentType: "application/json"}
+	htmlAPI := &ancestry.CapturedRequest{URL: "https://ancestry.com/api/error", ContentType: "text/html"}
+	requests := []*ancestry.CapturedRequest{familyView, script, page, tracker, htmlAPI}
+
+	tests := []struct {
+		name   string
+		filter captureFilter
+		want   []*ancestry.CapturedRequest
+	}{
+		{"no filter", captureFilter{}, requests},
+		{"substring ignores case", captureFilter{URLContains: "FamilyView"}, []*ancestry.CapturedRequest{familyView}},
+		{"api only", captureFilter{APIOnly: true}, []*ancestry.CapturedRequest{familyView}},
+		{"substring and api only", captureFilter{URLContains: "facts", APIOnly: true}, nil},
+		{"substring matching several", captureFilter{URLContains: "ancestry.com"}, []*ancestry.CapturedRequest{familyView, script, page, htmlAPI}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.filter.apply(requests)
+			if len(got) != len(tt.want) {
+				t.Fatalf("apply() kept %d requests, want %d", len(got), len(tt.want))
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("apply()[%d] = %s, want %s", i, got[i].URL, tt.want[i].URL)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package commands
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// gzipExtension is appended to the names of JSON files written with --compress
+const gzipExtension = ".gz"
+
+// jsonFileName returns the name a JSON export file is written under, name.gz when compressed
+func jsonFileName(name string, compress bool) string {
+	if compress {
+		return name + gzipExtension
+	}
+	return name
+}
+
+// writeJSONFile writes v as indented JSON to name in outputDir, or gzipped to name.gz when
+// compress is set. The other form is removed so later reads never pick up a stale copy.
+// The file is replaced atomically, so an interrupted write leaves the previous one intact.
+func writeJSONFile(outputDir, name string, v interface{}, compress bool) error {
+	path := filepath.Join(outputDir, jsonFileName(name, compress))
+	if compress {
+		if err := writeFileAtomic(path, func(w io.Writer) error { return writeGzipJSON(w, v) }); err != nil {
+			return err
+		}
+	} else {
+		data, err := json.MarshalIndent(v, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal: %w", err)
+		}
+		if err := writeFileAtomic(path, func(w io.Writer) error {
+			_, err := w.Write(data)
+			return err
+		}); err != nil {
+			return err
+		}
+	}
+
+	stale := filepath.Join(outputDir, jsonFileName(name, !compress))
+	if err := os.Remove(stale); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to remove stale %s: %w", filepath.Base(stale), err)
+	}
+	return nil
+}
+
+// writeGzipJSON streams v as indented JSON through gzip into w
+func writeGzipJSON(w io.Writer, v interface{}) error {
+	gz := gzip.NewWriter(w)
+	encoder := json.NewEncoder(gz)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		return fmt.Errorf("failed to encode: %w", err)
+	}
+	return gz.Close()
+}
+
+// writeFileAtomic writes a temporary file next to path with write and then renames it over
+// path, so readers see either the old file or the complete new one
+func writeFileAtomic(path string, write func(io.Writer) error) (err error) {
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = file.Close()
+			_ = os.Remove(file.Name())
+		}
+	}()
+
+	if err := write(file); err != nil {
+		return err
+	}
+	if err := file.Chmod(0644); err != nil {
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), path)
+}
+
+// readJSONFile reads name from outputDir, or decompresses name.gz when only the compressed form
+// exists. The error wraps os.ErrNotExist when neither does.
+func readJSONFile(outputDir, name string) ([]byte, error) {
+	data, err := os.ReadFile(filepath.Join(outputDir, name))
+	if !errors.Is(err, os.ErrNotExist) {
+		return data, err
+	}
+
+	file, gzErr := os.Open(filepath.Join(outputDir, name+gzipExtension))
+	if errors.Is(gzErr, os.ErrNotExist) {
+		return nil, err
+	}
+	if gzErr != nil {
+		return nil, gzErr
+	}
+	defer func() { _ = file.Close() }()
+
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s%s: %w", name, gzipExtension, err)
+	}
+	defer func() { _ = gz.Close() }()
+	return io.ReadAll(gz)
+}
+
+// jsonFileCompressed reports whether name in outputDir was written with --compress
+func jsonFileCompressed(outputDir, name string) bool {
+	_, err := os.Stat(filepath.Join(outputDir, name+gzipExtension))
+	return err == nil
+}
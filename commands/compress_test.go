@@ -0,0 +1,108 @@
+package commands
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteJSONFileCompressed(t *testing.T) {
+	dir := t.TempDir()
+	persons := []map[string]interface{}{{"personId": "1", "name": strings.Repeat("Smith ", 100)}}
+
+	if err := writeJSONFile(dir, "people.json", persons, false); err != nil {
+		t.Fatal(err)
+	}
+	plain, err := readJSONFile(dir, "people.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writeJSONFile(dir, "people.json", persons, true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "people.json")); !errors.Is(err, os.ErrNotExist) {
+		t.Error("compressed write should remove the stale people.json")
+	}
+	if !jsonFileCompressed(dir, "people.json") {
+		t.Error("jsonFileCompressed() = false after a compressed write")
+	}
+	info, err := os.Stat(filepath.Join(dir, "people.json.gz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Size() >= int64(len(plain)) {
+		t.Errorf("compressed size %d not smaller than %d", info.Size(), len(plain))
+	}
+
+	decompressed, err := readJSONFile(dir, "people.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(string(decompressed)) != strings.TrimSpace(string(plain)) {
+		t.Errorf("decompressed JSON differs:\n%s\nwant\n%s", decompressed, plain)
+	}
+
+	if err := writeJSONFile(dir, "people.json", persons, false); err != nil {
+		t.Fatal(err)
+	}
+	if jsonFileCompressed(dir, "people.json") {
+		t.Error("uncompressed write should remove the stale people.json.gz")
+	}
+}
+
+func TestWriteJSONFileKeepsOldFileOnFailure(t *testing.T) {
+	dir := t.TempDir()
+	if err := writeJSONFile(dir, "people.json", []string{"old"}, true); err != nil {
+		t.Fatal(err)
+	}
+
+	// A channel can't be encoded, so the write fails partway through the gzip stream
+	if err := writeJSONFile(dir, "people.json", map[string]interface{}{"persons": make(chan int)}, true); err == nil {
+		t.Fatal("writeJSONFile() succeeded with an unencodable value")
+	}
+	data, err := readJSONFile(dir, "people.json")
+	if err != nil || strings.TrimSpace(string(data)) != "[\n  \"old\"\n]" {
+		t.Errorf("people.json.gz = %q, %v after a failed write, want the old file", data, err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 1 {
+		t.Errorf("output directory has %d files after a failed write, want only people.json.gz", len(entries))
+	}
+}
+
+func TestReadJSONFileMissing(t *testing.T) {
+	if _, err := readJSONFile(t.TempDir(), "people.json"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("readJSONFile() error = %v, want os.ErrNotExist", err)
+	}
+}
+
+func TestLoadSavedExportCompressed(t *testing.T) {
+	dir := t.TempDir()
+	for name, v := range map[string]interface{}{
+		"metadata.json":    map[string]interface{}{"treeId": "t1"},
+		"people.json":      []map[string]interface{}{{"personId": "1"}},
+		"media-index.json": map[string]PersonMediaInfo{"1": {PersonID: "1"}},
+	} {
+		if err := writeJSONFile(dir, name, v, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	export, err := loadSavedExport(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !export.Compressed || export.Metadata["treeId"] != "t1" || len(export.Persons) != 1 || len(export.MediaIndex) != 1 {
+		t.Errorf("unexpected export %+v", export)
+	}
+
+	if err := export.save(dir, ThemeLight); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "people.json")); !errors.Is(err, os.ErrNotExist) {
+		t.Error("saving a compressed export should keep it compressed")
+	}
+}
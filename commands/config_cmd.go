@@ -3,16 +3,20 @@ package commands
 import (
 	"fmt"
 
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
 	"github.com/chrisrob11/ancestrydl/pkg/config"
 	"github.com/urfave/cli/v2"
 )
 
 // SetDefaultTree sets the default tree ID in config
 func SetDefaultTree(c *cli.Context) error {
-	treeID := c.Args().First()
-	if treeID == "" {
+	if c.Args().First() == "" {
 		return fmt.Errorf("tree ID is required\n\nUsage: ancestrydl config set-default-tree <tree-id>")
 	}
+	treeID, err := ancestry.NormalizeTreeID(c.Args().First())
+	if err != nil {
+		return err
+	}
 
 	if err := config.SetDefaultTreeID(treeID); err != nil {
 		return fmt.Errorf("failed to set default tree: %w", err)
@@ -0,0 +1,86 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+	"github.com/urfave/cli/v2"
+)
+
+// activityFileName is the file download-activity writes the activity feed to
+const activityFileName = "activity.json"
+
+// treeActivityExport is the content of activity.json
+type treeActivityExport struct {
+	TreeID     string                  `json:"treeId"`
+	ExportDate string                  `json:"exportDate"`
+	Activity   []ancestry.TreeActivity `json:"activity"`
+}
+
+// countActivityByType counts activity entries per change type, as "type (n)" sorted by type.
+// Entries without a change type are counted as "other".
+func countActivityByType(activity []ancestry.TreeActivity) []string {
+	counts := make(map[string]int)
+	for _, entry := range activity {
+		changeType := entry.ChangeType
+		if changeType == "" {
+			changeType = "other"
+		}
+		counts[changeType]++
+	}
+
+	lines := make([]string, 0, len(counts))
+	for changeType, n := range counts {
+		lines = append(lines, fmt.Sprintf("%s (%d)", changeType, n))
+	}
+	sort.Strings(lines)
+	return lines
+}
+
+// DownloadActivity saves a tree's activity feed, who changed what and when, to activity.json
+func DownloadActivity(c *cli.Context) error {
+	treeID, err := getTreeIDArgOrDefault(c, fmt.Errorf("tree ID is required\n\nUsage: ancestrydl download-activity <tree-id> [--output <directory>]"))
+	if err != nil {
+		return err
+	}
+
+	outputDir := c.String("output")
+	logger := loggerFrom(c)
+
+	logger.Println("1. Creating API client...")
+	apiClient, err := createAPIClientFromStoredCookies(c)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := apiClient.Close(); err != nil {
+			logger.Printf("Error closing API client: %v\n", err)
+		}
+	}()
+
+	logger.Println("2. Fetching tree activity...")
+	activity, err := apiClient.GetTreeActivity(c.Context, treeID)
+	if err != nil {
+		return treeAccessError(treeID, err)
+	}
+	logger.Printf("   ✓ Found %d change(s)\n", len(activity))
+	for _, line := range countActivityByType(activity) {
+		logger.Printf("     %s\n", line)
+	}
+
+	logger.Println("3. Saving activity...")
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	export := treeActivityExport{TreeID: treeID, ExportDate: time.Now().Format(time.RFC3339), Activity: activity}
+	if err := writeJSONFile(outputDir, activityFileName, export, false); err != nil {
+		return fmt.Errorf("failed to save %s: %w", activityFileName, err)
+	}
+
+	logger.Printf("\n✅ Activity saved to %s\n", filepath.Join(outputDir, activityFileName))
+	return nil
+}
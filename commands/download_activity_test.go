@@ -0,0 +1,24 @@
+package commands
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+)
+
+func TestCountActivityByType(t *testing.T) {
+	activity := []ancestry.TreeActivity{
+		{ChangeType: "PersonAdded"},
+		{ChangeType: "FactEdited"},
+		{ChangeType: "PersonAdded"},
+		{Description: "Something changed"},
+	}
+	want := []string{"FactEdited (1)", "PersonAdded (2)", "other (1)"}
+	if got := countActivityByType(activity); !reflect.DeepEqual(got, want) {
+		t.Errorf("countActivityByType() = %v, want %v", got, want)
+	}
+	if got := countActivityByType(nil); len(got) != 0 {
+		t.Errorf("countActivityByType(nil) = %v, want none", got)
+	}
+}
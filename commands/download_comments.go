@@ -0,0 +1,122 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+	"github.com/urfave/cli/v2"
+)
+
+// commentsFileName is the file download-comments writes the notes to
+const commentsFileName = "comments.json"
+
+// notesFetcher fetches the notes of persons, batchSize per request (see ancestry.GetNotesForPersons)
+type notesFetcher func(ctx context.Context, treeID string, personIDs []string, batchSize int) (map[string][]ancestry.PersonNote, error)
+
+// fetchNotesInBatches fetches the notes of persons batchSize at a time, so long ID lists are
+// split across requests with short URLs. A failed batch is reported to logger and skipped,
+// losing only the notes of its persons.
+func fetchNotesInBatches(ctx context.Context, fetch notesFetcher, treeID string, personIDs []string, batchSize int,
+	logger *log.Logger) map[string][]ancestry.PersonNote {
+	notes := make(map[string][]ancestry.PersonNote)
+	for start := 0; start < len(personIDs); start += batchSize {
+		end := start + batchSize
+		if end > len(personIDs) {
+			end = len(personIDs)
+		}
+		batch, err := fetch(ctx, treeID, personIDs[start:end], batchSize)
+		if err != nil {
+			logger.Printf("   [Warning] Failed to get notes for persons %d-%d of %d: %v\n", start+1, end, len(personIDs), err)
+			continue
+		}
+		for personID, personNotes := range batch {
+			notes[personID] = personNotes
+		}
+	}
+	return notes
+}
+
+// personComments is one person's notes in comments.json
+type personComments struct {
+	PersonID   string                `json:"personId"`
+	PersonName string                `json:"personName"`
+	Notes      []ancestry.PersonNote `json:"notes"`
+}
+
+// commentsExport is the content of comments.json
+type commentsExport struct {
+	TreeID     string           `json:"treeId"`
+	ExportDate string           `json:"exportDate"`
+	Persons    []personComments `json:"persons"`
+}
+
+// collectPersonComments lists the persons that have notes, in tree order
+func collectPersonComments(persons []ancestry.Person, notes map[string][]ancestry.PersonNote) []personComments {
+	var commented []personComments
+	for i := range persons {
+		personID := persons[i].GetPersonID()
+		if personNotes, ok := notes[personID]; ok {
+			commented = append(commented, personComments{PersonID: personID, PersonName: persons[i].GetDisplayName(), Notes: personNotes})
+		}
+	}
+	return commented
+}
+
+// DownloadComments saves the notes and comments attached to every person in a tree to
+// comments.json, fetching them --batch-size persons per request
+func DownloadComments(c *cli.Context) error {
+	treeID, err := getTreeIDArgOrDefault(c, fmt.Errorf("tree ID is required\n\nUsage: ancestrydl download-comments <tree-id> [--output <directory>]"))
+	if err != nil {
+		return err
+	}
+	pageSize, err := parsePageSize(c)
+	if err != nil {
+		return err
+	}
+	batchSize := c.Int("batch-size")
+	if batchSize < 1 {
+		return fmt.Errorf("--batch-size must be at least 1, got %d", batchSize)
+	}
+	outputDir := c.String("output")
+	logger := loggerFrom(c)
+
+	apiClient, err := createAPIClientFromStoredCookies(c)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := apiClient.Close(); err != nil {
+			logger.Printf("Error closing API client: %v\n", err)
+		}
+	}()
+
+	persons, err := fetchTreePersons(c.Context, apiClient, treeID, pageSize, personCacheFromFlags(c, treeID, nil), logger)
+	if err != nil {
+		return treeAccessError(treeID, err)
+	}
+
+	logger.Printf("3. Fetching notes, %d persons per request...\n", batchSize)
+	personIDs := make([]string, len(persons))
+	for i := range persons {
+		personIDs[i] = persons[i].GetPersonID()
+	}
+	commented := collectPersonComments(persons, fetchNotesInBatches(c.Context, apiClient.GetNotesForPersons, treeID, personIDs, batchSize, logger))
+	logger.Printf("   ✓ %d of %d persons have notes\n", len(commented), len(persons))
+
+	logger.Println("4. Saving notes...")
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return fmt.Errorf("failed to create output directory: %w", err)
+	}
+	export := commentsExport{TreeID: treeID, ExportDate: time.Now().Format(time.RFC3339), Persons: commented}
+	if err := writeJSONFile(outputDir, commentsFileName, export, false); err != nil {
+		return fmt.Errorf("failed to save %s: %w", commentsFileName, err)
+	}
+
+	logger.Printf("\n✅ Notes saved to %s\n", filepath.Join(outputDir, commentsFileName))
+	return nil
+}
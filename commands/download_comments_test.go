@@ -0,0 +1,53 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+)
+
+func TestFetchNotesInBatches(t *testing.T) {
+	var batches [][]string
+	fetch := func(_ context.Context, _ string, personIDs []string, _ int) (map[string][]ancestry.PersonNote, error) {
+		batches = append(batches, append([]string(nil), personIDs...))
+		if personIDs[0] == "3" {
+			return nil, errors.New("timeout")
+		}
+		notes := make(map[string][]ancestry.PersonNote)
+		for _, id := range personIDs {
+			notes[id] = []ancestry.PersonNote{{Text: "note " + id}}
+		}
+		return notes, nil
+	}
+
+	notes := fetchNotesInBatches(context.Background(), fetch, "t1", []string{"1", "2", "3", "4", "5"}, 2, defaultLogger)
+
+	wantBatches := [][]string{{"1", "2"}, {"3", "4"}, {"5"}}
+	if !reflect.DeepEqual(batches, wantBatches) {
+		t.Errorf("batches = %v, want %v", batches, wantBatches)
+	}
+	for _, id := range []string{"1", "2", "5"} {
+		if len(notes[id]) != 1 {
+			t.Errorf("notes for %s = %v, want one note", id, notes[id])
+		}
+	}
+	if _, ok := notes["3"]; ok {
+		t.Error("notes of a failed batch should be skipped")
+	}
+}
+
+func TestCollectPersonComments(t *testing.T) {
+	persons := []ancestry.Person{
+		{PID: "1", GivenName: "Ada", Surname: "Smith"},
+		{PID: "2", GivenName: "Bo"},
+	}
+	notes := map[string][]ancestry.PersonNote{"1": {{Text: "Check the census"}}}
+
+	want := []personComments{{PersonID: "1", PersonName: "Ada Smith", Notes: notes["1"]}}
+	if got := collectPersonComments(persons, notes); !reflect.DeepEqual(got, want) {
+		t.Errorf("collectPersonComments() = %+v, want %+v", got, want)
+	}
+}
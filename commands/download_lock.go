@@ -0,0 +1,77 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// downloadLockFile is created in the output directory while a download is running
+const downloadLockFile = ".ancestrydl.lock"
+
+// acquireDownloadLock creates the lock file holding this process's PID in outputDir,
+// so two downloads into the same directory don't overwrite each other's files.
+// A lock left behind by a process that is no longer running is replaced; a lock held
+// by a running process is only replaced when force is set. The returned function
+// removes the lock. Replacing or failing to remove a lock is reported to logger.
+func acquireDownloadLock(outputDir string, force bool, logger *log.Logger) (func(), error) {
+	if err := os.MkdirAll(outputDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create output directory: %w", err)
+	}
+
+	lockPath := filepath.Join(outputDir, downloadLockFile)
+	for attempt := 0; attempt < 2; attempt++ {
+		file, err := os.OpenFile(lockPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+		if err == nil {
+			_, writeErr := fmt.Fprintf(file, "%d\n", os.Getpid())
+			if closeErr := file.Close(); writeErr == nil {
+				writeErr = closeErr
+			}
+			if writeErr != nil {
+				_ = os.Remove(lockPath)
+				return nil, fmt.Errorf("failed to write lock file: %w", writeErr)
+			}
+			return func() {
+				if err := os.Remove(lockPath); err != nil {
+					logger.Printf("Error removing lock file: %v\n", err)
+				}
+			}, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("failed to create lock file: %w", err)
+		}
+
+		pid := readLockPID(lockPath)
+		if pid > 0 && processAlive(pid) {
+			if !force {
+				return nil, fmt.Errorf("another download is in progress in %s (PID %d)\n\n"+
+					"If that download is no longer running, re-run with --force-unlock", outputDir, pid)
+			}
+			logger.Printf("Removing lock file held by PID %d (--force-unlock)\n", pid)
+		} else {
+			logger.Printf("Removing stale lock file %s\n", lockPath)
+		}
+		if err := os.Remove(lockPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("failed to remove stale lock file: %w", err)
+		}
+	}
+
+	return nil, fmt.Errorf("failed to acquire lock file %s", lockPath)
+}
+
+// readLockPID returns the PID stored in a lock file, or 0 if it can't be read
+func readLockPID(lockPath string) int {
+	data, err := os.ReadFile(lockPath)
+	if err != nil {
+		return 0
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0
+	}
+	return pid
+}
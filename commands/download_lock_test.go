@@ -0,0 +1,53 @@
+package commands
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestAcquireDownloadLock(t *testing.T) {
+	dir := t.TempDir()
+
+	if _, err := acquireDownloadLock(dir, false, defaultLogger); err != nil {
+		t.Fatalf("first lock: %v", err)
+	}
+
+	if _, err := acquireDownloadLock(dir, false, defaultLogger); err == nil {
+		t.Fatal("second lock succeeded while the first is held")
+	}
+
+	// The forced lock takes over the first one, so only it is released
+	releaseForced, err := acquireDownloadLock(dir, true, defaultLogger)
+	if err != nil {
+		t.Fatalf("forced lock: %v", err)
+	}
+	releaseForced()
+
+	if _, err := os.Stat(filepath.Join(dir, downloadLockFile)); !os.IsNotExist(err) {
+		t.Errorf("lock file still exists after release: %v", err)
+	}
+}
+
+func TestAcquireDownloadLockStale(t *testing.T) {
+	dir := t.TempDir()
+
+	// A PID far above any real pid_max stands in for a process that has exited
+	if err := os.WriteFile(filepath.Join(dir, downloadLockFile), []byte("999999999\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var logged bytes.Buffer
+	release, err := acquireDownloadLock(dir, false, log.New(&logged, "", 0))
+	if err != nil {
+		t.Fatalf("stale lock was not replaced: %v", err)
+	}
+	release()
+
+	if !strings.Contains(logged.String(), "Removing stale lock file") {
+		t.Errorf("logged %q, want the stale lock reported to the logger", logged.String())
+	}
+}
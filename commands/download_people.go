@@ -0,0 +1,227 @@
+package commands
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+	"github.com/urfave/cli/v2"
+)
+
+// readPersonIDs collects person IDs from the --id flags and the --ids-file file, normalized
+// with ancestry.NormalizePersonID. The file holds one ID per line; blank lines and lines
+// starting with # are ignored.
+func readPersonIDs(c *cli.Context) ([]string, error) {
+	var ids []string
+	var invalid error
+	seen := make(map[string]bool)
+	addID := func(id string) {
+		id = strings.TrimSpace(id)
+		if id == "" || strings.HasPrefix(id, "#") {
+			return
+		}
+		id, err := ancestry.NormalizePersonID(id)
+		if err != nil {
+			if invalid == nil {
+				invalid = err
+			}
+			return
+		}
+		if !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+
+	for _, id := range c.StringSlice("id") {
+		addID(id)
+	}
+
+	if idsFile := c.String("ids-file"); idsFile != "" {
+		file, err := os.Open(idsFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open IDs file: %w", err)
+		}
+		defer func() {
+			if err := file.Close(); err != nil {
+				loggerFrom(c).Printf("Error closing IDs file: %v\n", err)
+			}
+		}()
+
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			addID(scanner.Text())
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read IDs file: %w", err)
+		}
+	}
+
+	if invalid != nil {
+		return nil, invalid
+	}
+	return ids, nil
+}
+
+// findFamilyViewPerson finds the person in a family view response matching the given person number
+func findFamilyViewPerson(familyView *ancestry.FamilyViewResponse, personNumber string) (ancestry.Person, bool) {
+	for _, person := range familyView.Persons {
+		if person.GetShortPersonID() == personNumber {
+			return person, true
+		}
+	}
+	return ancestry.Person{}, false
+}
+
+// fetchPersonsByID fetches the given persons and their immediate relationships from the family view API.
+// It returns the persons found, their relationships, and the IDs that could not be fetched.
+// Progress goes to logger.
+func fetchPersonsByID(ctx context.Context, apiClient *ancestry.APIClient, treeID string, personIDs []string, logger *log.Logger) ([]ancestry.Person, map[string]PersonRelationship, []string) {
+	persons := make([]ancestry.Person, 0, len(personIDs))
+	relationships := make(map[string]PersonRelationship)
+	var failed []string
+
+	for i, id := range personIDs {
+		personNumber := extractPersonNumber(id)
+		logger.Printf("   Fetching person %d/%d (ID: %s)...\n", i+1, len(personIDs), personNumber)
+
+		familyView, err := apiClient.GetFamilyView(ctx, treeID, personNumber, 1, 1)
+		if err != nil {
+			logger.Printf("   [Warning] Failed to get family view for %s: %v\n", id, err)
+			failed = append(failed, id)
+			continue
+		}
+
+		person, found := findFamilyViewPerson(familyView, personNumber)
+		if !found {
+			logger.Printf("   [Warning] Person %s not found in tree %s\n", id, treeID)
+			failed = append(failed, id)
+			continue
+		}
+
+		fullID := person.GetPersonID()
+		if rel, events, ok := processFamilyView(fullID, familyView); ok {
+			relationships[fullID] = rel
+			if len(events) > 0 {
+				person.Events = events
+			}
+		}
+
+		persons = append(persons, person)
+	}
+
+	return persons, relationships, failed
+}
+
+// downloadPeopleOutputStep is the number of the first output step of download-people, after
+// creating the API client and its four fetch steps
+const downloadPeopleOutputStep = 6
+
+// DownloadPeople downloads a partial export containing only the specified persons
+func DownloadPeople(c *cli.Context) error {
+	if c.Args().First() == "" {
+		return fmt.Errorf("tree ID is required\n\nUsage: ancestrydl download-people <tree-id> --ids-file ids.txt")
+	}
+	treeID, err := ancestry.NormalizeTreeID(c.Args().First())
+	if err != nil {
+		return err
+	}
+
+	personIDs, err := readPersonIDs(c)
+	if err != nil {
+		return err
+	}
+	if len(personIDs) == 0 {
+		return fmt.Errorf("no person IDs given\n\nUse --id <person-id> (repeatable) or --ids-file <file>")
+	}
+
+	outputDir := c.String("output")
+	if outputDir == "" {
+		outputDir = fmt.Sprintf("./tree-%s-people", treeID)
+	}
+
+	// With --output -, status messages go to stderr and the JSON export to stdout
+	toStdout := isStdoutOutput(outputDir)
+	jsonOut, _ := commandOutput(c)
+	logger := loggerFrom(c)
+
+	verbose := c.Bool("verbose")
+
+	logger.Printf("Downloading %d person(s) from tree %s to: %s\n", len(personIDs), treeID, outputDir)
+	if verbose {
+		logger.Println("Verbose mode enabled: HTTP requests/responses will be logged to http_log.txt")
+	}
+	logger.Println()
+
+	apiClient, err := setupAPIClientForDownload(c)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := apiClient.Close(); err != nil {
+			logger.Printf("Error closing API client: %v\n", err)
+		}
+	}()
+
+	logger.Println("2. Fetching tree information...")
+	treeInfo := lookupTreeInfo(c.Context, apiClient.GetTreeInfo, apiClient.ListTrees, treeID, logger.Writer())
+
+	logger.Println("3. Fetching persons and relationships...")
+	persons, relationships, failed := fetchPersonsByID(c.Context, apiClient, treeID, personIDs, logger)
+	logger.Printf("   ✓ Fetched %d of %d persons\n", len(persons), len(personIDs))
+	if len(persons) == 0 {
+		return fmt.Errorf("none of the requested persons could be fetched")
+	}
+
+	logger.Println("4. Fetching complete event data from Facts pages...")
+	fetchFactsForAllPersons(c.Context, apiClient, treeID, persons, c.Int("concurrency"), c.Bool("human-delay"), nil, nil, nil, logger)
+	logger.Println("   ✓ Fetched complete event data")
+	if c.Bool("strip-html") {
+		stripEventDescriptionsHTML(persons)
+	}
+
+	logger.Println("5. Inferring event types from relationships...")
+	inferredCount := inferEventTypes(persons, relationships)
+	logger.Printf("   ✓ Inferred %d event types\n", inferredCount)
+
+	if toStdout {
+		printFailedPersonIDs(logger, failed)
+		treeExport := TreeExport{
+			TreeID:      treeID,
+			TreeName:    treeInfo.TreeName,
+			ExportDate:  time.Now().Format(time.RFC3339),
+			PersonCount: len(persons),
+			Persons:     persons,
+			TreeInfo:    treeInfo,
+		}
+		return writeTreeJSON(jsonOut, &treeExport, relationships)
+	}
+
+	opts := downloadTreeOptions{MediaConcurrency: DefaultMediaConcurrency, Log: logger, FirstOutputStep: downloadPeopleOutputStep}
+	counts, err := saveTreeOutput(c.Context, apiClient, treeID, outputDir, treeInfo, persons, relationships, opts)
+	if err != nil {
+		return err
+	}
+
+	printDownloadSummary(outputDir, counts, opts)
+	printFailedPersonIDs(logger, failed)
+
+	return nil
+}
+
+// printFailedPersonIDs lists the requested persons that could not be fetched
+func printFailedPersonIDs(logger *log.Logger, failed []string) {
+	if len(failed) == 0 {
+		return
+	}
+	logger.Printf("⚠️  %d person(s) could not be fetched:\n", len(failed))
+	for _, id := range failed {
+		logger.Printf("  • %s\n", id)
+	}
+	logger.Println()
+}
@@ -0,0 +1,306 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+)
+
+// progressFile records in the output directory which persons an unfinished download has
+// fetched, so the next download into the directory resumes instead of starting over. It is a
+// log of JSON lines: a progressHeader, then one progressEntry per person and phase.
+const progressFile = ".ancestrydl-progress.json"
+
+// progressSaveInterval is the number of persons recorded between saves of the progress file
+const progressSaveInterval = 25
+
+// progressHeader is the first line of the progress file
+type progressHeader struct {
+	TreeID string `json:"treeId"`
+}
+
+// progressEntry is a line of the progress file recording a phase completed for a person
+type progressEntry struct {
+	PersonID     string              `json:"personId"`
+	Relationship *PersonRelationship `json:"relationship,omitempty"`
+	Events       []ancestry.Event    `json:"events,omitempty"` // Family view events, recorded with Relationship
+	Facts        *progressFacts      `json:"facts,omitempty"`
+	Media        *PersonMediaInfo    `json:"media,omitempty"`
+	Records      *PersonRecordInfo   `json:"records,omitempty"`
+}
+
+// progressFacts is what a person's Facts page added: the person's events merged with it, and
+// the sources with record images, so the record image phase needn't fetch the page again
+type progressFacts struct {
+	Events  []ancestry.Event              `json:"events,omitempty"`
+	Sources []ancestry.PersonSourceDetail `json:"sources,omitempty"`
+}
+
+// progressPerson is what an unfinished download has fetched for one person
+type progressPerson struct {
+	Relationship *PersonRelationship
+	Events       []ancestry.Event
+	Facts        *progressFacts
+	Media        *PersonMediaInfo
+	Records      *PersonRecordInfo
+}
+
+// downloadProgress is the per-person completion state of a tree download, appended to
+// .ancestrydl-progress.json as persons complete the relationship, facts, media and record
+// image phases. Only what was recorded since the last save is written, however large the
+// tree. The events are kept with the relationships and facts, so a resumed person ends up
+// with the same events as one fetched in full. A nil *downloadProgress records nothing, so
+// downloads to stdout and tests need none.
+type downloadProgress struct {
+	treeID  string
+	persons map[string]*progressPerson
+	log     *log.Logger // Warnings about the progress file
+
+	mu      sync.Mutex
+	path    string
+	fresh   bool            // The file must be rewritten from its header on the next save
+	pending []progressEntry // Recorded since the last save
+}
+
+// loadDownloadProgress reads the progress an earlier download of the tree left in outputDir,
+// or starts recording afresh if there is none or it belongs to another tree. Problems with the
+// file that don't stop the download are reported to logger.
+func loadDownloadProgress(outputDir, treeID string, logger *log.Logger) (*downloadProgress, error) {
+	progress := &downloadProgress{
+		treeID:  treeID,
+		persons: make(map[string]*progressPerson),
+		log:     logger,
+		path:    filepath.Join(outputDir, progressFile),
+		fresh:   true,
+	}
+	file, err := os.Open(progress.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return progress, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", progressFile, err)
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			logger.Printf("   [Warning] Failed to close %s: %v\n", progressFile, err)
+		}
+	}()
+
+	decoder := json.NewDecoder(file)
+	var header progressHeader
+	if err := decoder.Decode(&header); err != nil {
+		logger.Printf("   [Warning] Ignoring unreadable %s, starting over: %v\n", progressFile, err)
+		return progress, nil
+	}
+	if header.TreeID != treeID {
+		logger.Printf("   [Warning] %s is for tree %s, starting over\n", progressFile, header.TreeID)
+		return progress, nil
+	}
+	var entries []progressEntry
+	for {
+		var entry progressEntry
+		err := decoder.Decode(&entry)
+		if errors.Is(err, io.EOF) {
+			progress.fresh = false
+			return progress, nil
+		}
+		if err != nil {
+			// A save cut short leaves a partial last line. The entries before it still count
+			// and are written again when the file starts over.
+			progress.pending = entries
+			return progress, nil
+		}
+		progress.apply(entry)
+		entries = append(entries, entry)
+	}
+}
+
+// apply adds an entry to the persons recorded. p.mu must be held or p not yet shared.
+func (p *downloadProgress) apply(entry progressEntry) {
+	recorded, ok := p.persons[entry.PersonID]
+	if !ok {
+		recorded = &progressPerson{}
+		p.persons[entry.PersonID] = recorded
+	}
+	if entry.Relationship != nil {
+		recorded.Relationship = entry.Relationship
+		recorded.Events = entry.Events
+	}
+	if entry.Facts != nil {
+		recorded.Facts = entry.Facts
+	}
+	if entry.Media != nil {
+		recorded.Media = entry.Media
+	}
+	if entry.Records != nil {
+		recorded.Records = entry.Records
+	}
+}
+
+// resumeDownload loads the progress of an unfinished download of the tree into outputDir,
+// saying how many persons it resumes from
+func resumeDownload(outputDir, treeID string, logger *log.Logger) (*downloadProgress, error) {
+	progress, err := loadDownloadProgress(outputDir, treeID, logger)
+	if err != nil {
+		return nil, err
+	}
+	if resumed := progress.resumed(); resumed > 0 {
+		logger.Printf("   Resuming an unfinished download: %d person(s) already fetched (delete %s to start over)\n",
+			resumed, progressFile)
+	}
+	return progress, nil
+}
+
+// checkInterrupted returns an error once ctx is done, such as after Ctrl+C, saving the
+// progress so far first so the download can be resumed
+func checkInterrupted(ctx context.Context, progress *downloadProgress) error {
+	if ctx.Err() == nil {
+		return nil
+	}
+	if progress == nil {
+		return errors.New("download interrupted")
+	}
+	progress.save()
+	return fmt.Errorf("download interrupted; progress was saved to %s, run the same command again to resume", progressFile)
+}
+
+// resumed returns the number of persons the loaded progress has anything recorded for
+func (p *downloadProgress) resumed() int {
+	if p == nil {
+		return 0
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.persons)
+}
+
+// person returns a copy of what was recorded for a person, and whether anything was
+func (p *downloadProgress) person(personID string) (progressPerson, bool) {
+	if p == nil {
+		return progressPerson{}, false
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	recorded, ok := p.persons[personID]
+	if !ok {
+		return progressPerson{}, false
+	}
+	return *recorded, true
+}
+
+// record records a phase completed for a person, saving every progressSaveInterval entries
+func (p *downloadProgress) record(entry progressEntry) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.apply(entry)
+	p.pending = append(p.pending, entry)
+	if len(p.pending) >= progressSaveInterval {
+		p.saveLocked()
+	}
+}
+
+// recordRelationship records a person's relationships and the events of their family view.
+// The events are copied, as later phases change them in place.
+func (p *downloadProgress) recordRelationship(personID string, rel PersonRelationship, events []ancestry.Event) {
+	p.record(progressEntry{PersonID: personID, Relationship: &rel, Events: append([]ancestry.Event(nil), events...)})
+}
+
+// recordFacts records a person's events once merged with their Facts page, copied likewise,
+// and the page's sources that have record images
+func (p *downloadProgress) recordFacts(personID string, events []ancestry.Event, sources []ancestry.PersonSourceDetail) {
+	facts := progressFacts{Events: append([]ancestry.Event(nil), events...)}
+	for _, source := range sources {
+		if source.RecordImageUrl != "" {
+			facts.Sources = append(facts.Sources, source)
+		}
+	}
+	p.record(progressEntry{PersonID: personID, Facts: &facts})
+}
+
+// recordMedia records a person's downloaded media
+func (p *downloadProgress) recordMedia(personID string, media PersonMediaInfo) {
+	p.record(progressEntry{PersonID: personID, Media: &media})
+}
+
+// recordRecords records a person's downloaded record images
+func (p *downloadProgress) recordRecords(personID string, records PersonRecordInfo) {
+	p.record(progressEntry{PersonID: personID, Records: &records})
+}
+
+// save writes the entries recorded since the last save, only warning if it can't
+func (p *downloadProgress) save() {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.saveLocked()
+}
+
+// saveLocked appends the pending entries to the progress file, first starting it over from
+// its header if it belongs to another tree or couldn't be read. p.mu must be held.
+func (p *downloadProgress) saveLocked() {
+	if !p.fresh && len(p.pending) == 0 {
+		return
+	}
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	if p.fresh {
+		if err := encoder.Encode(progressHeader{TreeID: p.treeID}); err != nil {
+			p.log.Printf("   [Warning] Failed to save %s: %v\n", progressFile, err)
+			return
+		}
+	}
+	for _, entry := range p.pending {
+		if err := encoder.Encode(entry); err != nil {
+			p.log.Printf("   [Warning] Failed to save %s: %v\n", progressFile, err)
+			return
+		}
+	}
+
+	flags := os.O_WRONLY | os.O_CREATE | os.O_APPEND
+	if p.fresh {
+		flags = os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+	}
+	if err := appendProgress(p.path, flags, buf.Bytes()); err != nil {
+		p.log.Printf("   [Warning] Failed to save %s: %v\n", progressFile, err)
+		return
+	}
+	p.fresh = false
+	p.pending = p.pending[:0]
+}
+
+// appendProgress writes data to the progress file opened with flags
+func appendProgress(path string, flags int, data []byte) error {
+	file, err := os.OpenFile(path, flags, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		_ = file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// remove deletes the progress file once the download has finished
+func (p *downloadProgress) remove() {
+	if p == nil {
+		return
+	}
+	if err := os.Remove(p.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		p.log.Printf("   [Warning] Failed to remove %s: %v\n", progressFile, err)
+	}
+}
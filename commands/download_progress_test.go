@@ -0,0 +1,229 @@
+package commands
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+)
+
+func TestDownloadProgressRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	progress, err := loadDownloadProgress(dir, "42", defaultLogger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rel := PersonRelationship{PersonID: "1:1030:42", Parents: []RelationshipReference{{PersonID: "2:1030:42", Name: "Mary"}}}
+	familyEvents := []ancestry.Event{{Type: "Birth", Description: "family view"}}
+	factsEvents := []ancestry.Event{familyEvents[0], {Type: "Residence", Place: "Hartford"}}
+	sources := []ancestry.PersonSourceDetail{{CitationId: "c1"}, {CitationId: "c2", RecordImageUrl: "https://example.com/c2.jpg"}}
+	media := PersonMediaInfo{PersonID: "1:1030:42", Files: []MediaFileInfo{{FilePath: "media/a.jpg"}}}
+	records := PersonRecordInfo{PersonID: "1:1030:42", Records: []RecordImageInfo{{FilePath: "media/records/c2.jpg"}}}
+	progress.recordRelationship("1:1030:42", rel, familyEvents)
+	progress.save()
+	progress.recordFacts("1:1030:42", factsEvents, sources)
+	progress.recordMedia("1:1030:42", media)
+	progress.recordRecords("1:1030:42", records)
+	progress.recordRelationship("2:1030:42", PersonRelationship{PersonID: "2:1030:42"}, nil)
+	progress.save()
+
+	loaded, err := loadDownloadProgress(dir, "42", defaultLogger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, ok := loaded.person("1:1030:42")
+	if !ok || !reflect.DeepEqual(*got.Relationship, rel) || !reflect.DeepEqual(*got.Media, media) ||
+		!reflect.DeepEqual(*got.Records, records) || !reflect.DeepEqual(got.Events, familyEvents) {
+		t.Errorf("loaded progress = %+v", got)
+	}
+	// Only the sources with record images are kept
+	if got.Facts == nil || !reflect.DeepEqual(got.Facts.Events, factsEvents) || !reflect.DeepEqual(got.Facts.Sources, sources[1:]) {
+		t.Errorf("loaded facts = %+v", got.Facts)
+	}
+	if loaded.resumed() != 2 {
+		t.Errorf("loaded %d persons, want 2", loaded.resumed())
+	}
+
+	if other, err := loadDownloadProgress(dir, "43", defaultLogger); err != nil || other.resumed() != 0 {
+		t.Errorf("progress of another tree was resumed: %v, %d persons", err, other.resumed())
+	}
+
+	loaded.remove()
+	if _, err := os.Stat(filepath.Join(dir, progressFile)); !os.IsNotExist(err) {
+		t.Errorf("%s not removed: %v", progressFile, err)
+	}
+}
+
+func TestDownloadProgressAppends(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, progressFile)
+	progress, _ := loadDownloadProgress(dir, "42", defaultLogger)
+	progress.recordRelationship("1:1030:42", PersonRelationship{PersonID: "1:1030:42"}, nil)
+	progress.save()
+	first, _ := os.ReadFile(path)
+
+	progress.recordRelationship("2:1030:42", PersonRelationship{PersonID: "2:1030:42"}, nil)
+	progress.save()
+	progress.save()
+	second, _ := os.ReadFile(path)
+
+	if !strings.HasPrefix(string(second), string(first)) {
+		t.Errorf("second save rewrote the file:\n%s\nthen\n%s", first, second)
+	}
+	if lines := strings.Count(string(second), "\n"); lines != 3 {
+		t.Errorf("progress file has %d lines, want a header and 2 entries:\n%s", lines, second)
+	}
+}
+
+func TestDownloadProgressPartialLastLine(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, progressFile)
+	data := `{"treeId":"42"}` + "\n" + `{"personId":"1:1030:42","relationship":{"personId":"1:1030:42"}}` + "\n" + `{"personId":"2:10`
+	if err := os.WriteFile(path, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	progress, err := loadDownloadProgress(dir, "42", defaultLogger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if progress.resumed() != 1 {
+		t.Fatalf("resumed %d persons, want 1", progress.resumed())
+	}
+	progress.recordRelationship("3:1030:42", PersonRelationship{PersonID: "3:1030:42"}, nil)
+	progress.save()
+
+	if loaded, _ := loadDownloadProgress(dir, "42", defaultLogger); loaded.resumed() != 2 {
+		t.Errorf("after saving, resumed %d persons, want 2", loaded.resumed())
+	}
+}
+
+func TestDownloadProgressSavesPeriodically(t *testing.T) {
+	dir := t.TempDir()
+	progress, _ := loadDownloadProgress(dir, "42", defaultLogger)
+	for i, person := range numberedPersons(progressSaveInterval) {
+		if _, err := os.Stat(filepath.Join(dir, progressFile)); i > 0 && !os.IsNotExist(err) {
+			t.Fatalf("saved after %d persons, want after %d", i, progressSaveInterval)
+		}
+		progress.recordRelationship(person.GetPersonID(), PersonRelationship{PersonID: person.GetPersonID()}, nil)
+	}
+	loaded, _ := loadDownloadProgress(dir, "42", defaultLogger)
+	if loaded.resumed() != progressSaveInterval {
+		t.Errorf("saved %d persons, want %d", loaded.resumed(), progressSaveInterval)
+	}
+}
+
+func TestCollectRelationshipsResumes(t *testing.T) {
+	persons, fetch := pedigreeFamilyViews(7, 1)
+	baseline, baselineEvents, _ := collectRelationships(context.Background(), persons, 1, 1, inBatches(fetch), nil, nil, defaultLogger)
+
+	progress, _ := loadDownloadProgress(t.TempDir(), "1", defaultLogger)
+	for _, person := range persons[:4] {
+		progress.recordRelationship(person.GetPersonID(), baseline[person.GetPersonID()], baselineEvents[person.GetPersonID()])
+	}
+	relationships, events, calls := collectRelationships(context.Background(), persons, 1, 1, inBatches(fetch), nil, progress, defaultLogger)
+
+	if calls != 3 {
+		t.Errorf("made %d calls, want 3", calls)
+	}
+	if !reflect.DeepEqual(relationships, baseline) {
+		t.Error("resumed relationships differ from a full download")
+	}
+	// The family view events of resumed persons come from the progress, not a new fetch
+	if len(baselineEvents) != 7 || !reflect.DeepEqual(events, baselineEvents) {
+		t.Errorf("resumed events = %v, want those of a full download, %v", events, baselineEvents)
+	}
+	if progress.resumed() != 7 {
+		t.Errorf("progress has %d persons, want 7", progress.resumed())
+	}
+}
+
+func TestMergeFactsResumes(t *testing.T) {
+	persons := numberedPersons(5)
+	progress, _ := loadDownloadProgress(t.TempDir(), "1", defaultLogger)
+	resumedEvents := []ancestry.Event{{Type: "Residence", Description: "recorded"}}
+	for _, person := range persons[:2] {
+		progress.recordFacts(person.GetPersonID(), resumedEvents, nil)
+	}
+
+	var fetched atomic.Int32
+	facts := descriptionFacts(0, len(persons))
+	mergeFactsForPersons(context.Background(), persons, 2, false, func(personID string) (*ancestry.ResearchData, error) {
+		fetched.Add(1)
+		data, err := facts(personID)
+		data.PersonSources = []ancestry.PersonSourceDetail{{CitationId: personID, RecordImageUrl: "https://example.com/" + personID}}
+		return data, err
+	}, nil, nil, progress, defaultLogger)
+
+	if fetched.Load() != 3 {
+		t.Errorf("fetched %d Facts pages, want 3", fetched.Load())
+	}
+	for i, person := range persons {
+		recorded, _ := progress.person(person.GetPersonID())
+		if i < 2 {
+			if !reflect.DeepEqual(person.Events, resumedEvents) {
+				t.Errorf("resumed person %d events = %+v, want the recorded ones", i, person.Events)
+			}
+			continue
+		}
+		if recorded.Facts == nil || !reflect.DeepEqual(recorded.Facts.Events, person.Events) || len(recorded.Facts.Sources) != 1 {
+			t.Errorf("person %d facts recorded as %+v, want its events and source", i, recorded.Facts)
+		}
+	}
+}
+
+func TestDownloadRecordImagesResumes(t *testing.T) {
+	persons := numberedPersons(2)
+	progress, _ := loadDownloadProgress(t.TempDir(), "1", defaultLogger)
+	records := PersonRecordInfo{PersonID: persons[0].GetPersonID(), Records: []RecordImageInfo{{FilePath: "media/records/c1.jpg"}}}
+	progress.recordRecords(persons[0].GetPersonID(), records)
+	progress.recordFacts(persons[1].GetPersonID(), nil, nil)
+
+	// Neither person needs the API: the first has its record images, the second its sources
+	recordIndex, downloaded := downloadAllRecordImages(context.Background(), nil, "1", persons, t.TempDir(), nil, progress, defaultLogger)
+
+	if downloaded != 0 || !reflect.DeepEqual(recordIndex, map[string]PersonRecordInfo{records.PersonID: records}) {
+		t.Errorf("downloaded %d, record index = %+v, want only the recorded one", downloaded, recordIndex)
+	}
+	if recorded, _ := progress.person(persons[1].GetPersonID()); recorded.Records == nil {
+		t.Error("person without record images was not recorded as done")
+	}
+}
+
+func TestDownloadInterrupted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	persons, fetch := pedigreeFamilyViews(7, 1)
+	if _, _, calls := collectRelationships(ctx, persons, 1, 1, inBatches(fetch), nil, nil, defaultLogger); calls != 0 {
+		t.Errorf("made %d family view calls after interruption, want 0", calls)
+	}
+
+	var fetched atomic.Int32
+	failures := &failureLog{}
+	mergeFactsForPersons(ctx, numberedPersons(5), 2, false, func(personID string) (*ancestry.ResearchData, error) {
+		fetched.Add(1)
+		return nil, context.Canceled
+	}, failures, nil, nil, defaultLogger)
+	if fetched.Load() != 0 || len(failures.list()) != 0 {
+		t.Errorf("fetched %d Facts pages and recorded %d failures after interruption, want none", fetched.Load(), len(failures.list()))
+	}
+
+	if err := checkInterrupted(context.Background(), nil); err != nil {
+		t.Errorf("checkInterrupted() = %v before interruption", err)
+	}
+	dir := t.TempDir()
+	progress, _ := loadDownloadProgress(dir, "42", defaultLogger)
+	progress.recordRelationship("1:1030:42", PersonRelationship{PersonID: "1:1030:42"}, nil)
+	if err := checkInterrupted(ctx, progress); err == nil || !strings.Contains(err.Error(), "resume") {
+		t.Errorf("checkInterrupted() = %v, want an error saying how to resume", err)
+	}
+	if loaded, _ := loadDownloadProgress(dir, "42", defaultLogger); loaded.resumed() != 1 {
+		t.Error("progress was not saved on interruption")
+	}
+}
@@ -14,13 +14,19 @@ import (
 
 // DownloadRecord downloads a specific record for a person in a tree.
 func DownloadRecord(c *cli.Context) error {
-	recordTreeID := c.String("tree-id")
-	recordpID := c.String("person-id")
 	sourceID := c.String("source-id")
 
-	if recordTreeID == "" || recordpID == "" {
+	if c.String("tree-id") == "" || c.String("person-id") == "" {
 		return cli.Exit("Error: tree-id and person-id are required", 1)
 	}
+	recordTreeID, err := ancestry.NormalizeTreeID(c.String("tree-id"))
+	if err != nil {
+		return cli.Exit("Error: "+err.Error(), 1)
+	}
+	recordpID, err := ancestry.NormalizePersonID(c.String("person-id"))
+	if err != nil {
+		return cli.Exit("Error: "+err.Error(), 1)
+	}
 
 	client, err := setupClient(c)
 	if err != nil {
@@ -29,8 +35,8 @@ func DownloadRecord(c *cli.Context) error {
 	defer func() { _ = client.Close() }()
 
 	// Always fetch person facts to get source details (databaseId, recordId)
-	_, _ = fmt.Fprintf(c.App.Writer, "Fetching facts for person %s in tree %s...\n", recordpID, recordTreeID)
-	researchData, err := client.GetPersonFactsFromHTML(recordTreeID, recordpID)
+	_, _ = fmt.Fprintf(c.App.ErrWriter, "Fetching facts for person %s in tree %s...\n", recordpID, recordTreeID)
+	researchData, err := client.GetPersonFactsFromHTML(c.Context, recordTreeID, recordpID)
 	if err != nil {
 		return cli.Exit(fmt.Sprintf("Error getting person facts: %v", err), 1)
 	}
@@ -51,11 +57,12 @@ func DownloadRecord(c *cli.Context) error {
 		return cli.Exit(err.Error(), 1)
 	}
 
+	owner := ancestry.MediaOwner{TreeID: recordTreeID, PersonID: recordpID}
 	if sourceID != "" {
-		return downloadSingleSource(c, client, personSourcesMap, sourceID, recordpID, mediaDir)
+		return downloadSingleSource(c, client, personSourcesMap, sourceID, owner, mediaDir)
 	}
 
-	return downloadAllSources(c, researchData, personSourcesMap, client, mediaDir)
+	return downloadAllSources(c, researchData, personSourcesMap, client, owner, mediaDir)
 }
 
 func setupClient(c *cli.Context) (*ancestry.APIClient, error) {
@@ -73,6 +80,10 @@ func setupClient(c *cli.Context) (*ancestry.APIClient, error) {
 	if err != nil {
 		return nil, cli.Exit(fmt.Sprintf("Error creating API client: %v", err), 1)
 	}
+	client.SetMediaTimeout(c.Duration("media-timeout"))
+	if err := configureAPIClient(c, client); err != nil {
+		return nil, cli.Exit(fmt.Sprintf("Error configuring API client: %v", err), 1)
+	}
 	return client, nil
 }
 
@@ -85,15 +96,16 @@ func createMediaDir(recordTreeID string) (string, error) {
 	return mediaDir, nil
 }
 
-func downloadSingleSource(c *cli.Context, client *ancestry.APIClient, personSourcesMap map[string]ancestry.PersonSourceDetail, sourceID, recordpID, mediaDir string) error {
+func downloadSingleSource(c *cli.Context, client *ancestry.APIClient, personSourcesMap map[string]ancestry.PersonSourceDetail, sourceID string,
+	owner ancestry.MediaOwner, mediaDir string) error {
 	psDetail, found := personSourcesMap[sourceID]
 	if !found {
-		return cli.Exit(fmt.Sprintf("Error: source %s not found in PersonSources for person %s", sourceID, recordpID), 1)
+		return cli.Exit(fmt.Sprintf("Error: source %s not found in PersonSources for person %s", sourceID, owner.PersonID), 1)
 	}
 
 	sourceData := createSourceData(psDetail)
 
-	localPath, _ := DownloadAndSaveRecordImage(c.App.Writer, c.App.ErrWriter, client, psDetail.RecordImageUrl, sourceID, mediaDir, "media")
+	localPath, _ := DownloadAndSaveRecordImage(c.Context, c.App.ErrWriter, c.App.ErrWriter, client, owner, psDetail, mediaDir, "media")
 	if localPath != "" {
 		sourceData.LocalMediaFilePath = localPath
 	}
@@ -107,7 +119,8 @@ func downloadSingleSource(c *cli.Context, client *ancestry.APIClient, personSour
 	return nil
 }
 
-func downloadAllSources(c *cli.Context, researchData *ancestry.ResearchData, personSourcesMap map[string]ancestry.PersonSourceDetail, client *ancestry.APIClient, mediaDir string) error {
+func downloadAllSources(c *cli.Context, researchData *ancestry.ResearchData, personSourcesMap map[string]ancestry.PersonSourceDetail, client *ancestry.APIClient,
+	owner ancestry.MediaOwner, mediaDir string) error {
 	var allSources []*ancestry.FactEditData
 	uniqueCitationIDs := make(map[string]bool)
 	totalMediaDownloaded := 0
@@ -121,7 +134,7 @@ func downloadAllSources(c *cli.Context, researchData *ancestry.ResearchData, per
 			}
 			uniqueCitationIDs[cid] = true
 
-			_, _ = fmt.Fprintf(c.App.Writer, "Downloading source %s...\n", cid)
+			_, _ = fmt.Fprintf(c.App.ErrWriter, "Downloading source %s...\n", cid)
 
 			psDetail, found := personSourcesMap[cid]
 			if !found {
@@ -132,7 +145,7 @@ func downloadAllSources(c *cli.Context, researchData *ancestry.ResearchData, per
 			sourceData := createSourceData(psDetail)
 
 			if psDetail.RecordImageUrl != "" {
-				localPath, err := DownloadAndSaveRecordImage(c.App.Writer, c.App.ErrWriter, client, psDetail.RecordImageUrl, cid, mediaDir, "media")
+				localPath, err := DownloadAndSaveRecordImage(c.Context, c.App.ErrWriter, c.App.ErrWriter, client, owner, psDetail, mediaDir, "media")
 				if err == nil && localPath != "" {
 					sourceData.LocalMediaFilePath = localPath
 					totalMediaDownloaded++
@@ -148,7 +161,7 @@ func downloadAllSources(c *cli.Context, researchData *ancestry.ResearchData, per
 		return cli.Exit(fmt.Sprintf("Error marshalling sources to JSON: %v", err), 1)
 	}
 	fmt.Println(string(jsonBytes))
-	_, _ = fmt.Fprintf(c.App.Writer, "✅ Downloaded %d media files to %s.\n", totalMediaDownloaded, mediaDir)
+	_, _ = fmt.Fprintf(c.App.ErrWriter, "✅ Downloaded %d media files to %s.\n", totalMediaDownloaded, mediaDir)
 	return nil
 }
 
@@ -1,9 +1,11 @@
 package commands
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
+	"log"
 	"os"
 	"path/filepath"
 	"strings"
@@ -14,12 +16,23 @@ import (
 
 const unknownPersonName = "Unknown"
 
+// sourceDownloadOptions holds the settings shared by each step of downloading a tree's sources
+type sourceDownloadOptions struct {
+	MediaDir         string // Where record images are saved
+	PeopleSourcesDir string // Where each person's citation index is saved
+	Verbose          bool
+	FullCitations    bool // Fetch each source's edit page for its full citation and repository details
+}
+
 // DownloadSources downloads all source records for all people in a tree
 func DownloadSources(c *cli.Context) error {
-	treeID := c.Args().First()
-	if treeID == "" {
+	if c.Args().First() == "" {
 		return cli.Exit("Error: tree-id is required", 1)
 	}
+	treeID, err := ancestry.NormalizeTreeID(c.Args().First())
+	if err != nil {
+		return cli.Exit("Error: "+err.Error(), 1)
+	}
 
 	outputBaseDir := c.String("output")
 	if outputBaseDir == "" {
@@ -27,6 +40,10 @@ func DownloadSources(c *cli.Context) error {
 	}
 
 	verbose := c.Bool("verbose")
+	pageSize, err := parsePageSize(c)
+	if err != nil {
+		return err
+	}
 
 	fmt.Printf("Downloading sources for tree %s to: %s\n", treeID, outputBaseDir)
 	if verbose {
@@ -34,7 +51,7 @@ func DownloadSources(c *cli.Context) error {
 	}
 	fmt.Println()
 
-	apiClient, err := setupAPIClientForDownload(verbose)
+	apiClient, err := setupAPIClientForDownload(c)
 	if err != nil {
 		return err
 	}
@@ -44,7 +61,7 @@ func DownloadSources(c *cli.Context) error {
 		}
 	}()
 
-	allPersons, err := fetchTreePersons(apiClient, treeID)
+	allPersons, err := fetchTreePersons(c.Context, apiClient, treeID, pageSize, personCacheFromFlags(c, treeID, nil), loggerFrom(c))
 	if err != nil {
 		return err
 	}
@@ -59,8 +76,22 @@ func DownloadSources(c *cli.Context) error {
 	downloadedSources := make(map[string]*ancestry.FactEditData)
 	peopleWithSources := 0
 
+	opts := sourceDownloadOptions{
+		MediaDir:         mediaDir,
+		PeopleSourcesDir: peopleSourcesDir,
+		Verbose:          verbose,
+		FullCitations:    c.Bool("full-citations"),
+	}
+
 	fmt.Println("3. Collecting sources for each person...")
-	peopleWithSources = processAllPersons(apiClient, treeID, allPersons, downloadedSources, mediaDir, peopleSourcesDir, verbose)
+	peopleWithSources = processAllPersons(c.Context, apiClient, treeID, allPersons, downloadedSources, opts)
+
+	if opts.FullCitations {
+		fmt.Println("4. Saving repositories...")
+		if err := saveRepositories(outputBaseDir, downloadedSources); err != nil {
+			fmt.Printf("   [Error] Failed to save %s: %v\n", repositoriesFileName, err)
+		}
+	}
 
 	fmt.Println("5. Saving unique source data files...")
 	sourcesSavedCount, totalMediaDownloaded := saveDownloadedSources(downloadedSources, sourcesDir)
@@ -70,21 +101,31 @@ func DownloadSources(c *cli.Context) error {
 	return nil
 }
 
-func fetchTreePersons(apiClient *ancestry.APIClient, treeID string) ([]ancestry.Person, error) {
+// fetchTreePersons gets the tree's person list from cache or, failing that, fetches it,
+// reporting progress to logger
+func fetchTreePersons(ctx context.Context, apiClient *ancestry.APIClient, treeID string, pageSize int, cache personCache,
+	logger *log.Logger) ([]ancestry.Person, error) {
+	return cache.persons(treeID, logger.Writer(), func() ([]ancestry.Person, error) {
+		return fetchTreePersonList(ctx, apiClient, treeID, pageSize, logger)
+	})
+}
+
+// fetchTreePersonList fetches the tree's person count and then all its persons
+func fetchTreePersonList(ctx context.Context, apiClient *ancestry.APIClient, treeID string, pageSize int, logger *log.Logger) ([]ancestry.Person, error) {
 	// 1. Get all people
-	fmt.Println("1. Getting person count...")
-	totalCount, err := apiClient.GetPersonsCount(treeID)
+	logger.Println("1. Getting person count...")
+	totalCount, err := apiClient.GetPersonsCount(ctx, treeID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get person count: %w", err)
 	}
-	fmt.Printf("   ✓ Tree has %d persons\n", totalCount)
+	logger.Printf("   ✓ Tree has %d persons\n", totalCount)
 
-	fmt.Println("2. Fetching list of people...")
-	allPersons, err := downloadAllPersons(apiClient, treeID, totalCount)
+	logger.Println("2. Fetching list of people...")
+	allPersons, err := downloadAllPersons(ctx, apiClient, treeID, totalCount, pageSize, nil, logger)
 	if err != nil {
 		return nil, fmt.Errorf("failed to download person list: %w", err)
 	}
-	fmt.Printf("   ✓ Found %d persons\n", len(allPersons))
+	logger.Printf("   ✓ Found %d persons\n", len(allPersons))
 	return allPersons, nil
 }
 
@@ -104,7 +145,7 @@ func createSourceDirectories(outputBaseDir string) (string, string, string, erro
 	return sourcesDir, peopleSourcesDir, mediaDir, nil
 }
 
-func processAllPersons(apiClient *ancestry.APIClient, treeID string, allPersons []ancestry.Person, downloadedSources map[string]*ancestry.FactEditData, mediaDir, peopleSourcesDir string, verbose bool) int {
+func processAllPersons(ctx context.Context, apiClient *ancestry.APIClient, treeID string, allPersons []ancestry.Person, downloadedSources map[string]*ancestry.FactEditData, opts sourceDownloadOptions) int {
 	peopleWithSources := 0
 	for i, person := range allPersons {
 		// Log progress
@@ -116,7 +157,7 @@ func processAllPersons(apiClient *ancestry.APIClient, treeID string, allPersons
 			fmt.Printf("   Processing person %d/%d: %s...\n", i+1, len(allPersons), personName)
 		}
 
-		hasSources, err := processPersonForSources(apiClient, treeID, person, downloadedSources, mediaDir, peopleSourcesDir, verbose)
+		hasSources, err := processPersonForSources(ctx, apiClient, treeID, person, downloadedSources, opts)
 		if err != nil {
 			// Log error but continue
 			name := person.GetDisplayName()
@@ -142,7 +183,8 @@ func printSourceDownloadSummary(sourcesSavedCount, peopleWithSources, totalMedia
 	fmt.Printf("   Media files saved to: %s\n", mediaDir)
 }
 
-func processPersonForSources(apiClient *ancestry.APIClient, treeID string, person ancestry.Person, downloadedSources map[string]*ancestry.FactEditData, mediaDir, peopleSourcesDir string, verbose bool) (bool, error) {
+func processPersonForSources(ctx context.Context, apiClient *ancestry.APIClient, treeID string, person ancestry.Person, downloadedSources map[string]*ancestry.FactEditData, opts sourceDownloadOptions) (bool, error) {
+	verbose := opts.Verbose
 	personID := person.GetPersonID()
 	personName := person.GetDisplayName()
 	if personName == "" {
@@ -150,7 +192,7 @@ func processPersonForSources(apiClient *ancestry.APIClient, treeID string, perso
 	}
 
 	// Fetch facts for the person
-	researchData, err := apiClient.GetPersonFactsFromHTML(treeID, personID)
+	researchData, err := apiClient.GetPersonFactsFromHTML(ctx, treeID, personID)
 	if err != nil {
 		return false, fmt.Errorf("failed to get facts for %s (ID: %s): %w", personName, personID, err)
 	}
@@ -166,13 +208,14 @@ func processPersonForSources(apiClient *ancestry.APIClient, treeID string, perso
 		fmt.Printf("      Found %d facts for %s\n", len(researchData.PersonFacts), personName)
 	}
 
-	citationIDsForPerson := processFacts(researchData, downloadedSources, apiClient, mediaDir, verbose)
+	owner := ancestry.MediaOwner{TreeID: treeID, PersonID: personID}
+	citationIDsForPerson := processFacts(ctx, researchData, downloadedSources, apiClient, owner, opts)
 
 	if len(citationIDsForPerson) > 0 {
 		if verbose {
 			fmt.Printf("      Found %d citations for %s\n", len(citationIDsForPerson), personName)
 		}
-		savePersonSourceIndex(peopleSourcesDir, personName, personID, citationIDsForPerson)
+		savePersonSourceIndex(opts.PeopleSourcesDir, personName, personID, citationIDsForPerson)
 		return true, nil
 	} else if verbose {
 		fmt.Printf("      No citations found for %s\n", personName)
@@ -181,7 +224,8 @@ func processPersonForSources(apiClient *ancestry.APIClient, treeID string, perso
 	return false, nil
 }
 
-func processFacts(researchData *ancestry.ResearchData, downloadedSources map[string]*ancestry.FactEditData, apiClient *ancestry.APIClient, mediaDir string, verbose bool) []string {
+func processFacts(ctx context.Context, researchData *ancestry.ResearchData, downloadedSources map[string]*ancestry.FactEditData, apiClient *ancestry.APIClient,
+	owner ancestry.MediaOwner, opts sourceDownloadOptions) []string {
 	var citationIDsForPerson []string
 
 	uniqueCitationIDsForPerson := make(map[string]bool)
@@ -207,44 +251,76 @@ func processFacts(researchData *ancestry.ResearchData, downloadedSources map[str
 			citationIDsForPerson = append(citationIDsForPerson, cid)
 
 			if _, ok := downloadedSources[cid]; !ok {
-				sourceData := downloadSource(apiClient, personSourcesMap, cid, mediaDir, verbose)
+				sourceData := downloadSource(ctx, apiClient, personSourcesMap, owner, cid, opts)
 				if sourceData != nil {
 					downloadedSources[cid] = sourceData
 				}
 			}
 		}
 	}
+	linkSupportedFacts(researchData, downloadedSources, owner.PersonID)
 	return citationIDsForPerson
 }
 
-func downloadSource(apiClient *ancestry.APIClient, personSourcesMap map[string]ancestry.PersonSourceDetail, cid, mediaDir string, verbose bool) *ancestry.FactEditData {
+// linkSupportedFacts records on each downloaded source which of the person's facts it supports,
+// matching the source's AssertionIds against the facts' AssertionId
+func linkSupportedFacts(researchData *ancestry.ResearchData, downloadedSources map[string]*ancestry.FactEditData, personID string) {
+	factsByAssertion := make(map[string]ancestry.PersonFactDetail, len(researchData.PersonFacts))
+	for _, fact := range researchData.PersonFacts {
+		if fact.AssertionID != "" {
+			factsByAssertion[fact.AssertionID] = fact
+		}
+	}
+
+	for _, ps := range researchData.PersonSources {
+		sourceData, ok := downloadedSources[ps.CitationId]
+		if !ok {
+			continue
+		}
+		for _, assertionID := range ps.GetAssertionIDs() {
+			fact, found := factsByAssertion[assertionID]
+			if !found {
+				continue
+			}
+			eventType := fact.GetEventType()
+			if eventType == "CustomEvent" && fact.Title != "" {
+				eventType = fact.Title
+			}
+			sourceData.SupportedFacts = append(sourceData.SupportedFacts, ancestry.SupportedFact{
+				PersonID:    personID,
+				AssertionID: assertionID,
+				Type:        eventType,
+				Date:        fact.Date,
+				Place:       fact.Place,
+			})
+		}
+	}
+}
+
+func downloadSource(ctx context.Context, apiClient *ancestry.APIClient, personSourcesMap map[string]ancestry.PersonSourceDetail, owner ancestry.MediaOwner,
+	cid string, opts sourceDownloadOptions) *ancestry.FactEditData {
 	psDetail, found := personSourcesMap[cid]
 	if !found {
-		if verbose {
+		if opts.Verbose {
 			fmt.Printf("      [Warning] No PersonSourceDetail found for citation %s\n", cid)
 		}
 		return nil
 	}
 
-	sourceData := &ancestry.FactEditData{
-		CitationID:            psDetail.CitationId,
-		DatabaseID:            psDetail.DatabaseId,
-		RecordID:              psDetail.RecordId,
-		SourceID:              psDetail.SourceId,
-		CitationTitle:         psDetail.Title,
-		RecordImageUrl:        psDetail.RecordImageUrl,
-		RecordImagePreviewUrl: psDetail.RecordImagePreviewUrl,
+	sourceData := createSourceData(psDetail)
+	if opts.FullCitations {
+		sourceData = fetchFullCitation(ctx, apiClient, owner, psDetail, sourceData)
 	}
 
 	if psDetail.RecordImageUrl != "" {
 		var writer, errWriter io.Writer
-		if verbose {
+		if opts.Verbose {
 			writer = os.Stdout
 		}
 		// Always log errors to stdout if we are in CLI, but reusing existing logic that used printf
 		errWriter = os.Stdout
 
-		localPath, _ := DownloadAndSaveRecordImage(writer, errWriter, apiClient, psDetail.RecordImageUrl, cid, mediaDir, "media")
+		localPath, _ := DownloadAndSaveRecordImage(ctx, writer, errWriter, apiClient, owner, psDetail, opts.MediaDir, "media")
 		if localPath != "" {
 			sourceData.LocalMediaFilePath = localPath
 		}
@@ -252,6 +328,34 @@ func downloadSource(apiClient *ancestry.APIClient, personSourcesMap map[string]a
 	return sourceData
 }
 
+// fetchFullCitation returns the source's full citation, including its repository, from the
+// source's edit page. If that fails the basic sourceData from the facts page is kept.
+func fetchFullCitation(ctx context.Context, apiClient *ancestry.APIClient, owner ancestry.MediaOwner, psDetail ancestry.PersonSourceDetail,
+	sourceData *ancestry.FactEditData) *ancestry.FactEditData {
+	full, err := apiClient.GetSource(ctx, owner.TreeID, owner.PersonID, psDetail.CitationId, psDetail.DatabaseId, psDetail.RecordId)
+	if err != nil {
+		fmt.Printf("      [Warning] Failed to fetch full citation %s: %v\n", psDetail.CitationId, err)
+		return sourceData
+	}
+	if full.CitationID == "" {
+		full.CitationID = psDetail.CitationId
+	}
+	full.RecordImageUrl = psDetail.RecordImageUrl
+	full.RecordImagePreviewUrl = psDetail.RecordImagePreviewUrl
+	return full
+}
+
+// saveRepositories writes each repository the sources cite once to repositories.json, leaving
+// only a RepositoryId reference on the sources themselves
+func saveRepositories(outputBaseDir string, downloadedSources map[string]*ancestry.FactEditData) error {
+	repositories := normalizeRepositories(downloadedSources)
+	if err := writeJSONFile(outputBaseDir, repositoriesFileName, repositories, false); err != nil {
+		return err
+	}
+	fmt.Printf("   ✓ Saved %d unique repositories\n", len(repositories))
+	return nil
+}
+
 func saveDownloadedSources(downloadedSources map[string]*ancestry.FactEditData, sourcesDir string) (int, int) {
 	sourcesSavedCount := 0
 	totalMediaDownloaded := 0
@@ -0,0 +1,45 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+)
+
+func TestLinkSupportedFacts(t *testing.T) {
+	researchData := &ancestry.ResearchData{
+		PersonFacts: []ancestry.PersonFactDetail{
+			{TypeString: "Birth", Date: "1 Jan 1850", Place: "York", AssertionID: "a1"},
+			{TypeString: "Residence", Date: "1881", Place: "Leeds", AssertionID: "a2"},
+			{TypeString: "CustomEvent", Title: "Prison", AssertionID: "a3"},
+		},
+		PersonSources: []ancestry.PersonSourceDetail{
+			{CitationId: "census", AssertionIds: "a2  a3 missing"},
+			{CitationId: "baptism", AssertionIds: "a1"},
+			{CitationId: "not-downloaded", AssertionIds: "a1"},
+		},
+	}
+	downloaded := map[string]*ancestry.FactEditData{
+		"census":  {CitationID: "census"},
+		"baptism": {CitationID: "baptism", SupportedFacts: []ancestry.SupportedFact{{PersonID: "other", AssertionID: "x"}}},
+	}
+
+	linkSupportedFacts(researchData, downloaded, "p1")
+
+	census := downloaded["census"].SupportedFacts
+	if len(census) != 2 {
+		t.Fatalf("census supports %d facts, want 2: %+v", len(census), census)
+	}
+	if census[0] != (ancestry.SupportedFact{PersonID: "p1", AssertionID: "a2", Type: "Residence", Date: "1881", Place: "Leeds"}) {
+		t.Errorf("unexpected census fact: %+v", census[0])
+	}
+	if census[1].Type != "Prison" {
+		t.Errorf("custom event type = %q, want its title", census[1].Type)
+	}
+
+	// Facts of other persons sharing the source are kept
+	baptism := downloaded["baptism"].SupportedFacts
+	if len(baptism) != 2 || baptism[0].PersonID != "other" || baptism[1].AssertionID != "a1" {
+		t.Errorf("unexpected baptism facts: %+v", baptism)
+	}
+}
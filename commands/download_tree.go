@@ -1,11 +1,18 @@
 package commands
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
+	"math/rand/v2"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
+	"text/template"
 	"time"
 
 	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
@@ -28,81 +35,147 @@ type TreeExport struct {
 	PersonCount int                `json:"personCount"`
 	Persons     []ancestry.Person  `json:"persons"`
 	TreeInfo    *ancestry.TreeInfo `json:"treeInfo,omitempty"`
+
+	// FactsSkipped is set when --no-facts left out the place and description detail of the Facts pages
+	FactsSkipped bool `json:"factsSkipped,omitempty"`
+
+	// EmbedFullMedia makes the self-contained format embed full-size media, not just thumbnails
+	EmbedFullMedia bool `json:"-"`
+
+	// RecordIndex holds the record images downloaded for each person, keyed by person ID
+	RecordIndex map[string]PersonRecordInfo `json:"-"`
+
+	// Theme is the HTML viewer's color theme (ThemeLight, ThemeDark or ThemeAuto)
+	Theme string `json:"-"`
+
+	// Compress gzips the JSON files, which are then named people.json.gz and so on
+	Compress bool `json:"-"`
+
+	// Restricted lists the persons a shared tree only partly showed; their relationships or facts are missing
+	Restricted []restrictedPerson `json:"restrictedPersons,omitempty"`
+
+	// MediaExcluded is set when --exclude-media skipped the media files and record images
+	MediaExcluded bool `json:"mediaExcluded,omitempty"`
+
+	// Redacted is set when --redact anonymized the export
+	Redacted bool `json:"redacted,omitempty"`
+
+	// Incomplete is set when the download was interrupted before all media was fetched
+	Incomplete bool `json:"incomplete,omitempty"`
 }
 
-// extractPlaceFromNPS extracts place name from Nested Place Structure
-// NPS is an array of maps containing place information
+// extractPlaceFromNPS extracts the place name from a Nested Place Structure,
+// preferring the place as entered over its standardized form
 func extractPlaceFromNPS(nps []map[string]interface{}) string {
-	if len(nps) == 0 {
-		return ""
+	original, standardized := ancestry.ParsePlaceForms(nps)
+	if original != "" {
+		return original
 	}
+	return standardized
+}
 
-	// NPS typically contains place components like city, county, state, country
-	// We want to build a comma-separated string from the place names
-	var places []string
-	for _, placeMap := range nps {
-		// Check for 'v' field which contains the place name value
-		if v, ok := placeMap["v"].(string); ok && v != "" {
-			places = append(places, v)
+// resolveEventPlaces fills in the original and standardized place of every event
+func resolveEventPlaces(persons []ancestry.Person) {
+	for i := range persons {
+		for j := range persons[i].Events {
+			persons[i].Events[j].ResolvePlaces()
 		}
 	}
-
-	return strings.Join(places, ", ")
 }
 
 // getTreeIDForDownload retrieves tree ID from arguments or uses default
 func getTreeIDForDownload(c *cli.Context) (string, error) {
-	treeID := c.Args().First()
-	if treeID != "" {
-		return treeID, nil
-	}
-
-	defaultTreeID, err := config.GetDefaultTreeID()
-	if err != nil {
-		return "", fmt.Errorf("failed to get default tree: %w", err)
-	}
-	if defaultTreeID == "" {
-		return "", fmt.Errorf("tree ID is required\n\nUsage: ancestrydl download-tree <tree-id> --output <directory>")
-	}
-	fmt.Printf("Using default tree: %s\n", defaultTreeID)
-	return defaultTreeID, nil
+	return getTreeIDArgOrDefault(c, fmt.Errorf("tree ID is required\n\nUsage: ancestrydl download-tree <tree-id> --output <directory>"))
 }
 
-// setupAPIClientForDownload creates an API client from stored cookies
-func setupAPIClientForDownload(verbose bool) (*ancestry.APIClient, error) {
+// setupAPIClientForDownload creates an API client from stored cookies, configured
+// from the --verbose, --breaker-threshold, --rate, --media-timeout, --keep-html-debug,
+// --timing-report, --user-agent and TLS flags, and checks that the session is still valid
+func setupAPIClientForDownload(c *cli.Context) (*ancestry.APIClient, error) {
 	cookiesJSON, err := config.GetCookies()
 	if err != nil {
 		return nil, fmt.Errorf("failed to load stored cookies: %w\n\nPlease run 'ancestrydl login' first to authenticate", err)
 	}
 
-	fmt.Println("1. Creating API client...")
-	apiClient, err := ancestry.NewAPIClientFromJSON(cookiesJSON, verbose)
+	logger := loggerFrom(c)
+	logger.Println("1. Creating API client...")
+	apiClient, err := ancestry.NewAPIClientFromJSON(cookiesJSON, c.Bool("verbose"))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create API client: %w", err)
 	}
-	fmt.Println("   ✓ API client ready")
+	apiClient.SetCircuitBreakerThreshold(c.Int("breaker-threshold"))
+	rate := ancestry.DefaultRequestsPerSecond
+	if c.IsSet("rate") {
+		rate = c.Float64("rate")
+	}
+	apiClient.SetRateLimit(rate, ancestry.DefaultRequestBurst)
+	apiClient.SetMediaTimeout(c.Duration("media-timeout"))
+	apiClient.SetHTMLDebugDir(c.String("keep-html-debug"))
+	if c.Bool("timing-report") {
+		apiClient.EnableRequestTimings()
+	}
+	if err := configureAPIClient(c, apiClient); err != nil {
+		return nil, err
+	}
+	logger.Println("   ✓ API client ready")
+
+	// Fail fast on a stale session rather than partway through the download
+	if err := verifySession(c.Context, apiClient, logger.Writer()); err != nil {
+		if closeErr := apiClient.Close(); closeErr != nil {
+			logger.Printf("Error closing API client: %v\n", closeErr)
+		}
+		return nil, err
+	}
 	return apiClient, nil
 }
 
-// fetchTreeData downloads all persons, relationships, and events from the tree
-func fetchTreeData(apiClient *ancestry.APIClient, treeID string) ([]ancestry.Person, map[string]PersonRelationship, int, error) {
-	fmt.Println("3. Getting person count...")
-	totalCount, err := apiClient.GetPersonsCount(treeID)
+// downloadPersonList gets the tree's person count and then downloads its persons, or those with opts.Tags
+func downloadPersonList(ctx context.Context, apiClient *ancestry.APIClient, treeID string, opts downloadTreeOptions) ([]ancestry.Person, error) {
+	opts.Log.Println("3. Getting person count...")
+	totalCount, err := apiClient.GetPersonsCount(ctx, treeID)
 	if err != nil {
-		return nil, nil, 0, fmt.Errorf("failed to get person count: %w", err)
+		return nil, treeAccessError(treeID, fmt.Errorf("failed to get person count: %w", err))
 	}
-	fmt.Printf("   ✓ Tree has %d persons\n", totalCount)
+	opts.Log.Printf("   ✓ Tree has %d persons\n", totalCount)
 
-	fmt.Println("4. Downloading all persons...")
-	allPersons, err := downloadAllPersons(apiClient, treeID, totalCount)
+	if len(opts.Tags) > 0 {
+		opts.Log.Printf("4. Downloading persons tagged %s...\n", strings.Join(opts.Tags, ", "))
+	} else {
+		opts.Log.Println("4. Downloading all persons...")
+	}
+	allPersons, err := downloadAllPersons(ctx, apiClient, treeID, totalCount, opts.PageSize, opts.Tags, opts.Log)
 	if err != nil {
-		return nil, nil, 0, fmt.Errorf("failed to download persons: %w", err)
+		return nil, fmt.Errorf("failed to download persons: %w", err)
 	}
-	fmt.Printf("   ✓ Downloaded %d persons\n", len(allPersons))
+	opts.Log.Printf("   ✓ Downloaded %d persons\n", len(allPersons))
+	return allPersons, nil
+}
 
-	fmt.Println("5. Building relationship map...")
-	relationships, familyViewEvents := buildRelationships(apiClient, treeID, allPersons)
-	fmt.Printf("   ✓ Built relationships for %d persons\n", len(relationships))
+// fetchTreeData downloads all persons, relationships, and events from the tree. It stops
+// between persons once ctx is done.
+func fetchTreeData(ctx context.Context, apiClient *ancestry.APIClient, treeID string, opts downloadTreeOptions) ([]ancestry.Person, map[string]PersonRelationship, int, error) {
+	endPhase := opts.Timings.start(phasePersonFetch)
+	allPersons, err := opts.PersonCache.persons(treeID, opts.Log.Writer(), func() ([]ancestry.Person, error) {
+		return downloadPersonList(ctx, apiClient, treeID, opts)
+	})
+	endPhase()
+	if err != nil {
+		return nil, nil, 0, err
+	}
+	totalCount := len(allPersons)
+	if !opts.Since.IsZero() {
+		opts.Log.Printf("   %d person(s) changed since %s\n", countChangedSince(allPersons, opts.Since), opts.Since.Format(time.RFC3339))
+	}
+
+	opts.Log.Println("5. Building relationship map...")
+	endPhase = opts.Timings.start(phaseRelationshipBuild)
+	relationships, familyViewEvents := buildRelationships(ctx, apiClient, treeID, allPersons, opts.FamilyViewGenerations, opts.Concurrency,
+		opts.Restricted, opts.Progress, opts.Log)
+	endPhase()
+	if err := checkInterrupted(ctx, opts.Progress); err != nil {
+		return nil, nil, 0, err
+	}
+	opts.Log.Printf("   ✓ Built relationships for %d persons\n", len(relationships))
 
 	// Merge FamilyView events into persons
 	for i := range allPersons {
@@ -112,133 +185,593 @@ func fetchTreeData(apiClient *ancestry.APIClient, treeID string) ([]ancestry.Per
 		}
 	}
 
-	fmt.Println("6. Fetching complete event data from Facts pages...")
-	fetchFactsForAllPersons(apiClient, treeID, allPersons)
-	fmt.Println("   ✓ Fetched complete event data")
+	if opts.NoFacts {
+		opts.Log.Println("6. Skipping Facts pages (--no-facts)")
+	} else {
+		opts.Log.Println("6. Fetching complete event data from Facts pages...")
+		endPhase = opts.Timings.start(phaseFactsFetch)
+		fetchFactsForAllPersons(ctx, apiClient, treeID, allPersons, opts.Concurrency, opts.HumanDelay, opts.Failures, opts.Restricted,
+			opts.Progress, opts.Log)
+		endPhase()
+		if err := checkInterrupted(ctx, opts.Progress); err != nil {
+			return nil, nil, 0, err
+		}
+		opts.Log.Println("   ✓ Fetched complete event data")
+	}
+
+	resolveEventPlaces(allPersons)
+	if opts.StripHTML {
+		stripEventDescriptionsHTML(allPersons)
+	}
+	if truncated := truncateEventDescriptions(allPersons, opts.MaxDescriptionLength); truncated > 0 {
+		opts.Log.Printf("   Shortened %d long event description(s) to %d characters (--full-descriptions keeps them whole)\n",
+			truncated, opts.MaxDescriptionLength)
+	}
 
-	fmt.Println("7. Inferring event types from relationships...")
+	if opts.IncludeNotes {
+		opts.Log.Println("   Fetching person notes...")
+		notedCount := fetchNotesForAllPersons(ctx, apiClient, treeID, allPersons, opts.ExcludeLiving, opts.Log)
+		if opts.ExcludeLiving {
+			opts.Log.Printf("   ✓ Attached notes to %d persons (living persons skipped)\n", notedCount)
+		} else {
+			opts.Log.Printf("   ✓ Attached notes to %d persons\n", notedCount)
+		}
+	}
+
+	opts.Log.Println("7. Inferring event types from relationships...")
 	inferredCount := inferEventTypes(allPersons, relationships)
-	fmt.Printf("   ✓ Inferred %d event types\n", inferredCount)
+	opts.Log.Printf("   ✓ Inferred %d event types\n", inferredCount)
 
 	return allPersons, relationships, totalCount, nil
 }
 
-// saveTreeOutput saves all tree data, media, and generates the HTML viewer
-func saveTreeOutput(apiClient *ancestry.APIClient, treeID, outputDir string, treeInfo *ancestry.TreeInfo,
-	allPersons []ancestry.Person, relationships map[string]PersonRelationship) (int, int, error) {
-	fmt.Println("8. Creating output directories...")
-	if err := createDirectoryStructure(outputDir); err != nil {
-		return 0, 0, fmt.Errorf("failed to create directories: %w", err)
+// maxFamilyViewGenerations caps --family-view-generations; larger views get slow and very large
+const maxFamilyViewGenerations = 4
+
+// downloadTreeOptions holds the optional settings for a tree download
+type downloadTreeOptions struct {
+	GraphFormat           string             // Relationship graph format to write ("graphml", "dot"), empty for none
+	MediaConcurrency      int                // Number of persons whose media is downloaded in parallel
+	NameCollisionStrategy string             // How to rename a media file whose name is taken ("index", "hash", "uuid")
+	MediaCategories       map[string]bool    // Media categories to download, nil for all
+	Formats               []string           // Output formats to write (see RegisterTreeWriter), nil for the defaults
+	HumanDelay            bool               // Pause a random 1-4s between facts-page requests
+	Concurrency           int                // Number of persons whose family views and Facts pages are fetched in parallel
+	IncludeNotes          bool               // Attach each person's private notes to the export
+	ExcludeLiving         bool               // Fetch no private notes for living persons
+	OutputTemplate        *template.Template // Names the output directory when --output isn't given
+	StripHTML             bool               // Strip HTML markup from event descriptions
+	FamilyViewGenerations int                // Generations up and down fetched per family view request
+	NoFacts               bool               // Skip the Facts pages and keep only FamilyView events
+	ExcludeMedia          bool               // Skip media files and record images for a data-only backup
+	Redact                bool               // Anonymize the export for sharing publicly (see redactTree)
+	Failures              *failureLog        // Collects failed per-person fetches for failures.json
+	Restricted            *restrictedLog     // Collects persons a shared tree refused to show
+	MediaProgress         *mediaProgress     // Totals the media bytes saved; nil to not track them
+	EmbedFullMedia        bool               // Embed full-size media in tree.html, not just thumbnails
+	MaxDescriptionLength  int                // Longest event description or media title/description kept, 0 for no limit
+	Theme                 string             // HTML viewer color theme: "light", "dark" or "auto"
+	OnlyWithMedia         bool               // Only check persons who had media in the previous download, and new persons
+	ValidateMedia         bool               // Check that media decodes as an image, downloading it again if it doesn't
+	MaxMediaPerPerson     int                // Most media items downloaded per person, newest first; 0 for no limit
+	Compress              bool               // Gzip the JSON files
+	Since                 time.Time          // Skip the download if the tree is unchanged since then; zero to always download
+	Tags                  []string           // Only download persons with at least one of these tags; nil for everyone
+	PageSize              int                // Persons requested per page of the person list
+	Log                   *log.Logger        // Status output, safe to share between concurrent workers
+	PersonCache           personCache        // Reuses a recently fetched person list (see personCacheFromFlags)
+	Timings               *phaseTimings      // Times each phase for timings.json (--timing-report); nil to not time them
+	Progress              *downloadProgress  // Per-person progress to resume an interrupted download; nil to not record it
+	FirstOutputStep       int                // Number of the first step saveTreeOutput prints; 0 to follow download-tree's steps
+}
+
+// downloadCounts holds the number of files handled by a tree download
+type downloadCounts struct {
+	Media          int   // Media files downloaded
+	MediaBytes     int64 // Total size of the media files downloaded
+	MediaUnchanged int   // Media files kept from a previous run
+	Records        int   // Record images downloaded
+	FilteredMedia  int   // Media items skipped by --media-categories
+	OverLimitMedia int   // Media items skipped by --max-media-per-person
+	Failures       int   // Failed fetches listed in failures.json
+	Restricted     int   // Persons a shared tree only partly showed
+}
+
+// parseDownloadTreeOptions reads and validates the download-tree option flags
+func parseDownloadTreeOptions(c *cli.Context) (downloadTreeOptions, error) {
+	opts := downloadTreeOptions{
+		GraphFormat:           strings.ToLower(c.String("graph")),
+		MediaConcurrency:      c.Int("media-concurrency"),
+		NameCollisionStrategy: strings.ToLower(c.String("name-collision-strategy")),
+		HumanDelay:            c.Bool("human-delay"),
+		Concurrency:           c.Int("concurrency"),
+		IncludeNotes:          c.Bool("include-notes"),
+		ExcludeLiving:         c.Bool("exclude-living"),
+		StripHTML:             c.Bool("strip-html"),
+		FamilyViewGenerations: c.Int("family-view-generations"),
+		NoFacts:               c.Bool("no-facts"),
+		ExcludeMedia:          c.Bool("exclude-media"),
+		Redact:                c.Bool("redact"),
+		Failures:              &failureLog{},
+		Restricted:            &restrictedLog{},
+		EmbedFullMedia:        c.Bool("embed-full-media"),
+		OnlyWithMedia:         c.Bool("only-with-media"),
+		ValidateMedia:         c.Bool("validate-media"),
+		MaxMediaPerPerson:     c.Int("max-media-per-person"),
+		Compress:              c.Bool("compress"),
+		Tags:                  parseTags(c.String("tags")),
+		Theme:                 strings.ToLower(c.String("theme")),
+		Log:                   loggerFrom(c),
+	}
+	if c.Bool("timing-report") {
+		opts.Timings = &phaseTimings{}
+	}
+	if opts.Redact {
+		// Media, record images and notes could identify people, so a redacted export has none
+		opts.ExcludeMedia = true
+		opts.IncludeNotes = false
+	}
+	if err := validateGraphFormat(opts.GraphFormat); err != nil {
+		return opts, err
+	}
+	if err := validateNameCollisionStrategy(opts.NameCollisionStrategy); err != nil {
+		return opts, err
+	}
+	if err := validateTheme(opts.Theme); err != nil {
+		return opts, err
+	}
+
+	categories, err := parseMediaCategories(c.String("media-categories"))
+	if err != nil {
+		return opts, err
+	}
+	opts.MediaCategories = categories
+
+	opts.MaxDescriptionLength, err = parseMaxDescriptionLength(c)
+	if err != nil {
+		return opts, err
+	}
+
+	opts.PageSize, err = parsePageSize(c)
+	if err != nil {
+		return opts, err
 	}
-	fmt.Println("   ✓ Directories created")
 
-	fmt.Println("9. Downloading media files...")
-	mediaIndex, downloadCount := downloadAllMedia(apiClient, treeID, allPersons, outputDir)
-	fmt.Printf("   ✓ Downloaded %d media files\n", downloadCount)
+	if opts.MaxMediaPerPerson < 0 {
+		return opts, fmt.Errorf("--max-media-per-person must be 0 (no limit) or more")
+	}
 
-	fmt.Println("10. Downloading record images (census, vital records, etc.)...")
-	recordIndex, recordCount := downloadAllRecordImages(apiClient, treeID, allPersons, outputDir)
-	fmt.Printf("   ✓ Downloaded %d record images\n", recordCount)
+	if opts.FamilyViewGenerations < 1 || opts.FamilyViewGenerations > maxFamilyViewGenerations {
+		return opts, fmt.Errorf("--family-view-generations must be between 1 and %d", maxFamilyViewGenerations)
+	}
 
-	fmt.Println("11. Saving tree data...")
-	treeExport := TreeExport{
-		TreeID:      treeID,
-		TreeName:    treeInfo.TreeName,
-		ExportDate:  time.Now().Format(time.RFC3339),
-		PersonCount: len(allPersons),
-		Persons:     allPersons,
-		TreeInfo:    treeInfo,
+	opts.OutputTemplate, err = parseOutputTemplate(c.String("output-template"))
+	if err != nil {
+		return opts, err
+	}
+
+	formats, err := parseTreeFormats(c.String("formats"))
+	if err != nil {
+		return opts, err
+	}
+	opts.Formats = addImpliedFormats(formats, opts, c.Bool("self-contained"), c.Bool("relationships-csv"))
+
+	return opts, nil
+}
+
+// addImpliedFormats adds the formats requested through --graph, --self-contained, --embed-full-media
+// and --relationships-csv
+func addImpliedFormats(formats []string, opts downloadTreeOptions, selfContained, relationshipsCSV bool) []string {
+	if opts.GraphFormat != "" && !hasTreeFormat(formats, opts.GraphFormat) {
+		formats = append(formats, opts.GraphFormat)
 	}
+	if (selfContained || opts.EmbedFullMedia) && !hasTreeFormat(formats, TreeFormatSelfContained) {
+		formats = append(formats, TreeFormatSelfContained)
+	}
+	if relationshipsCSV && !hasTreeFormat(formats, TreeFormatRelationshipsCSV) {
+		formats = append(formats, TreeFormatRelationshipsCSV)
+	}
+	return formats
+}
 
-	if err := saveTreeData(outputDir, &treeExport, relationships, mediaIndex, recordIndex); err != nil {
-		return 0, 0, fmt.Errorf("failed to save tree data: %w", err)
+// downloadTreeMedia downloads the persons' media files and record images (the second and third
+// output steps), filling in the media counts. Both stop between persons once ctx is done.
+func downloadTreeMedia(ctx context.Context, apiClient *ancestry.APIClient, treeID, outputDir string, allPersons []ancestry.Person,
+	counts *downloadCounts, opts downloadTreeOptions) (map[string]PersonMediaInfo, map[string]PersonRecordInfo) {
+	opts.Log.Printf("%d. Downloading media files...\n", opts.outputStep(1))
+	mediaPersons := allPersons
+	if opts.OnlyWithMedia {
+		mediaPersons = personsToCheckForMedia(outputDir, allPersons, opts)
+	}
+	mediaIndex, downloaded, filtered, size := downloadAllMedia(ctx, apiClient, treeID, mediaPersons, outputDir, opts)
+	counts.Media, counts.FilteredMedia, counts.MediaBytes = downloaded, filtered, size
+	counts.MediaUnchanged = countMediaFiles(mediaIndex) - counts.Media
+	counts.OverLimitMedia = countOverLimitMedia(mediaIndex)
+	opts.Log.Printf("   ✓ Downloaded %d media files (%s)\n", counts.Media, formatByteSize(counts.MediaBytes))
+
+	opts.Log.Printf("%d. Downloading record images (census, vital records, etc.)...\n", opts.outputStep(2))
+	recordIndex, records := downloadAllRecordImages(ctx, apiClient, treeID, allPersons, outputDir, opts.Failures, opts.Progress, opts.Log)
+	counts.Records = records
+	opts.Log.Printf("   ✓ Downloaded %d record images\n", counts.Records)
+	return mediaIndex, recordIndex
+}
+
+// saveTreeOutput saves all tree data, media, and generates the HTML viewer. If ctx is done
+// while media is downloading, only the JSON files are written, marked incomplete, with what
+// was collected so far, and the interruption is returned.
+func saveTreeOutput(ctx context.Context, apiClient *ancestry.APIClient, treeID, outputDir string, treeInfo *ancestry.TreeInfo,
+	allPersons []ancestry.Person, relationships map[string]PersonRelationship, opts downloadTreeOptions) (downloadCounts, error) {
+	var counts downloadCounts
+
+	opts.Log.Printf("%d. Creating output directories...\n", opts.outputStep(0))
+	if err := createDirectoryStructure(outputDir); err != nil {
+		return counts, fmt.Errorf("failed to create directories: %w", err)
 	}
-	fmt.Println("   ✓ Tree data saved")
+	opts.Log.Println("   ✓ Directories created")
 
-	fmt.Println("12. Generating HTML viewer...")
-	if err := generateHTMLViewer(outputDir, &treeExport); err != nil {
-		fmt.Printf("   Warning: Failed to generate HTML viewer: %v\n", err)
+	mediaIndex := make(map[string]PersonMediaInfo)
+	var recordIndex map[string]PersonRecordInfo
+	if opts.ExcludeMedia {
+		opts.Log.Printf("%d. Skipping media files (--exclude-media)\n", opts.outputStep(1))
+		opts.Log.Printf("%d. Skipping record images (--exclude-media)\n", opts.outputStep(2))
 	} else {
-		fmt.Println("   ✓ HTML viewer created")
+		endPhase := opts.Timings.start(phaseMediaDownload)
+		mediaIndex, recordIndex = downloadTreeMedia(ctx, apiClient, treeID, outputDir, allPersons, &counts, opts)
+		endPhase()
 	}
 
-	return downloadCount, recordCount, nil
+	formats := opts.outputFormats()
+	interrupted := checkInterrupted(ctx, opts.Progress)
+	if interrupted != nil {
+		// The other formats are left for the resumed run, which writes them from the whole tree
+		formats = []string{TreeFormatJSON}
+		opts.Log.Printf("%d. Saving what was downloaded so far (%s)...\n", opts.outputStep(3), strings.Join(formats, ", "))
+	} else {
+		opts.Log.Printf("%d. Writing output (%s)...\n", opts.outputStep(3), strings.Join(formats, ", "))
+	}
+	treeExport := TreeExport{
+		TreeID:         treeID,
+		TreeName:       treeInfo.TreeName,
+		ExportDate:     time.Now().Format(time.RFC3339),
+		PersonCount:    len(allPersons),
+		Persons:        allPersons,
+		TreeInfo:       treeInfo,
+		FactsSkipped:   opts.NoFacts,
+		EmbedFullMedia: opts.EmbedFullMedia,
+		RecordIndex:    recordIndex,
+		Theme:          opts.Theme,
+		Compress:       opts.Compress,
+		Restricted:     opts.Restricted.list(),
+		MediaExcluded:  opts.ExcludeMedia,
+		Redacted:       opts.Redact,
+		Incomplete:     interrupted != nil,
+	}
+	counts.Restricted = len(treeExport.Restricted)
+
+	// The media index is kept so later runs can skip unchanged media. A run without media
+	// leaves the previous index alone, as does an interrupted one, whose media is in the progress file.
+	if !opts.ExcludeMedia && interrupted == nil {
+		if err := saveMediaIndex(outputDir, mediaIndex, opts.Compress); err != nil {
+			return counts, fmt.Errorf("failed to save tree data: %w", err)
+		}
+	}
+
+	if err := writeTreeFormats(ctx, outputDir, formats, &treeExport, relationships, mediaIndex, opts.Log); err != nil {
+		return counts, err
+	}
+
+	failures := opts.Failures.list()
+	if err := saveFailures(outputDir, failures); err != nil {
+		return counts, err
+	}
+	counts.Failures = len(failures)
+
+	return counts, interrupted
+}
+
+// countOverLimitMedia returns the number of media items --max-media-per-person left out of the media index
+func countOverLimitMedia(mediaIndex map[string]PersonMediaInfo) int {
+	n := 0
+	for _, info := range mediaIndex {
+		n += info.OverLimit
+	}
+	return n
+}
+
+// countMediaFiles returns the number of files in the media index
+func countMediaFiles(mediaIndex map[string]PersonMediaInfo) int {
+	n := 0
+	for _, personInfo := range mediaIndex {
+		n += len(personInfo.Files)
+	}
+	return n
+}
+
+// outputStep returns the number printed for saveTreeOutput's nth step, counting from 0.
+// By default the steps follow download-tree's seven fetch steps.
+func (o downloadTreeOptions) outputStep(n int) int {
+	first := o.FirstOutputStep
+	if first == 0 {
+		first = 8
+	}
+	return first + n
+}
+
+// outputFormats returns the formats to write, falling back to the defaults
+func (o downloadTreeOptions) outputFormats() []string {
+	if len(o.Formats) == 0 {
+		return defaultTreeFormats
+	}
+	return o.Formats
+}
+
+// printFormatFiles lists the files written by the extra output formats
+func printFormatFiles(formats []string, opts downloadTreeOptions) {
+	formatFiles := []struct {
+		format string
+		line   string
+	}{
+		{GraphFormatGraphML, "relationships.graphml - Family relationship graph (parent/child/spouse edges)"},
+		{GraphFormatDOT, "relationships.dot - Family relationship graph (parent/child/spouse edges)"},
+		{TreeFormatVCard, vCardFile + " - Every person as a contact (vCard)"},
+		{TreeFormatRelationshipsCSV, relationshipsCSVFile + " - One row per parent, spouse and child relationship"},
+		{TreeFormatJSONLD, jsonFileName(jsonLDFile, opts.Compress) + " - schema.org Person graph (JSON-LD)"},
+		{TreeFormatGEDCOM, gedcomFile + " - GEDCOM 5.5.1 file for desktop genealogy software"},
+	}
+	for _, file := range formatFiles {
+		if hasTreeFormat(formats, file.format) {
+			opts.Log.Printf("  • %s\n", file.line)
+		}
+	}
+}
+
+// printDownloadNotes prints what the download left out and how to fetch what failed
+func printDownloadNotes(outputDir string, counts downloadCounts, opts downloadTreeOptions) {
+	if opts.Redact {
+		opts.Log.Println()
+		opts.Log.Println("The export is redacted (--redact): given names are initials, dates are decades, and descriptions, notes and media are left out.")
+	} else if opts.ExcludeMedia {
+		opts.Log.Println()
+		opts.Log.Println("Media files and record images were skipped (--exclude-media); metadata.json records mediaExcluded.")
+	}
+	if counts.FilteredMedia > 0 {
+		opts.Log.Println()
+		opts.Log.Printf("Skipped %d media item(s) not matching --media-categories\n", counts.FilteredMedia)
+	}
+	if counts.OverLimitMedia > 0 {
+		opts.Log.Println()
+		opts.Log.Printf("Skipped %d media item(s) over --max-media-per-person (counted per person in media-index.json)\n", counts.OverLimitMedia)
+	}
+	if counts.Restricted > 0 {
+		opts.Log.Println()
+		opts.Log.Printf("%d person(s) are restricted by the tree's sharing settings, so their relationships or facts are missing.\n", counts.Restricted)
+		opts.Log.Println("They are listed under restrictedPersons in metadata.json.")
+	}
+	if counts.Failures > 0 {
+		opts.Log.Println()
+		opts.Log.Printf("Some data could not be fetched. To try just those items again, run: ancestrydl retry-failed %s\n", outputDir)
+	}
 }
 
 // printDownloadSummary prints the summary of downloaded tree data
-func printDownloadSummary(outputDir string, downloadCount, recordCount int) {
-	fmt.Println("\n✅ Tree download complete!")
-	fmt.Printf("   Output: %s\n", outputDir)
-	fmt.Println()
-	fmt.Println("Files created:")
-	fmt.Println("  • index.html - Interactive HTML viewer (open directly in browser)")
-	fmt.Println("  • people.json - All persons with readable details")
-	fmt.Println("  • metadata.json - Tree information")
-	if downloadCount > 0 {
-		fmt.Printf("  • media/photos/ - %d media files (photos, documents)\n", downloadCount)
-		fmt.Println("  • media-index.json - Media file index with titles and descriptions")
-	}
-	if recordCount > 0 {
-		fmt.Printf("  • media/records/ - %d record images (census, vital records)\n", recordCount)
-	}
-	fmt.Println()
-	fmt.Printf("👉 To view your tree, open: %s/index.html\n", outputDir)
-	fmt.Println()
+func printDownloadSummary(outputDir string, counts downloadCounts, opts downloadTreeOptions) {
+	formats := opts.outputFormats()
+	opts.Log.Println("\n✅ Tree download complete!")
+	opts.Log.Printf("   Output: %s\n", outputDir)
+	opts.Log.Println()
+	opts.Log.Println("Files created:")
+	if hasTreeFormat(formats, TreeFormatHTML) {
+		opts.Log.Println("  • index.html - Interactive HTML viewer (open directly in browser)")
+	}
+	if hasTreeFormat(formats, TreeFormatSelfContained) {
+		opts.Log.Printf("  • %s - Single-file viewer with embedded thumbnails, for sharing\n", selfContainedFile)
+	}
+	if hasTreeFormat(formats, TreeFormatJSON) {
+		opts.Log.Printf("  • %s - All persons with readable details\n", jsonFileName("people.json", opts.Compress))
+		opts.Log.Printf("  • %s - Tree information\n", jsonFileName("metadata.json", opts.Compress))
+	}
+	if counts.Media > 0 {
+		opts.Log.Printf("  • media/photos/ - %d media files (photos, documents), %s\n", counts.Media, formatByteSize(counts.MediaBytes))
+		opts.Log.Printf("  • %s - Media file index with titles and descriptions\n", jsonFileName("media-index.json", opts.Compress))
+	}
+	if counts.Records > 0 {
+		opts.Log.Printf("  • media/records/ - %d record images (census, vital records)\n", counts.Records)
+	}
+	printFormatFiles(formats, opts)
+	opts.Log.Printf("  • %s - What this run downloaded, skipped and failed\n", runReportFile)
+	if opts.Timings != nil {
+		opts.Log.Printf("  • %s - How long each phase and endpoint took\n", timingsFile)
+	}
+	if counts.Failures > 0 {
+		opts.Log.Printf("  • %s - %d fetch(es) that failed\n", failuresFile, counts.Failures)
+	}
+	printDownloadNotes(outputDir, counts, opts)
+	opts.Log.Println()
+	if hasTreeFormat(formats, TreeFormatHTML) {
+		opts.Log.Printf("👉 To view your tree, open: %s/index.html\n", outputDir)
+		opts.Log.Println()
+	}
 }
 
 // DownloadTree downloads a complete family tree with all data and media
 func DownloadTree(c *cli.Context) error {
+	if c.Bool("list-formats") {
+		out, _ := commandOutput(c)
+		printTreeFormats(out)
+		return nil
+	}
+
+	startTime := time.Now()
 	treeID, err := getTreeIDForDownload(c)
 	if err != nil {
 		return err
 	}
 
+	// Without --output, the directory is named from --output-template once the tree name is known
 	outputDir := c.String("output")
-	if outputDir == "" {
-		outputDir = fmt.Sprintf("./tree-%s", treeID)
-	}
 
-	verbose := c.Bool("verbose")
+	// With --output -, status messages go to stderr and the JSON export to stdout
+	toStdout := isStdoutOutput(outputDir)
+	jsonOut, _ := commandOutput(c)
 
-	fmt.Printf("Downloading tree %s to: %s\n", treeID, outputDir)
-	if verbose {
-		fmt.Println("Verbose mode enabled: HTTP requests/responses will be logged to http_log.txt")
+	opts, err := parseDownloadTreeOptions(c)
+	if err != nil {
+		return err
+	}
+	opts.PersonCache = personCacheFromFlags(c, treeID, opts.Tags)
+
+	release, err := lockOutputDir(outputDir, toStdout, c.Bool("force-unlock"), opts.Log)
+	if err != nil {
+		return err
 	}
-	fmt.Println()
+	defer release()
 
-	apiClient, err := setupAPIClientForDownload(verbose)
+	printDownloadStart(opts.Log, treeID, outputDir, c.Bool("verbose"))
+
+	apiClient, err := setupAPIClientForDownload(c)
 	if err != nil {
 		return err
 	}
 	defer func() {
 		if err := apiClient.Close(); err != nil {
-			fmt.Printf("Error closing API client: %v\n", err)
+			opts.Log.Printf("Error closing API client: %v\n", err)
 		}
 	}()
 
-	fmt.Println("2. Fetching tree information...")
-	treeInfo, err := apiClient.GetTreeInfo(treeID)
+	treeInfo, err := fetchTreeInfo(c.Context, apiClient, treeID, opts.Log)
 	if err != nil {
-		fmt.Printf("   Warning: Could not fetch tree info: %v\n", err)
-	} else {
-		fmt.Printf("   ✓ Tree: %s\n", treeInfo.TreeName)
+		return err
 	}
 
-	allPersons, relationships, _, err := fetchTreeData(apiClient, treeID)
-	if err != nil {
+	if outputDir == "" {
+		var release func()
+		outputDir, release, err = resolveTemplatedOutputDir(opts, treeID, treeInfo, c.Bool("force-unlock"))
+		if err != nil {
+			return err
+		}
+		defer release()
+	}
+
+	if skip, err := prepareDownload(c, apiClient, treeID, outputDir, toStdout, startTime, &opts); skip || err != nil {
 		return err
 	}
 
-	downloadCount, recordCount, err := saveTreeOutput(apiClient, treeID, outputDir, treeInfo, allPersons, relationships)
+	allPersons, relationships, _, err := fetchTreeData(c.Context, apiClient, treeID, opts)
 	if err != nil {
 		return err
 	}
+	redactTree(allPersons, relationships, treeInfo, opts)
+
+	if toStdout {
+		interrupted := checkInterrupted(c.Context, opts.Progress)
+		treeExport := TreeExport{
+			TreeID:        treeID,
+			TreeName:      treeInfo.TreeName,
+			ExportDate:    time.Now().Format(time.RFC3339),
+			PersonCount:   len(allPersons),
+			Persons:       allPersons,
+			TreeInfo:      treeInfo,
+			FactsSkipped:  opts.NoFacts,
+			Restricted:    opts.Restricted.list(),
+			MediaExcluded: opts.ExcludeMedia,
+			Redacted:      opts.Redact,
+			Incomplete:    interrupted != nil,
+		}
+		if err := writeTreeJSON(jsonOut, &treeExport, relationships); err != nil {
+			return err
+		}
+		return interrupted
+	}
 
-	printDownloadSummary(outputDir, downloadCount, recordCount)
+	return finishTreeDownload(c.Context, apiClient, treeID, outputDir, treeInfo, allPersons, relationships, opts, startTime)
+}
 
+// prepareDownload resolves --since into opts and reports whether the tree is unchanged since
+// then, in which case the run is recorded and nothing is downloaded. Otherwise it loads the
+// progress of an unfinished download into the output directory, to resume it.
+func prepareDownload(c *cli.Context, apiClient *ancestry.APIClient, treeID, outputDir string, toStdout bool,
+	startTime time.Time, opts *downloadTreeOptions) (bool, error) {
+	var err error
+	if opts.Since, err = resolveSince(c, outputDir); err != nil {
+		return false, err
+	}
+	if toStdout {
+		return false, nil
+	}
+	if skipUnchangedTree(c.Context, apiClient, treeID, opts.Since, opts.Log) {
+		recordLastRun(outputDir, startTime, opts.Log)
+		return true, nil
+	}
+	opts.Progress, err = resumeDownload(outputDir, treeID, opts.Log)
+	return false, err
+}
+
+// lockOutputDir locks an output directory given with --output. The lock of a directory named
+// from --output-template is taken once it is named (see resolveTemplatedOutputDir), and stdout
+// needs none, so release does nothing for those.
+func lockOutputDir(outputDir string, toStdout, forceUnlock bool, logger *log.Logger) (func(), error) {
+	if outputDir == "" || toStdout {
+		return func() {}, nil
+	}
+	return acquireDownloadLock(outputDir, forceUnlock, logger)
+}
+
+// finishTreeDownload saves the download's output and run report and prints the summary
+func finishTreeDownload(ctx context.Context, apiClient *ancestry.APIClient, treeID, outputDir string, treeInfo *ancestry.TreeInfo,
+	allPersons []ancestry.Person, relationships map[string]PersonRelationship, opts downloadTreeOptions, startTime time.Time) error {
+	counts, err := saveTreeOutput(ctx, apiClient, treeID, outputDir, treeInfo, allPersons, relationships, opts)
+	saveRunReport(outputDir, newRunReport(treeID, startTime, len(allPersons), len(relationships), counts, opts, err))
+	if opts.Timings != nil {
+		saveTimingReport(outputDir, newTimingReport(treeID, startTime, opts, apiClient.RequestTimings()))
+	}
+	if err != nil {
+		return err
+	}
+
+	recordLastRun(outputDir, startTime, opts.Log)
+	opts.Progress.remove()
+	printDownloadSummary(outputDir, counts, opts)
 	return nil
 }
 
+// printDownloadStart prints the download banner. outputDir is empty when it is not named yet.
+func printDownloadStart(logger *log.Logger, treeID, outputDir string, verbose bool) {
+	if outputDir != "" {
+		logger.Printf("Downloading tree %s to: %s\n", treeID, outputDir)
+	} else {
+		logger.Printf("Downloading tree %s\n", treeID)
+	}
+	if verbose {
+		logger.Println("Verbose mode enabled: HTTP requests/responses will be logged to http_log.txt")
+	}
+	logger.Println()
+}
+
+// fetchTreeInfo opens the tree if it was shared with the user and fetches its metadata (see
+// lookupTreeInfo). It fails only if a shared tree can't be opened.
+func fetchTreeInfo(ctx context.Context, apiClient *ancestry.APIClient, treeID string, logger *log.Logger) (*ancestry.TreeInfo, error) {
+	logger.Println("2. Fetching tree information...")
+	if err := openIfSharedTree(ctx, apiClient, treeID, logger.Writer()); err != nil {
+		return nil, err
+	}
+	return lookupTreeInfo(ctx, apiClient.GetTreeInfo, apiClient.ListTrees, treeID, logger.Writer()), nil
+}
+
+// resolveTemplatedOutputDir names the output directory from --output-template and locks it
+func resolveTemplatedOutputDir(opts downloadTreeOptions, treeID string, treeInfo *ancestry.TreeInfo, forceUnlock bool) (string, func(), error) {
+	outputDir, err := renderOutputDir(opts.OutputTemplate, treeID, treeInfo, time.Now())
+	if err != nil {
+		return "", nil, err
+	}
+	opts.Log.Printf("   ✓ Output directory: %s\n", outputDir)
+
+	release, err := acquireDownloadLock(outputDir, forceUnlock, opts.Log)
+	if err != nil {
+		return "", nil, err
+	}
+	return outputDir, release, nil
+}
+
 // PersonRelationship stores relationship information for a person
 type PersonRelationship struct {
 	PersonID string                  `json:"personId"`
@@ -336,63 +869,236 @@ func processFamilyView(personID string, familyView *ancestry.FamilyViewResponse)
 	return rel, focusPerson.Events, true
 }
 
+// familyViewBatchFetcher fetches the family views of several persons by person number, in order
+type familyViewBatchFetcher func(personNumbers []string) []ancestry.FamilyViewResult
+
 // buildRelationships creates a map of relationships for all persons
 // It also returns a map of person IDs to their Events from FamilyView API (which has more complete data)
-func buildRelationships(apiClient *ancestry.APIClient, treeID string, persons []ancestry.Person) (map[string]PersonRelationship, map[string][]ancestry.Event) {
-	relationships := make(map[string]PersonRelationship)
-	eventsMap := make(map[string][]ancestry.Event)
+// Up to concurrency family views are fetched in parallel.
+// Persons whose family view is refused are recorded in restricted, which may be nil.
+// Persons already recorded in progress are not fetched again. Progress goes to logger.
+func buildRelationships(ctx context.Context, apiClient *ancestry.APIClient, treeID string, persons []ancestry.Person, generations, concurrency int,
+	restricted *restrictedLog, progress *downloadProgress, logger *log.Logger) (map[string]PersonRelationship, map[string][]ancestry.Event) {
+	relationships, eventsMap, calls := collectRelationships(ctx, persons, generations, concurrency, func(personNumbers []string) []ancestry.FamilyViewResult {
+		return apiClient.GetFamilyViewBatch(ctx, treeID, personNumbers, generations, generations, concurrency)
+	}, restricted, progress, logger)
+	progress.save()
+	logger.Printf("   Made %d family view requests for %d persons\n", calls, len(persons))
+	return relationships, eventsMap
+}
 
-	for i, person := range persons {
-		personID := person.GetPersonID()
-		if personID == "" {
+// familyViewHasAllFamily reports whether a person lists family and all of it is in the family view
+func familyViewHasAllFamily(person ancestry.Person, personsMap map[string]*ancestry.Person) bool {
+	if len(person.Family) == 0 {
+		return false
+	}
+	for _, familyMember := range person.Family {
+		targetID, ok := familyMember.TGID["v"].(string)
+		if !ok {
 			continue
 		}
-
-		if i%10 == 0 && i > 0 {
-			fmt.Printf("   Building relationships %d/%d...\n", i, len(persons))
+		if _, present := personsMap[targetID]; !present {
+			return false
 		}
+	}
+	return true
+}
 
-		personNumber := extractPersonNumber(personID)
+// relationshipCollector records the relationships and events of the persons found in family views
+type relationshipCollector struct {
+	generations   int
+	inTree        map[string]bool
+	visited       map[string]bool
+	relationships map[string]PersonRelationship
+	eventsMap     map[string][]ancestry.Event
+	progress      *downloadProgress
+}
 
-		familyView, err := apiClient.GetFamilyView(treeID, personNumber, 1, 1)
-		if err != nil {
-			if i < 3 {
-				fmt.Printf("   [Debug] Failed to get family view for %s: %v\n", person.GetDisplayName(), err)
+// newRelationshipCollector creates a collector for the tree's persons, starting from the
+// relationships and family view events recorded in progress by an earlier, unfinished download
+func newRelationshipCollector(persons []ancestry.Person, generations int, progress *downloadProgress) *relationshipCollector {
+	rc := &relationshipCollector{
+		generations:   generations,
+		inTree:        make(map[string]bool, len(persons)),
+		visited:       make(map[string]bool),
+		relationships: make(map[string]PersonRelationship),
+		eventsMap:     make(map[string][]ancestry.Event),
+		progress:      progress,
+	}
+	for _, person := range persons {
+		personID := person.GetPersonID()
+		rc.inTree[personID] = true
+		if recorded, ok := progress.person(personID); ok && recorded.Relationship != nil {
+			rc.visited[personID] = true
+			rc.relationships[personID] = *recorded.Relationship
+			if len(recorded.Events) > 0 {
+				rc.eventsMap[personID] = recorded.Events
 			}
-			continue
 		}
+	}
+	return rc
+}
+
+// record records a person's relationships and events from a family view that includes them
+func (rc *relationshipCollector) record(personID string, familyView *ancestry.FamilyViewResponse) {
+	rel, events, ok := processFamilyView(personID, familyView)
+	if !ok {
+		return
+	}
+	rc.visited[personID] = true
+	rc.relationships[personID] = rel
+	if len(events) > 0 {
+		rc.eventsMap[personID] = events
+	}
+	rc.progress.recordRelationship(personID, rel, events)
+}
+
+// add records the focus person of a family view and, with more than one generation,
+// every other tree person in it whose whole family is also in it
+func (rc *relationshipCollector) add(focusID string, familyView *ancestry.FamilyViewResponse) {
+	rc.record(focusID, familyView)
+	if rc.generations <= 1 {
+		return
+	}
+	personsMap := buildFamilyViewPersonsMap(familyView.Persons)
+	for _, seen := range familyView.Persons {
+		seenID := seen.GetPersonID()
+		if rc.inTree[seenID] && !rc.visited[seenID] && familyViewHasAllFamily(seen, personsMap) {
+			rc.record(seenID, familyView)
+		}
+	}
+}
 
-		rel, events, ok := processFamilyView(personID, familyView)
-		if !ok {
+// nextBatch returns up to size persons from persons[start:] that haven't been recorded yet,
+// and the index to continue from
+func (rc *relationshipCollector) nextBatch(persons []ancestry.Person, start, size int) ([]ancestry.Person, int) {
+	var batch []ancestry.Person
+	for ; start < len(persons) && len(batch) < size; start++ {
+		personID := persons[start].GetPersonID()
+		if personID != "" && !rc.visited[personID] {
+			batch = append(batch, persons[start])
+		}
+	}
+	return batch, start
+}
+
+// fetchFamilyViews fetches the family views of a batch, fetching once more those the API answered
+// with an error object, which is usually transient. It returns the results and the number of
+// requests made.
+func fetchFamilyViews(personNumbers []string, fetchBatch familyViewBatchFetcher) ([]ancestry.FamilyViewResult, int) {
+	results := fetchBatch(personNumbers)
+	var retryIndexes []int
+	var retryNumbers []string
+	for i, result := range results {
+		var viewErr *ancestry.FamilyViewError
+		if errors.As(result.Err, &viewErr) {
+			retryIndexes = append(retryIndexes, i)
+			retryNumbers = append(retryNumbers, personNumbers[i])
+		}
+	}
+	if len(retryNumbers) == 0 {
+		return results, len(results)
+	}
+
+	for j, result := range fetchBatch(retryNumbers) {
+		results[retryIndexes[j]] = result
+	}
+	return results, len(results) + len(retryNumbers)
+}
+
+// collectRelationships fetches a family view per person, batchSize persons at a time, and
+// records their relationships. With one generation only the focus person of each response is
+// recorded. With more, every tree person in the response whose whole family is also in the
+// response is recorded too and skipped in later batches, so far fewer requests are needed.
+// Persons at the edge of a response are left for their own request. It returns the number
+// of family views fetched. Persons whose family view is refused are recorded in restricted,
+// and fetched relationships in progress; either may be nil. Collection stops between batches
+// once ctx is done. Progress and failures go to logger.
+func collectRelationships(ctx context.Context, persons []ancestry.Person, generations, batchSize int, fetchBatch familyViewBatchFetcher,
+	restricted *restrictedLog, progress *downloadProgress, logger *log.Logger) (map[string]PersonRelationship, map[string][]ancestry.Event, int) {
+	rc := newRelationshipCollector(persons, generations, progress)
+	calls, failed := 0, 0
+	nextProgress := 10
+
+	for start := 0; start < len(persons) && ctx.Err() == nil; {
+		var batch []ancestry.Person
+		batch, start = rc.nextBatch(persons, start, max(batchSize, 1))
+		if len(batch) == 0 {
 			continue
 		}
 
-		relationships[personID] = rel
-		if len(events) > 0 {
-			eventsMap[personID] = events
+		if start >= nextProgress {
+			logger.Printf("   Building relationships %d/%d...\n", start, len(persons))
+			nextProgress = start - start%10 + 10
+		}
+
+		personNumbers := make([]string, len(batch))
+		for i, person := range batch {
+			personNumbers[i] = extractPersonNumber(person.GetPersonID())
+		}
+
+		results, requests := fetchFamilyViews(personNumbers, fetchBatch)
+		calls += requests
+		for i, result := range results {
+			if result.Err != nil && isRestricted(result.Err) {
+				restricted.add(RestrictedFamilyView, batch[i])
+				continue
+			}
+			if result.Err != nil {
+				if failed++; failed <= 3 {
+					logger.Printf("   [Debug] Failed to get family view for %s: %v\n", batch[i].GetDisplayName(), result.Err)
+				}
+				continue
+			}
+			rc.add(batch[i].GetPersonID(), result.FamilyView)
 		}
 	}
 
-	return relationships, eventsMap
+	return rc.relationships, rc.eventsMap, calls
 }
 
 // downloadAllPersons fetches all persons from the tree with pagination
-func downloadAllPersons(apiClient *ancestry.APIClient, treeID string, totalCount int) ([]ancestry.Person, error) {
-	limit := 100
+// With tags only the persons with one of the tags are fetched. Progress goes to logger.
+func downloadAllPersons(ctx context.Context, apiClient *ancestry.APIClient, treeID string, totalCount, limit int, tags []string, logger *log.Logger) ([]ancestry.Person, error) {
 	totalPages := (totalCount + limit - 1) / limit
 
-	allPersons := []ancestry.Person{}
+	allPersons, err := fetchPersonPages(limit, func(page int) ([]ancestry.Person, error) {
+		if len(tags) > 0 {
+			// The count covers the whole tree, so the number of tagged pages isn't known
+			logger.Printf("   Fetching page %d...\n", page)
+		} else if page > totalPages {
+			logger.Printf("   Fetching page %d (beyond the expected %d)...\n", page, totalPages)
+		} else {
+			logger.Printf("   Fetching page %d/%d...\n", page, totalPages)
+		}
+		return apiClient.GetAllPersons(ctx, treeID, page, limit, tags)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(tags) == 0 && len(allPersons) != totalCount {
+		logger.Printf("   [Warning] Tree reported %d persons but %d were returned; the tree may have changed during the download\n", totalCount, len(allPersons))
+	}
+
+	return allPersons, nil
+}
 
-	for page := 1; page <= totalPages; page++ {
-		fmt.Printf("   Fetching page %d/%d...\n", page, totalPages)
-		persons, err := apiClient.GetAllPersons(treeID, page, limit)
+// fetchPersonPages fetches pages of persons until a page comes back with fewer than
+// limit persons. The person count can be stale if the tree is edited mid-run, so the
+// pages actually returned decide when to stop rather than the computed page count.
+func fetchPersonPages(limit int, fetchPage func(page int) ([]ancestry.Person, error)) ([]ancestry.Person, error) {
+	allPersons := []ancestry.Person{}
+	for page := 1; ; page++ {
+		persons, err := fetchPage(page)
 		if err != nil {
 			return nil, fmt.Errorf("failed to fetch page %d: %w", page, err)
 		}
 		allPersons = append(allPersons, persons...)
+		if len(persons) < limit {
+			return allPersons, nil
+		}
 	}
-
-	return allPersons, nil
 }
 
 // createDirectoryStructure creates the output directory structure
@@ -413,21 +1119,35 @@ func createDirectoryStructure(outputDir string) error {
 	return nil
 }
 
-// saveTreeData saves all tree data to JSON files
-// convertEventToReadableFormat converts an ancestry event to readable map format
+// convertEventToReadableFormat converts an ancestry event to readable map format. The parsed
+// year and sort key let the HTML viewer order events without parsing dates itself.
 func convertEventToReadableFormat(event ancestry.Event) map[string]interface{} {
+	parsed, _ := ancestry.ParseGenealogyDate(event.Date)
 	eventData := map[string]interface{}{
-		"type": event.Type,
-		"date": event.Date,
+		"type":    event.Type,
+		"date":    event.Date,
+		"sortKey": parsed.SortKey(),
+	}
+	if parsed.Year != 0 {
+		eventData["year"] = parsed.Year
 	}
 
 	if place := extractPlaceFromNPS(event.NPS); place != "" {
 		eventData["place"] = place
+		if detail, ok := ancestry.ParseNPS(event.NPS); ok {
+			eventData["placeDetail"] = detail
+		}
+		if _, standardized := ancestry.ParsePlaceForms(event.NPS); standardized != "" && standardized != place {
+			eventData["placeStd"] = standardized
+		}
 	}
 
 	if event.Description != "" {
 		eventData["description"] = event.Description
 	}
+	if event.DescriptionTruncated {
+		eventData["descriptionTruncated"] = true
+	}
 
 	return eventData
 }
@@ -453,13 +1173,25 @@ func convertPersonToReadableFormat(person ancestry.Person, relationships map[str
 		readable["gender"] = person.Gender
 	}
 
-	// Add events in readable format
-	if len(person.Events) > 0 {
-		events := make([]map[string]interface{}, 0, len(person.Events))
-		for _, event := range person.Events {
+	if len(person.Notes) > 0 {
+		readable["notes"] = person.Notes
+	}
+
+	// Add events in readable format, in chronological order so consumers don't have to re-sort.
+	// Without detailed events, the list API's summary still gives basic birth and death dates.
+	personEvents := person.Events
+	if len(personEvents) == 0 {
+		personEvents = person.SummaryLifeEvents()
+	}
+	if len(personEvents) > 0 {
+		sorted := append([]ancestry.Event(nil), personEvents...)
+		sortEventsByDate(sorted)
+		events := make([]map[string]interface{}, 0, len(sorted))
+		for _, event := range sorted {
 			events = append(events, convertEventToReadableFormat(event))
 		}
 		readable["events"] = events
+		addReadableAges(readable)
 	}
 
 	// Add relationships
@@ -488,29 +1220,51 @@ func convertPersonToReadableFormat(person ancestry.Person, relationships map[str
 	return readable
 }
 
-// savePersonsData saves persons to a JSON file in readable format
-func savePersonsData(outputDir string, persons []ancestry.Person, relationships map[string]PersonRelationship,
-	mediaIndex map[string]PersonMediaInfo, recordIndex map[string]PersonRecordInfo) error {
+// buildReadablePersons converts all persons to the readable format used by people.json
+func buildReadablePersons(persons []ancestry.Person, relationships map[string]PersonRelationship,
+	mediaIndex map[string]PersonMediaInfo, recordIndex map[string]PersonRecordInfo) []map[string]interface{} {
 	readablePersons := make([]map[string]interface{}, 0, len(persons))
 	for _, person := range persons {
 		readablePersons = append(readablePersons, convertPersonToReadableFormat(person, relationships, mediaIndex, recordIndex))
 	}
+	return readablePersons
+}
 
-	readableJSON, err := json.MarshalIndent(readablePersons, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal readable persons data: %w", err)
-	}
+// savePersonsData saves persons to a JSON file in readable format, gzipped when compress is set
+func savePersonsData(outputDir string, persons []ancestry.Person, relationships map[string]PersonRelationship,
+	mediaIndex map[string]PersonMediaInfo, recordIndex map[string]PersonRecordInfo, compress bool) error {
+	readablePersons := buildReadablePersons(persons, relationships, mediaIndex, recordIndex)
 
-	personsPath := filepath.Join(outputDir, "people.json")
-	if err := os.WriteFile(personsPath, readableJSON, 0644); err != nil {
-		return fmt.Errorf("failed to write people.json: %w", err)
+	if err := writeJSONFile(outputDir, "people.json", readablePersons, compress); err != nil {
+		return fmt.Errorf("failed to write %s: %w", jsonFileName("people.json", compress), err)
 	}
 
 	return nil
 }
 
+// factsSkippedNote explains in exported metadata what --no-facts leaves out
+const factsSkippedNote = "Facts pages were skipped (--no-facts): events come from the family view only and may lack places, descriptions and custom event types"
+
+// addFactsSkippedNote marks exported metadata as missing the Facts page detail
+func addFactsSkippedNote(metadata map[string]interface{}, factsSkipped bool) {
+	if factsSkipped {
+		metadata["factsSkipped"] = true
+		metadata["note"] = factsSkippedNote
+	}
+}
+
 // saveMetadata saves tree metadata to a JSON file
 func saveMetadata(outputDir string, treeExport *TreeExport) error {
+	if err := writeJSONFile(outputDir, "metadata.json", treeMetadata(treeExport), treeExport.Compress); err != nil {
+		return fmt.Errorf("failed to write %s: %w", jsonFileName("metadata.json", treeExport.Compress), err)
+	}
+
+	return nil
+}
+
+// treeMetadata returns the export's metadata as written to metadata.json, including the
+// markers for skipped facts, excluded media, redaction, an interrupted run and restricted persons
+func treeMetadata(treeExport *TreeExport) map[string]interface{} {
 	metadata := map[string]interface{}{
 		"treeId":      treeExport.TreeID,
 		"treeName":    treeExport.TreeName,
@@ -518,30 +1272,58 @@ func saveMetadata(outputDir string, treeExport *TreeExport) error {
 		"personCount": treeExport.PersonCount,
 		"treeInfo":    treeExport.TreeInfo,
 	}
-
-	metadataJSON, err := json.MarshalIndent(metadata, "", "  ")
-	if err != nil {
-		return fmt.Errorf("failed to marshal metadata: %w", err)
+	addFactsSkippedNote(metadata, treeExport.FactsSkipped)
+	if treeExport.MediaExcluded {
+		metadata["mediaExcluded"] = true
+	}
+	if treeExport.Redacted {
+		metadata["redacted"] = true
+	}
+	if treeExport.Incomplete {
+		metadata["incomplete"] = true
+	}
+	if len(treeExport.Restricted) > 0 {
+		metadata["restrictedPersons"] = treeExport.Restricted
 	}
+	return metadata
+}
 
-	metadataPath := filepath.Join(outputDir, "metadata.json")
-	if err := os.WriteFile(metadataPath, metadataJSON, 0644); err != nil {
-		return fmt.Errorf("failed to write metadata.json: %w", err)
+// saveMediaIndex saves the media file index, including cache validators, to media-index.json
+// (media-index.json.gz when compress is set)
+func saveMediaIndex(outputDir string, mediaIndex map[string]PersonMediaInfo, compress bool) error {
+	if err := writeJSONFile(outputDir, "media-index.json", mediaIndex, compress); err != nil {
+		return fmt.Errorf("failed to write %s: %w", jsonFileName("media-index.json", compress), err)
 	}
 
 	return nil
 }
 
-func saveTreeData(outputDir string, treeExport *TreeExport, relationships map[string]PersonRelationship, mediaIndex map[string]PersonMediaInfo, recordIndex map[string]PersonRecordInfo) error {
-	if err := savePersonsData(outputDir, treeExport.Persons, relationships, mediaIndex, recordIndex); err != nil {
-		return err
+// loadPreviousMediaFiles reads media-index.json from a previous run and returns its files keyed by media ID.
+// A missing or unreadable index simply means everything is downloaded fresh; an unreadable
+// one is reported to logger.
+func loadPreviousMediaFiles(outputDir string, logger *log.Logger) map[string]MediaFileInfo {
+	files := make(map[string]MediaFileInfo)
+
+	data, err := readJSONFile(outputDir, "media-index.json")
+	if err != nil {
+		return files
 	}
 
-	if err := saveMetadata(outputDir, treeExport); err != nil {
-		return err
+	var mediaIndex map[string]PersonMediaInfo
+	if err := json.Unmarshal(data, &mediaIndex); err != nil {
+		logger.Printf("   [Warning] Ignoring unreadable media-index.json: %v\n", err)
+		return files
 	}
 
-	return nil
+	for _, personInfo := range mediaIndex {
+		for _, file := range personInfo.Files {
+			if file.MediaID != "" {
+				files[file.MediaID] = file
+			}
+		}
+	}
+
+	return files
 }
 
 // PersonMediaInfo tracks media files for a person
@@ -549,81 +1331,251 @@ type PersonMediaInfo struct {
 	PersonID   string          `json:"personId"`
 	PersonName string          `json:"personName"`
 	Files      []MediaFileInfo `json:"files"`
+	OverLimit  int             `json:"overLimit,omitempty"` // Media items not downloaded because of --max-media-per-person
 }
 
 // MediaFileInfo contains information about a downloaded media file
 type MediaFileInfo struct {
-	FilePath    string `json:"filePath"`
-	Title       string `json:"title"`
-	Category    string `json:"category"`
-	Subcategory string `json:"subcategory"`
-	Description string `json:"description"`
-	Date        string `json:"date"`
-	Type        string `json:"type"`
+	MediaID      string `json:"mediaId,omitempty"`
+	FilePath     string `json:"filePath"`
+	Title        string `json:"title"`
+	Category     string `json:"category"`
+	Subcategory  string `json:"subcategory"`
+	Description  string `json:"description"`
+	Date         string `json:"date"`
+	Type         string `json:"type"`
+	ETag         string `json:"etag,omitempty"`         // Cache validator from the last download
+	LastModified string `json:"lastModified,omitempty"` // Cache validator from the last download
+
+	TitleTruncated       bool `json:"titleTruncated,omitempty"`       // Title was shortened to --max-description-length
+	DescriptionTruncated bool `json:"descriptionTruncated,omitempty"` // Description was shortened to --max-description-length
+
+	Width  int `json:"width,omitempty"`  // Image width in pixels; 0 for PDFs and images that couldn't be decoded
+	Height int `json:"height,omitempty"` // Image height in pixels
 }
 
+// factsFetcher fetches the facts-page research data of one person
+type factsFetcher func(personID string) (*ancestry.ResearchData, error)
+
 // fetchFactsForAllPersons fetches complete event data from Facts pages for all persons
 // This includes place names and descriptions that aren't available in the JSON APIs
-func fetchFactsForAllPersons(apiClient *ancestry.APIClient, treeID string, persons []ancestry.Person) {
+// Up to concurrency persons are fetched in parallel (see mergeFactsForPersons).
+// With humanDelay set, a randomized pause (see nextHumanDelay) is taken before each request after
+// the first. The pause is independent of any client-side rate limiting, which still applies.
+// Failed fetches are recorded in failures and refused ones in restricted, and fetched facts in
+// progress; any may be nil. Progress and failures go to logger.
+func fetchFactsForAllPersons(ctx context.Context, apiClient *ancestry.APIClient, treeID string, persons []ancestry.Person, concurrency int,
+	humanDelay bool, failures *failureLog, restricted *restrictedLog, progress *downloadProgress, logger *log.Logger) {
+	fetch := func(personID string) (*ancestry.ResearchData, error) {
+		return apiClient.GetPersonFactsFromHTML(ctx, treeID, personID)
+	}
+	mergeFactsForPersons(ctx, persons, concurrency, humanDelay, fetch, failures, restricted, progress, logger)
+	progress.save()
+}
+
+// mergeFactsForPersons fetches every person's facts with up to concurrency workers and merges
+// the events into persons[i]. Each person is handled by one worker, so results always land on
+// the right person whatever order the fetches finish in. With humanDelay set, each worker
+// pauses before every request after its first. No more persons are fetched once ctx is done.
+// Persons whose facts an earlier, unfinished download recorded in progress get their events
+// from it instead of being fetched again.
+func mergeFactsForPersons(ctx context.Context, persons []ancestry.Person, concurrency int, humanDelay bool, fetch factsFetcher,
+	failures *failureLog, restricted *restrictedLog, progress *downloadProgress, logger *log.Logger) {
+	if concurrency < 1 {
+		concurrency = 1
+	}
 	totalPersons := len(persons)
 
-	for i := range persons {
-		personID := persons[i].GetPersonID()
+	var mu sync.Mutex
+	started := 0
+	var wg sync.WaitGroup
+	jobs := make(chan int)
+
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func(seed uint64) {
+			defer wg.Done()
+			rng := rand.New(rand.NewPCG(seed, 0))
+			first := true
+			for i := range jobs {
+				// An interrupted pause skips the person; the producer stops sending more
+				if humanDelay && !first && !pause(ctx, nextHumanDelay(rng)) {
+					continue
+				}
+				first = false
 
-		// Show progress every 10 people
-		if (i+1)%10 == 0 || i == 0 {
-			fmt.Printf("   Fetching facts %d/%d...\n", i+1, totalPersons)
+				// Show progress every 10 people
+				mu.Lock()
+				started++
+				if started%10 == 0 || started == 1 {
+					logger.Printf("   Fetching facts %d/%d...\n", started, totalPersons)
+				}
+				mu.Unlock()
+
+				if researchData, ok := mergePersonFacts(&persons[i], fetch, failures, restricted, logger); ok {
+					var sources []ancestry.PersonSourceDetail
+					if researchData != nil {
+						sources = researchData.PersonSources
+					}
+					progress.recordFacts(persons[i].GetPersonID(), persons[i].Events, sources)
+				}
+			}
+		}(uint64(time.Now().UnixNano()) + uint64(w))
+	}
+
+	for i := range persons {
+		if ctx.Err() != nil {
+			break
+		}
+		if recorded, ok := progress.person(persons[i].GetPersonID()); ok && recorded.Facts != nil {
+			persons[i].Events = recorded.Facts.Events
+			continue
 		}
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+}
 
-		// Fetch facts from HTML page
-		researchData, err := apiClient.GetPersonFactsFromHTML(treeID, personID)
-		if err != nil {
-			// Don't fail the whole process, just log and continue
-			fmt.Printf("\n   [Warning] Failed to get facts for %s: %v\n", persons[i].GetDisplayName(), err)
+// mergePersonFacts fetches one person's facts and merges the events into the person. It
+// returns the Facts page data, which may be nil, and whether it was fetched.
+func mergePersonFacts(person *ancestry.Person, fetch factsFetcher, failures *failureLog, restricted *restrictedLog,
+	logger *log.Logger) (*ancestry.ResearchData, bool) {
+	researchData, err := fetch(person.GetPersonID())
+	if errors.Is(err, context.Canceled) {
+		return nil, false
+	}
+	if err != nil && isRestricted(err) {
+		restricted.add(RestrictedFacts, *person)
+		return nil, false
+	}
+	if err != nil {
+		// Don't fail the whole process, just log and continue
+		logger.Printf("\n   [Warning] Failed to get facts for %s: %v\n", person.GetDisplayName(), err)
+		failures.add(FailureFacts, *person, err)
+		return nil, false
+	}
+
+	if researchData == nil || len(researchData.PersonFacts) == 0 {
+		return researchData, true
+	}
+
+	// Merge the complete facts-page data with the FamilyView events
+	if events := factsToEvents(researchData.PersonFacts); len(events) > 0 {
+		person.Events = mergeEvents(person.Events, events)
+	}
+	return researchData, true
+}
+
+// notePersonIDs returns the IDs of the persons whose notes are fetched, leaving out living
+// persons with excludeLiving set
+func notePersonIDs(persons []ancestry.Person, excludeLiving bool) []string {
+	personIDs := make([]string, 0, len(persons))
+	for i := range persons {
+		if excludeLiving && persons[i].IsLiving {
 			continue
 		}
+		personIDs = append(personIDs, persons[i].GetPersonID())
+	}
+	return personIDs
+}
+
+// fetchNotesForAllPersons attaches private notes to each person and returns how many
+// persons had notes. Notes can hold sensitive details, so with excludeLiving set living
+// persons are skipped.
+func fetchNotesForAllPersons(ctx context.Context, apiClient *ancestry.APIClient, treeID string, persons []ancestry.Person, excludeLiving bool,
+	logger *log.Logger) int {
+	personIDs := notePersonIDs(persons, excludeLiving)
+	notes := fetchNotesInBatches(ctx, apiClient.GetNotesForPersons, treeID, personIDs, ancestry.DefaultCommentsBatchSize, logger)
+	notedCount := 0
+	for i := range persons {
+		if personNotes, ok := notes[persons[i].GetPersonID()]; ok {
+			persons[i].Notes = personNotes
+			notedCount++
+		}
+	}
+	return notedCount
+}
 
-		if researchData == nil || len(researchData.PersonFacts) == 0 {
+// factsToEvents converts facts-page facts into events, skipping facts without meaningful data
+func factsToEvents(facts []ancestry.PersonFactDetail) []ancestry.Event {
+	events := make([]ancestry.Event, 0, len(facts))
+	for _, fact := range facts {
+		// Only include facts that have meaningful data
+		eventType := fact.GetEventType()
+		if eventType == "" && fact.Place == "" && fact.Description == "" {
 			continue
 		}
 
-		// Convert PersonFacts to Events and update the person
-		events := make([]ancestry.Event, 0, len(researchData.PersonFacts))
-		for _, fact := range researchData.PersonFacts {
-			// Only include facts that have meaningful data
-			if fact.TypeString == "" && fact.Place == "" && fact.Description == "" {
-				continue
-			}
+		// Use Title field for custom events (like "Prison"), otherwise use the type name
+		if eventType == "CustomEvent" && fact.Title != "" {
+			eventType = fact.Title
+		}
 
-			// Use Title field for custom events (like "Prison"), otherwise use TypeString
-			eventType := fact.TypeString
-			if fact.TypeString == "CustomEvent" && fact.Title != "" {
-				eventType = fact.Title
-			}
+		event := ancestry.Event{
+			Type:        eventType,
+			Date:        fact.Date,
+			Description: fact.Description,
+		}
 
-			event := ancestry.Event{
-				Type:        eventType,
-				Date:        fact.Date,
-				Description: fact.Description,
+		// Add place data if available
+		if fact.Place != "" {
+			// Create NPS structure to match existing Event format
+			nps := []map[string]interface{}{
+				{"v": fact.Place},
 			}
+			event.NPS = nps
+		}
 
-			// Add place data if available
-			if fact.Place != "" {
-				// Create NPS structure to match existing Event format
-				nps := []map[string]interface{}{
-					{"v": fact.Place},
-				}
-				event.NPS = nps
-			}
+		events = append(events, event)
+	}
+	return events
+}
 
-			events = append(events, event)
-		}
+// sortEventsByDate sorts events chronologically, keeping undated events last
+// in their original order
+func sortEventsByDate(events []ancestry.Event) {
+	sort.SliceStable(events, func(i, j int) bool {
+		di, _ := ancestry.ParseGenealogyDate(events[i].Date)
+		dj, _ := ancestry.ParseGenealogyDate(events[j].Date)
+		return di.SortKey() < dj.SortKey()
+	})
+}
+
+// eventDateText returns an event's date as text, whichever form Ancestry returned it in,
+// so the same date as a string and as an object compares equal
+func eventDateText(raw interface{}) string {
+	parsed, _ := ancestry.ParseGenealogyDate(raw)
+	return parsed.Original
+}
+
+// eventKey identifies an event by type and date for merging
+func eventKey(event ancestry.Event) string {
+	return event.Type + "|" + eventDateText(event.Date)
+}
+
+// mergeEvents unions FamilyView events with facts-page events by (type, date).
+// The facts-page version wins when both have an event, since it carries places
+// and descriptions, but events only FamilyView knows about are kept.
+func mergeEvents(familyViewEvents, factsEvents []ancestry.Event) []ancestry.Event {
+	merged := make([]ancestry.Event, 0, len(factsEvents)+len(familyViewEvents))
+	seen := make(map[string]bool, len(factsEvents))
 
-		// Update person's events with complete data
-		if len(events) > 0 {
-			persons[i].Events = events
+	for _, event := range factsEvents {
+		merged = append(merged, event)
+		seen[eventKey(event)] = true
+	}
+
+	for _, event := range familyViewEvents {
+		key := eventKey(event)
+		if seen[key] {
+			continue
 		}
+		merged = append(merged, event)
+		seen[key] = true
 	}
+
+	return merged
 }
 
 // getRelationshipGenderLabel returns a gender-specific relationship label
@@ -645,7 +1597,7 @@ func processChildEvents(childRefs []RelationshipReference, personMap map[string]
 		}
 		for _, evt := range child.Events {
 			if (evt.Type == Birth || evt.Type == Death) && evt.Date != nil {
-				dateStr := fmt.Sprintf("%v", evt.Date)
+				dateStr := eventDateText(evt.Date)
 				genderLabel := getRelationshipGenderLabel(child.Gender, "son", "daughter", "child")
 				label := fmt.Sprintf("%s of %s %s", evt.Type, genderLabel, child.GetDisplayName())
 				dateToEventType[dateStr] = label
@@ -654,42 +1606,50 @@ func processChildEvents(childRefs []RelationshipReference, personMap map[string]
 	}
 }
 
-// hasSharedParent checks if two people share at least one parent
-func hasSharedParent(myParents, theirParents []RelationshipReference) bool {
-	for _, myParent := range myParents {
-		for _, theirParent := range theirParents {
-			if myParent.PersonID == theirParent.PersonID {
-				return true
-			}
+// buildChildrenByParent indexes persons by each of their parents, so siblings can be found
+// without scanning the whole tree. Indices are in persons order.
+func buildChildrenByParent(persons []ancestry.Person, relationships map[string]PersonRelationship) map[string][]int {
+	childrenByParent := make(map[string][]int)
+	for i := range persons {
+		rels, hasRels := relationships[persons[i].GetPersonID()]
+		if !hasRels {
+			continue
+		}
+		for _, parent := range rels.Parents {
+			childrenByParent[parent.PersonID] = append(childrenByParent[parent.PersonID], i)
 		}
 	}
-	return false
+	return childrenByParent
 }
 
-// processSiblingEvents processes siblings' birth and death events for inference
-func processSiblingEvents(personID string, rels PersonRelationship, persons []ancestry.Person,
-	relationships map[string]PersonRelationship, dateToEventType map[string]string) {
-	if len(rels.Parents) == 0 {
-		return
-	}
-
-	for _, otherPerson := range persons {
-		if otherPerson.GetPersonID() == personID {
-			continue
-		}
-		otherRels, hasOtherRels := relationships[otherPerson.GetPersonID()]
-		if !hasOtherRels || len(otherRels.Parents) == 0 {
-			continue
+// findSiblingIndices returns the indices of persons sharing at least one parent with
+// the given person, in persons order
+func findSiblingIndices(personIndex int, rels PersonRelationship, childrenByParent map[string][]int) []int {
+	seen := map[int]bool{personIndex: true}
+	var siblings []int
+	for _, parent := range rels.Parents {
+		for _, idx := range childrenByParent[parent.PersonID] {
+			if !seen[idx] {
+				seen[idx] = true
+				siblings = append(siblings, idx)
+			}
 		}
+	}
+	sort.Ints(siblings)
+	return siblings
+}
 
-		if hasSharedParent(rels.Parents, otherRels.Parents) {
-			for _, evt := range otherPerson.Events {
-				if (evt.Type == Birth || evt.Type == Death) && evt.Date != nil {
-					dateStr := fmt.Sprintf("%v", evt.Date)
-					genderLabel := getRelationshipGenderLabel(otherPerson.Gender, "brother", "sister", "sibling")
-					label := fmt.Sprintf("%s of %s %s", evt.Type, genderLabel, otherPerson.GetDisplayName())
-					dateToEventType[dateStr] = label
-				}
+// processSiblingEvents processes siblings' birth and death events for inference
+func processSiblingEvents(personIndex int, rels PersonRelationship, persons []ancestry.Person,
+	childrenByParent map[string][]int, dateToEventType map[string]string) {
+	for _, idx := range findSiblingIndices(personIndex, rels, childrenByParent) {
+		sibling := persons[idx]
+		for _, evt := range sibling.Events {
+			if (evt.Type == Birth || evt.Type == Death) && evt.Date != nil {
+				dateStr := eventDateText(evt.Date)
+				genderLabel := getRelationshipGenderLabel(sibling.Gender, "brother", "sister", "sibling")
+				label := fmt.Sprintf("%s of %s %s", evt.Type, genderLabel, sibling.GetDisplayName())
+				dateToEventType[dateStr] = label
 			}
 		}
 	}
@@ -705,7 +1665,7 @@ func processRelativeDeathEvents(relativeRefs []RelationshipReference, personMap
 		}
 		for _, evt := range relative.Events {
 			if evt.Type == Death && evt.Date != nil {
-				dateStr := fmt.Sprintf("%v", evt.Date)
+				dateStr := eventDateText(evt.Date)
 				genderLabel := getRelationshipGenderLabel(relative.Gender, maleLabel, femaleLabel, neutralLabel)
 				label := fmt.Sprintf("Death of %s %s", genderLabel, relative.GetDisplayName())
 				dateToEventType[dateStr] = label
@@ -728,7 +1688,7 @@ func updateEmptyEvents(person *ancestry.Person, dateToEventType map[string]strin
 	count := 0
 	for j := range person.Events {
 		if person.Events[j].Type == "" && person.Events[j].Date != nil {
-			dateStr := fmt.Sprintf("%v", person.Events[j].Date)
+			dateStr := eventDateText(person.Events[j].Date)
 			if inferredType, found := dateToEventType[dateStr]; found {
 				person.Events[j].Type = inferredType
 				count++
@@ -742,6 +1702,7 @@ func updateEmptyEvents(person *ancestry.Person, dateToEventType map[string]strin
 // Returns the count of events that were inferred
 func inferEventTypes(persons []ancestry.Person, relationships map[string]PersonRelationship) int {
 	personMap := buildPersonMap(persons)
+	childrenByParent := buildChildrenByParent(persons, relationships)
 	inferredCount := 0
 
 	for i := range persons {
@@ -755,7 +1716,7 @@ func inferEventTypes(persons []ancestry.Person, relationships map[string]PersonR
 
 		// Process different types of relatives
 		processChildEvents(rels.Children, personMap, dateToEventType)
-		processSiblingEvents(personID, rels, persons, relationships, dateToEventType)
+		processSiblingEvents(i, rels, persons, childrenByParent, dateToEventType)
 		processRelativeDeathEvents(rels.Parents, personMap, dateToEventType, "father", "mother", "parent")
 		processRelativeDeathEvents(rels.Spouses, personMap, dateToEventType, "husband", "wife", "spouse")
 
@@ -807,16 +1768,19 @@ func getMediaSubdirectory(category string) string {
 }
 
 // processMediaItem downloads and saves a single media item
-func processMediaItem(apiClient *ancestry.APIClient, mediaItem ancestry.PrimaryMediaItem, personID, personName string,
-	idx int, outputDir string) (MediaFileInfo, bool, error) {
+// If a previous download of the same media is known, its cache validators are sent so an
+// unchanged file is skipped without transferring it again.
+func processMediaItem(ctx context.Context, apiClient *ancestry.APIClient, mediaItem ancestry.PrimaryMediaItem, owner ancestry.MediaOwner, personName string,
+	idx int, outputDir string, previous *MediaFileInfo, opts downloadTreeOptions) (MediaFileInfo, bool, error) {
 
-	filename := generateMediaFilename(personName, personID, mediaItem, idx)
+	filename := generateMediaFilename(personName, owner.PersonID, mediaItem, idx)
 	subdir := getMediaSubdirectory(mediaItem.Category)
 
 	filePath := filepath.Join(outputDir, "media", subdir, filename)
 	relativeFilePath := filepath.Join("media", subdir, filename)
 
 	mediaFileInfo := MediaFileInfo{
+		MediaID:     mediaItem.MediaID,
 		FilePath:    relativeFilePath,
 		Title:       mediaItem.Title,
 		Category:    mediaItem.Category,
@@ -825,61 +1789,96 @@ func processMediaItem(apiClient *ancestry.APIClient, mediaItem ancestry.PrimaryM
 		Date:        mediaItem.Date,
 		Type:        mediaItem.Type,
 	}
+	mediaFileInfo.truncateText(opts.MaxDescriptionLength)
 
 	// Check if file already exists
 	if _, err := os.Stat(filePath); err == nil {
-		return mediaFileInfo, false, nil
+		if !opts.ValidateMedia || validMediaFile(outputDir, &mediaFileInfo, opts) {
+			return mediaFileInfo, false, nil
+		}
 	}
 
 	namespaceToUse, mediaGUIDToUse, ok := ExtractMediaDetailsFromURL(mediaItem.URL)
 	var fileData []byte
 	var err error
 
+	cached := previousValidators(outputDir, previous, opts)
+	changedUpstream := false
+
 	if !ok {
 		// Fallback to old download method if namespace/GUID cannot be extracted
-		fileData, err = apiClient.DownloadFile(mediaItem.URL)
+		fileData, err = apiClient.DownloadFile(ctx, owner, mediaItem.URL)
 		if err != nil {
 			return mediaFileInfo, false, fmt.Errorf("fallback download failed for %s: %w", mediaItem.URL, err)
 		}
 	} else {
-		// Download using GetMediaImage
-		fileData, err = apiClient.GetMediaImage(namespaceToUse, mediaGUIDToUse, 0, 0) // 0,0 for largest
-		if err != nil {
+		// Download using GetMediaImage, skipping the transfer if the file is unchanged
+		var download *ancestry.MediaDownload
+		download, err = apiClient.GetMediaImageIfModified(ctx, namespaceToUse, mediaGUIDToUse, 0, 0, cached) // 0,0 for largest
+		if err == nil && download.NotModified {
+			mediaFileInfo.FilePath = previous.FilePath
+			mediaFileInfo.ETag = cached.ETag
+			mediaFileInfo.LastModified = cached.LastModified
+			mediaFileInfo.Width, mediaFileInfo.Height = previous.Width, previous.Height
+			return mediaFileInfo, false, nil
+		}
+		if err == nil {
+			fileData = download.Data
+			changedUpstream = cached != ancestry.CacheValidators{}
+			mediaFileInfo.ETag = download.Validators.ETag
+			mediaFileInfo.LastModified = download.Validators.LastModified
+		} else {
 			// Fallback to old download method if GetMediaImage fails
-			fmt.Printf("   [Warning] GetMediaImage failed for %s (namespace: %s, GUID: %s): %v. Falling back to direct download.\n", mediaItem.URL, namespaceToUse, mediaGUIDToUse, err)
-			fileData, err = apiClient.DownloadFile(mediaItem.URL)
+			opts.Log.Printf("   [Warning] GetMediaImage failed for %s (namespace: %s, GUID: %s): %v. Falling back to direct download.\n", mediaItem.URL, namespaceToUse, mediaGUIDToUse, err)
+			fileData, err = apiClient.DownloadFile(ctx, owner, mediaItem.URL)
 			if err != nil {
 				return mediaFileInfo, false, fmt.Errorf("fallback download failed after GetMediaImage failure for %s: %w", mediaItem.URL, err)
 			}
 		}
 	}
 
+	if opts.ValidateMedia {
+		if fileData, err = validatedMediaData(fileData, mediaRefetcher(ctx, apiClient, owner, mediaItem), mediaItem.URL, opts); err != nil {
+			return mediaFileInfo, false, err
+		}
+	}
+	mediaFileInfo.setImageDimensions(fileData)
+
 	// Detect file extension from downloaded data
 	ext := DetectFileExtension(fileData)
-	filenameWithExt := filename + ext
-	filePathWithExt := filepath.Join(outputDir, "media", subdir, filenameWithExt)
-	relativeFilePathWithExt := filepath.Join("media", subdir, filenameWithExt)
+	mediaDir := filepath.Join(outputDir, "media", subdir)
 
-	// Check if file with extension already exists
-	if _, err := os.Stat(filePathWithExt); err == nil {
-		mediaFileInfo.FilePath = relativeFilePathWithExt
-		return mediaFileInfo, false, nil
-	}
+	// Another goroutine may be resolving or writing the same name
+	unlock := mediaFileLocks.Lock(filepath.Join(mediaDir, filename))
+	defer unlock()
 
-	// Save the file with proper extension
-	if err := os.WriteFile(filePathWithExt, fileData, 0644); err != nil {
-		return mediaFileInfo, false, fmt.Errorf("save failed for %s: %w", filenameWithExt, err)
+	var savePath string
+	if changedUpstream {
+		// The media was revalidated and the server sent new content, so replace the local copy
+		savePath = filepath.Join(outputDir, previous.FilePath)
+		mediaFileInfo.FilePath = previous.FilePath
+	} else {
+		// Skip files already saved by a previous run, and pick a new name if a
+		// different file already uses this one
+		filenameWithExt, alreadySaved := resolveFilenameCollision(mediaDir, filename, ext, fileData, mediaItem.MediaID, opts.NameCollisionStrategy)
+		mediaFileInfo.FilePath = filepath.Join("media", subdir, filenameWithExt)
+		if alreadySaved {
+			return mediaFileInfo, false, nil
+		}
+		savePath = filepath.Join(mediaDir, filenameWithExt)
 	}
 
-	// Update the media file info with the actual filepath including extension
-	mediaFileInfo.FilePath = relativeFilePathWithExt
+	if err := os.WriteFile(savePath, fileData, 0644); err != nil {
+		return mediaFileInfo, false, fmt.Errorf("save failed for %s: %w", filepath.Base(savePath), err)
+	}
+	opts.MediaProgress.add(len(fileData))
 
 	return mediaFileInfo, true, nil
 }
 
 // processPersonMedia fetches and downloads all media for a single person
-func processPersonMedia(apiClient *ancestry.APIClient, treeID string, person ancestry.Person,
-	outputDir string) (PersonMediaInfo, int, error) {
+func processPersonMedia(ctx context.Context, apiClient *ancestry.APIClient, treeID string, person ancestry.Person,
+	outputDir string, previousFiles map[string]MediaFileInfo, opts downloadTreeOptions) (PersonMediaInfo, int, int, error) {
 	personID := person.GetPersonID()
 	personName := person.GetDisplayName()
 	if personName == "" {
@@ -893,23 +1892,34 @@ func processPersonMedia(apiClient *ancestry.APIClient, treeID string, person anc
 	}
 	downloaded := 0
 
-	mediaItems, err := apiClient.GetPersonMediaFromAPI(treeID, personID)
+	mediaItems, err := apiClient.GetPersonMediaFromAPI(ctx, treeID, personID)
 	if err != nil {
-		return personInfo, 0, fmt.Errorf("error getting media: %w", err)
+		return personInfo, 0, 0, fmt.Errorf("error getting media: %w", err)
 	}
 
 	if len(mediaItems) == 0 {
-		return personInfo, 0, nil
+		return personInfo, 0, 0, nil
 	}
 
-	fmt.Printf("   ✓ Found %d media item(s) for %s (ID: %s)\n",
+	opts.Log.Printf("   ✓ Found %d media item(s) for %s (ID: %s)\n",
 		len(mediaItems), personName, personID)
 
-	for idx, mediaItem := range mediaItems {
-		mediaFileInfo, wasDownloaded, err := processMediaItem(apiClient, mediaItem, personID, personName, idx, outputDir)
+	owner := ancestry.MediaOwner{TreeID: treeID, PersonID: personID}
+	indexes, filtered, overLimit := mediaItemsToDownload(mediaItems, opts.MediaCategories, opts.MaxMediaPerPerson)
+	personInfo.OverLimit = overLimit
+	for _, idx := range indexes {
+		mediaItem := mediaItems[idx]
+
+		var previous *MediaFileInfo
+		if prev, ok := previousFiles[mediaItem.MediaID]; ok && mediaItem.MediaID != "" {
+			previous = &prev
+		}
+
+		mediaFileInfo, wasDownloaded, err := processMediaItem(ctx, apiClient, mediaItem, owner, personName, idx, outputDir, previous, opts)
 		if err != nil {
-			fmt.Printf("   [Warning] Failed to process media for %s (ID: %s): %v\n",
+			opts.Log.Printf("   [Warning] Failed to process media for %s (ID: %s): %v\n",
 				personName, personID, err)
+			opts.Failures.add(FailureMedia, person, err)
 			continue
 		}
 		personInfo.Files = append(personInfo.Files, mediaFileInfo)
@@ -917,8 +1927,11 @@ func processPersonMedia(apiClient *ancestry.APIClient, treeID string, person anc
 			downloaded++
 		}
 	}
+	if personInfo.OverLimit > 0 {
+		opts.Log.Printf("   Skipped %d media item(s) for %s over --max-media-per-person\n", personInfo.OverLimit, personName)
+	}
 
-	return personInfo, downloaded, nil
+	return personInfo, downloaded, filtered, nil
 }
 
 // RecordImageInfo contains information about a downloaded record image
@@ -936,78 +1949,171 @@ type PersonRecordInfo struct {
 	Records  []RecordImageInfo `json:"records"`
 }
 
-// downloadAllRecordImages downloads census and vital record images from sources
-func downloadAllRecordImages(apiClient *ancestry.APIClient, treeID string, persons []ancestry.Person, outputDir string) (map[string]PersonRecordInfo, int) {
+// downloadAllRecordImages downloads census and vital record images from sources.
+// Failed fetches are recorded in failures and downloaded persons in progress; either may be
+// nil. Persons whose record images progress already has are skipped, and the Facts page is
+// only fetched for the sources of those whose facts it doesn't have. Progress goes to logger.
+func downloadAllRecordImages(ctx context.Context, apiClient *ancestry.APIClient, treeID string, persons []ancestry.Person, outputDir string,
+	failures *failureLog, progress *downloadProgress, logger *log.Logger) (map[string]PersonRecordInfo, int) {
 	recordIndex := make(map[string]PersonRecordInfo)
 	totalDownloaded := 0
 	recordMediaDir := filepath.Join(outputDir, "media", "records")
 
 	// Create records directory
 	if err := os.MkdirAll(recordMediaDir, 0755); err != nil {
-		fmt.Printf("   [Warning] Failed to create records directory: %v\n", err)
+		logger.Printf("   [Warning] Failed to create records directory: %v\n", err)
 		return recordIndex, 0
 	}
 
 	for i, person := range persons {
 		personID := person.GetPersonID()
 
+		if ctx.Err() != nil {
+			break
+		}
 		if personID == "" {
 			continue
 		}
 
 		if i%10 == 0 {
-			fmt.Printf("   Processing sources for person %d/%d...\n", i+1, len(persons))
+			logger.Printf("   Processing sources for person %d/%d...\n", i+1, len(persons))
 		}
 
-		// Fetch sources for this person
-		researchData, err := apiClient.GetPersonFactsFromHTML(treeID, personID)
-		if err != nil || researchData == nil {
+		recorded, _ := progress.person(personID)
+		if recorded.Records != nil {
+			if len(recorded.Records.Records) > 0 {
+				recordIndex[personID] = *recorded.Records
+			}
 			continue
 		}
 
-		personRecords := []RecordImageInfo{}
-
-		// Download record images from PersonSources
-		for _, source := range researchData.PersonSources {
-			if source.RecordImageUrl == "" {
-				continue
-			}
-
-			localPath, err := DownloadAndSaveRecordImage(nil, nil, apiClient, source.RecordImageUrl, source.CitationId, recordMediaDir, "media/records")
-			if err != nil || localPath == "" {
-				continue
-			}
-
-			// Add to person's record list
-			personRecords = append(personRecords, RecordImageInfo{
-				FilePath:    localPath,
-				SourceTitle: source.Title,
-				CitationID:  source.CitationId,
-				DatabaseID:  source.DatabaseId,
-				RecordID:    source.RecordId,
-			})
-
-			totalDownloaded++
+		var personRecords []RecordImageInfo
+		var complete bool
+		if recorded.Facts != nil {
+			personRecords, complete = downloadSourceRecordImages(ctx, apiClient, treeID, person, recorded.Facts.Sources, recordMediaDir, failures)
+		} else {
+			personRecords, complete = downloadPersonRecordImages(ctx, apiClient, treeID, person, recordMediaDir, failures)
+		}
+		if complete {
+			progress.recordRecords(personID, PersonRecordInfo{PersonID: personID, Records: personRecords})
 		}
-
 		if len(personRecords) > 0 {
 			recordIndex[personID] = PersonRecordInfo{
 				PersonID: personID,
 				Records:  personRecords,
 			}
+			totalDownloaded += len(personRecords)
 		}
 	}
 
+	progress.save()
 	return recordIndex, totalDownloaded
 }
 
-// downloadAllMedia downloads all media files for all persons
-func downloadAllMedia(apiClient *ancestry.APIClient, treeID string, persons []ancestry.Person, outputDir string) (map[string]PersonMediaInfo, int) {
+// downloadPersonRecordImages downloads the record images attached to one person's sources,
+// reporting whether all of them were downloaded
+func downloadPersonRecordImages(ctx context.Context, apiClient *ancestry.APIClient, treeID string, person ancestry.Person,
+	recordMediaDir string, failures *failureLog) ([]RecordImageInfo, bool) {
+	// Fetch sources for this person
+	researchData, err := apiClient.GetPersonFactsFromHTML(ctx, treeID, person.GetPersonID())
+	if err != nil {
+		failures.add(FailureRecords, person, fmt.Errorf("failed to get sources: %w", err))
+		return nil, false
+	}
+	if researchData == nil {
+		return nil, true
+	}
+	return downloadSourceRecordImages(ctx, apiClient, treeID, person, researchData.PersonSources, recordMediaDir, failures)
+}
+
+// downloadSourceRecordImages downloads the record images of a person's sources, reporting
+// whether all of them were downloaded
+func downloadSourceRecordImages(ctx context.Context, apiClient *ancestry.APIClient, treeID string, person ancestry.Person,
+	sources []ancestry.PersonSourceDetail, recordMediaDir string, failures *failureLog) ([]RecordImageInfo, bool) {
+	personRecords := []RecordImageInfo{}
+	complete := true
+	owner := ancestry.MediaOwner{TreeID: treeID, PersonID: person.GetPersonID()}
+
+	// Download record images from PersonSources
+	for _, source := range sources {
+		if source.RecordImageUrl == "" {
+			continue
+		}
+
+		localPath, err := DownloadAndSaveRecordImage(ctx, nil, nil, apiClient, owner, source, recordMediaDir, "media/records")
+		if err != nil {
+			failures.add(FailureRecords, person, err)
+			complete = false
+			continue
+		}
+		if localPath == "" {
+			continue
+		}
+
+		// Add to person's record list
+		personRecords = append(personRecords, RecordImageInfo{
+			FilePath:    localPath,
+			SourceTitle: source.Title,
+			CitationID:  source.CitationId,
+			DatabaseID:  source.DatabaseId,
+			RecordID:    source.RecordId,
+		})
+	}
+
+	return personRecords, complete
+}
+
+// downloadAllMedia downloads all media files for all persons, processing up to
+// concurrency persons in parallel. Each person's files stay grouped in the index.
+// Persons whose media is recorded in opts.Progress keep the recorded files.
+func downloadAllMedia(ctx context.Context, apiClient *ancestry.APIClient, treeID string, persons []ancestry.Person, outputDir string,
+	opts downloadTreeOptions) (map[string]PersonMediaInfo, int, int, int64) {
 	mediaIndex := make(map[string]PersonMediaInfo)
 	totalDownloaded := 0
+	totalFiltered := 0
 	skippedCount := 0
 
+	concurrency := opts.MediaConcurrency
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	// Cache validators from the previous run let unchanged files be skipped
+	previousFiles := loadPreviousMediaFiles(outputDir, opts.Log)
+	opts.MediaProgress = newMediaProgress(opts.Log)
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	jobs := make(chan ancestry.Person)
+
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for person := range jobs {
+				personInfo, downloaded, filtered, err := processPersonMedia(ctx, apiClient, treeID, person, outputDir, previousFiles, opts)
+				if err != nil {
+					opts.Log.Printf("   [Warning] %v\n", err)
+					opts.Failures.add(FailureMedia, person, err)
+					continue
+				}
+				opts.Progress.recordMedia(person.GetPersonID(), personInfo)
+
+				mu.Lock()
+				if len(personInfo.Files) > 0 {
+					mediaIndex[personInfo.PersonID] = personInfo
+				}
+				totalDownloaded += downloaded
+				totalFiltered += filtered
+				mu.Unlock()
+			}
+		}()
+	}
+
 	for i, person := range persons {
+		if ctx.Err() != nil {
+			break
+		}
 		personID := person.GetPersonID()
 		personName := person.GetDisplayName()
 		if personName == "" {
@@ -1015,63 +2121,73 @@ func downloadAllMedia(apiClient *ancestry.APIClient, treeID string, persons []an
 		}
 
 		if personID == "" {
-			fmt.Printf("   [Debug] Skipping person %d with missing ID (Name: %s, GID: %+v)\n",
+			opts.Log.Printf("   [Debug] Skipping person %d with missing ID (Name: %s, GID: %+v)\n",
 				i+1, personName, person.GID)
 			skippedCount++
 			continue
 		}
 
 		if i%10 == 0 {
-			fmt.Printf("   Processing person %d/%d (ID: %s, Name: %s)...\n",
+			opts.Log.Printf("   Processing person %d/%d (ID: %s, Name: %s)...\n",
 				i+1, len(persons), personID, personName)
 		}
 
-		personInfo, downloaded, err := processPersonMedia(apiClient, treeID, person, outputDir)
-		if err != nil {
-			fmt.Printf("   [Warning] %v\n", err)
+		if recorded, ok := opts.Progress.person(personID); ok && recorded.Media != nil {
+			mu.Lock()
+			if len(recorded.Media.Files) > 0 {
+				mediaIndex[personID] = *recorded.Media
+			}
+			mu.Unlock()
 			continue
 		}
-
-		if len(personInfo.Files) > 0 {
-			mediaIndex[personID] = personInfo
-		}
-		totalDownloaded += downloaded
+		jobs <- person
 	}
+	close(jobs)
+	wg.Wait()
+	opts.Progress.save()
 
 	if skippedCount > 0 {
-		fmt.Printf("   Skipped %d persons due to missing person ID\n", skippedCount)
+		opts.Log.Printf("   Skipped %d persons due to missing person ID\n", skippedCount)
 	}
 
-	return mediaIndex, totalDownloaded
+	return mediaIndex, totalDownloaded, totalFiltered, opts.MediaProgress.totalBytes()
 }
 
 // generateHTMLViewer creates a self-contained HTML viewer with embedded data
-func generateHTMLViewer(outputDir string, treeExport *TreeExport) error {
-	// Read the people.json file we just created (it has relationships + media embedded)
-	peopleJSONPath := filepath.Join(outputDir, "people.json")
-	peopleJSON, err := os.ReadFile(peopleJSONPath)
-	if err != nil {
-		return fmt.Errorf("failed to read people.json: %w", err)
-	}
-
-	// Marshal metadata
+func generateHTMLViewer(outputDir string, treeExport *TreeExport, relationships map[string]PersonRelationship,
+	mediaIndex map[string]PersonMediaInfo) error {
+	// Embed the same readable person data written to people.json (relationships + media)
+	readablePersons := buildReadablePersons(treeExport.Persons, relationships, mediaIndex, treeExport.RecordIndex)
 	metadata := map[string]interface{}{
 		"treeId":      treeExport.TreeID,
 		"treeName":    treeExport.TreeName,
 		"exportDate":  treeExport.ExportDate,
 		"personCount": treeExport.PersonCount,
 	}
+	if treeExport.MediaExcluded {
+		metadata["mediaExcluded"] = true
+	}
+	return writeHTMLViewer(outputDir, readablePersons, metadata, treeExport.Theme)
+}
+
+// writeHTMLViewer writes index.html and person.html with the readable persons and metadata embedded,
+// colored with the given theme
+func writeHTMLViewer(outputDir string, readablePersons []map[string]interface{}, metadata map[string]interface{}, theme string) error {
+	peopleJSON, err := json.MarshalIndent(readablePersons, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal readable persons data: %w", err)
+	}
 	metadataJSON, _ := json.Marshal(metadata)
 
 	// Generate main index HTML with embedded data
-	htmlContent := generateHTMLTemplate(string(peopleJSON), string(metadataJSON))
+	htmlContent := generateHTMLTemplate(string(peopleJSON), string(metadataJSON), theme)
 	htmlPath := filepath.Join(outputDir, "index.html")
 	if err := os.WriteFile(htmlPath, []byte(htmlContent), 0644); err != nil {
 		return fmt.Errorf("failed to write index.html: %w", err)
 	}
 
 	// Generate single person page that uses URL parameters
-	personHTML := generatePersonPageTemplate(string(peopleJSON), string(metadataJSON))
+	personHTML := generatePersonPageTemplate(string(peopleJSON), string(metadataJSON), theme)
 	personPath := filepath.Join(outputDir, "person.html")
 	if err := os.WriteFile(personPath, []byte(personHTML), 0644); err != nil {
 		return fmt.Errorf("failed to write person.html: %w", err)
@@ -0,0 +1,476 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+)
+
+func TestFetchPersonPages(t *testing.T) {
+	tests := []struct {
+		name      string
+		pageSizes []int // persons returned by each page; pages past the end return none
+		wantPages int
+		wantTotal int
+	}{
+		{name: "last page short", pageSizes: []int{3, 3, 1}, wantPages: 3, wantTotal: 7},
+		{name: "exact multiple ends with empty page", pageSizes: []int{3, 3}, wantPages: 3, wantTotal: 6},
+		{name: "first page short", pageSizes: []int{2}, wantPages: 1, wantTotal: 2},
+		{name: "empty tree", pageSizes: nil, wantPages: 1, wantTotal: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pages := 0
+			persons, err := fetchPersonPages(3, func(page int) ([]ancestry.Person, error) {
+				pages++
+				if page > len(tt.pageSizes) {
+					return nil, nil
+				}
+				return make([]ancestry.Person, tt.pageSizes[page-1]), nil
+			})
+			if err != nil {
+				t.Fatalf("fetchPersonPages() error = %v", err)
+			}
+			if pages != tt.wantPages || len(persons) != tt.wantTotal {
+				t.Errorf("fetched %d pages and %d persons, want %d pages and %d persons", pages, len(persons), tt.wantPages, tt.wantTotal)
+			}
+		})
+	}
+}
+
+func TestFetchPersonPagesError(t *testing.T) {
+	_, err := fetchPersonPages(3, func(page int) ([]ancestry.Person, error) {
+		if page == 2 {
+			return nil, errors.New("boom")
+		}
+		return make([]ancestry.Person, 3), nil
+	})
+	if err == nil {
+		t.Fatal("fetchPersonPages() error = nil, want error from page 2")
+	}
+}
+
+// pedigreeFamilyViews builds a pedigree of size persons where person k's parents are
+// 2k and 2k+1, and returns the persons plus a fake family view fetcher that includes
+// everyone within the given number of generations of the focus person.
+func pedigreeFamilyViews(size, generations int) ([]ancestry.Person, func(personNumber string) (*ancestry.FamilyViewResponse, error)) {
+	id := func(k int) string { return fmt.Sprintf("%d:1030:1", k) }
+	neighbors := func(k int) []int {
+		var n []int
+		for _, parent := range []int{2 * k, 2*k + 1} {
+			if parent <= size {
+				n = append(n, parent)
+			}
+		}
+		if k > 1 {
+			n = append(n, k/2)
+		}
+		return n
+	}
+
+	people := make(map[int]ancestry.Person, size)
+	persons := make([]ancestry.Person, 0, size)
+	for k := 1; k <= size; k++ {
+		person := ancestry.Person{GID: map[string]interface{}{"v": id(k)}, GivenName: fmt.Sprintf("P%d", k),
+			Events: []ancestry.Event{{Type: "Birth", Description: "born " + id(k)}}}
+		for _, n := range neighbors(k) {
+			relType := "C"
+			if n > k {
+				relType = map[bool]string{true: "F", false: "M"}[n%2 == 0]
+			}
+			person.Family = append(person.Family, ancestry.FamilyMember{Type: relType, TGID: map[string]interface{}{"v": id(n)}})
+		}
+		people[k] = person
+		persons = append(persons, person)
+	}
+
+	fetch := func(personNumber string) (*ancestry.FamilyViewResponse, error) {
+		focus, err := strconv.Atoi(personNumber)
+		if err != nil {
+			return nil, err
+		}
+		seen := map[int]bool{focus: true}
+		frontier := []int{focus}
+		for depth := 0; depth < generations; depth++ {
+			var next []int
+			for _, k := range frontier {
+				for _, n := range neighbors(k) {
+					if !seen[n] {
+						seen[n] = true
+						next = append(next, n)
+					}
+				}
+			}
+			frontier = next
+		}
+		view := &ancestry.FamilyViewResponse{}
+		for k := range seen {
+			view.Persons = append(view.Persons, people[k])
+		}
+		return view, nil
+	}
+
+	return persons, fetch
+}
+
+// inBatches fetches each family view of a batch in turn with fetch
+func inBatches(fetch func(personNumber string) (*ancestry.FamilyViewResponse, error)) familyViewBatchFetcher {
+	return func(personNumbers []string) []ancestry.FamilyViewResult {
+		results := make([]ancestry.FamilyViewResult, len(personNumbers))
+		for i, personNumber := range personNumbers {
+			familyView, err := fetch(personNumber)
+			results[i] = ancestry.FamilyViewResult{FocusID: personNumber, FamilyView: familyView, Err: err}
+		}
+		return results
+	}
+}
+
+func TestCollectRelationshipsGenerations(t *testing.T) {
+	const size = 63
+	persons, fetchOne := pedigreeFamilyViews(size, 1)
+	baseline, _, baselineCalls := collectRelationships(context.Background(), persons, 1, 1, inBatches(fetchOne), nil, nil, defaultLogger)
+	if baselineCalls != size || len(baseline) != size {
+		t.Fatalf("generations=1 made %d calls for %d relationships, want %d each", baselineCalls, len(baseline), size)
+	}
+
+	_, fetchTwo := pedigreeFamilyViews(size, 2)
+	relationships, _, calls := collectRelationships(context.Background(), persons, 2, 1, inBatches(fetchTwo), nil, nil, defaultLogger)
+	if calls >= baselineCalls {
+		t.Errorf("generations=2 made %d calls, want fewer than %d", calls, baselineCalls)
+	}
+	if !reflect.DeepEqual(relationships, baseline) {
+		t.Error("generations=2 relationships differ from generations=1")
+	}
+
+	batched, _, batchedCalls := collectRelationships(context.Background(), persons, 2, 4, inBatches(fetchTwo), nil, nil, defaultLogger)
+	if batchedCalls >= baselineCalls {
+		t.Errorf("batched generations=2 made %d calls, want fewer than %d", batchedCalls, baselineCalls)
+	}
+	if !reflect.DeepEqual(batched, baseline) {
+		t.Error("batched relationships differ from generations=1")
+	}
+}
+
+func TestCollectRelationshipsBatchFailures(t *testing.T) {
+	persons, fetch := pedigreeFamilyViews(7, 1)
+	failing := func(personNumbers []string) []ancestry.FamilyViewResult {
+		results := inBatches(fetch)(personNumbers)
+		for i := range results {
+			if results[i].FocusID == "3" {
+				results[i] = ancestry.FamilyViewResult{FocusID: "3", Err: errors.New("status 503")}
+			}
+		}
+		return results
+	}
+
+	relationships, _, calls := collectRelationships(context.Background(), persons, 1, 3, failing, nil, nil, defaultLogger)
+	if calls != 7 {
+		t.Errorf("made %d calls, want 7", calls)
+	}
+	if _, ok := relationships["3:1030:1"]; ok || len(relationships) != 6 {
+		t.Errorf("expected every person but 3 recorded, got %d", len(relationships))
+	}
+	if rel := relationships["2:1030:1"]; len(rel.Parents) != 2 || len(rel.Children) != 1 {
+		t.Errorf("unexpected relationships for person 2: %+v", rel)
+	}
+}
+
+func TestCollectRelationshipsRetriesFamilyViewErrors(t *testing.T) {
+	persons, fetch := pedigreeFamilyViews(7, 1)
+	attempts := map[string]int{}
+	flaky := func(personNumbers []string) []ancestry.FamilyViewResult {
+		results := inBatches(fetch)(personNumbers)
+		for i := range results {
+			focus := results[i].FocusID
+			attempts[focus]++
+			switch {
+			case focus == "3" && attempts[focus] == 1:
+				// An error object the first time, then the real view
+				results[i] = ancestry.FamilyViewResult{FocusID: focus, Err: &ancestry.FamilyViewError{FocusPersonID: focus, Message: "try again"}}
+			case focus == "5":
+				// Other errors aren't retried
+				results[i] = ancestry.FamilyViewResult{FocusID: focus, Err: errors.New("status 404")}
+			}
+		}
+		return results
+	}
+
+	relationships, _, calls := collectRelationships(context.Background(), persons, 1, 3, flaky, nil, nil, defaultLogger)
+	if calls != 8 {
+		t.Errorf("made %d calls, want 8", calls)
+	}
+	if attempts["3"] != 2 || attempts["5"] != 1 {
+		t.Errorf("attempts = %v, want person 3 retried once and person 5 not retried", attempts)
+	}
+	if _, ok := relationships["3:1030:1"]; !ok {
+		t.Error("person 3 should be recorded after the retry")
+	}
+	if _, ok := relationships["5:1030:1"]; ok || len(relationships) != 6 {
+		t.Errorf("expected every person but 5 recorded, got %d", len(relationships))
+	}
+}
+
+func BenchmarkCollectRelationships(b *testing.B) {
+	for _, generations := range []int{1, 2, 3} {
+		b.Run(fmt.Sprintf("generations=%d", generations), func(b *testing.B) {
+			persons, fetch := pedigreeFamilyViews(1023, generations)
+			calls := 0
+			for i := 0; i < b.N; i++ {
+				_, _, calls = collectRelationships(context.Background(), persons, generations, 1, inBatches(fetch), nil, nil, defaultLogger)
+			}
+			b.ReportMetric(float64(calls), "requests/op")
+		})
+	}
+}
+
+func TestConvertPersonToReadableFormatSortsEvents(t *testing.T) {
+	person := ancestry.Person{
+		PID: "1",
+		Events: []ancestry.Event{
+			{Type: "Residence", Date: nil},
+			{Type: Death, Date: "12 Mar 1961"},
+			{Type: "Marriage", Date: "Jun 1925"},
+			{Type: Birth, Date: "1 Jan 1900"},
+		},
+	}
+
+	readable := convertPersonToReadableFormat(person, nil, nil, nil)
+
+	events := readable["events"].([]map[string]interface{})
+	want := []string{Birth, "Marriage", Death, "Residence"}
+	for i, eventType := range want {
+		if events[i]["type"] != eventType {
+			t.Errorf("event %d = %v, want %s", i, events[i]["type"], eventType)
+		}
+	}
+	if person.Events[0].Type != "Residence" {
+		t.Error("sorting changed the person's own events")
+	}
+}
+
+func TestCollectRelationshipsRecordsRestrictedPersons(t *testing.T) {
+	persons, fetch := pedigreeFamilyViews(7, 1)
+	refusing := func(personNumbers []string) []ancestry.FamilyViewResult {
+		results := inBatches(fetch)(personNumbers)
+		for i := range results {
+			if results[i].FocusID == "4" {
+				results[i] = ancestry.FamilyViewResult{FocusID: "4", Err: &ancestry.StatusError{Code: http.StatusForbidden}}
+			}
+		}
+		return results
+	}
+
+	restricted := &restrictedLog{}
+	relationships, _, _ := collectRelationships(context.Background(), persons, 1, 3, refusing, restricted, nil, defaultLogger)
+	if len(relationships) != 6 {
+		t.Errorf("recorded %d persons, want 6", len(relationships))
+	}
+	got := restricted.list()
+	if len(got) != 1 || got[0].PersonID != "4:1030:1" || len(got[0].Parts) != 1 || got[0].Parts[0] != RestrictedFamilyView {
+		t.Errorf("restricted = %+v, want person 4's family view", got)
+	}
+}
+
+func TestSaveMetadataRecordsExcludedMedia(t *testing.T) {
+	for _, excluded := range []bool{false, true} {
+		dir := t.TempDir()
+		if err := saveMetadata(dir, &TreeExport{TreeID: "42", MediaExcluded: excluded}); err != nil {
+			t.Fatalf("saveMetadata() error = %v", err)
+		}
+		data, err := os.ReadFile(filepath.Join(dir, "metadata.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var metadata map[string]interface{}
+		if err := json.Unmarshal(data, &metadata); err != nil {
+			t.Fatal(err)
+		}
+		if _, got := metadata["mediaExcluded"]; got != excluded {
+			t.Errorf("MediaExcluded=%v: metadata.json = %s", excluded, data)
+		}
+	}
+}
+
+func TestSaveTreeOutputStepNumbers(t *testing.T) {
+	tests := []struct {
+		name      string
+		firstStep int
+		want      []string
+	}{
+		{"download-tree", 0, []string{"8", "9", "10", "11"}},
+		// download-people prints steps 1 to 5 before its output
+		{"download-people", downloadPeopleOutputStep, []string{"6", "7", "8", "9"}},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		opts := downloadTreeOptions{
+			ExcludeMedia:    true,
+			Formats:         []string{TreeFormatJSON},
+			Log:             log.New(&buf, "", 0),
+			FirstOutputStep: tt.firstStep,
+		}
+		_, err := saveTreeOutput(context.Background(), nil, "42", t.TempDir(), &ancestry.TreeInfo{TreeID: "42"},
+			nil, map[string]PersonRelationship{}, opts)
+		if err != nil {
+			t.Fatalf("%s: saveTreeOutput() error = %v", tt.name, err)
+		}
+
+		var steps []string
+		for _, line := range strings.Split(buf.String(), "\n") {
+			if number, _, ok := strings.Cut(line, ". "); ok && number != "" && strings.Trim(number, "0123456789") == "" {
+				steps = append(steps, number)
+			}
+		}
+		if !reflect.DeepEqual(steps, tt.want) {
+			t.Errorf("%s: steps = %v, want %v\n%s", tt.name, steps, tt.want, buf.String())
+		}
+	}
+}
+
+func TestSaveTreeOutputInterrupted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	dir := t.TempDir()
+	opts := downloadTreeOptions{ExcludeMedia: true, Log: defaultLogger}
+	_, err := saveTreeOutput(ctx, nil, "42", dir, &ancestry.TreeInfo{TreeID: "42"},
+		numberedPersons(2), map[string]PersonRelationship{}, opts)
+	if err == nil || !strings.Contains(err.Error(), "interrupted") {
+		t.Fatalf("saveTreeOutput() error = %v, want the interruption", err)
+	}
+
+	// What was collected is flushed as JSON, and only as JSON
+	people, err := os.ReadFile(filepath.Join(dir, "people.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var persons []map[string]interface{}
+	if err := json.Unmarshal(people, &persons); err != nil || len(persons) != 2 {
+		t.Errorf("people.json has %d persons (%v), want 2", len(persons), err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "metadata.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var metadata map[string]interface{}
+	if err := json.Unmarshal(data, &metadata); err != nil || metadata["incomplete"] != true {
+		t.Errorf("metadata.json = %s, want it marked incomplete", data)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "index.html")); !os.IsNotExist(err) {
+		t.Errorf("index.html written for an interrupted download: %v", err)
+	}
+}
+
+func TestConvertEventToReadableFormatDates(t *testing.T) {
+	tests := []struct {
+		name        string
+		date        interface{}
+		wantYear    interface{}
+		wantSortKey int
+	}{
+		{name: "full date", date: "12 Mar 1850", wantYear: 1850, wantSortKey: 18500312},
+		{name: "qualified year", date: "Abt 1875", wantYear: 1875, wantSortKey: 18750000},
+		{name: "no date", date: nil, wantYear: nil, wantSortKey: 99999999},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			readable := convertEventToReadableFormat(ancestry.Event{Type: Birth, Date: tt.date})
+			if readable["year"] != tt.wantYear {
+				t.Errorf("year = %v, want %v", readable["year"], tt.wantYear)
+			}
+			if readable["sortKey"] != tt.wantSortKey {
+				t.Errorf("sortKey = %v, want %d", readable["sortKey"], tt.wantSortKey)
+			}
+		})
+	}
+}
+
+func TestNotePersonIDs(t *testing.T) {
+	persons := numberedPersons(3)
+	persons[1].IsLiving = true
+
+	if got := notePersonIDs(persons, false); len(got) != 3 {
+		t.Errorf("notePersonIDs(excludeLiving=false) = %v, want all 3 persons", got)
+	}
+	got := notePersonIDs(persons, true)
+	want := []string{persons[0].GetPersonID(), persons[2].GetPersonID()}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("notePersonIDs(excludeLiving=true) = %v, want %v", got, want)
+	}
+}
+
+func TestProcessMediaItemRevalidates(t *testing.T) {
+	const (
+		etag         = `"v1"`
+		lastModified = "Mon, 06 Jan 2025 10:00:00 GMT"
+	)
+	changed := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !changed && r.Header.Get("If-None-Match") == etag && r.Header.Get("If-Modified-Since") == lastModified {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v2"`)
+		_, _ = w.Write([]byte("\xff\xd8\xff new image"))
+	}))
+	defer server.Close()
+
+	apiClient, err := ancestry.NewAPIClient(nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	apiClient.SetBaseURL(server.URL)
+
+	outputDir := t.TempDir()
+	previousPath := filepath.Join("media", "photos", "Ada-1001-old.jpg")
+	if err := os.MkdirAll(filepath.Join(outputDir, "media", "photos"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(outputDir, previousPath), []byte("old image"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	previous := &MediaFileInfo{MediaID: "m1", FilePath: previousPath, ETag: etag, LastModified: lastModified}
+	item := ancestry.PrimaryMediaItem{MediaID: "m1", Category: "photo",
+		URL: server.URL + "/api/media/retrieval/v2/image/namespaces/1093/media/abc.jpg"}
+	owner := ancestry.MediaOwner{TreeID: "42", PersonID: "1001:1030:42"}
+	opts := downloadTreeOptions{Log: log.New(io.Discard, "", 0)}
+
+	info, downloaded, err := processMediaItem(context.Background(), apiClient, item, owner, "Ada", 0, outputDir, previous, opts)
+	if err != nil {
+		t.Fatalf("unchanged media: %v", err)
+	}
+	if downloaded || info.FilePath != previousPath || info.ETag != etag || info.LastModified != lastModified {
+		t.Errorf("304: downloaded = %v, info = %+v, want the previous file kept", downloaded, info)
+	}
+	if data, _ := os.ReadFile(filepath.Join(outputDir, previousPath)); string(data) != "old image" {
+		t.Errorf("304 rewrote the file with %q", data)
+	}
+
+	changed = true
+	info, downloaded, err = processMediaItem(context.Background(), apiClient, item, owner, "Ada", 0, outputDir, previous, opts)
+	if err != nil {
+		t.Fatalf("changed media: %v", err)
+	}
+	if !downloaded || info.FilePath != previousPath || info.ETag != `"v2"` {
+		t.Errorf("200: downloaded = %v, info = %+v, want the previous file replaced", downloaded, info)
+	}
+	if data, _ := os.ReadFile(filepath.Join(outputDir, previousPath)); string(data) != "\xff\xd8\xff new image" {
+		t.Errorf("file holds %q after a 200, want the new image", data)
+	}
+}
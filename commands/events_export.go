@@ -0,0 +1,219 @@
+package commands
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+	"github.com/urfave/cli/v2"
+)
+
+// Formats the events command writes
+const (
+	eventsFormatJSON = "json"
+	eventsFormatCSV  = "csv"
+)
+
+// eventsCSVHeader names the columns of an events-<type>.csv file
+var eventsCSVHeader = []string{"person_id", "person_name", "type", "date", "year", "month", "day", "qualifier", "place", "description"}
+
+// exportedEvent is one event of a person in an events-<type> file
+type exportedEvent struct {
+	PersonID    string               `json:"personId"`
+	PersonName  string               `json:"personName"`
+	Type        string               `json:"type"`
+	Date        string               `json:"date,omitempty"`
+	ParsedDate  *ancestry.ParsedDate `json:"parsedDate,omitempty"` // Omitted when the date can't be parsed
+	Place       *ancestry.Place      `json:"place,omitempty"`
+	Description string               `json:"description,omitempty"`
+}
+
+// readablePlace returns the structured place of a readable event: its placeDetail when the
+// download recorded one, or else its place text split into components
+func readablePlace(event map[string]interface{}) *ancestry.Place {
+	if detail, ok := event["placeDetail"].(map[string]interface{}); ok {
+		data, _ := json.Marshal(detail)
+		var place ancestry.Place
+		if json.Unmarshal(data, &place) == nil && place.Name != "" {
+			return &place
+		}
+	}
+	text, _ := event["place"].(string)
+	if place, ok := ancestry.ParseNPS([]map[string]interface{}{{"v": text}}); ok {
+		return &place
+	}
+	return nil
+}
+
+// newExportedEvent converts a readable event of a person from people.json
+func newExportedEvent(person, event map[string]interface{}) exportedEvent {
+	parsed, err := ancestry.ParseGenealogyDate(event["date"])
+	exported := exportedEvent{
+		PersonID:    stringField(person, "personId"),
+		PersonName:  stringField(person, "fullName"),
+		Type:        stringField(event, "type"),
+		Date:        parsed.Original,
+		Place:       readablePlace(event),
+		Description: stringField(event, "description"),
+	}
+	if err == nil {
+		exported.ParsedDate = &parsed
+	}
+	return exported
+}
+
+// stringField returns a string value of a readable map, or "" if it isn't a string
+func stringField(m map[string]interface{}, key string) string {
+	value, _ := m[key].(string)
+	return value
+}
+
+// groupEventsByType collects the events of all persons by type, in chronological order.
+// Types are matched case-insensitively and keyed by the first spelling seen. With eventType
+// set only that type is collected.
+func groupEventsByType(persons []map[string]interface{}, eventType string) map[string][]exportedEvent {
+	groups := make(map[string][]exportedEvent)
+	spellings := make(map[string]string)
+	for _, person := range persons {
+		events, _ := person["events"].([]interface{})
+		for _, raw := range events {
+			event, ok := raw.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			exported := newExportedEvent(person, event)
+			if exported.Type == "" || (eventType != "" && !strings.EqualFold(exported.Type, eventType)) {
+				continue
+			}
+			key, seen := spellings[strings.ToLower(exported.Type)]
+			if !seen {
+				key = exported.Type
+				spellings[strings.ToLower(key)] = key
+			}
+			groups[key] = append(groups[key], exported)
+		}
+	}
+	for _, events := range groups {
+		sort.SliceStable(events, func(i, j int) bool {
+			return eventSortKey(events[i]) < eventSortKey(events[j])
+		})
+	}
+	return groups
+}
+
+// eventSortKey orders exported events chronologically, undated events last
+func eventSortKey(event exportedEvent) int {
+	if event.ParsedDate == nil {
+		return ancestry.ParsedDate{}.SortKey()
+	}
+	return event.ParsedDate.SortKey()
+}
+
+// eventsFileName returns the file an event type is written to, e.g. events-marriage.json
+func eventsFileName(eventType, format string) string {
+	return "events-" + strings.ToLower(sanitizeFilename(eventType)) + "." + format
+}
+
+// eventCSVRow returns the CSV columns of an exported event
+func eventCSVRow(event exportedEvent) []string {
+	var year, month, day, qualifier, place string
+	if event.ParsedDate != nil {
+		year, month, day = formatDatePart(event.ParsedDate.Year), formatDatePart(event.ParsedDate.Month), formatDatePart(event.ParsedDate.Day)
+		qualifier = event.ParsedDate.Qualifier
+	}
+	if event.Place != nil {
+		place = event.Place.Name
+	}
+	return []string{event.PersonID, event.PersonName, event.Type, event.Date, year, month, day, qualifier, place, event.Description}
+}
+
+// formatDatePart formats a date part for CSV, leaving unknown (zero) parts empty
+func formatDatePart(n int) string {
+	if n == 0 {
+		return ""
+	}
+	return strconv.Itoa(n)
+}
+
+// writeEventsFile writes the events of one type as JSON or CSV and returns the file's path
+func writeEventsFile(outputDir, eventType, format string, events []exportedEvent) (string, error) {
+	path := filepath.Join(outputDir, eventsFileName(eventType, format))
+	if format == eventsFormatJSON {
+		data, err := json.MarshalIndent(events, "", "  ")
+		if err == nil {
+			err = os.WriteFile(path, data, 0644)
+		}
+		return path, err
+	}
+
+	rows := [][]string{eventsCSVHeader}
+	for _, event := range events {
+		rows = append(rows, eventCSVRow(event))
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return path, err
+	}
+	writer := csv.NewWriter(file)
+	if err := writer.WriteAll(rows); err != nil {
+		_ = file.Close()
+		return path, err
+	}
+	return path, file.Close()
+}
+
+// parseEventsOptions reads the event type and format flags of the events command
+func parseEventsOptions(c *cli.Context) (eventType, format string, err error) {
+	eventType = strings.TrimSpace(c.String("type"))
+	if (eventType == "") == !c.Bool("all-types") {
+		return "", "", fmt.Errorf("specify exactly one of --type or --all-types")
+	}
+	format = strings.ToLower(c.String("format"))
+	if format != eventsFormatJSON && format != eventsFormatCSV {
+		return "", "", fmt.Errorf("invalid --format %q (use %s or %s)", c.String("format"), eventsFormatJSON, eventsFormatCSV)
+	}
+	return eventType, format, nil
+}
+
+// Events writes the events of a downloaded tree grouped by type, one events-<type> file
+// per type, listing each event's person, parsed date, structured place and description
+func Events(c *cli.Context) error {
+	outputDir := c.Args().First()
+	if outputDir == "" {
+		return cli.Exit("Output directory is required\n\nUsage: ancestrydl events <output-dir> --type <type>", 1)
+	}
+	eventType, format, err := parseEventsOptions(c)
+	if err != nil {
+		return cli.Exit(err.Error(), 1)
+	}
+
+	persons, err := loadReadablePersons(outputDir)
+	if err != nil {
+		return cli.Exit(err.Error(), 1)
+	}
+	groups := groupEventsByType(persons, eventType)
+	if len(groups) == 0 {
+		loggerFrom(c).Printf("No %s events found among %d person(s)\n", eventType, len(persons))
+		return nil
+	}
+
+	types := make([]string, 0, len(groups))
+	for t := range groups {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	for _, t := range types {
+		path, err := writeEventsFile(outputDir, t, format, groups[t])
+		if err != nil {
+			return cli.Exit(fmt.Sprintf("Failed to write %s: %v", path, err), 1)
+		}
+		loggerFrom(c).Printf("✓ Wrote %d %s event(s) to %s\n", len(groups[t]), t, path)
+	}
+	return nil
+}
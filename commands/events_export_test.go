@@ -0,0 +1,102 @@
+package commands
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func eventsTestPersons() []map[string]interface{} {
+	return []map[string]interface{}{
+		{
+			"personId": "1", "fullName": "John Smith",
+			"events": []interface{}{
+				map[string]interface{}{"type": Birth, "date": "12 Mar 1850", "place": "Springfield, Illinois, USA"},
+				map[string]interface{}{"type": "Marriage", "date": "Abt 1875", "description": "St. Mary's"},
+			},
+		},
+		{
+			"personId": "2", "fullName": "Mary Jones",
+			"events": []interface{}{
+				map[string]interface{}{"type": Birth, "date": "1848",
+					"placeDetail": map[string]interface{}{"name": "Kent, England", "components": []interface{}{"Kent", "England"}}},
+				map[string]interface{}{"type": "marriage", "date": "sometime"},
+			},
+		},
+	}
+}
+
+func TestGroupEventsByType(t *testing.T) {
+	groups := groupEventsByType(eventsTestPersons(), "")
+	if len(groups) != 2 || len(groups["Marriage"]) != 2 {
+		t.Fatalf("got %v, want Birth and Marriage with both marriage spellings", groups)
+	}
+
+	births := groups[Birth]
+	if len(births) != 2 || births[0].PersonID != "2" || births[1].PersonID != "1" {
+		t.Fatalf("births = %+v, want Mary (1848) before John (1850)", births)
+	}
+	if births[0].Place == nil || !reflect.DeepEqual(births[0].Place.Components, []string{"Kent", "England"}) {
+		t.Errorf("placeDetail not kept: %+v", births[0].Place)
+	}
+	if births[1].Place == nil || !reflect.DeepEqual(births[1].Place.Components, []string{"Springfield", "Illinois", "USA"}) {
+		t.Errorf("place text not split: %+v", births[1].Place)
+	}
+	if births[1].ParsedDate == nil || births[1].ParsedDate.Month != 3 || births[1].ParsedDate.Day != 12 {
+		t.Errorf("parsed date = %+v", births[1].ParsedDate)
+	}
+
+	marriages := groupEventsByType(eventsTestPersons(), "MARRIAGE")["Marriage"]
+	if len(marriages) != 2 || marriages[0].PersonID != "1" {
+		t.Fatalf("--type matched %+v, want both marriages, the dated one first", marriages)
+	}
+	if marriages[1].ParsedDate != nil || marriages[1].Date != "sometime" {
+		t.Errorf("unparseable date = %+v, want the text without a parsed date", marriages[1])
+	}
+}
+
+func TestWriteEventsFile(t *testing.T) {
+	dir := t.TempDir()
+	events := groupEventsByType(eventsTestPersons(), "Marriage")["Marriage"]
+
+	path, err := writeEventsFile(dir, "Marriage", eventsFormatCSV, events)
+	if err != nil {
+		t.Fatalf("writeEventsFile() error = %v", err)
+	}
+	if filepath.Base(path) != "events-marriage.csv" {
+		t.Errorf("path = %s", path)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	rows, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		eventsCSVHeader,
+		{"1", "John Smith", "Marriage", "Abt 1875", "1875", "", "", "about", "", "St. Mary's"},
+		{"2", "Mary Jones", "marriage", "sometime", "", "", "", "", "", ""},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows = %v, want %v", rows, want)
+	}
+
+	path, err = writeEventsFile(dir, "Marriage", eventsFormatJSON, events)
+	if err != nil {
+		t.Fatalf("writeEventsFile() error = %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded []exportedEvent
+	if err := json.Unmarshal(data, &decoded); err != nil || len(decoded) != 2 || decoded[0].ParsedDate.Year != 1875 {
+		t.Errorf("events-marriage.json = %s (%v)", data, err)
+	}
+}
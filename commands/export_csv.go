@@ -0,0 +1,117 @@
+package commands
+
+import (
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+	"github.com/urfave/cli/v2"
+)
+
+// peopleCSVFile is the file export-csv writes by default
+const peopleCSVFile = "people.csv"
+
+// peopleCSVHeader names the columns of people.csv. The ID columns hold short person IDs,
+// semicolon-joined.
+var peopleCSVHeader = []string{
+	"personId", "fullName", "givenName", "surname", "gender", "isLiving",
+	"birthDate", "birthPlace", "deathDate", "deathPlace",
+	"parentIds", "spouseIds", "childIds",
+}
+
+// lifeEventCells returns the date and place of the person's first event of the given type that
+// has either, or blanks
+func lifeEventCells(person ancestry.Person, eventType string) (date, place string) {
+	for _, event := range person.Events {
+		if !strings.EqualFold(event.Type, eventType) {
+			continue
+		}
+		parsed, _ := ancestry.ParseGenealogyDate(event.Date)
+		if date, place = parsed.Original, eventPlace(event); date != "" || place != "" {
+			return date, place
+		}
+	}
+	return "", ""
+}
+
+// shortIDs joins the short person IDs of relationship references with semicolons
+func shortIDs(refs []RelationshipReference) string {
+	ids := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		ids = append(ids, extractPersonNumber(ref.PersonID))
+	}
+	return strings.Join(ids, ";")
+}
+
+// peopleCSVRows returns one people.csv row per person, in person order
+func peopleCSVRows(persons []ancestry.Person, relationships map[string]PersonRelationship) [][]string {
+	rows := make([][]string, 0, len(persons))
+	for _, person := range persons {
+		given, surname := personNameParts(person)
+		birthDate, birthPlace := lifeEventCells(person, Birth)
+		deathDate, deathPlace := lifeEventCells(person, Death)
+		rel := relationships[person.GetPersonID()]
+		rows = append(rows, []string{
+			extractPersonNumber(person.GetPersonID()),
+			person.GetDisplayName(),
+			given,
+			surname,
+			person.Gender,
+			strconv.FormatBool(person.IsLiving),
+			birthDate, birthPlace, deathDate, deathPlace,
+			shortIDs(rel.Parents),
+			shortIDs(rel.Spouses),
+			shortIDs(rel.Children),
+		})
+	}
+	return rows
+}
+
+// writePeopleCSV writes the persons to a CSV file at path
+func writePeopleCSV(path string, persons []ancestry.Person, relationships map[string]PersonRelationship) (err error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", path, err)
+	}
+	defer func() {
+		if closeErr := file.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("failed to write %s: %w", path, closeErr)
+		}
+	}()
+
+	writer := csv.NewWriter(file)
+	if err := writer.Write(peopleCSVHeader); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	if err := writer.WriteAll(peopleCSVRows(persons, relationships)); err != nil {
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+	return nil
+}
+
+// ExportCSV writes a tree's persons as a flat CSV file, one row per person, for spreadsheets and
+// scripts. Given a download directory it converts that download's people.json; given a tree ID
+// it downloads the tree first.
+func ExportCSV(c *cli.Context) error {
+	logger := loggerFrom(c)
+	export, relationships, dir, err := loadTreeForExport(c, "ancestrydl export-csv <output-dir | tree-id> [--output <file>]")
+	if err != nil {
+		return err
+	}
+
+	output := c.String("output")
+	if output == "" {
+		output = filepath.Join(dir, peopleCSVFile)
+	}
+
+	logger.Println("Writing CSV...")
+	if err := writePeopleCSV(output, export.Persons, relationships); err != nil {
+		return err
+	}
+	logger.Printf("\n✅ Exported %d persons to %s\n", len(export.Persons), output)
+	return nil
+}
@@ -0,0 +1,52 @@
+package commands
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+)
+
+func TestPeopleCSVRows(t *testing.T) {
+	persons := []ancestry.Person{
+		{PID: "10:1030:42", GivenName: "John", Surname: "Smith, Jr.", Gender: "m", Events: []ancestry.Event{
+			{Type: "Residence", Date: "1860", Place: "Leeds"},
+			{Type: Birth, Date: "12 Mar 1850", NPS: []map[string]interface{}{{"v": "York, England"}}},
+			{Type: Death, Date: nil, Place: ""},
+			{Type: Death, Date: 1920.0},
+		}},
+		{PID: "12:1030:42", GivenName: `Ann "Nan"`, Surname: "Smith", IsLiving: true},
+	}
+	relationships := map[string]PersonRelationship{
+		"10:1030:42": {Spouses: []RelationshipReference{{PersonID: "11:1030:42"}}, Children: []RelationshipReference{{PersonID: "12:1030:42"}, {PersonID: "13:1030:42"}}},
+		"12:1030:42": {Parents: []RelationshipReference{{PersonID: "10:1030:42"}, {PersonID: "11:1030:42"}}},
+	}
+
+	want := [][]string{
+		{"10", "John Smith, Jr.", "John", "Smith, Jr.", "m", "false", "12 Mar 1850", "York, England", "1920", "", "", "11", "12;13"},
+		{"12", `Ann "Nan" Smith`, `Ann "Nan"`, "Smith", "", "true", "", "", "", "", "10;11", "", ""},
+	}
+	if got := peopleCSVRows(persons, relationships); !reflect.DeepEqual(got, want) {
+		t.Errorf("peopleCSVRows() =\n%q\nwant\n%q", got, want)
+	}
+
+	path := filepath.Join(t.TempDir(), peopleCSVFile)
+	if err := writePeopleCSV(path, persons, relationships); err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatalf("people.csv doesn't parse: %v", err)
+	}
+	if !reflect.DeepEqual(records, append([][]string{peopleCSVHeader}, want...)) {
+		t.Errorf("people.csv round trip =\n%q", records)
+	}
+}
@@ -0,0 +1,175 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+	"github.com/urfave/cli/v2"
+)
+
+// readableReferences converts the relatives listed under key in a person read back from
+// people.json to relationship references
+func readableReferences(readable map[string]interface{}, key string) []RelationshipReference {
+	items, _ := readable[key].([]interface{})
+	refs := make([]RelationshipReference, 0, len(items))
+	for _, item := range items {
+		if ref, ok := item.(map[string]interface{}); ok {
+			refs = append(refs, RelationshipReference{PersonID: stringField(ref, "personId"), Name: stringField(ref, "name")})
+		}
+	}
+	return refs
+}
+
+// savedPerson converts a person read back from people.json to an ancestry.Person and their relationships
+func savedPerson(readable map[string]interface{}) (ancestry.Person, PersonRelationship) {
+	personID := stringField(readable, "personId")
+	isLiving, _ := readable["isLiving"].(bool)
+	person := ancestry.Person{
+		PID:       personID,
+		GivenName: stringField(readable, "givenName"),
+		Surname:   stringField(readable, "surname"),
+		Gender:    stringField(readable, "gender"),
+		IsLiving:  isLiving,
+	}
+	for _, event := range readableEventMaps(readable) {
+		person.Events = append(person.Events, ancestry.Event{
+			Type:        stringField(event, "type"),
+			Date:        event["date"],
+			Place:       stringField(event, "place"),
+			Description: stringField(event, "description"),
+		})
+	}
+	return person, PersonRelationship{
+		PersonID: personID,
+		Name:     stringField(readable, "fullName"),
+		Parents:  readableReferences(readable, "parents"),
+		Spouses:  readableReferences(readable, "spouses"),
+		Children: readableReferences(readable, "children"),
+	}
+}
+
+// loadSavedTreeExport reads a download's people.json, and metadata.json if there is one, back into
+// a TreeExport and its relationships, so a download can be written in another format offline
+func loadSavedTreeExport(outputDir string) (*TreeExport, map[string]PersonRelationship, error) {
+	saved, err := loadReadablePersons(outputDir)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	export := &TreeExport{PersonCount: len(saved)}
+	if data, err := readJSONFile(outputDir, "metadata.json"); err == nil {
+		_ = json.Unmarshal(data, export) // treeId, treeName and exportDate share the TreeExport keys
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return nil, nil, fmt.Errorf("failed to read metadata.json: %w", err)
+	}
+
+	relationships := make(map[string]PersonRelationship, len(saved))
+	export.Persons = make([]ancestry.Person, 0, len(saved))
+	for _, readable := range saved {
+		person, rel := savedPerson(readable)
+		export.Persons = append(export.Persons, person)
+		relationships[rel.PersonID] = rel
+	}
+	return export, relationships, nil
+}
+
+// downloadTreeForExport downloads a tree's persons, relationships and, unless --no-facts,
+// their Facts page events, without saving anything
+func downloadTreeForExport(c *cli.Context, treeID string) (*TreeExport, map[string]PersonRelationship, error) {
+	logger := loggerFrom(c)
+	apiClient, err := setupAPIClientForDownload(c)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer func() {
+		if err := apiClient.Close(); err != nil {
+			logger.Printf("Error closing API client: %v\n", err)
+		}
+	}()
+
+	treeInfo, err := fetchTreeInfo(c.Context, apiClient, treeID, logger)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	opts := downloadTreeOptions{
+		Concurrency:           c.Int("concurrency"),
+		FamilyViewGenerations: 1,
+		NoFacts:               c.Bool("no-facts"),
+		PageSize:              ancestry.DefaultPageSize,
+		Failures:              &failureLog{},
+		Restricted:            &restrictedLog{},
+		Log:                   logger,
+		PersonCache:           personCacheFromFlags(c, treeID, nil),
+	}
+	persons, relationships, _, err := fetchTreeData(c.Context, apiClient, treeID, opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	if failed := len(opts.Failures.list()); failed > 0 {
+		logger.Printf("   Warning: %d fetch(es) failed; those persons' events may be incomplete\n", failed)
+	}
+
+	return &TreeExport{
+		TreeID:      treeID,
+		TreeName:    treeInfo.TreeName,
+		ExportDate:  time.Now().Format(time.RFC3339),
+		PersonCount: len(persons),
+		Persons:     persons,
+		TreeInfo:    treeInfo,
+	}, relationships, nil
+}
+
+// loadTreeForExport returns the tree an export command converts: the download in the directory
+// given as its argument, or else the tree whose ID is given, downloaded first. dir is "" for a
+// downloaded tree.
+func loadTreeForExport(c *cli.Context, usage string) (export *TreeExport, relationships map[string]PersonRelationship, dir string, err error) {
+	logger := loggerFrom(c)
+	source := c.Args().First()
+	if info, statErr := os.Stat(source); source != "" && statErr == nil && info.IsDir() {
+		logger.Println("1. Reading downloaded tree...")
+		if export, relationships, err = loadSavedTreeExport(source); err != nil {
+			return nil, nil, "", cli.Exit(err.Error(), 1)
+		}
+		return export, relationships, source, nil
+	}
+
+	treeID, err := getTreeIDArgOrDefault(c, fmt.Errorf("a download directory or tree ID is required\n\nUsage: %s", usage))
+	if err != nil {
+		return nil, nil, "", err
+	}
+	export, relationships, err = downloadTreeForExport(c, treeID)
+	return export, relationships, "", err
+}
+
+// ExportGEDCOM writes a tree as a GEDCOM 5.5.1 file for desktop genealogy software. Given a
+// download directory it converts that download's people.json; given a tree ID it downloads
+// the tree first.
+func ExportGEDCOM(c *cli.Context) error {
+	logger := loggerFrom(c)
+	export, relationships, dir, err := loadTreeForExport(c, "ancestrydl export-gedcom <output-dir | tree-id> [--output <file>]")
+	if err != nil {
+		return err
+	}
+
+	output := c.String("output")
+	switch {
+	case output != "":
+	case dir != "":
+		output = filepath.Join(dir, gedcomFile)
+	default:
+		output = fmt.Sprintf("tree-%s.ged", export.TreeID)
+	}
+
+	logger.Println("Writing GEDCOM...")
+	if err := os.WriteFile(output, []byte(renderGEDCOM(export, relationships)), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", output, err)
+	}
+	logger.Printf("\n✅ Exported %d persons to %s\n", len(export.Persons), output)
+	return nil
+}
@@ -0,0 +1,82 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+)
+
+// descriptionFacts returns a fetcher whose single fact describes the person it was fetched
+// for, taking longer for lower IDs so fetches finish out of order
+func descriptionFacts(delay time.Duration, total int) factsFetcher {
+	return func(personID string) (*ancestry.ResearchData, error) {
+		var n int
+		_, _ = fmt.Sscanf(personID, "%d", &n)
+		time.Sleep(delay * time.Duration(total-n) / time.Duration(total))
+		return &ancestry.ResearchData{PersonFacts: []ancestry.PersonFactDetail{
+			{TypeString: "Residence", Description: "facts of " + personID},
+		}}, nil
+	}
+}
+
+func numberedPersons(n int) []ancestry.Person {
+	persons := make([]ancestry.Person, n)
+	for i := range persons {
+		persons[i] = ancestry.Person{PID: fmt.Sprintf("%d:1030:1", i)}
+	}
+	return persons
+}
+
+func TestMergeFactsForPersons(t *testing.T) {
+	persons := numberedPersons(20)
+	mergeFactsForPersons(context.Background(), persons, 4, false, descriptionFacts(time.Millisecond, len(persons)), nil, nil, nil, defaultLogger)
+
+	for i, person := range persons {
+		want := "facts of " + person.PID
+		if len(person.Events) != 1 || person.Events[0].Description != want {
+			t.Errorf("person %d events = %+v, want %q", i, person.Events, want)
+		}
+	}
+}
+
+func TestMergeFactsForPersonsRecordsFailures(t *testing.T) {
+	persons := numberedPersons(3)
+	fetch := func(personID string) (*ancestry.ResearchData, error) {
+		switch {
+		case strings.HasPrefix(personID, "1:"):
+			return nil, &ancestry.StatusError{Code: http.StatusForbidden}
+		case strings.HasPrefix(personID, "2:"):
+			return nil, errors.New("status 503")
+		}
+		return nil, nil
+	}
+	failures, restricted := &failureLog{}, &restrictedLog{}
+	mergeFactsForPersons(context.Background(), persons, 2, false, fetch, failures, restricted, nil, defaultLogger)
+
+	if got := failures.list(); len(got) != 1 || got[0].PersonID != "2:1030:1" {
+		t.Errorf("failures = %+v, want person 2", got)
+	}
+	if got := restricted.list(); len(got) != 1 || got[0].PersonID != "1:1030:1" {
+		t.Errorf("restricted = %+v, want person 1", got)
+	}
+}
+
+// BenchmarkMergeFactsForPersons compares fetching a 500-person tree's facts one at a time
+// with the default worker pool, against a fetcher that simulates network latency
+func BenchmarkMergeFactsForPersons(b *testing.B) {
+	const treeSize = 500
+	for _, concurrency := range []int{1, 4} {
+		b.Run(fmt.Sprintf("concurrency=%d", concurrency), func(b *testing.B) {
+			for b.Loop() {
+				persons := numberedPersons(treeSize)
+				mergeFactsForPersons(context.Background(), persons, concurrency, false, descriptionFacts(200*time.Microsecond, treeSize), nil, nil, nil, defaultLogger)
+			}
+		})
+	}
+}
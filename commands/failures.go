@@ -0,0 +1,126 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+)
+
+// failuresFile lists the fetches that failed during a download, for retry-failed
+const failuresFile = "failures.json"
+
+// Kinds of per-person fetch that can fail and be retried
+const (
+	FailureFacts   = "facts"   // Facts page events
+	FailureMedia   = "media"   // Attached photos, documents and stories
+	FailureRecords = "records" // Record images from the person's sources
+)
+
+// downloadFailure records one failed fetch for a person
+type downloadFailure struct {
+	Kind       string `json:"kind"`
+	PersonID   string `json:"personId"`
+	PersonName string `json:"personName,omitempty"`
+	Reason     string `json:"reason"`
+}
+
+// failureLog collects download failures from concurrent workers. A nil log ignores them.
+type failureLog struct {
+	mu       sync.Mutex
+	failures []downloadFailure
+}
+
+// add records a failed fetch of the given kind for a person
+func (l *failureLog) add(kind string, person ancestry.Person, err error) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.failures = append(l.failures, downloadFailure{
+		Kind:       kind,
+		PersonID:   person.GetPersonID(),
+		PersonName: person.GetDisplayName(),
+		Reason:     err.Error(),
+	})
+}
+
+// list returns the recorded failures
+func (l *failureLog) list() []downloadFailure {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]downloadFailure(nil), l.failures...)
+}
+
+// count returns the number of recorded failures
+func (l *failureLog) count() int {
+	if l == nil {
+		return 0
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.failures)
+}
+
+// saveFailures writes failures.json, or removes it when nothing failed so a stale list isn't retried
+func saveFailures(outputDir string, failures []downloadFailure) error {
+	failuresPath := filepath.Join(outputDir, failuresFile)
+	if len(failures) == 0 {
+		if err := os.Remove(failuresPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove %s: %w", failuresFile, err)
+		}
+		return nil
+	}
+
+	data, err := json.MarshalIndent(failures, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal failures: %w", err)
+	}
+	if err := os.WriteFile(failuresPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", failuresFile, err)
+	}
+	return nil
+}
+
+// loadFailures reads failures.json from a previous download; a missing file means nothing failed
+func loadFailures(outputDir string) ([]downloadFailure, error) {
+	data, err := os.ReadFile(filepath.Join(outputDir, failuresFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", failuresFile, err)
+	}
+
+	var failures []downloadFailure
+	if err := json.Unmarshal(data, &failures); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", failuresFile, err)
+	}
+	return failures, nil
+}
+
+// failedPersons groups failures by kind into the distinct persons to retry, in file order
+func failedPersons(failures []downloadFailure) map[string][]ancestry.Person {
+	byKind := make(map[string][]ancestry.Person)
+	seen := make(map[string]bool)
+	for _, failure := range failures {
+		key := failure.Kind + "|" + failure.PersonID
+		if failure.PersonID == "" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		byKind[failure.Kind] = append(byKind[failure.Kind], ancestry.Person{
+			PID:       failure.PersonID,
+			GivenName: failure.PersonName,
+		})
+	}
+	return byKind
+}
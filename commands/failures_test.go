@@ -0,0 +1,74 @@
+package commands
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+)
+
+func TestSaveAndLoadFailures(t *testing.T) {
+	dir := t.TempDir()
+
+	log := &failureLog{}
+	log.add(FailureFacts, ancestry.Person{PID: "101", GivenName: "Ada"}, errors.New("status 503"))
+	log.add(FailureMedia, ancestry.Person{PID: "102"}, errors.New("timeout"))
+	if err := saveFailures(dir, log.list()); err != nil {
+		t.Fatalf("saveFailures: %v", err)
+	}
+
+	failures, err := loadFailures(dir)
+	if err != nil {
+		t.Fatalf("loadFailures: %v", err)
+	}
+	if len(failures) != 2 || failures[0].Kind != FailureFacts || failures[0].PersonID != "101" ||
+		failures[0].PersonName != "Ada" || failures[0].Reason != "status 503" {
+		t.Fatalf("unexpected failures: %+v", failures)
+	}
+
+	// Saving no failures removes the stale file
+	if err := saveFailures(dir, nil); err != nil {
+		t.Fatalf("saveFailures(nil): %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, failuresFile)); !os.IsNotExist(err) {
+		t.Errorf("%s still exists after a clean run: %v", failuresFile, err)
+	}
+	if failures, err := loadFailures(dir); err != nil || len(failures) != 0 {
+		t.Errorf("loadFailures without a file = %v, %v; want none", failures, err)
+	}
+}
+
+func TestFailedPersons(t *testing.T) {
+	failures := []downloadFailure{
+		{Kind: FailureMedia, PersonID: "1", PersonName: "Ada", Reason: "a"},
+		{Kind: FailureMedia, PersonID: "1", PersonName: "Ada", Reason: "b"},
+		{Kind: FailureFacts, PersonID: "1", Reason: "c"},
+		{Kind: FailureMedia, PersonID: "2", Reason: "d"},
+		{Kind: FailureRecords, Reason: "no person"},
+	}
+
+	byKind := failedPersons(failures)
+
+	if got := len(byKind[FailureMedia]); got != 2 {
+		t.Errorf("media persons = %d, want 2 (duplicates merged)", got)
+	}
+	if got := len(byKind[FailureFacts]); got != 1 {
+		t.Errorf("facts persons = %d, want 1", got)
+	}
+	if got := len(byKind[FailureRecords]); got != 0 {
+		t.Errorf("records persons = %d, want 0 (no person ID)", got)
+	}
+	if person := byKind[FailureMedia][0]; person.GetPersonID() != "1" || person.GetDisplayName() != "Ada" {
+		t.Errorf("first media person = %q %q, want 1 Ada", person.GetPersonID(), person.GetDisplayName())
+	}
+}
+
+func TestFailureLogNil(t *testing.T) {
+	var log *failureLog
+	log.add(FailureFacts, ancestry.Person{PID: "1"}, errors.New("ignored"))
+	if log.count() != 0 || log.list() != nil {
+		t.Error("nil failureLog should ignore failures")
+	}
+}
@@ -0,0 +1,116 @@
+package commands
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+const (
+	// CollisionStrategyIndex appends an increasing counter (-2, -3, ...) to a conflicting filename
+	CollisionStrategyIndex = "index"
+	// CollisionStrategyHash appends a short hash of the file contents to a conflicting filename
+	CollisionStrategyHash = "hash"
+	// CollisionStrategyUUID appends a UUID derived from the media ID to a conflicting filename
+	CollisionStrategyUUID = "uuid"
+)
+
+// validateNameCollisionStrategy checks that the --name-collision-strategy value is supported
+func validateNameCollisionStrategy(strategy string) error {
+	switch strategy {
+	case "", CollisionStrategyIndex, CollisionStrategyHash, CollisionStrategyUUID:
+		return nil
+	}
+	return fmt.Errorf("invalid name collision strategy %q (must be '%s', '%s' or '%s')",
+		strategy, CollisionStrategyIndex, CollisionStrategyHash, CollisionStrategyUUID)
+}
+
+// sameFileContentsChunk is how much of an existing file sameFileContents reads at a time
+const sameFileContentsChunk = 32 * 1024
+
+// sameFileContents reports whether the file at path exists and holds exactly data. Files of
+// a different size are told apart without reading them; otherwise the file is compared a
+// chunk at a time, stopping at the first difference.
+func sameFileContents(path string, data []byte) (exists, same bool) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false, false
+	}
+	if info.Size() != int64(len(data)) {
+		return true, false
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return true, false
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			fmt.Printf("Error closing %s: %v\n", path, err)
+		}
+	}()
+
+	buf := make([]byte, sameFileContentsChunk)
+	for offset := 0; offset < len(data); {
+		n, err := io.ReadFull(file, buf[:min(len(buf), len(data)-offset)])
+		if err != nil || !bytes.Equal(buf[:n], data[offset:offset+n]) {
+			return true, false
+		}
+		offset += n
+	}
+	return true, true
+}
+
+// shortContentHash returns the first 8 hex characters of the SHA-256 of data
+func shortContentHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])[:8]
+}
+
+// nameBasedUUID returns a deterministic UUID (version 5 layout) for the given name,
+// so re-running a download produces the same filename for the same media
+func nameBasedUUID(name string) string {
+	sum := sha1.Sum([]byte(name))
+	sum[6] = (sum[6] & 0x0f) | 0x50
+	sum[8] = (sum[8] & 0x3f) | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", sum[0:4], sum[4:6], sum[6:8], sum[8:10], sum[10:16])
+}
+
+// resolveFilenameCollision picks the filename (base + ext) to write data to in dir.
+// If the preferred name is free it is used as is; if it already holds identical
+// contents, alreadySaved is true and nothing needs to be written. Otherwise a
+// different file owns the name and a new one is derived using the strategy.
+func resolveFilenameCollision(dir, base, ext string, data []byte, mediaID, strategy string) (filename string, alreadySaved bool) {
+	candidate := base + ext
+	exists, same := sameFileContents(filepath.Join(dir, candidate), data)
+	if !exists || same {
+		return candidate, same
+	}
+
+	switch strategy {
+	case CollisionStrategyHash:
+		candidate = fmt.Sprintf("%s-%s%s", base, shortContentHash(data), ext)
+	case CollisionStrategyUUID:
+		key := mediaID
+		if key == "" {
+			key = shortContentHash(data)
+		}
+		candidate = fmt.Sprintf("%s-%s%s", base, nameBasedUUID(key), ext)
+	default:
+		for counter := 2; ; counter++ {
+			candidate = fmt.Sprintf("%s-%d%s", base, counter, ext)
+			exists, same = sameFileContents(filepath.Join(dir, candidate), data)
+			if !exists || same {
+				return candidate, same
+			}
+		}
+	}
+
+	_, same = sameFileContents(filepath.Join(dir, candidate), data)
+	return candidate, same
+}
@@ -0,0 +1,122 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+)
+
+func TestResolveFilenameCollision(t *testing.T) {
+	// Two different people whose names sanitize to the same filename
+	itemA := ancestry.PrimaryMediaItem{MediaID: "media-a", Title: "Portrait"}
+	itemB := ancestry.PrimaryMediaItem{MediaID: "media-b", Title: "Portrait"}
+	baseA := generateMediaFilename("João/Silva", "1234:1030:1", itemA, 0)
+	baseB := generateMediaFilename("Joao-Silva", "1234:1030:1", itemB, 0)
+	if baseA != baseB {
+		t.Fatalf("expected identical sanitized names, got %q and %q", baseA, baseB)
+	}
+
+	dataA := []byte("first file contents")
+	dataB := []byte("second file contents")
+
+	tests := []struct {
+		strategy string
+		check    func(t *testing.T, name string)
+	}{
+		{CollisionStrategyIndex, func(t *testing.T, name string) {
+			if name != baseB+"-2.jpg" {
+				t.Errorf("got %q, want %q", name, baseB+"-2.jpg")
+			}
+		}},
+		{CollisionStrategyHash, func(t *testing.T, name string) {
+			if name != baseB+"-"+shortContentHash(dataB)+".jpg" {
+				t.Errorf("got %q, want content hash suffix", name)
+			}
+		}},
+		{CollisionStrategyUUID, func(t *testing.T, name string) {
+			if name != baseB+"-"+nameBasedUUID("media-b")+".jpg" {
+				t.Errorf("got %q, want media ID UUID suffix", name)
+			}
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.strategy, func(t *testing.T) {
+			dir := t.TempDir()
+
+			nameA, saved := resolveFilenameCollision(dir, baseA, ".jpg", dataA, itemA.MediaID, tt.strategy)
+			if saved || nameA != baseA+".jpg" {
+				t.Fatalf("first file: got (%q, %v), want (%q, false)", nameA, saved, baseA+".jpg")
+			}
+			if err := os.WriteFile(filepath.Join(dir, nameA), dataA, 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			nameB, saved := resolveFilenameCollision(dir, baseB, ".jpg", dataB, itemB.MediaID, tt.strategy)
+			if saved {
+				t.Fatalf("second file reported as already saved")
+			}
+			if nameB == nameA {
+				t.Fatalf("second file would overwrite the first: %q", nameB)
+			}
+			tt.check(t, nameB)
+			if err := os.WriteFile(filepath.Join(dir, nameB), dataB, 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			// Re-running the download resolves both files to the names they already have
+			if again, saved := resolveFilenameCollision(dir, baseA, ".jpg", dataA, itemA.MediaID, tt.strategy); !saved || again != nameA {
+				t.Errorf("rerun of first file: got (%q, %v), want (%q, true)", again, saved, nameA)
+			}
+			if again, saved := resolveFilenameCollision(dir, baseB, ".jpg", dataB, itemB.MediaID, tt.strategy); !saved || again != nameB {
+				t.Errorf("rerun of second file: got (%q, %v), want (%q, true)", again, saved, nameB)
+			}
+		})
+	}
+}
+
+func TestNameBasedUUID(t *testing.T) {
+	id := nameBasedUUID("media-a")
+	if id != nameBasedUUID("media-a") {
+		t.Error("UUID is not deterministic")
+	}
+	if id == nameBasedUUID("media-b") {
+		t.Error("different names produced the same UUID")
+	}
+	if parts := strings.Split(id, "-"); len(parts) != 5 || id[14] != '5' {
+		t.Errorf("unexpected UUID format: %q", id)
+	}
+}
+
+func TestSameFileContents(t *testing.T) {
+	dir := t.TempDir()
+	existing := []byte(strings.Repeat("photo", sameFileContentsChunk)) // spans several chunks
+	path := filepath.Join(dir, "photo.jpg")
+	if err := os.WriteFile(path, existing, 0644); err != nil {
+		t.Fatal(err)
+	}
+	changedLast := append([]byte(nil), existing...)
+	changedLast[len(changedLast)-1] = 'X'
+
+	tests := []struct {
+		name       string
+		path       string
+		data       []byte
+		wantExists bool
+		wantSame   bool
+	}{
+		{"identical", path, existing, true, true},
+		{"differs in the last chunk", path, changedLast, true, false},
+		{"different size", path, existing[:len(existing)-1], true, false},
+		{"missing file", filepath.Join(dir, "missing.jpg"), existing, false, false},
+	}
+	for _, tt := range tests {
+		exists, same := sameFileContents(tt.path, tt.data)
+		if exists != tt.wantExists || same != tt.wantSame {
+			t.Errorf("%s: sameFileContents() = %v, %v, want %v, %v", tt.name, exists, same, tt.wantExists, tt.wantSame)
+		}
+	}
+}
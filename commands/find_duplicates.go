@@ -0,0 +1,208 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+	"github.com/urfave/cli/v2"
+)
+
+// duplicatesFile is the report find-duplicates writes into the download directory
+const duplicatesFile = "duplicates.json"
+
+// Similarity scores for a duplicate group. Every group shares a normalized name and birth
+// year; matching full birth dates and birth places raise the score.
+const (
+	duplicateBaseScore  = 0.6
+	duplicateDateScore  = 0.2
+	duplicatePlaceScore = 0.2
+)
+
+// duplicatePerson is one person in a duplicate group
+type duplicatePerson struct {
+	PersonID   string `json:"personId"`
+	FullName   string `json:"fullName"`
+	BirthDate  string `json:"birthDate,omitempty"`
+	BirthPlace string `json:"birthPlace,omitempty"`
+
+	name      string // Normalized full name
+	birthYear int
+	place     string // Normalized birth place
+}
+
+// duplicateGroup is a set of persons that are likely the same individual
+type duplicateGroup struct {
+	Name      string            `json:"name"`
+	BirthYear int               `json:"birthYear"`
+	Score     float64           `json:"score"`
+	Persons   []duplicatePerson `json:"persons"`
+}
+
+// normalizeDuplicateName lowercases a name and drops punctuation and extra spaces, so
+// "O'Brien,  John" and "obrien john" compare equal
+func normalizeDuplicateName(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(name) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+		case unicode.IsSpace(r) || r == ',':
+			b.WriteRune(' ')
+		}
+	}
+	return strings.Join(strings.Fields(b.String()), " ")
+}
+
+// readableBirth returns the date and place of a readable person's first birth event
+func readableBirth(person map[string]interface{}) (date, place string) {
+	events, _ := person["events"].([]interface{})
+	for _, raw := range events {
+		event, ok := raw.(map[string]interface{})
+		if !ok || event["type"] != Birth {
+			continue
+		}
+		date, _ = event["date"].(string)
+		place, _ = event["place"].(string)
+		return date, place
+	}
+	return "", ""
+}
+
+// duplicateCandidates returns the readable persons with a name and a parseable birth year.
+// Persons without either can't be bucketed and are left out.
+func duplicateCandidates(persons []map[string]interface{}) []duplicatePerson {
+	var candidates []duplicatePerson
+	for _, person := range persons {
+		fullName, _ := person["fullName"].(string)
+		name := normalizeDuplicateName(fullName)
+		date, place := readableBirth(person)
+		parsed, err := ancestry.ParseGenealogyDate(date)
+		if name == "" || err != nil || parsed.Year == 0 {
+			continue
+		}
+		personID, _ := person["personId"].(string)
+		candidates = append(candidates, duplicatePerson{
+			PersonID:   personID,
+			FullName:   fullName,
+			BirthDate:  date,
+			BirthPlace: place,
+			name:       name,
+			birthYear:  parsed.Year,
+			place:      normalizeDuplicateName(place),
+		})
+	}
+	return candidates
+}
+
+// duplicateScore scores a group: the base score for a shared name and birth year, plus
+// more when all members share the full birth date or the birth place
+func duplicateScore(persons []duplicatePerson) float64 {
+	score := duplicateBaseScore
+	sameDate, samePlace := true, persons[0].place != ""
+	for _, person := range persons[1:] {
+		sameDate = sameDate && person.BirthDate == persons[0].BirthDate
+		samePlace = samePlace && person.place == persons[0].place
+	}
+	if sameDate {
+		score += duplicateDateScore
+	}
+	if samePlace {
+		score += duplicatePlaceScore
+	}
+	return score
+}
+
+// findDuplicates groups persons by normalized name and birth year, and also by birth place
+// when matchPlace is set. Groups of two or more are returned, highest score first.
+func findDuplicates(persons []map[string]interface{}, matchPlace bool) []duplicateGroup {
+	buckets := make(map[string][]duplicatePerson)
+	var keys []string
+	for _, candidate := range duplicateCandidates(persons) {
+		key := fmt.Sprintf("%s|%d", candidate.name, candidate.birthYear)
+		if matchPlace {
+			key += "|" + candidate.place
+		}
+		if _, seen := buckets[key]; !seen {
+			keys = append(keys, key)
+		}
+		buckets[key] = append(buckets[key], candidate)
+	}
+
+	groups := []duplicateGroup{}
+	for _, key := range keys {
+		members := buckets[key]
+		if len(members) < 2 {
+			continue
+		}
+		groups = append(groups, duplicateGroup{
+			Name:      members[0].FullName,
+			BirthYear: members[0].birthYear,
+			Score:     duplicateScore(members),
+			Persons:   members,
+		})
+	}
+	sort.SliceStable(groups, func(i, j int) bool {
+		return groups[i].Score > groups[j].Score
+	})
+	return groups
+}
+
+// loadReadablePersons reads people.json (or people.json.gz) from a download directory
+func loadReadablePersons(outputDir string) ([]map[string]interface{}, error) {
+	data, err := readJSONFile(outputDir, "people.json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read people.json (the download must have been written with the json format): %w", err)
+	}
+	var persons []map[string]interface{}
+	if err := json.Unmarshal(data, &persons); err != nil {
+		return nil, fmt.Errorf("failed to parse people.json: %w", err)
+	}
+	return persons, nil
+}
+
+// FindDuplicates reports persons in a downloaded tree that are likely duplicates of each
+// other, writing the groups to duplicates.json in the download directory
+func FindDuplicates(c *cli.Context) error {
+	outputDir := c.Args().First()
+	if outputDir == "" {
+		return cli.Exit("Output directory is required\n\nUsage: ancestrydl find-duplicates <output-dir>", 1)
+	}
+
+	persons, err := loadReadablePersons(outputDir)
+	if err != nil {
+		return cli.Exit(err.Error(), 1)
+	}
+
+	groups := findDuplicates(persons, c.Bool("match-place"))
+	data, err := json.MarshalIndent(groups, "", "  ")
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("Failed to encode %s: %v", duplicatesFile, err), 1)
+	}
+	path := filepath.Join(outputDir, duplicatesFile)
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return cli.Exit(fmt.Sprintf("Failed to write %s: %v", path, err), 1)
+	}
+
+	out, _ := commandOutput(c)
+	if len(groups) == 0 {
+		fmt.Fprintf(out, "No likely duplicates among %d person(s)\n", len(persons))
+	} else {
+		fmt.Fprintf(out, "Found %d group(s) of likely duplicates among %d person(s):\n\n", len(groups), len(persons))
+		for _, group := range groups {
+			ids := make([]string, 0, len(group.Persons))
+			for _, person := range group.Persons {
+				ids = append(ids, person.PersonID)
+			}
+			fmt.Fprintf(out, "  %s (b. %d) - score %.1f: %s\n", group.Name, group.BirthYear, group.Score, strings.Join(ids, ", "))
+		}
+		fmt.Fprintln(out)
+	}
+	loggerFrom(c).Printf("✓ Wrote %s\n", path)
+	return nil
+}
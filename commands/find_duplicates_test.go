@@ -0,0 +1,63 @@
+package commands
+
+import (
+	"testing"
+)
+
+func readableWithBirth(id, name, date, place string) map[string]interface{} {
+	person := map[string]interface{}{"personId": id, "fullName": name}
+	if date != "" {
+		person["events"] = []interface{}{
+			map[string]interface{}{"type": "Death", "date": "1900"},
+			map[string]interface{}{"type": Birth, "date": date, "place": place},
+		}
+	}
+	return person
+}
+
+func TestNormalizeDuplicateName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"John  Smith", "john smith"},
+		{"O'Brien, Mary", "obrien mary"},
+		{"  ", ""},
+	}
+	for _, tt := range tests {
+		if got := normalizeDuplicateName(tt.name); got != tt.want {
+			t.Errorf("normalizeDuplicateName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFindDuplicates(t *testing.T) {
+	persons := []map[string]interface{}{
+		readableWithBirth("1", "John Smith", "12 Mar 1850", "Ohio, USA"),
+		readableWithBirth("2", "john  smith", "Abt 1850", "Kent, England"),
+		readableWithBirth("3", "Mary Jones", "1 Jan 1820", "Kent, England"),
+		readableWithBirth("4", "Mary Jones", "1 Jan 1820", "Kent, England"),
+		readableWithBirth("5", "John Smith", "1851", "Ohio, USA"),
+		readableWithBirth("6", "John Smith", "", ""),
+	}
+
+	groups := findDuplicates(persons, false)
+	if len(groups) != 2 {
+		t.Fatalf("got %d groups, want 2: %+v", len(groups), groups)
+	}
+	if groups[0].Name != "Mary Jones" || groups[0].Score != 1.0 || len(groups[0].Persons) != 2 {
+		t.Errorf("first group = %+v, want both Mary Jones with full score", groups[0])
+	}
+	smiths := groups[1]
+	if smiths.BirthYear != 1850 || smiths.Score != duplicateBaseScore || len(smiths.Persons) != 2 {
+		t.Errorf("second group = %+v, want persons 1 and 2 with the base score", smiths)
+	}
+	if smiths.Persons[0].PersonID != "1" || smiths.Persons[1].PersonID != "2" {
+		t.Errorf("second group persons = %+v", smiths.Persons)
+	}
+
+	groups = findDuplicates(persons, true)
+	if len(groups) != 1 || groups[0].Name != "Mary Jones" {
+		t.Errorf("with matchPlace got %+v, want only Mary Jones", groups)
+	}
+}
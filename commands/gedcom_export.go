@@ -0,0 +1,484 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+)
+
+const (
+	// TreeFormatGEDCOM writes the tree as a GEDCOM 5.5.1 file, tree.ged
+	TreeFormatGEDCOM = "gedcom"
+	// gedcomFile is the file the gedcom format writes
+	gedcomFile = "tree.ged"
+	// gedcomLineLength is the longest line value written before it continues on a CONC line,
+	// well under the 255 characters GEDCOM 5.5.1 allows per line
+	gedcomLineLength = 200
+)
+
+// gedcomMonths are the month abbreviations of GEDCOM dates
+var gedcomMonths = []string{"JAN", "FEB", "MAR", "APR", "MAY", "JUN", "JUL", "AUG", "SEP", "OCT", "NOV", "DEC"}
+
+// gedcomQualifiers maps ParsedDate qualifiers to GEDCOM date modifiers
+var gedcomQualifiers = map[string]string{
+	ancestry.DateQualifierAbout:  "ABT ",
+	ancestry.DateQualifierBefore: "BEF ",
+	ancestry.DateQualifierAfter:  "AFT ",
+}
+
+// gedcomIndividualTags maps event types to GEDCOM individual event and attribute tags.
+// Types not listed are written as EVEN with a TYPE.
+var gedcomIndividualTags = map[string]string{
+	"birth":          "BIRT",
+	"death":          "DEAT",
+	"burial":         "BURI",
+	"christening":    "CHR",
+	"baptism":        "BAPM",
+	"residence":      "RESI",
+	"census":         "CENS",
+	"occupation":     "OCCU",
+	"immigration":    "IMMI",
+	"emigration":     "EMIG",
+	"naturalization": "NATU",
+	"education":      "EDUC",
+	"religion":       "RELI",
+	"probate":        "PROB",
+	"will":           "WILL",
+	"cremation":      "CREM",
+	"graduation":     "GRAD",
+	"retirement":     "RETI",
+	"adoption":       "ADOP",
+}
+
+// gedcomFamilyTags maps event types that belong on a family record to their GEDCOM tags
+var gedcomFamilyTags = map[string]string{
+	"marriage":   "MARR",
+	"divorce":    "DIV",
+	"engagement": "ENGA",
+}
+
+// gedcomAttributeTags are the tags whose line value is the fact itself (the event's description)
+var gedcomAttributeTags = map[string]bool{"OCCU": true, "EDUC": true, "RELI": true}
+
+// gedcomDayMonthYear formats a date as "12 MAR 1850", "MAR 1850" or "1850", leaving out unknown parts
+func gedcomDayMonthYear(year, month, day int) string {
+	date := fmt.Sprintf("%d", year)
+	if month >= 1 && month <= 12 {
+		date = gedcomMonths[month-1] + " " + date
+		if day > 0 {
+			date = fmt.Sprintf("%d %s", day, date)
+		}
+	}
+	return date
+}
+
+// gedcomDate formats an event date as a GEDCOM date: "12 MAR 1850", "ABT 1850", "BET 1850 AND
+// 1860". Dates that can't be expressed that way are passed through as a date phrase, the
+// original text in parentheses. It returns "" for an event without a date.
+func gedcomDate(raw interface{}) string {
+	parsed, err := ancestry.ParseGenealogyDate(raw)
+	if errors.Is(err, ancestry.ErrNoDate) {
+		return ""
+	}
+	phrase := "(" + strings.NewReplacer("(", "", ")", "").Replace(parsed.Original) + ")"
+	if err != nil || parsed.Year == 0 {
+		return phrase
+	}
+
+	date := gedcomDayMonthYear(parsed.Year, parsed.Month, parsed.Day)
+	if parsed.Qualifier == ancestry.DateQualifierBetween {
+		if parsed.EndYear == 0 {
+			return phrase
+		}
+		return fmt.Sprintf("BET %s AND %s", date, gedcomDayMonthYear(parsed.EndYear, parsed.EndMonth, parsed.EndDay))
+	}
+	return gedcomQualifiers[parsed.Qualifier] + date
+}
+
+// eventPlace returns an event's place from its NPS place structure, or the place
+// resolved from it earlier
+func eventPlace(event ancestry.Event) string {
+	if place := extractPlaceFromNPS(event.NPS); place != "" {
+		return place
+	}
+	return event.Place
+}
+
+// gedcomSex returns the SEX value of a person
+func gedcomSex(person ancestry.Person) string {
+	gender := person.Gender
+	if gender == "" && len(person.Genders) > 0 {
+		gender = person.Genders[0].Gender
+	}
+	switch strings.ToLower(gender) {
+	case "m", "male":
+		return "M"
+	case "f", "female":
+		return "F"
+	}
+	return "U"
+}
+
+// gedcomXref returns a cross-reference ID built from prefix and the digits and letters of id,
+// such as @I232573524428@ for person 232573524428:1030:197283789
+func gedcomXref(prefix, id string) string {
+	id = strings.Map(func(r rune) rune {
+		if r < utf8.RuneSelf && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			return r
+		}
+		return -1
+	}, id)
+	return "@" + prefix + id + "@"
+}
+
+// gedcomWriter builds the lines of a GEDCOM file
+type gedcomWriter struct {
+	b strings.Builder
+}
+
+// line writes one line. Text values go through text instead, to escape and split them.
+func (w *gedcomWriter) line(level int, tag, value string) {
+	if value == "" {
+		fmt.Fprintf(&w.b, "%d %s\n", level, tag)
+		return
+	}
+	fmt.Fprintf(&w.b, "%d %s %s\n", level, tag, value)
+}
+
+// text writes a free-text value, doubling @ signs and continuing newlines on CONT lines and
+// long lines on CONC lines
+func (w *gedcomWriter) text(level int, tag, value string) {
+	value = strings.ReplaceAll(value, "@", "@@")
+	for i, part := range strings.Split(strings.ReplaceAll(value, "\r\n", "\n"), "\n") {
+		if i > 0 {
+			tag = "CONT"
+			level++
+		}
+		chunks := splitGEDCOMText(part)
+		w.line(level, tag, chunks[0])
+		for _, chunk := range chunks[1:] {
+			w.line(level+1, "CONC", chunk)
+		}
+		if i > 0 {
+			level--
+		}
+	}
+}
+
+// splitGEDCOMText splits text into pieces of at most gedcomLineLength bytes, without
+// splitting a character or cutting next to a space, which importers may trim
+func splitGEDCOMText(text string) []string {
+	var chunks []string
+	for len(text) > gedcomLineLength {
+		cut := gedcomLineLength
+		for cut > gedcomLineLength/2 && (!utf8.RuneStart(text[cut]) || text[cut] == ' ' || text[cut-1] == ' ') {
+			cut--
+		}
+		for !utf8.RuneStart(text[cut]) {
+			cut--
+		}
+		chunks = append(chunks, text[:cut])
+		text = text[cut:]
+	}
+	return append(chunks, text)
+}
+
+// gedcomFamily is a FAM record: one or two partners and their children
+type gedcomFamily struct {
+	Xref     string
+	Partners []string // Person IDs
+	Children []string // Person IDs
+	Events   []ancestry.Event
+}
+
+// gedcomTree is a tree prepared for writing as GEDCOM
+type gedcomTree struct {
+	persons      []ancestry.Person
+	xrefs        map[string]string          // Person ID to INDI xref
+	families     []*gedcomFamily            // Sorted by partner IDs, so xrefs are stable
+	childOf      map[string][]*gedcomFamily // Person ID to the families they are a child in
+	partnerIn    map[string][]*gedcomFamily // Person ID to the families they are a partner in
+	familyEvents map[string]bool            // "personID|index" of events moved to a family record
+}
+
+// newGEDCOMTree assigns each person an xref and builds the families from the relationships
+func newGEDCOMTree(persons []ancestry.Person, relationships map[string]PersonRelationship) *gedcomTree {
+	tree := &gedcomTree{
+		persons:      persons,
+		xrefs:        make(map[string]string, len(persons)),
+		childOf:      make(map[string][]*gedcomFamily),
+		partnerIn:    make(map[string][]*gedcomFamily),
+		familyEvents: make(map[string]bool),
+	}
+	taken := make(map[string]bool, len(persons))
+	for i, person := range persons {
+		xref := gedcomXref("I", extractPersonNumber(person.GetPersonID()))
+		if xref == "@I@" || taken[xref] {
+			xref = fmt.Sprintf("@I%dX@", i+1)
+		}
+		taken[xref] = true
+		tree.xrefs[person.GetPersonID()] = xref
+	}
+	tree.buildFamilies(relationships)
+	tree.moveFamilyEvents()
+	return tree
+}
+
+// buildFamilies creates a family for each couple and for each set of parents of a child.
+// Relatives outside the exported persons are left out, as GEDCOM pointers must resolve.
+func (t *gedcomTree) buildFamilies(relationships map[string]PersonRelationship) {
+	byKey := make(map[string]*gedcomFamily)
+	family := func(partners []string) *gedcomFamily {
+		sorted := append([]string(nil), partners...)
+		sort.Strings(sorted)
+		key := strings.Join(sorted, "|")
+		if byKey[key] == nil {
+			byKey[key] = &gedcomFamily{Partners: sorted}
+		}
+		return byKey[key]
+	}
+
+	for _, person := range t.persons {
+		personID := person.GetPersonID()
+		rel := relationships[personID]
+		for _, spouse := range rel.Spouses {
+			if t.xrefs[spouse.PersonID] != "" && spouse.PersonID != personID {
+				family([]string{personID, spouse.PersonID})
+			}
+		}
+		if parents := t.knownParents(rel.Parents); len(parents) > 0 {
+			f := family(parents)
+			f.Children = append(f.Children, personID)
+		}
+	}
+
+	keys := make([]string, 0, len(byKey))
+	for key := range byKey {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for i, key := range keys {
+		f := byKey[key]
+		f.Xref = fmt.Sprintf("@F%d@", i+1)
+		t.families = append(t.families, f)
+		for _, partner := range f.Partners {
+			t.partnerIn[partner] = append(t.partnerIn[partner], f)
+		}
+		for _, child := range f.Children {
+			t.childOf[child] = append(t.childOf[child], f)
+		}
+	}
+}
+
+// knownParents returns up to two of a person's parents that are among the exported persons
+func (t *gedcomTree) knownParents(refs []RelationshipReference) []string {
+	var parents []string
+	for _, ref := range refs {
+		if t.xrefs[ref.PersonID] != "" && !slices.Contains(parents, ref.PersonID) && len(parents) < 2 {
+			parents = append(parents, ref.PersonID)
+		}
+	}
+	return parents
+}
+
+// moveFamilyEvents moves marriage, divorce and engagement events to the family record of a
+// person with a single spouse, once per family. The events of persons with several spouses
+// can't be placed and stay on their individual record.
+func (t *gedcomTree) moveFamilyEvents() {
+	seen := make(map[string]bool)
+	for _, person := range t.persons {
+		personID := person.GetPersonID()
+		couples := t.couples(personID)
+		if len(couples) != 1 {
+			continue
+		}
+		for i, event := range person.Events {
+			tag := gedcomFamilyTags[strings.ToLower(event.Type)]
+			if tag == "" {
+				continue
+			}
+			t.familyEvents[fmt.Sprintf("%s|%d", personID, i)] = true
+			key := strings.Join([]string{couples[0].Xref, tag, gedcomDate(event.Date), eventPlace(event)}, "|")
+			if !seen[key] {
+				seen[key] = true
+				couples[0].Events = append(couples[0].Events, event)
+			}
+		}
+	}
+}
+
+// couples returns the families in which the person has a partner
+func (t *gedcomTree) couples(personID string) []*gedcomFamily {
+	var couples []*gedcomFamily
+	for _, f := range t.partnerIn[personID] {
+		if len(f.Partners) == 2 {
+			couples = append(couples, f)
+		}
+	}
+	return couples
+}
+
+// writeEvent writes an event under the given tag with its DATE and PLAC, and its description
+// as the value of attributes and EVEN, or as a NOTE otherwise
+func (w *gedcomWriter) writeEvent(tag string, event ancestry.Event) {
+	date, place := gedcomDate(event.Date), eventPlace(event)
+	value := ""
+	if gedcomAttributeTags[tag] || tag == "EVEN" {
+		value = event.Description
+	} else if date == "" && place == "" {
+		value = "Y" // The event happened, but nothing more is known
+	}
+
+	w.text(1, tag, value)
+	if tag == "EVEN" {
+		w.text(2, "TYPE", event.Type)
+	}
+	if date != "" {
+		w.line(2, "DATE", date)
+	}
+	if place != "" {
+		w.text(2, "PLAC", place)
+	}
+	if !gedcomAttributeTags[tag] && tag != "EVEN" && event.Description != "" {
+		w.text(2, "NOTE", event.Description)
+	}
+}
+
+// writeIndividual writes a person's INDI record. Living persons are exported with a RESN
+// privacy restriction, so importers know to keep them private.
+func (w *gedcomWriter) writeIndividual(t *gedcomTree, person ancestry.Person) {
+	personID := person.GetPersonID()
+	w.line(0, t.xrefs[personID], "INDI")
+
+	given, surname := personNameParts(person)
+	w.text(1, "NAME", strings.TrimSpace(given+" /"+surname+"/"))
+	if given != "" {
+		w.text(2, "GIVN", given)
+	}
+	if surname != "" {
+		w.text(2, "SURN", surname)
+	}
+	w.line(1, "SEX", gedcomSex(person))
+	if person.IsLiving {
+		w.line(1, "RESN", "privacy")
+	}
+
+	for i, event := range person.Events {
+		if t.familyEvents[fmt.Sprintf("%s|%d", personID, i)] {
+			continue
+		}
+		tag := gedcomIndividualTags[strings.ToLower(event.Type)]
+		if tag == "" {
+			tag = "EVEN"
+		}
+		w.writeEvent(tag, event)
+	}
+
+	for _, f := range t.childOf[personID] {
+		w.line(1, "FAMC", f.Xref)
+	}
+	for _, f := range t.partnerIn[personID] {
+		w.line(1, "FAMS", f.Xref)
+	}
+	if number := extractPersonNumber(personID); number != "" {
+		w.text(1, "REFN", number)
+		w.line(2, "TYPE", "Ancestry person ID")
+	}
+}
+
+// writeFamily writes a FAM record, placing partners as HUSB or WIFE by their sex
+func (w *gedcomWriter) writeFamily(t *gedcomTree, f *gedcomFamily, sexes map[string]string) {
+	w.line(0, f.Xref, "FAM")
+	husband, wife := "", ""
+	for _, partner := range f.Partners {
+		switch {
+		case sexes[partner] == "F" && wife == "":
+			wife = partner
+		case husband == "":
+			husband = partner
+		default:
+			wife = partner
+		}
+	}
+	if husband != "" {
+		w.line(1, "HUSB", t.xrefs[husband])
+	}
+	if wife != "" {
+		w.line(1, "WIFE", t.xrefs[wife])
+	}
+	for _, child := range f.Children {
+		w.line(1, "CHIL", t.xrefs[child])
+	}
+	for _, event := range f.Events {
+		w.writeEvent(gedcomFamilyTags[strings.ToLower(event.Type)], event)
+	}
+}
+
+// writeHeader writes the HEAD record and the submitter it points to
+func (w *gedcomWriter) writeHeader(export *TreeExport, date time.Time) {
+	w.line(0, "HEAD", "")
+	w.line(1, "SOUR", "ANCESTRYDL")
+	w.line(2, "NAME", "ancestrydl")
+	w.line(1, "DATE", strings.ToUpper(date.Format("2 Jan 2006")))
+	w.line(1, "SUBM", "@SUBM@")
+	w.line(1, "GEDC", "")
+	w.line(2, "VERS", "5.5.1")
+	w.line(2, "FORM", "LINEAGE-LINKED")
+	w.line(1, "CHAR", "UTF-8")
+	if export.TreeID != "" {
+		w.text(1, "NOTE", strings.Join(strings.Fields("Ancestry tree "+export.TreeName+" ("+export.TreeID+")"), " "))
+	}
+	w.line(0, "@SUBM@", "SUBM")
+	w.line(1, "NAME", "ancestrydl")
+}
+
+// renderGEDCOM returns the tree as a GEDCOM 5.5.1 file
+func renderGEDCOM(export *TreeExport, relationships map[string]PersonRelationship) string {
+	date, err := time.Parse(time.RFC3339, export.ExportDate)
+	if err != nil {
+		date = time.Now()
+	}
+	tree := newGEDCOMTree(export.Persons, relationships)
+
+	var w gedcomWriter
+	w.writeHeader(export, date)
+	sexes := make(map[string]string, len(export.Persons))
+	for _, person := range export.Persons {
+		w.writeIndividual(tree, person)
+		sexes[person.GetPersonID()] = gedcomSex(person)
+	}
+	for _, f := range tree.families {
+		w.writeFamily(tree, f, sexes)
+	}
+	w.line(0, "TRLR", "")
+	return w.b.String()
+}
+
+// gedcomTreeWriter writes the tree as GEDCOM to tree.ged
+type gedcomTreeWriter struct {
+	outputDir string
+}
+
+// Write implements TreeWriter
+func (w gedcomTreeWriter) Write(_ context.Context, export *TreeExport, relationships map[string]PersonRelationship, _ map[string]PersonMediaInfo) error {
+	if err := os.WriteFile(filepath.Join(w.outputDir, gedcomFile), []byte(renderGEDCOM(export, relationships)), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", gedcomFile, err)
+	}
+	return nil
+}
+
+func init() {
+	RegisterTreeWriter(TreeFormatGEDCOM, func(outputDir string) TreeWriter {
+		return gedcomTreeWriter{outputDir: outputDir}
+	})
+}
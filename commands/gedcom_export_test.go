@@ -0,0 +1,137 @@
+package commands
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+)
+
+func TestGEDCOMDate(t *testing.T) {
+	tests := []struct {
+		raw  interface{}
+		want string
+	}{
+		{"12 Mar 1850", "12 MAR 1850"},
+		{"Mar 1850", "MAR 1850"},
+		{1850.0, "1850"},
+		{"Abt 1850", "ABT 1850"},
+		{"Bef 3 Feb 1920", "BEF 3 FEB 1920"},
+		{"Bet 1850 and 1860", "BET 1850 AND 1860"},
+		{"Bet 3 Mar 1850 and 12 Jun 1851", "BET 3 MAR 1850 AND 12 JUN 1851"},
+		{"Bet 1850 and Jun 1851", "BET 1850 AND JUN 1851"},
+		{"Christmas (probably) 1850", "(Christmas probably 1850)"},
+		{"", ""},
+		{nil, ""},
+	}
+	for _, tt := range tests {
+		if got := gedcomDate(tt.raw); got != tt.want {
+			t.Errorf("gedcomDate(%v) = %q, want %q", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestGEDCOMWriterText(t *testing.T) {
+	var w gedcomWriter
+	w.text(1, "NOTE", "Email a@b.com\nSecond line "+strings.Repeat("x", gedcomLineLength+10))
+
+	lines := strings.Split(strings.TrimSuffix(w.b.String(), "\n"), "\n")
+	if lines[0] != "1 NOTE Email a@@b.com" {
+		t.Errorf("first line = %q", lines[0])
+	}
+	if !strings.HasPrefix(lines[1], "2 CONT Second line ") || len(lines) != 3 || !strings.HasPrefix(lines[2], "3 CONC x") {
+		t.Errorf("continuation lines = %q", lines[1:])
+	}
+	for _, line := range lines {
+		if len(line) > 255 {
+			t.Errorf("line longer than 255 characters: %q", line)
+		}
+	}
+}
+
+func TestRenderGEDCOM(t *testing.T) {
+	export := &TreeExport{
+		TreeID:     "42",
+		TreeName:   "Smith Family",
+		ExportDate: "2024-05-01T12:00:00Z",
+		Persons: []ancestry.Person{
+			{PID: "10:1030:42", GivenName: "John", Surname: "Smith", Gender: "m", Events: []ancestry.Event{
+				{Type: Birth, Date: "12 Mar 1850", NPS: []map[string]interface{}{{"v": "York, England"}}},
+				{Type: "Marriage", Date: "1875", Place: "Leeds"},
+				{Type: "Occupation", Description: "Farmer"},
+				{Type: "Prison", Date: "about 1880"},
+			}},
+			{PID: "11:1030:42", GivenName: "Mary", Surname: "Jones", Gender: "f", Events: []ancestry.Event{
+				{Type: "Marriage", Date: "1875", Place: "Leeds"},
+				{Type: Death, Date: "sometime in the 1900s"},
+			}},
+			{PID: "12:1030:42", GivenName: "Ann", Surname: "Smith", Gender: "f", IsLiving: true},
+		},
+	}
+	relationships := map[string]PersonRelationship{
+		"10:1030:42": {Spouses: []RelationshipReference{{PersonID: "11:1030:42"}}, Children: []RelationshipReference{{PersonID: "12:1030:42"}}},
+		"11:1030:42": {Spouses: []RelationshipReference{{PersonID: "10:1030:42"}}, Children: []RelationshipReference{{PersonID: "12:1030:42"}}},
+		"12:1030:42": {Parents: []RelationshipReference{{PersonID: "10:1030:42"}, {PersonID: "11:1030:42"}, {PersonID: "99:1030:42"}}},
+	}
+
+	got := renderGEDCOM(export, relationships)
+
+	for _, want := range []string{
+		"0 HEAD\n1 SOUR ANCESTRYDL\n2 NAME ancestrydl\n1 DATE 1 MAY 2024\n1 SUBM @SUBM@\n1 GEDC\n2 VERS 5.5.1\n2 FORM LINEAGE-LINKED\n1 CHAR UTF-8\n1 NOTE Ancestry tree Smith Family (42)\n",
+		"0 @I10@ INDI\n1 NAME John /Smith/\n2 GIVN John\n2 SURN Smith\n1 SEX M\n1 BIRT\n2 DATE 12 MAR 1850\n2 PLAC York, England\n1 OCCU Farmer\n1 EVEN\n2 TYPE Prison\n2 DATE ABT 1880\n1 FAMS @F1@\n1 REFN 10\n2 TYPE Ancestry person ID\n",
+		"1 DEAT\n2 DATE (sometime in the 1900s)\n",
+		"0 @I12@ INDI\n1 NAME Ann /Smith/\n2 GIVN Ann\n2 SURN Smith\n1 SEX F\n1 RESN privacy\n1 FAMC @F1@\n",
+		"0 @F1@ FAM\n1 HUSB @I10@\n1 WIFE @I11@\n1 CHIL @I12@\n1 MARR\n2 DATE 1875\n2 PLAC Leeds\n0 TRLR\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("GEDCOM is missing\n%s\ngot\n%s", want, got)
+		}
+	}
+	if n := strings.Count(got, " MARR"); n != 1 {
+		t.Errorf("marriage written %d times, want once on the family", n)
+	}
+	if strings.Contains(got, "@I99@") {
+		t.Error("a relative outside the export should not be referenced")
+	}
+}
+
+func TestLoadSavedTreeExport(t *testing.T) {
+	dir := t.TempDir()
+	persons := []ancestry.Person{
+		{PID: "10:1030:42", Names: []ancestry.Name{{GivenName: "John", Surname: "Smith"}}, Gender: "m",
+			Events: []ancestry.Event{{Type: Birth, Date: "1850", NPS: []map[string]interface{}{{"v": "York"}}}}},
+		{PID: "12:1030:42", Names: []ancestry.Name{{GivenName: "Ann", Surname: "Smith"}}, IsLiving: true},
+	}
+	relationships := map[string]PersonRelationship{
+		"10:1030:42": {PersonID: "10:1030:42", Children: []RelationshipReference{{PersonID: "12:1030:42", Name: "Ann Smith"}}},
+		"12:1030:42": {PersonID: "12:1030:42", Parents: []RelationshipReference{{PersonID: "10:1030:42", Name: "John Smith"}}},
+	}
+	if err := savePersonsData(dir, persons, relationships, nil, nil, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := saveMetadata(dir, &TreeExport{TreeID: "42", TreeName: "Smiths", ExportDate: "2024-05-01T12:00:00Z"}); err != nil {
+		t.Fatal(err)
+	}
+
+	export, loaded, err := loadSavedTreeExport(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if export.TreeID != "42" || export.TreeName != "Smiths" || len(export.Persons) != 2 {
+		t.Fatalf("export = %+v", export)
+	}
+	john := export.Persons[0]
+	if john.GetPersonID() != "10:1030:42" || john.GivenName != "John" || john.Gender != "m" {
+		t.Errorf("John = %+v", john)
+	}
+	if len(john.Events) != 1 || john.Events[0].Place != "York" || john.Events[0].Date != "1850" {
+		t.Errorf("John's events = %+v", john.Events)
+	}
+	if !export.Persons[1].IsLiving {
+		t.Error("Ann should be living")
+	}
+	if want := relationships["12:1030:42"].Parents; !reflect.DeepEqual(loaded["12:1030:42"].Parents, want) {
+		t.Errorf("Ann's parents = %+v, want %+v", loaded["12:1030:42"].Parents, want)
+	}
+}
@@ -0,0 +1,203 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+)
+
+const (
+	// GraphFormatGraphML writes the relationship graph as GraphML (Gephi, Cytoscape, yEd)
+	GraphFormatGraphML = "graphml"
+	// GraphFormatDOT writes the relationship graph as Graphviz DOT
+	GraphFormatDOT = "dot"
+)
+
+// graphNode is a person in the relationship graph
+type graphNode struct {
+	ID    string
+	Label string
+}
+
+// graphEdge is a typed relationship between two persons
+type graphEdge struct {
+	Source string
+	Target string
+	Type   string // What target is to source: "parent", "child" or "spouse"
+}
+
+// relationshipGraph holds the nodes and deduplicated edges built from the relationship map
+type relationshipGraph struct {
+	Nodes []graphNode
+	Edges []graphEdge
+}
+
+// validateGraphFormat checks that the --graph value is a supported format
+func validateGraphFormat(format string) error {
+	switch format {
+	case "", GraphFormatGraphML, GraphFormatDOT:
+		return nil
+	}
+	return fmt.Errorf("invalid graph format %q (must be '%s' or '%s')", format, GraphFormatGraphML, GraphFormatDOT)
+}
+
+// buildRelationshipGraph converts the relationship map into graph nodes and edges.
+// Every parent/child link becomes a "parent" edge from the child to the parent and a
+// "child" edge from the parent to the child, whichever side lists it, and every
+// marriage becomes a single "spouse" edge.
+func buildRelationshipGraph(persons []ancestry.Person, relationships map[string]PersonRelationship) relationshipGraph {
+	labels := make(map[string]string)
+	addNode := func(id, name string) {
+		if id == "" {
+			return
+		}
+		if _, exists := labels[id]; !exists || labels[id] == id {
+			if name == "" {
+				name = id
+			}
+			labels[id] = name
+		}
+	}
+
+	for _, person := range persons {
+		addNode(person.GetPersonID(), person.GetDisplayName())
+	}
+
+	seenEdges := make(map[string]bool)
+	var edges []graphEdge
+	addEdge := func(source, target, edgeType string) {
+		key := source + "|" + target + "|" + edgeType
+		if edgeType == "spouse" && target < source {
+			key = target + "|" + source + "|" + edgeType
+		}
+		if seenEdges[key] {
+			return
+		}
+		seenEdges[key] = true
+		edges = append(edges, graphEdge{Source: source, Target: target, Type: edgeType})
+	}
+
+	personIDs := make([]string, 0, len(relationships))
+	for personID := range relationships {
+		personIDs = append(personIDs, personID)
+	}
+	sort.Strings(personIDs)
+
+	for _, personID := range personIDs {
+		rel := relationships[personID]
+		addNode(personID, rel.Name)
+
+		for _, parent := range rel.Parents {
+			addNode(parent.PersonID, parent.Name)
+			addEdge(personID, parent.PersonID, "parent")
+			addEdge(parent.PersonID, personID, "child")
+		}
+		for _, child := range rel.Children {
+			addNode(child.PersonID, child.Name)
+			addEdge(personID, child.PersonID, "child")
+			addEdge(child.PersonID, personID, "parent")
+		}
+		for _, spouse := range rel.Spouses {
+			addNode(spouse.PersonID, spouse.Name)
+			addEdge(personID, spouse.PersonID, "spouse")
+		}
+	}
+
+	nodes := make([]graphNode, 0, len(labels))
+	for id, label := range labels {
+		nodes = append(nodes, graphNode{ID: id, Label: label})
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID < nodes[j].ID })
+
+	return relationshipGraph{Nodes: nodes, Edges: edges}
+}
+
+// xmlEscape escapes a string for use in XML attribute values and text
+func xmlEscape(s string) string {
+	var buf bytes.Buffer
+	_ = xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
+
+// renderGraphML renders the graph in GraphML format
+func renderGraphML(graph relationshipGraph) string {
+	var sb strings.Builder
+	sb.WriteString(`<?xml version="1.0" encoding="UTF-8"?>` + "\n")
+	sb.WriteString(`<graphml xmlns="http://graphml.graphdrawing.org/xmlns">` + "\n")
+	sb.WriteString(`  <key id="label" for="node" attr.name="label" attr.type="string"/>` + "\n")
+	sb.WriteString(`  <key id="type" for="edge" attr.name="type" attr.type="string"/>` + "\n")
+	sb.WriteString(`  <graph id="family" edgedefault="directed">` + "\n")
+
+	for _, node := range graph.Nodes {
+		fmt.Fprintf(&sb, "    <node id=\"%s\"><data key=\"label\">%s</data></node>\n",
+			xmlEscape(node.ID), xmlEscape(node.Label))
+	}
+
+	for i, edge := range graph.Edges {
+		directed := "true"
+		if edge.Type == "spouse" {
+			directed = "false"
+		}
+		fmt.Fprintf(&sb, "    <edge id=\"e%d\" source=\"%s\" target=\"%s\" directed=\"%s\"><data key=\"type\">%s</data></edge>\n",
+			i, xmlEscape(edge.Source), xmlEscape(edge.Target), directed, edge.Type)
+	}
+
+	sb.WriteString("  </graph>\n")
+	sb.WriteString("</graphml>\n")
+	return sb.String()
+}
+
+// dotQuote quotes a string as a Graphviz DOT identifier
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", " ").Replace(s) + `"`
+}
+
+// renderDOT renders the graph in Graphviz DOT format
+func renderDOT(graph relationshipGraph) string {
+	var sb strings.Builder
+	sb.WriteString("digraph family {\n")
+
+	for _, node := range graph.Nodes {
+		fmt.Fprintf(&sb, "  %s [label=%s];\n", dotQuote(node.ID), dotQuote(node.Label))
+	}
+
+	for _, edge := range graph.Edges {
+		if edge.Type == "spouse" {
+			fmt.Fprintf(&sb, "  %s -> %s [type=spouse, dir=none];\n", dotQuote(edge.Source), dotQuote(edge.Target))
+			continue
+		}
+		fmt.Fprintf(&sb, "  %s -> %s [type=%s];\n", dotQuote(edge.Source), dotQuote(edge.Target), edge.Type)
+	}
+
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+// saveRelationshipGraph writes relationships.graphml or relationships.dot to the output directory
+func saveRelationshipGraph(outputDir, format string, persons []ancestry.Person, relationships map[string]PersonRelationship) (string, error) {
+	graph := buildRelationshipGraph(persons, relationships)
+
+	var content string
+	switch format {
+	case GraphFormatGraphML:
+		content = renderGraphML(graph)
+	case GraphFormatDOT:
+		content = renderDOT(graph)
+	default:
+		return "", validateGraphFormat(format)
+	}
+
+	fileName := "relationships." + format
+	graphPath := filepath.Join(outputDir, fileName)
+	if err := os.WriteFile(graphPath, []byte(content), 0644); err != nil {
+		return "", fmt.Errorf("failed to write %s: %w", fileName, err)
+	}
+
+	return fileName, nil
+}
@@ -0,0 +1,51 @@
+package commands
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+)
+
+func TestBuildRelationshipGraph(t *testing.T) {
+	persons := []ancestry.Person{
+		{PID: "1", GivenName: "John", Surname: "Smith"},
+		{PID: "2", GivenName: "Mary", Surname: "Jones"},
+		{PID: "3", GivenName: "Ann", Surname: "Smith"},
+	}
+	// Mary lists Ann as a child but Ann only lists John as a parent
+	relationships := map[string]PersonRelationship{
+		"1": {PersonID: "1", Spouses: []RelationshipReference{{PersonID: "2"}}, Children: []RelationshipReference{{PersonID: "3"}}},
+		"2": {PersonID: "2", Spouses: []RelationshipReference{{PersonID: "1"}}, Children: []RelationshipReference{{PersonID: "3"}}},
+		"3": {PersonID: "3", Parents: []RelationshipReference{{PersonID: "1"}}},
+	}
+
+	graph := buildRelationshipGraph(persons, relationships)
+
+	want := []graphEdge{
+		{Source: "1", Target: "3", Type: "child"},
+		{Source: "3", Target: "1", Type: "parent"},
+		{Source: "1", Target: "2", Type: "spouse"},
+		{Source: "2", Target: "3", Type: "child"},
+		{Source: "3", Target: "2", Type: "parent"},
+	}
+	if !reflect.DeepEqual(graph.Edges, want) {
+		t.Errorf("edges = %+v\nwant %+v", graph.Edges, want)
+	}
+	if len(graph.Nodes) != 3 || graph.Nodes[2].Label != "Ann Smith" {
+		t.Errorf("nodes = %+v", graph.Nodes)
+	}
+}
+
+func TestRenderDOTEdges(t *testing.T) {
+	dot := renderDOT(relationshipGraph{
+		Nodes: []graphNode{{ID: "1", Label: "John"}, {ID: "3", Label: "Ann"}},
+		Edges: []graphEdge{{Source: "1", Target: "3", Type: "child"}, {Source: "3", Target: "1", Type: "parent"}},
+	})
+	for _, want := range []string{`"1" -> "3" [type=child];`, `"3" -> "1" [type=parent];`} {
+		if !strings.Contains(dot, want) {
+			t.Errorf("DOT missing %q:\n%s", want, dot)
+		}
+	}
+}
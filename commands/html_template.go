@@ -3,7 +3,7 @@ package commands
 import "fmt"
 
 // generateHTMLTemplate creates the HTML viewer with embedded JSON data
-func generateHTMLTemplate(peopleJSON, metadataJSON string) string {
+func generateHTMLTemplate(peopleJSON, metadataJSON, theme string) string {
 	return fmt.Sprintf(`<!DOCTYPE html>
 <html lang="en">
 <head>
@@ -11,7 +11,7 @@ func generateHTMLTemplate(peopleJSON, metadataJSON string) string {
     <meta name="viewport" content="width=device-width, initial-scale=1.0">
     <title>Family Tree Viewer</title>
     <style>
-        * {
+%s        * {
             margin: 0;
             padding: 0;
             box-sizing: border-box;
@@ -19,30 +19,30 @@ func generateHTMLTemplate(peopleJSON, metadataJSON string) string {
 
         body {
             font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, Oxygen, Ubuntu, Cantarell, sans-serif;
-            background: #f5f5f5;
+            background: var(--page-bg);
             padding: 20px;
         }
 
         .container {
             max-width: 1400px;
             margin: 0 auto;
-            background: white;
+            background: var(--surface);
             border-radius: 8px;
             box-shadow: 0 2px 8px rgba(0,0,0,0.1);
             padding: 30px;
         }
 
         h1 {
-            color: #2c3e50;
+            color: var(--text);
             margin-bottom: 10px;
             font-size: 2.5em;
         }
 
         .metadata {
-            color: #7f8c8d;
+            color: var(--text-secondary);
             margin-bottom: 30px;
             padding: 15px;
-            background: #ecf0f1;
+            background: var(--surface-muted);
             border-radius: 4px;
         }
 
@@ -51,7 +51,7 @@ func generateHTMLTemplate(peopleJSON, metadataJSON string) string {
             padding: 12px 20px;
             margin-bottom: 20px;
             font-size: 16px;
-            border: 2px solid #bdc3c7;
+            border: 2px solid var(--input-border);
             border-radius: 4px;
             outline: none;
         }
@@ -93,10 +93,10 @@ func generateHTMLTemplate(peopleJSON, metadataJSON string) string {
         }
 
         .person-card {
-            border: 1px solid #e0e0e0;
+            border: 1px solid var(--border);
             border-radius: 8px;
             padding: 20px;
-            background: white;
+            background: var(--surface);
             transition: transform 0.2s, box-shadow 0.2s;
         }
 
@@ -106,24 +106,24 @@ func generateHTMLTemplate(peopleJSON, metadataJSON string) string {
         }
 
         .person-card h3 {
-            color: #2c3e50;
+            color: var(--text);
             margin-bottom: 10px;
             font-size: 1.3em;
         }
 
         .person-id {
-            color: #95a5a6;
+            color: var(--text-faint);
             font-size: 0.85em;
             margin-bottom: 10px;
         }
 
         .person-info {
             margin: 10px 0;
-            color: #555;
+            color: var(--text-body);
         }
 
         .person-info strong {
-            color: #2c3e50;
+            color: var(--text);
         }
 
         .media-gallery {
@@ -175,12 +175,12 @@ func generateHTMLTemplate(peopleJSON, metadataJSON string) string {
 
         .sources-preview {
             margin-top: 15px;
-            border-top: 1px solid #ecf0f1;
+            border-top: 1px solid var(--surface-muted);
             padding-top: 15px;
         }
 
         .sources-preview h4 {
-            color: #7f8c8d;
+            color: var(--text-secondary);
             font-size: 0.9em;
             margin-bottom: 10px;
             font-weight: 600;
@@ -197,7 +197,7 @@ func generateHTMLTemplate(peopleJSON, metadataJSON string) string {
             align-items: center;
             padding: 8px;
             margin-bottom: 6px;
-            background: #fafafa;
+            background: var(--surface-alt);
             border-radius: 4px;
             font-size: 0.85em;
             border-left: 3px solid #f39c12;
@@ -206,7 +206,7 @@ func generateHTMLTemplate(peopleJSON, metadataJSON string) string {
         }
 
         .source-item-compact:hover {
-            background: #fff8e1;
+            background: var(--highlight);
         }
 
         .source-icon-small {
@@ -216,7 +216,7 @@ func generateHTMLTemplate(peopleJSON, metadataJSON string) string {
 
         .source-name-compact {
             flex: 1;
-            color: #2c3e50;
+            color: var(--text);
             font-weight: 500;
             overflow: hidden;
             text-overflow: ellipsis;
@@ -226,7 +226,7 @@ func generateHTMLTemplate(peopleJSON, metadataJSON string) string {
         .no-results {
             text-align: center;
             padding: 40px;
-            color: #95a5a6;
+            color: var(--text-faint);
             font-size: 1.2em;
         }
 
@@ -289,7 +289,7 @@ func generateHTMLTemplate(peopleJSON, metadataJSON string) string {
 
         <div style="margin: 20px 0; display: flex; gap: 10px; align-items: center;">
             <label for="sort-select" style="font-weight: bold;">Sort by:</label>
-            <select id="sort-select" style="padding: 8px 12px; border: 1px solid #ddd; border-radius: 4px; font-size: 1em;">
+            <select id="sort-select" style="padding: 8px 12px; border: 1px solid var(--border-strong); border-radius: 4px; font-size: 1em;">
                 <option value="name">Name (A-Z)</option>
                 <option value="name-desc">Name (Z-A)</option>
                 <option value="birth-asc">Birth Year (Oldest First)</option>
@@ -350,12 +350,8 @@ func generateHTMLTemplate(peopleJSON, metadataJSON string) string {
                 }
             });
 
-            const statsDiv = document.getElementById('stats');
-            statsDiv.innerHTML = `+"`"+`
-                <div class="stat-card">
-                    <h3>${totalPeople}</h3>
-                    <p>Total People</p>
-                </div>
+            // A download made with --exclude-media has no media to count
+            const mediaCards = metadata.mediaExcluded ? '' : `+"`"+`
                 <div class="stat-card">
                     <h3>${withMedia}</h3>
                     <p>People with Media</p>
@@ -369,6 +365,15 @@ func generateHTMLTemplate(peopleJSON, metadataJSON string) string {
                     <p>Photos</p>
                 </div>
             `+"`"+`;
+
+            const statsDiv = document.getElementById('stats');
+            statsDiv.innerHTML = `+"`"+`
+                <div class="stat-card">
+                    <h3>${totalPeople}</h3>
+                    <p>Total People</p>
+                </div>
+                ${mediaCards}
+            `+"`"+`;
         }
 
         function displayPeople(people) {
@@ -410,7 +415,7 @@ func generateHTMLTemplate(peopleJSON, metadataJSON string) string {
                             const tooltip = [file.title, file.subcategory].filter(x => x).join(' - ');
                             const metadataText = [file.title, file.date, file.subcategory, file.description].filter(x => x).join(' | ');
 
-                            return `+"`"+`<img src="${file.filePath}" alt="${tooltip || name}" title="${tooltip}" onclick='event.stopPropagation(); openLightbox("${file.filePath}", ${JSON.stringify(metadataText).replace(/'/g, "&apos;")})'>`+"`"+`;
+                            return `+"`"+`<img src="${file.thumbnail || file.filePath}" alt="${tooltip || name}" title="${tooltip}" onclick='event.stopPropagation(); openLightbox("${file.filePath}", ${JSON.stringify(metadataText).replace(/'/g, "&apos;")})'>`+"`"+`;
                         }).join('')}
                     </div>
                 `+"`"+` : '';
@@ -454,7 +459,7 @@ func generateHTMLTemplate(peopleJSON, metadataJSON string) string {
                                     </li>
                                 `+"`"+`;
                             }).join('')}
-                            ${recordImages.length > 3 ? `+"`"+`<li style="text-align: center; color: #666; font-size: 0.85em; padding: 5px;">+${recordImages.length - 3} more...</li>`+"`"+` : ''}
+                            ${recordImages.length > 3 ? `+"`"+`<li style="text-align: center; color: var(--text-soft); font-size: 0.85em; padding: 5px;">+${recordImages.length - 3} more...</li>`+"`"+` : ''}
                         </ul>
                     </div>
                 `+"`"+` : '';
@@ -472,7 +477,7 @@ func generateHTMLTemplate(peopleJSON, metadataJSON string) string {
                 }
 
                 return `+"`"+`
-                    <div class="person-card" onclick="window.location='person.html?id=${encodeURIComponent(personId)}'" style="cursor: pointer;">
+                    <div class="person-card" ${metadata.selfContained ? '' : `+"`"+`onclick="window.location='person.html?id=${encodeURIComponent(personId)}'" style="cursor: pointer;"`+"`"+`}>
                         <h3>${name}</h3>
                         ${person.gender ? `+"`"+`<div class="person-info"><strong>Gender:</strong> ${person.gender}</div>`+"`"+` : ''}
                         ${birthInfo ? `+"`"+`<div class="person-info"><strong>Birth:</strong> ${birthInfo}</div>`+"`"+` : ''}
@@ -516,9 +521,7 @@ func generateHTMLTemplate(peopleJSON, metadataJSON string) string {
         function getEventYear(person, eventType) {
             if (!person.events) return null;
             const event = person.events.find(e => e.type === eventType);
-            if (!event || !event.date) return null;
-            const match = event.date.toString().match(/\d{4}/);
-            return match ? parseInt(match[0]) : null;
+            return event && event.year ? event.year : null;
         }
 
         // Sort function
@@ -611,5 +614,5 @@ func generateHTMLTemplate(peopleJSON, metadataJSON string) string {
         });
     </script>
 </body>
-</html>`, peopleJSON, metadataJSON)
+</html>`, themeCSS(theme), peopleJSON, metadataJSON)
 }
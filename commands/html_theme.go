@@ -0,0 +1,90 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+)
+
+// HTML viewer color themes
+const (
+	ThemeLight = "light" // The original light colors (default)
+	ThemeDark  = "dark"  // Dark backgrounds with light text
+	ThemeAuto  = "auto"  // Follows the reader's system setting
+)
+
+// themeColor is a CSS variable of the viewer's color scheme with its light and dark values
+type themeColor struct {
+	Name  string
+	Light string
+	Dark  string
+}
+
+// themeColors are the viewer colors that change with the theme. Accent and badge colors
+// read well on both backgrounds and stay fixed.
+var themeColors = []themeColor{
+	{Name: "--page-bg", Light: "#f5f5f5", Dark: "#121417"},
+	{Name: "--surface", Light: "white", Dark: "#1e2227"},
+	{Name: "--surface-alt", Light: "#fafafa", Dark: "#23272d"},
+	{Name: "--surface-subtle", Light: "#f8f9fa", Dark: "#23272d"},
+	{Name: "--surface-muted", Light: "#ecf0f1", Dark: "#2a2f36"},
+	{Name: "--surface-hover", Light: "#f0f0f0", Dark: "#2a2f36"},
+	{Name: "--text", Light: "#2c3e50", Dark: "#e6e9ec"},
+	{Name: "--text-secondary", Light: "#7f8c8d", Dark: "#a0a8b0"},
+	{Name: "--text-faint", Light: "#95a5a6", Dark: "#8a939b"},
+	{Name: "--text-body", Light: "#555", Dark: "#c8cdd2"},
+	{Name: "--text-soft", Light: "#666", Dark: "#b4bac0"},
+	{Name: "--border", Light: "#e0e0e0", Dark: "#343a41"},
+	{Name: "--border-strong", Light: "#ddd", Dark: "#3d444c"},
+	{Name: "--input-border", Light: "#bdc3c7", Dark: "#4a525b"},
+	{Name: "--highlight", Light: "#fff8e1", Dark: "#3a3322"},
+	{Name: "--highlight-warm", Light: "#fff4e6", Dark: "#3a3020"},
+	{Name: "--success-bg", Light: "#e8f5e9", Dark: "#1f3324"},
+}
+
+// validateTheme checks that theme is one of the supported HTML viewer themes
+func validateTheme(theme string) error {
+	switch theme {
+	case ThemeLight, ThemeDark, ThemeAuto:
+		return nil
+	default:
+		return fmt.Errorf("invalid --theme %q: must be 'light', 'dark' or 'auto'", theme)
+	}
+}
+
+// themeRootBlock returns a :root rule setting every theme color to its light or dark value
+func themeRootBlock(dark bool, indent string) string {
+	var b strings.Builder
+	scheme := ThemeLight
+	if dark {
+		scheme = ThemeDark
+	}
+	b.WriteString(indent + ":root {\n")
+	// color-scheme gives form controls and scrollbars matching colors
+	fmt.Fprintf(&b, "%s    color-scheme: %s;\n", indent, scheme)
+	for _, color := range themeColors {
+		value := color.Light
+		if dark {
+			value = color.Dark
+		}
+		fmt.Fprintf(&b, "%s    %s: %s;\n", indent, color.Name, value)
+	}
+	b.WriteString(indent + "}\n")
+	return b.String()
+}
+
+// themeCSS returns the CSS variables for a theme. Auto uses the light colors unless the
+// reader's system prefers a dark color scheme. Unknown themes get the light colors.
+func themeCSS(theme string) string {
+	const indent = "        "
+	switch theme {
+	case ThemeDark:
+		return themeRootBlock(true, indent)
+	case ThemeAuto:
+		return themeRootBlock(false, indent) +
+			indent + "@media (prefers-color-scheme: dark) {\n" +
+			themeRootBlock(true, indent+"    ") +
+			indent + "}\n"
+	default:
+		return themeRootBlock(false, indent)
+	}
+}
@@ -0,0 +1,72 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestThemeCSS(t *testing.T) {
+	tests := []struct {
+		theme     string
+		want      []string
+		notWanted []string
+	}{
+		{
+			theme:     ThemeLight,
+			want:      []string{"color-scheme: light;", "--page-bg: #f5f5f5;", "--text: #2c3e50;"},
+			notWanted: []string{"prefers-color-scheme", "#121417"},
+		},
+		{
+			theme:     ThemeDark,
+			want:      []string{"color-scheme: dark;", "--page-bg: #121417;", "--text: #e6e9ec;"},
+			notWanted: []string{"prefers-color-scheme", "#f5f5f5"},
+		},
+		{
+			theme: ThemeAuto,
+			want:  []string{"--page-bg: #f5f5f5;", "@media (prefers-color-scheme: dark) {", "--page-bg: #121417;"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.theme, func(t *testing.T) {
+			css := themeCSS(tt.theme)
+			for _, want := range tt.want {
+				if !strings.Contains(css, want) {
+					t.Errorf("CSS missing %q:\n%s", want, css)
+				}
+			}
+			for _, unwanted := range tt.notWanted {
+				if strings.Contains(css, unwanted) {
+					t.Errorf("CSS should not contain %q:\n%s", unwanted, css)
+				}
+			}
+
+			for name, html := range map[string]string{
+				"index.html":  generateHTMLTemplate("[]", "{}", tt.theme),
+				"person.html": generatePersonPageTemplate("[]", "{}", tt.theme),
+			} {
+				if !strings.Contains(html, css) {
+					t.Errorf("%s doesn't include the theme CSS", name)
+				}
+				if strings.Contains(html, "%!") {
+					t.Errorf("%s has a formatting error", name)
+				}
+			}
+		})
+	}
+
+	if themeCSS("") != themeCSS(ThemeLight) {
+		t.Error("an unset theme should be light")
+	}
+}
+
+func TestValidateTheme(t *testing.T) {
+	for _, theme := range []string{ThemeLight, ThemeDark, ThemeAuto} {
+		if err := validateTheme(theme); err != nil {
+			t.Errorf("validateTheme(%q) = %v", theme, err)
+		}
+	}
+	if err := validateTheme("sepia"); err == nil {
+		t.Error("expected an error for an unknown theme")
+	}
+}
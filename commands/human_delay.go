@@ -0,0 +1,35 @@
+package commands
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+)
+
+// Bounds of the --human-delay pause between facts-page requests
+const (
+	humanDelayMin  = 1 * time.Second
+	humanDelayMax  = 4 * time.Second
+	humanDelayMean = 1 * time.Second // Mean of the exponential jitter added to humanDelayMin
+)
+
+// nextHumanDelay returns a randomized pause between humanDelayMin and humanDelayMax.
+// The jitter is exponentially distributed, so most pauses are short with the
+// occasional longer one, which looks more like a person clicking through pages
+// than a fixed interval does.
+func nextHumanDelay(rng *rand.Rand) time.Duration {
+	jitter := time.Duration(rng.ExpFloat64() * float64(humanDelayMean))
+	return min(humanDelayMin+jitter, humanDelayMax)
+}
+
+// pause waits for d and reports whether it did, returning false as soon as ctx is done
+func pause(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,66 @@
+package commands
+
+import (
+	"context"
+	"math/rand/v2"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+)
+
+func TestNextHumanDelay(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	varied := false
+	first := nextHumanDelay(rng)
+	for i := 0; i < 1000; i++ {
+		d := nextHumanDelay(rng)
+		if d < humanDelayMin || d > humanDelayMax {
+			t.Fatalf("nextHumanDelay() = %v, want between %v and %v", d, humanDelayMin, humanDelayMax)
+		}
+		if d != first {
+			varied = true
+		}
+	}
+	if !varied {
+		t.Error("nextHumanDelay() returned the same delay every time")
+	}
+}
+
+func TestPause(t *testing.T) {
+	if !pause(context.Background(), time.Millisecond) {
+		t.Error("pause() = false without cancellation")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	start := time.Now()
+	if pause(ctx, time.Hour) {
+		t.Error("pause() = true after cancellation")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("pause() took %v after cancellation", elapsed)
+	}
+}
+
+func TestMergeFactsHumanDelayInterrupted(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Ctrl+C during the first fetch must not wait out the pause before the second
+	var fetched atomic.Int32
+	start := time.Now()
+	mergeFactsForPersons(ctx, numberedPersons(3), 1, true, func(personID string) (*ancestry.ResearchData, error) {
+		fetched.Add(1)
+		cancel()
+		return nil, context.Canceled
+	}, &failureLog{}, nil, nil, defaultLogger)
+
+	if fetched.Load() != 1 {
+		t.Errorf("fetched %d Facts pages, want 1", fetched.Load())
+	}
+	if elapsed := time.Since(start); elapsed >= humanDelayMin {
+		t.Errorf("mergeFactsForPersons() took %v after interruption, want less than %v", elapsed, humanDelayMin)
+	}
+}
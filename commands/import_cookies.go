@@ -0,0 +1,83 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+	"github.com/chrisrob11/ancestrydl/pkg/config"
+	"github.com/go-rod/rod/lib/proto"
+	"github.com/urfave/cli/v2"
+)
+
+// ImportCookies imports an existing browser session from a cookies.txt or JSON export,
+// as an alternative to logging in through the automated browser
+func ImportCookies(c *cli.Context) error {
+	logger := loggerFrom(c)
+	cookieFile := c.String("file")
+
+	logger.Printf("1. Reading cookies from %s...\n", cookieFile)
+	data, err := os.ReadFile(cookieFile)
+	if err != nil {
+		return fmt.Errorf("failed to read cookie file: %w", err)
+	}
+
+	cookies, err := ancestry.ParseCookieFile(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse cookie file: %w", err)
+	}
+	logger.Printf("   ✓ Found %d Ancestry cookie(s)\n", len(cookies))
+
+	if err := saveValidatedCookies(c, cookies, "Make sure you are logged in to Ancestry.com in the browser you exported from"); err != nil {
+		return err
+	}
+
+	logger.Println()
+	logger.Println("✅ Cookies imported successfully!")
+	logger.Println()
+
+	return nil
+}
+
+// saveValidatedCookies checks that cookies hold a logged-in session and stores them for later
+// commands. hint is added to the errors for a session that isn't logged in.
+func saveValidatedCookies(c *cli.Context, cookies []*proto.NetworkCookie, hint string) error {
+	logger := loggerFrom(c)
+	logger.Println("2. Validating session...")
+	apiClient, err := ancestry.NewAPIClient(cookies, false)
+	if err != nil {
+		return fmt.Errorf("failed to create API client: %w", err)
+	}
+	if err := configureAPIClient(c, apiClient); err != nil {
+		return err
+	}
+	defer func() {
+		if err := apiClient.Close(); err != nil {
+			logger.Printf("Error closing API client: %v\n", err)
+		}
+	}()
+
+	userData, err := apiClient.GetUserData(c.Context)
+	if err != nil {
+		return fmt.Errorf("session validation failed: %w\n\n%s", err, hint)
+	}
+	if !userData.IsAuthenticated() {
+		return fmt.Errorf("the session is not logged in\n\n%s", hint)
+	}
+	if name := userData.GetDisplayName(); name != "" {
+		logger.Printf("   ✓ Logged in as: %s\n", name)
+	} else {
+		logger.Println("   ✓ Session is valid")
+	}
+
+	logger.Println("3. Saving session...")
+	cookiesJSON, err := ancestry.SerializeCookies(cookies)
+	if err != nil {
+		return fmt.Errorf("failed to serialize cookies: %w", err)
+	}
+	if err := config.SaveCookies(cookiesJSON); err != nil {
+		return fmt.Errorf("failed to save cookies: %w", err)
+	}
+	logger.Println("   ✓ Session saved")
+	return nil
+}
@@ -0,0 +1,127 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+	"github.com/urfave/cli/v2"
+)
+
+// lastRunFile records in the output directory when the last successful download started
+const lastRunFile = ".last-run"
+
+// parseSince parses a --since value, either an RFC 3339 time or a YYYY-MM-DD date (midnight UTC)
+func parseSince(value string) (time.Time, error) {
+	value = strings.TrimSpace(value)
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.DateOnly, value); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --since %q: use a date like 2025-01-31 or a time like 2025-01-31T18:00:00Z", value)
+}
+
+// readLastRun returns the start time of the last successful download into outputDir, or the
+// zero time if there is no record of one
+func readLastRun(outputDir string) (time.Time, error) {
+	data, err := os.ReadFile(filepath.Join(outputDir, lastRunFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	t, err := time.Parse(time.RFC3339, strings.TrimSpace(string(data)))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid %s: %w", lastRunFile, err)
+	}
+	return t, nil
+}
+
+// recordLastRun writes the start time of a successful download to outputDir, warning logger if it
+// can't. The start time is used so changes made while the download ran are picked up next time.
+func recordLastRun(outputDir string, startTime time.Time, logger *log.Logger) {
+	data := []byte(startTime.UTC().Format(time.RFC3339) + "\n")
+	if err := os.WriteFile(filepath.Join(outputDir, lastRunFile), data, 0644); err != nil {
+		logger.Printf("   [Warning] Failed to write %s: %v\n", lastRunFile, err)
+	}
+}
+
+// resolveSince returns the --since time or, with --incremental, the time recorded in outputDir's
+// .last-run. The zero time means everything is downloaded.
+func resolveSince(c *cli.Context, outputDir string) (time.Time, error) {
+	if value := c.String("since"); value != "" {
+		return parseSince(value)
+	}
+	if !c.Bool("incremental") {
+		return time.Time{}, nil
+	}
+
+	since, err := readLastRun(outputDir)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if since.IsZero() {
+		loggerFrom(c).Printf("   No %s in %s yet (--incremental); downloading everything\n", lastRunFile, outputDir)
+	}
+	return since, nil
+}
+
+// skipUnchangedTree reports whether the tree can be skipped because it hasn't been modified since
+// the --since or --incremental time. Trees whose modification time isn't known are downloaded.
+// The outcome is reported to logger.
+func skipUnchangedTree(ctx context.Context, apiClient *ancestry.APIClient, treeID string, since time.Time, logger *log.Logger) bool {
+	if since.IsZero() {
+		return false
+	}
+	trees, err := apiClient.ListTrees(ctx)
+	if err != nil {
+		logger.Printf("   [Warning] Couldn't check when the tree was modified (%v); downloading it\n", err)
+		return false
+	}
+	tree, found := findTree(trees, treeID)
+	if !found || treeChanged(getTreeModifiedDate(tree), since) {
+		return false
+	}
+	logger.Printf("\n✅ Tree unchanged since %s; nothing to download\n", since.Format(time.RFC3339))
+	return true
+}
+
+// personModified parses a person's modification date (Person.MD), which comes as an RFC 3339
+// time or as milliseconds since the epoch
+func personModified(person ancestry.Person) (time.Time, bool) {
+	md := strings.TrimSpace(person.MD)
+	if md == "" {
+		return time.Time{}, false
+	}
+	if ms, err := strconv.ParseInt(md, 10, 64); err == nil {
+		return time.UnixMilli(ms), true
+	}
+	for _, layout := range []string{time.RFC3339, "2006-01-02T15:04:05"} {
+		if t, err := time.Parse(layout, md); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// countChangedSince returns the number of persons modified after since. Persons without a
+// readable modification date are counted as changed.
+func countChangedSince(persons []ancestry.Person, since time.Time) int {
+	changed := 0
+	for _, person := range persons {
+		if modified, ok := personModified(person); !ok || modified.After(since) {
+			changed++
+		}
+	}
+	return changed
+}
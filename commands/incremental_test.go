@@ -0,0 +1,76 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+)
+
+func TestParseSince(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{"date", "2025-01-31", time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC), false},
+		{"rfc3339", "2025-01-31T18:00:00Z", time.Date(2025, 1, 31, 18, 0, 0, 0, time.UTC), false},
+		{"surrounding space", " 2025-01-31 ", time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC), false},
+		{"invalid", "last week", time.Time{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSince(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseSince(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("parseSince(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLastRunRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+
+	got, err := readLastRun(dir)
+	if err != nil || !got.IsZero() {
+		t.Fatalf("readLastRun() with no file = %v, %v; want zero time, nil", got, err)
+	}
+
+	start := time.Date(2025, 3, 4, 5, 6, 7, 0, time.UTC)
+	recordLastRun(dir, start, defaultLogger)
+	got, err = readLastRun(dir)
+	if err != nil {
+		t.Fatalf("readLastRun() error = %v", err)
+	}
+	if !got.Equal(start) {
+		t.Errorf("readLastRun() = %v, want %v", got, start)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, lastRunFile), []byte("garbage"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readLastRun(dir); err == nil {
+		t.Error("readLastRun() with an invalid file should fail")
+	}
+}
+
+func TestCountChangedSince(t *testing.T) {
+	since := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	persons := []ancestry.Person{
+		{MD: "2024-12-31T23:59:59Z"},
+		{MD: "2025-01-02T00:00:00Z"},
+		{MD: "2025-01-02T00:00:00"},
+		{MD: "1735603200000"}, // 2024-12-31
+		{MD: ""},
+		{MD: "not a date"},
+	}
+	if got := countChangedSince(persons, since); got != 4 {
+		t.Errorf("countChangedSince() = %d, want 4", got)
+	}
+}
@@ -0,0 +1,162 @@
+package commands
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+)
+
+// buildSyntheticTree creates families of four children per couple, with each child's
+// birth date also recorded as an untyped event on every sibling
+func buildSyntheticTree(personCount int) ([]ancestry.Person, map[string]PersonRelationship) {
+	persons := make([]ancestry.Person, 0, personCount)
+	relationships := make(map[string]PersonRelationship)
+
+	for family := 0; len(persons) < personCount; family++ {
+		father := RelationshipReference{PersonID: fmt.Sprintf("f%d", family), Name: "Father"}
+		mother := RelationshipReference{PersonID: fmt.Sprintf("m%d", family), Name: "Mother"}
+
+		for child := 0; child < 4 && len(persons) < personCount; child++ {
+			id := fmt.Sprintf("p%d-%d", family, child)
+			events := []ancestry.Event{{Type: Birth, Date: fmt.Sprintf("%d-%d", family, child)}}
+			for sibling := 0; sibling < 4; sibling++ {
+				if sibling != child {
+					events = append(events, ancestry.Event{Date: fmt.Sprintf("%d-%d", family, sibling)})
+				}
+			}
+
+			persons = append(persons, ancestry.Person{
+				PID:    id,
+				Gender: "f",
+				Names:  []ancestry.Name{{GivenName: fmt.Sprintf("Child%d", child), Surname: "Smith"}},
+				Events: events,
+			})
+			relationships[id] = PersonRelationship{
+				PersonID: id,
+				Parents:  []RelationshipReference{father, mother},
+			}
+		}
+	}
+
+	return persons, relationships
+}
+
+func TestInferEventTypesSiblings(t *testing.T) {
+	persons, relationships := buildSyntheticTree(4)
+
+	if inferred := inferEventTypes(persons, relationships); inferred != 12 {
+		t.Fatalf("inferred %d events, want 12", inferred)
+	}
+
+	want := "Birth of sister Child1 Smith"
+	if got := persons[0].Events[1].Type; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+// scanSiblingIndices is the per-person scan inferEventTypes used before the
+// children-by-parent index: it compares the person's parents with every other person's
+func scanSiblingIndices(personIndex int, rels PersonRelationship, persons []ancestry.Person,
+	relationships map[string]PersonRelationship) []int {
+	var siblings []int
+	for idx := range persons {
+		if idx == personIndex {
+			continue
+		}
+		otherRels, hasOtherRels := relationships[persons[idx].GetPersonID()]
+		if !hasOtherRels {
+			continue
+		}
+		if sharesParent(rels.Parents, otherRels.Parents) {
+			siblings = append(siblings, idx)
+		}
+	}
+	return siblings
+}
+
+func sharesParent(myParents, theirParents []RelationshipReference) bool {
+	for _, myParent := range myParents {
+		for _, theirParent := range theirParents {
+			if myParent.PersonID == theirParent.PersonID {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// inferEventTypesByScan mirrors inferEventTypes with the per-person sibling scan, for the benchmark
+func inferEventTypesByScan(persons []ancestry.Person, relationships map[string]PersonRelationship) int {
+	personMap := buildPersonMap(persons)
+	inferredCount := 0
+
+	for i := range persons {
+		rels, hasRels := relationships[persons[i].GetPersonID()]
+		if !hasRels {
+			continue
+		}
+
+		dateToEventType := make(map[string]string)
+		processChildEvents(rels.Children, personMap, dateToEventType)
+		for _, idx := range scanSiblingIndices(i, rels, persons, relationships) {
+			sibling := persons[idx]
+			for _, evt := range sibling.Events {
+				if (evt.Type == Birth || evt.Type == Death) && evt.Date != nil {
+					genderLabel := getRelationshipGenderLabel(sibling.Gender, "brother", "sister", "sibling")
+					dateToEventType[eventDateText(evt.Date)] = fmt.Sprintf("%s of %s %s", evt.Type, genderLabel, sibling.GetDisplayName())
+				}
+			}
+		}
+		processRelativeDeathEvents(rels.Parents, personMap, dateToEventType, "father", "mother", "parent")
+		processRelativeDeathEvents(rels.Spouses, personMap, dateToEventType, "husband", "wife", "spouse")
+
+		inferredCount += updateEmptyEvents(&persons[i], dateToEventType)
+	}
+
+	return inferredCount
+}
+
+func TestFindSiblingIndicesMatchesScan(t *testing.T) {
+	persons, relationships := buildSyntheticTree(50)
+	childrenByParent := buildChildrenByParent(persons, relationships)
+
+	for i := range persons {
+		rels := relationships[persons[i].GetPersonID()]
+		indexed := findSiblingIndices(i, rels, childrenByParent)
+		scanned := scanSiblingIndices(i, rels, persons, relationships)
+		if fmt.Sprint(indexed) != fmt.Sprint(scanned) {
+			t.Fatalf("person %d: index found siblings %v, scan found %v", i, indexed, scanned)
+		}
+	}
+}
+
+func BenchmarkInferEventTypes(b *testing.B) {
+	implementations := []struct {
+		name  string
+		infer func([]ancestry.Person, map[string]PersonRelationship) int
+	}{
+		{name: "index", infer: inferEventTypes},
+		{name: "scan", infer: inferEventTypesByScan},
+	}
+
+	for _, impl := range implementations {
+		b.Run(impl.name, func(b *testing.B) {
+			persons, relationships := buildSyntheticTree(2000)
+
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				// Reset the untyped events so every iteration does the same work
+				for p := range persons {
+					for e := 1; e < len(persons[p].Events); e++ {
+						persons[p].Events[e].Type = ""
+					}
+				}
+				b.StartTimer()
+
+				impl.infer(persons, relationships)
+			}
+		})
+	}
+}
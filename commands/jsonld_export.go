@@ -0,0 +1,157 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+)
+
+const (
+	// TreeFormatJSONLD writes the tree as a schema.org Person graph in tree.jsonld
+	TreeFormatJSONLD = "jsonld"
+	// jsonLDFile is the file the jsonld format writes
+	jsonLDFile = "tree.jsonld"
+)
+
+// jsonLDDocument is a JSON-LD document holding a graph of schema.org entities
+type jsonLDDocument struct {
+	Context string         `json:"@context"`
+	Graph   []jsonLDPerson `json:"@graph"`
+}
+
+// jsonLDRef links to another entity by its @id
+type jsonLDRef struct {
+	ID string `json:"@id"`
+}
+
+// jsonLDPlace is a schema.org Place
+type jsonLDPlace struct {
+	Type string `json:"@type"`
+	Name string `json:"name"`
+}
+
+// jsonLDPerson is a schema.org Person
+type jsonLDPerson struct {
+	ID         string       `json:"@id"`
+	Type       string       `json:"@type"`
+	Identifier string       `json:"identifier,omitempty"`
+	Name       string       `json:"name,omitempty"`
+	GivenName  string       `json:"givenName,omitempty"`
+	FamilyName string       `json:"familyName,omitempty"`
+	Gender     string       `json:"gender,omitempty"`
+	BirthDate  string       `json:"birthDate,omitempty"`
+	BirthPlace *jsonLDPlace `json:"birthPlace,omitempty"`
+	DeathDate  string       `json:"deathDate,omitempty"`
+	DeathPlace *jsonLDPlace `json:"deathPlace,omitempty"`
+	Parent     []jsonLDRef  `json:"parent,omitempty"`
+	Spouse     []jsonLDRef  `json:"spouse,omitempty"`
+	Children   []jsonLDRef  `json:"children,omitempty"`
+}
+
+// jsonLDPersonID returns the @id of a person: their page on Ancestry
+func jsonLDPersonID(treeID, personID string) string {
+	return fmt.Sprintf("https://www.ancestry.com/family-tree/person/tree/%s/person/%s", treeID, extractPersonNumber(personID))
+}
+
+// jsonLDDate formats an event date as an ISO 8601 date, to the precision known. Approximate
+// dates, ranges and dates that can't be parsed give "".
+func jsonLDDate(raw interface{}) string {
+	date, err := ancestry.ParseGenealogyDate(raw)
+	if err != nil || date.Qualifier != "" || date.EndYear != 0 || date.Year == 0 {
+		return ""
+	}
+	switch {
+	case date.Month == 0:
+		return fmt.Sprintf("%04d", date.Year)
+	case date.Day == 0:
+		return fmt.Sprintf("%04d-%02d", date.Year, date.Month)
+	default:
+		return fmt.Sprintf("%04d-%02d-%02d", date.Year, date.Month, date.Day)
+	}
+}
+
+// jsonLDGender maps Ancestry's gender code to a schema.org GenderType
+func jsonLDGender(gender string) string {
+	return getRelationshipGenderLabel(strings.ToLower(gender), "https://schema.org/Male", "https://schema.org/Female", "")
+}
+
+// jsonLDEvent returns the date and place of the person's first event of the given type that has either
+func jsonLDEvent(person ancestry.Person, eventType string) (string, *jsonLDPlace) {
+	for _, event := range person.Events {
+		if event.Type != eventType {
+			continue
+		}
+		date := jsonLDDate(event.Date)
+		var place *jsonLDPlace
+		if name := event.Place; name != "" {
+			place = &jsonLDPlace{Type: "Place", Name: name}
+		} else if event.PlaceStd != "" {
+			place = &jsonLDPlace{Type: "Place", Name: event.PlaceStd}
+		}
+		if date != "" || place != nil {
+			return date, place
+		}
+	}
+	return "", nil
+}
+
+// jsonLDRefs links to related persons
+func jsonLDRefs(treeID string, refs []RelationshipReference) []jsonLDRef {
+	links := make([]jsonLDRef, 0, len(refs))
+	for _, ref := range refs {
+		if ref.PersonID != "" {
+			links = append(links, jsonLDRef{ID: jsonLDPersonID(treeID, ref.PersonID)})
+		}
+	}
+	return links
+}
+
+// buildJSONLDPerson converts a person and their relationships to a schema.org Person
+func buildJSONLDPerson(treeID string, person ancestry.Person, relationship PersonRelationship) jsonLDPerson {
+	given, surname := personNameParts(person)
+	entity := jsonLDPerson{
+		ID:         jsonLDPersonID(treeID, person.GetPersonID()),
+		Type:       "Person",
+		Identifier: person.GetPersonID(),
+		Name:       strings.TrimSpace(given + " " + surname),
+		GivenName:  given,
+		FamilyName: surname,
+		Gender:     jsonLDGender(person.Gender),
+		Parent:     jsonLDRefs(treeID, relationship.Parents),
+		Spouse:     jsonLDRefs(treeID, relationship.Spouses),
+		Children:   jsonLDRefs(treeID, relationship.Children),
+	}
+	entity.BirthDate, entity.BirthPlace = jsonLDEvent(person, Birth)
+	entity.DeathDate, entity.DeathPlace = jsonLDEvent(person, Death)
+	return entity
+}
+
+// buildJSONLDDocument converts the exported persons to a schema.org Person graph
+func buildJSONLDDocument(export *TreeExport, relationships map[string]PersonRelationship) jsonLDDocument {
+	doc := jsonLDDocument{Context: "https://schema.org", Graph: make([]jsonLDPerson, 0, len(export.Persons))}
+	for _, person := range export.Persons {
+		doc.Graph = append(doc.Graph, buildJSONLDPerson(export.TreeID, person, relationships[person.GetPersonID()]))
+	}
+	return doc
+}
+
+// jsonLDTreeWriter writes the tree as a schema.org Person graph in tree.jsonld
+type jsonLDTreeWriter struct {
+	outputDir string
+}
+
+// Write implements TreeWriter
+func (w jsonLDTreeWriter) Write(_ context.Context, export *TreeExport, relationships map[string]PersonRelationship, _ map[string]PersonMediaInfo) error {
+	if err := writeJSONFile(w.outputDir, jsonLDFile, buildJSONLDDocument(export, relationships), export.Compress); err != nil {
+		return fmt.Errorf("failed to write %s: %w", jsonFileName(jsonLDFile, export.Compress), err)
+	}
+	return nil
+}
+
+func init() {
+	RegisterTreeWriter(TreeFormatJSONLD, func(outputDir string) TreeWriter {
+		return jsonLDTreeWriter{outputDir: outputDir}
+	})
+}
@@ -0,0 +1,86 @@
+package commands
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+)
+
+func TestJSONLDDate(t *testing.T) {
+	tests := []struct {
+		raw  interface{}
+		want string
+	}{
+		{"12 Mar 1850", "1850-03-12"},
+		{"Mar 1850", "1850-03"},
+		{"1850", "1850"},
+		{"Abt 1850", ""},
+		{"Bet 1850 and 1860", ""},
+		{"sometime", ""},
+		{nil, ""},
+	}
+	for _, tt := range tests {
+		if got := jsonLDDate(tt.raw); got != tt.want {
+			t.Errorf("jsonLDDate(%v) = %q, want %q", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestJSONLDTreeWriter(t *testing.T) {
+	dir := t.TempDir()
+	export := &TreeExport{
+		TreeID: "42",
+		Persons: []ancestry.Person{
+			{
+				PID: "1:1030:42", GivenName: "Ada", Surname: "Byron", Gender: "f",
+				Events: []ancestry.Event{
+					{Type: Birth, Date: "10 Dec 1815", Place: "London, England"},
+					{Type: Death, Date: "Abt 1852", PlaceStd: "Marylebone, London"},
+				},
+			},
+			{PID: "2:1030:42", GivenName: "George", Surname: "Byron", Gender: "m"},
+		},
+	}
+	relationships := map[string]PersonRelationship{
+		"1:1030:42": {Parents: []RelationshipReference{{PersonID: "2:1030:42", Name: "George Byron"}}},
+		"2:1030:42": {Children: []RelationshipReference{{PersonID: "1:1030:42", Name: "Ada Byron"}}},
+	}
+
+	if err := (jsonLDTreeWriter{outputDir: dir}).Write(t.Context(), export, relationships, nil); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, jsonLDFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc jsonLDDocument
+	if err := json.Unmarshal(data, &doc); err != nil {
+		t.Fatalf("invalid JSON-LD: %v", err)
+	}
+
+	if doc.Context != "https://schema.org" || len(doc.Graph) != 2 {
+		t.Fatalf("unexpected document: %+v", doc)
+	}
+	ada, george := doc.Graph[0], doc.Graph[1]
+	if ada.ID != "https://www.ancestry.com/family-tree/person/tree/42/person/1" || ada.Type != "Person" || ada.Name != "Ada Byron" {
+		t.Errorf("unexpected person: %+v", ada)
+	}
+	if ada.Gender != "https://schema.org/Female" || george.Gender != "https://schema.org/Male" {
+		t.Errorf("genders = %q, %q", ada.Gender, george.Gender)
+	}
+	if ada.BirthDate != "1815-12-10" || ada.BirthPlace == nil || ada.BirthPlace.Name != "London, England" {
+		t.Errorf("birth = %q, %+v", ada.BirthDate, ada.BirthPlace)
+	}
+	if ada.DeathDate != "" || ada.DeathPlace == nil || ada.DeathPlace.Name != "Marylebone, London" {
+		t.Errorf("death = %q, %+v; want the place without the approximate date", ada.DeathDate, ada.DeathPlace)
+	}
+	if len(ada.Parent) != 1 || ada.Parent[0].ID != george.ID {
+		t.Errorf("parent links = %+v, want George", ada.Parent)
+	}
+	if len(george.Children) != 1 || george.Children[0].ID != ada.ID {
+		t.Errorf("children links = %+v, want Ada", george.Children)
+	}
+}
@@ -0,0 +1,136 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+	"github.com/urfave/cli/v2"
+)
+
+// mediaManifestEntry describes one media item attached to a person, without its bytes
+type mediaManifestEntry struct {
+	PersonID   string `json:"personId"`
+	PersonName string `json:"personName"`
+	MediaID    string `json:"mediaId"`
+	Title      string `json:"title,omitempty"`
+	Category   string `json:"category,omitempty"`
+	URL        string `json:"url"`
+}
+
+// personMediaFetcher returns the media items attached to a person
+type personMediaFetcher func(personID string) ([]ancestry.PrimaryMediaItem, error)
+
+// buildMediaManifest lists every media item of every person, in person order. Persons whose
+// media can't be fetched are skipped with a warning; it returns how many were. Progress and
+// warnings go to logger.
+func buildMediaManifest(persons []ancestry.Person, fetchMedia personMediaFetcher, logger *log.Logger) ([]mediaManifestEntry, int) {
+	entries := []mediaManifestEntry{}
+	failed := 0
+	for i, person := range persons {
+		if (i+1)%10 == 0 || i == 0 {
+			logger.Printf("   Checking media %d/%d...\n", i+1, len(persons))
+		}
+
+		items, err := fetchMedia(person.GetPersonID())
+		if err != nil {
+			logger.Printf("   [Warning] Failed to get media for %s: %v\n", person.GetDisplayName(), err)
+			failed++
+			continue
+		}
+		for _, item := range items {
+			entries = append(entries, mediaManifestEntry{
+				PersonID:   person.GetPersonID(),
+				PersonName: person.GetDisplayName(),
+				MediaID:    item.MediaID,
+				Title:      item.Title,
+				Category:   item.Category,
+				URL:        item.URL,
+			})
+		}
+	}
+	return entries, failed
+}
+
+// printMediaManifest prints the media items to w, grouped under their person
+func printMediaManifest(w io.Writer, entries []mediaManifestEntry) {
+	lastPersonID := ""
+	for _, entry := range entries {
+		if entry.PersonID != lastPersonID {
+			fmt.Fprintf(w, "\n%s (%s)\n", entry.PersonName, entry.PersonID)
+			lastPersonID = entry.PersonID
+		}
+		title := entry.Title
+		if title == "" {
+			title = entry.MediaID
+		}
+		if entry.Category != "" {
+			fmt.Fprintf(w, "    [%s] %s\n", entry.Category, title)
+		} else {
+			fmt.Fprintf(w, "    %s\n", title)
+		}
+	}
+}
+
+// ListMedia lists every media item in a tree, without downloading any, and writes the list to
+// a manifest file
+func ListMedia(c *cli.Context) error {
+	treeID, err := getTreeIDArgOrDefault(c, fmt.Errorf("tree ID is required\n\nUsage: ancestrydl list-media <tree-id>\n\nOr set a default tree with: ancestrydl config set-default-tree <tree-id>"))
+	if err != nil {
+		return err
+	}
+	outputPath := c.String("output")
+	pageSize, err := parsePageSize(c)
+	if err != nil {
+		return err
+	}
+
+	logger := loggerFrom(c)
+	out, _ := commandOutput(c)
+
+	logger.Println("1. Creating API client...")
+	apiClient, err := createAPIClientFromStoredCookies(c)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := apiClient.Close(); err != nil {
+			logger.Printf("Error closing API client: %v\n", err)
+		}
+	}()
+
+	logger.Println("2. Getting people...")
+	persons, err := personCacheFromFlags(c, treeID, nil).persons(treeID, logger.Writer(), func() ([]ancestry.Person, error) {
+		totalCount, err := apiClient.GetPersonsCount(c.Context, treeID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get person count: %w", err)
+		}
+		return fetchAllPersons(c.Context, apiClient, treeID, totalCount, pageSize, nil, logger.Writer())
+	})
+	if err != nil {
+		return err
+	}
+
+	logger.Println("3. Listing media...")
+	entries, failed := buildMediaManifest(persons, func(personID string) ([]ancestry.PrimaryMediaItem, error) {
+		return apiClient.GetPersonMediaFromAPI(c.Context, treeID, personID)
+	}, logger)
+	printMediaManifest(out, entries)
+
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal media manifest: %w", err)
+	}
+	if err := os.WriteFile(outputPath, data, 0644); err != nil {
+		return fmt.Errorf("failed to write media manifest: %w", err)
+	}
+
+	logger.Printf("\n✅ Found %d media item(s) for %d person(s); manifest saved to %s\n", len(entries), len(persons), outputPath)
+	if failed > 0 {
+		logger.Printf("   [Warning] Media couldn't be listed for %d person(s)\n", failed)
+	}
+	return nil
+}
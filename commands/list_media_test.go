@@ -0,0 +1,51 @@
+package commands
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+)
+
+func TestBuildMediaManifest(t *testing.T) {
+	persons := []ancestry.Person{
+		{PID: "1", GivenName: "Ada", Surname: "Byron"},
+		{PID: "2", GivenName: "Noone"},
+		{PID: "3", GivenName: "Broken"},
+	}
+	media := map[string][]ancestry.PrimaryMediaItem{
+		"1": {
+			{MediaID: "m1", Title: "Portrait", Category: "Photo", URL: "https://example.com/m1"},
+			{MediaID: "m2", Category: "Document", URL: "https://example.com/m2"},
+		},
+	}
+	fetch := func(personID string) ([]ancestry.PrimaryMediaItem, error) {
+		if personID == "3" {
+			return nil, errors.New("status 500")
+		}
+		return media[personID], nil
+	}
+
+	entries, failed := buildMediaManifest(persons, fetch, defaultLogger)
+	if failed != 1 {
+		t.Errorf("failed = %d, want 1", failed)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2: %+v", len(entries), entries)
+	}
+	want := mediaManifestEntry{PersonID: "1", PersonName: persons[0].GetDisplayName(), MediaID: "m1",
+		Title: "Portrait", Category: "Photo", URL: "https://example.com/m1"}
+	if entries[0] != want {
+		t.Errorf("entries[0] = %+v, want %+v", entries[0], want)
+	}
+	if entries[1].MediaID != "m2" || entries[1].PersonID != "1" {
+		t.Errorf("unexpected second entry: %+v", entries[1])
+	}
+}
+
+func TestBuildMediaManifestEmpty(t *testing.T) {
+	entries, failed := buildMediaManifest(nil, nil, defaultLogger)
+	if entries == nil || len(entries) != 0 || failed != 0 {
+		t.Errorf("buildMediaManifest(nil) = %v, %d; want an empty list so the manifest is []", entries, failed)
+	}
+}
@@ -1,7 +1,10 @@
 package commands
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"strings"
 
 	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
 	"github.com/chrisrob11/ancestrydl/pkg/config"
@@ -10,24 +13,11 @@ import (
 
 // getTreeIDOrDefault retrieves the tree ID from arguments or uses the default
 func getTreeIDOrDefault(c *cli.Context) (string, error) {
-	treeID := c.Args().First()
-	if treeID != "" {
-		return treeID, nil
-	}
-
-	defaultTreeID, err := config.GetDefaultTreeID()
-	if err != nil {
-		return "", fmt.Errorf("failed to get default tree: %w", err)
-	}
-	if defaultTreeID == "" {
-		return "", fmt.Errorf("tree ID is required\n\nUsage: ancestrydl list-people <tree-id>\n\nOr set a default tree with: ancestrydl config set-default-tree <tree-id>")
-	}
-	fmt.Printf("Using default tree: %s\n", defaultTreeID)
-	return defaultTreeID, nil
+	return getTreeIDArgOrDefault(c, fmt.Errorf("tree ID is required\n\nUsage: ancestrydl list-people <tree-id>\n\nOr set a default tree with: ancestrydl config set-default-tree <tree-id>"))
 }
 
 // createAPIClientFromStoredCookies creates an API client from stored session cookies
-func createAPIClientFromStoredCookies() (*ancestry.APIClient, error) {
+func createAPIClientFromStoredCookies(c *cli.Context) (*ancestry.APIClient, error) {
 	cookiesJSON, err := config.GetCookies()
 	if err != nil {
 		return nil, fmt.Errorf("failed to load stored cookies: %w\n\nPlease run 'ancestrydl login' first to authenticate", err)
@@ -40,27 +30,32 @@ func createAPIClientFromStoredCookies() (*ancestry.APIClient, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to create API client: %w", err)
 	}
+	if err := configureAPIClient(c, apiClient); err != nil {
+		return nil, err
+	}
 	return apiClient, nil
 }
 
-// fetchAllPersons retrieves all persons from a tree with pagination
-func fetchAllPersons(apiClient *ancestry.APIClient, treeID string, totalCount int) ([]ancestry.Person, error) {
-	limit := 100
+// fetchAllPersons retrieves all persons from a tree with pagination, writing progress to status
+func fetchAllPersons(ctx context.Context, apiClient *ancestry.APIClient, treeID string, totalCount, limit int, tags []string, status io.Writer) ([]ancestry.Person, error) {
 	totalPages := (totalCount + limit - 1) / limit
 
-	fmt.Printf("Fetching %d page(s) of data...\n", totalPages)
-	fmt.Println()
+	// The count covers the whole tree, so with tags the number of pages isn't known
+	if len(tags) > 0 {
+		fmt.Fprintf(status, "Fetching people tagged %s...\n", strings.Join(tags, ", "))
+	} else {
+		fmt.Fprintf(status, "Fetching %d page(s) of data...\n", totalPages)
+	}
+	fmt.Fprintln(status)
 
-	allPersons := []ancestry.Person{}
-	for page := 1; page <= totalPages; page++ {
-		fmt.Printf("Fetching page %d/%d...\n", page, totalPages)
-		persons, err := apiClient.GetAllPersons(treeID, page, limit)
-		if err != nil {
-			return nil, fmt.Errorf("failed to retrieve persons on page %d: %w", page, err)
+	return fetchPersonPages(limit, func(page int) ([]ancestry.Person, error) {
+		if len(tags) > 0 {
+			fmt.Fprintf(status, "Fetching page %d...\n", page)
+		} else {
+			fmt.Fprintf(status, "Fetching page %d/%d...\n", page, max(page, totalPages))
 		}
-		allPersons = append(allPersons, persons...)
-	}
-	return allPersons, nil
+		return apiClient.GetAllPersons(ctx, treeID, page, limit, tags)
+	})
 }
 
 // getPersonName extracts the display name from a person
@@ -74,88 +69,184 @@ func getPersonName(person ancestry.Person) string {
 	return ""
 }
 
-// getPersonLifeEvents extracts birth and death years from events
+// lifeEventDates returns the dates of the last dated birth and death events among events
+func lifeEventDates(events []ancestry.Event) (birthDate, deathDate string) {
+	for _, event := range events {
+		date := eventDateText(event.Date)
+		if date == "" {
+			continue
+		}
+		switch event.Type {
+		case Birth:
+			birthDate = date
+		case Death:
+			deathDate = date
+		}
+	}
+	return birthDate, deathDate
+}
+
+// getPersonLifeEvents extracts birth and death years from events, falling back to the
+// person's events summary and then their lifespan fields for whichever is missing
 func getPersonLifeEvents(person ancestry.Person) (birthYear, deathYear string) {
-	for _, event := range person.Events {
-		if event.Type == "Birth" && event.Date != nil {
-			birthYear = fmt.Sprintf("%v", event.Date)
+	birthYear, deathYear = lifeEventDates(person.Events)
+
+	fallbacks := []func() (string, string){
+		func() (string, string) { return lifeEventDates(person.SummaryLifeEvents()) },
+		person.Lifespan,
+	}
+	for _, fallback := range fallbacks {
+		if birthYear != "" && deathYear != "" {
+			break
 		}
-		if event.Type == "Death" && event.Date != nil {
-			deathYear = fmt.Sprintf("%v", event.Date)
+		fallbackBirth, fallbackDeath := fallback()
+		if birthYear == "" {
+			birthYear = fallbackBirth
+		}
+		if deathYear == "" {
+			deathYear = fallbackDeath
 		}
 	}
 	return birthYear, deathYear
 }
 
-// displayPerson prints formatted person information
-func displayPerson(i int, person ancestry.Person) {
+// displayPerson writes formatted person information to out
+func displayPerson(out io.Writer, i int, person ancestry.Person) {
 	name := getPersonName(person)
 	birthYear, deathYear := getPersonLifeEvents(person)
 
-	fmt.Printf("[%d] %s\n", i+1, name)
+	fmt.Fprintf(out, "[%d] %s\n", i+1, name)
 	if personID := person.GetPersonID(); personID != "" {
-		fmt.Printf("    ID: %s\n", personID)
+		fmt.Fprintf(out, "    ID: %s\n", personID)
 	}
 	if person.Gender != "" {
-		fmt.Printf("    Gender: %s\n", person.Gender)
+		fmt.Fprintf(out, "    Gender: %s\n", person.Gender)
 	}
 	if birthYear != "" {
-		fmt.Printf("    Birth: %s\n", birthYear)
+		fmt.Fprintf(out, "    Birth: %s\n", birthYear)
 	}
 	if deathYear != "" {
-		fmt.Printf("    Death: %s\n", deathYear)
+		fmt.Fprintf(out, "    Death: %s\n", deathYear)
 	}
 	if person.IsLiving {
-		fmt.Printf("    Living: Yes\n")
+		fmt.Fprintf(out, "    Living: Yes\n")
 	}
-	fmt.Println()
+	fmt.Fprintln(out)
+}
+
+// personListing is a person as list-people --json prints it
+type personListing struct {
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Gender   string `json:"gender,omitempty"`
+	Birth    string `json:"birth,omitempty"`
+	Death    string `json:"death,omitempty"`
+	IsLiving bool   `json:"isLiving"`
 }
 
-// ListPeople retrieves and displays all people in a family tree
+// writePersonListings writes the persons to w as a JSON array
+func writePersonListings(w io.Writer, persons []ancestry.Person) error {
+	listings := make([]personListing, 0, len(persons))
+	for _, person := range persons {
+		birth, death := getPersonLifeEvents(person)
+		listings = append(listings, personListing{
+			ID:       person.GetPersonID(),
+			Name:     strings.TrimSpace(getPersonName(person)),
+			Gender:   person.Gender,
+			Birth:    birth,
+			Death:    death,
+			IsLiving: person.IsLiving,
+		})
+	}
+	return writeJSON(w, listings)
+}
+
+// listTreePersons gets the tree's person count and then its persons, none if the tree is empty
+func listTreePersons(ctx context.Context, apiClient *ancestry.APIClient, treeID string, pageSize int, tags []string, status io.Writer) ([]ancestry.Person, error) {
+	fmt.Fprintln(status, "Getting person count...")
+	totalCount, err := apiClient.GetPersonsCount(ctx, treeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get person count: %w\n\nYour session may have expired. Try running 'ancestrydl login' again", err)
+	}
+
+	fmt.Fprintf(status, "Tree has %d total persons\n", totalCount)
+	fmt.Fprintln(status)
+
+	if totalCount == 0 {
+		return []ancestry.Person{}, nil
+	}
+	return fetchAllPersons(ctx, apiClient, treeID, totalCount, pageSize, tags, status)
+}
+
+// ListPeople retrieves and displays all people in a family tree.
+// With --json it writes them to stdout as a JSON array, with the status messages on stderr.
 func ListPeople(c *cli.Context) error {
+	asJSON := c.Bool("json")
+	out, status := commandOutput(c)
+
 	treeID, err := getTreeIDOrDefault(c)
 	if err != nil {
 		return err
 	}
 
-	fmt.Printf("Retrieving people from tree %s...\n", treeID)
-	fmt.Println()
+	sortBy, err := parseSortSpec(c.String("sort"), []string{SortByName, SortByBirthYear, SortByDeathYear})
+	if err != nil {
+		return err
+	}
+	pageSize, err := parsePageSize(c)
+	if err != nil {
+		return err
+	}
+	filter, err := parsePersonFilter(c)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(status, "Retrieving people from tree %s...\n", treeID)
+	fmt.Fprintln(status)
 
-	fmt.Println("Creating API client from stored session...")
-	apiClient, err := createAPIClientFromStoredCookies()
+	fmt.Fprintln(status, "Creating API client from stored session...")
+	apiClient, err := createAPIClientFromStoredCookies(c)
 	if err != nil {
 		return err
 	}
 	defer func() {
 		if err := apiClient.Close(); err != nil {
-			fmt.Printf("Error closing API client: %v\n", err)
+			fmt.Fprintf(status, "Error closing API client: %v\n", err)
 		}
 	}()
 
-	fmt.Println("Getting person count...")
-	totalCount, err := apiClient.GetPersonsCount(treeID)
+	tags := parseTags(c.String("tags"))
+	allPersons, err := personCacheFromFlags(c, treeID, tags).persons(treeID, status, func() ([]ancestry.Person, error) {
+		return listTreePersons(c.Context, apiClient, treeID, pageSize, tags, status)
+	})
 	if err != nil {
-		return fmt.Errorf("failed to get person count: %w\n\nYour session may have expired. Try running 'ancestrydl login' again", err)
+		return err
 	}
-
-	fmt.Printf("Tree has %d total persons\n", totalCount)
-	fmt.Println()
-
-	if totalCount == 0 {
-		fmt.Println("No people found in this tree.")
+	if len(allPersons) == 0 {
+		fmt.Fprintln(status, "No people found in this tree.")
+		if asJSON {
+			return writeJSON(out, []personListing{})
+		}
 		return nil
 	}
 
-	allPersons, err := fetchAllPersons(apiClient, treeID, totalCount)
-	if err != nil {
-		return err
+	sortPersons(allPersons, sortBy)
+	listed := filter.apply(allPersons)
+	if asJSON {
+		fmt.Fprintf(status, "Successfully retrieved %d person(s), listing %d\n", len(allPersons), len(listed))
+		return writePersonListings(out, listed)
 	}
 
-	fmt.Println()
-	fmt.Printf("Successfully retrieved %d person(s):\n\n", len(allPersons))
+	fmt.Fprintln(out)
+	if filter.active() {
+		fmt.Fprintf(out, "Successfully retrieved %d person(s), %d matching:\n\n", len(allPersons), len(listed))
+	} else {
+		fmt.Fprintf(out, "Successfully retrieved %d person(s):\n\n", len(allPersons))
+	}
 
-	for i, person := range allPersons {
-		displayPerson(i, person)
+	for i, person := range listed {
+		displayPerson(out, i, person)
 	}
 
 	return nil
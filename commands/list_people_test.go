@@ -0,0 +1,94 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+)
+
+func TestGetPersonLifeEvents(t *testing.T) {
+	summary := []interface{}{
+		map[string]interface{}{"t": "B", "d": "1850"},
+		map[string]interface{}{"t": "D", "d": "1920"},
+	}
+	tests := []struct {
+		name      string
+		person    ancestry.Person
+		wantBirth string
+		wantDeath string
+	}{
+		{
+			name:      "detailed events win",
+			person:    ancestry.Person{Events: []ancestry.Event{{Type: "Birth", Date: "12 Mar 1851"}}, EventsSummary: summary},
+			wantBirth: "12 Mar 1851",
+			wantDeath: "1920",
+		},
+		{name: "events summary", person: ancestry.Person{EventsSummary: summary}, wantBirth: "1850", wantDeath: "1920"},
+		{
+			name:      "lifespan fills what the summary lacks",
+			person:    ancestry.Person{EventsSummary: summary[:1], L: "1849–1921"},
+			wantBirth: "1850",
+			wantDeath: "1921",
+		},
+		{
+			name: "object-form and numeric dates",
+			person: ancestry.Person{Events: []ancestry.Event{
+				{Type: "Birth", Date: map[string]interface{}{"d": "Abt  1850"}},
+				{Type: "Death", Date: 1920.0},
+			}},
+			wantBirth: "Abt 1850",
+			wantDeath: "1920",
+		},
+		{name: "nothing", person: ancestry.Person{}},
+	}
+	for _, tt := range tests {
+		birth, death := getPersonLifeEvents(tt.person)
+		if birth != tt.wantBirth || death != tt.wantDeath {
+			t.Errorf("%s: getPersonLifeEvents() = (%q, %q), want (%q, %q)", tt.name, birth, death, tt.wantBirth, tt.wantDeath)
+		}
+	}
+}
+
+func TestWritePersonListings(t *testing.T) {
+	persons := []ancestry.Person{
+		{PID: "10:1030:42", GivenName: "John", Surname: "Smith", Gender: "m",
+			Events: []ancestry.Event{{Type: "Birth", Date: "1850"}, {Type: "Death", Date: "1920"}}},
+		{PID: "11:1030:42", GivenName: "Ann", IsLiving: true},
+	}
+
+	var buf bytes.Buffer
+	if err := writePersonListings(&buf, persons); err != nil {
+		t.Fatal(err)
+	}
+	var got []personListing
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("output is not a JSON array: %v\n%s", err, buf.String())
+	}
+	want := []personListing{
+		{ID: "10:1030:42", Name: "John Smith", Gender: "m", Birth: "1850", Death: "1920"},
+		{ID: "11:1030:42", Name: "Ann", IsLiving: true},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d persons, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("person %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestDisplayPersonWritesToOut(t *testing.T) {
+	person := ancestry.Person{PID: "10:1030:42", GivenName: "John", Surname: "Smith", Gender: "m",
+		Events: []ancestry.Event{{Type: "Birth", Date: "1850"}}}
+
+	var buf bytes.Buffer
+	displayPerson(&buf, 0, person)
+
+	want := "[1] John Smith\n    ID: 10:1030:42\n    Gender: m\n    Birth: 1850\n\n"
+	if got := buf.String(); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
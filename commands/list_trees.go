@@ -1,7 +1,10 @@
 package commands
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"sync"
 	"time"
 
 	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
@@ -32,74 +35,234 @@ func getTreeModifiedDate(tree ancestry.Tree) time.Time {
 	return tree.DateModified
 }
 
-// displayTreeInfo prints formatted information for a single tree
-func displayTreeInfo(i int, tree ancestry.Tree) {
-	fmt.Printf("[%d] %s\n", i+1, tree.Name)
-	fmt.Printf("    ID: %s\n", tree.ID)
+// treeDetailsConcurrency is the number of trees whose details list-trees --detailed fetches in parallel
+const treeDetailsConcurrency = 4
+
+// treeDetails holds the per-tree information shown by list-trees --detailed
+type treeDetails struct {
+	Info        *ancestry.TreeInfo
+	PersonCount int
+	InfoErr     error
+	CountErr    error
+}
+
+// fetchTreeDetailsFromAPI fetches the info and person count of a single tree
+func fetchTreeDetailsFromAPI(ctx context.Context, apiClient *ancestry.APIClient, treeID string) treeDetails {
+	var details treeDetails
+	details.Info, details.InfoErr = apiClient.GetTreeInfo(ctx, treeID)
+	details.PersonCount, details.CountErr = apiClient.GetPersonsCount(ctx, treeID)
+	return details
+}
+
+// fetchTreeDetails calls fetch for every tree using up to concurrency workers.
+// The results are in the same order as trees.
+func fetchTreeDetails(trees []ancestry.Tree, concurrency int, fetch func(treeID string) treeDetails) []treeDetails {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	results := make([]treeDetails, len(trees))
+	var wg sync.WaitGroup
+	jobs := make(chan int)
+
+	for w := 0; w < concurrency; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = fetch(trees[i].ID)
+			}
+		}()
+	}
+
+	for i := range trees {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return results
+}
+
+// displayTreeDetails writes the person count and privacy status of a tree to out
+func displayTreeDetails(out io.Writer, details treeDetails) {
+	if details.CountErr != nil {
+		fmt.Fprintf(out, "    Persons: unavailable (%v)\n", details.CountErr)
+	} else {
+		fmt.Fprintf(out, "    Persons: %d\n", details.PersonCount)
+	}
+
+	switch {
+	case details.InfoErr != nil:
+		fmt.Fprintf(out, "    Privacy: unavailable (%v)\n", details.InfoErr)
+	case details.Info.IsPrivate:
+		fmt.Fprintln(out, "    Privacy: Private")
+	default:
+		fmt.Fprintln(out, "    Privacy: Public")
+	}
+}
+
+// displayTreeInfo writes formatted information for a single tree to out, including
+// its details when details is not nil
+func displayTreeInfo(out io.Writer, i int, tree ancestry.Tree, details *treeDetails) {
+	fmt.Fprintf(out, "[%d] %s\n", i+1, tree.Name)
+	fmt.Fprintf(out, "    ID: %s\n", tree.ID)
 
 	if ownerID := getTreeOwnerID(tree); ownerID != "" {
-		fmt.Printf("    Owner: %s\n", ownerID)
+		fmt.Fprintf(out, "    Owner: %s\n", ownerID)
 	}
 
 	if createdDate := getTreeCreatedDate(tree); !createdDate.IsZero() {
-		fmt.Printf("    Created: %s\n", createdDate.Format("2006-01-02"))
+		fmt.Fprintf(out, "    Created: %s\n", createdDate.Format("2006-01-02"))
 	}
 
 	if modifiedDate := getTreeModifiedDate(tree); !modifiedDate.IsZero() {
-		fmt.Printf("    Modified: %s\n", modifiedDate.Format("2006-01-02"))
+		fmt.Fprintf(out, "    Modified: %s\n", modifiedDate.Format("2006-01-02"))
 	}
 
 	if tree.Description != "" {
-		fmt.Printf("    Description: %s\n", tree.Description)
+		fmt.Fprintf(out, "    Description: %s\n", tree.Description)
 	}
 
 	if tree.CanSeeLiving {
-		fmt.Printf("    Can See Living: Yes\n")
+		fmt.Fprintf(out, "    Can See Living: Yes\n")
 	}
 
 	if tree.SH {
-		fmt.Printf("    Shared: Yes\n")
+		fmt.Fprintf(out, "    Shared: Yes\n")
 	}
 
 	if tree.TotalInvitedCount > 0 {
-		fmt.Printf("    Total Invited: %d\n", tree.TotalInvitedCount)
+		fmt.Fprintf(out, "    Total Invited: %d\n", tree.TotalInvitedCount)
+	}
+
+	if details != nil {
+		displayTreeDetails(out, *details)
+	}
+
+	fmt.Fprintln(out)
+}
+
+// treeListing is a tree as list-trees --json prints it. The person count and privacy status
+// are only set with --detailed, when they could be fetched.
+type treeListing struct {
+	ID           string     `json:"id"`
+	Name         string     `json:"name"`
+	Owner        string     `json:"owner,omitempty"`
+	Created      *time.Time `json:"created,omitempty"`
+	Modified     *time.Time `json:"modified,omitempty"`
+	Description  string     `json:"description,omitempty"`
+	CanSeeLiving bool       `json:"canSeeLiving"`
+	Shared       bool       `json:"shared"`
+	TotalInvited int        `json:"totalInvited,omitempty"`
+	PersonCount  *int       `json:"personCount,omitempty"`
+	IsPrivate    *bool      `json:"isPrivate,omitempty"`
+}
+
+// optionalTime returns a pointer to t, or nil if t is zero
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// newTreeListing returns the listing of a tree, with its details when details is not nil
+func newTreeListing(tree ancestry.Tree, details *treeDetails) treeListing {
+	listing := treeListing{
+		ID:           tree.ID,
+		Name:         tree.Name,
+		Owner:        getTreeOwnerID(tree),
+		Created:      optionalTime(getTreeCreatedDate(tree)),
+		Modified:     optionalTime(getTreeModifiedDate(tree)),
+		Description:  tree.Description,
+		CanSeeLiving: tree.CanSeeLiving,
+		Shared:       tree.SH,
+		TotalInvited: tree.TotalInvitedCount,
+	}
+	if details != nil && details.CountErr == nil {
+		listing.PersonCount = &details.PersonCount
+	}
+	if details != nil && details.InfoErr == nil && details.Info != nil {
+		listing.IsPrivate = &details.Info.IsPrivate
 	}
+	return listing
+}
 
-	fmt.Println()
+// writeTreeListings writes the trees to w as a JSON array
+func writeTreeListings(w io.Writer, trees []ancestry.Tree, details []treeDetails) error {
+	listings := make([]treeListing, 0, len(trees))
+	for i, tree := range trees {
+		if details != nil {
+			listings = append(listings, newTreeListing(tree, &details[i]))
+		} else {
+			listings = append(listings, newTreeListing(tree, nil))
+		}
+	}
+	return writeJSON(w, listings)
 }
 
-// ListTrees retrieves and displays all family trees for the authenticated user
+// ListTrees retrieves and displays all family trees for the authenticated user.
+// With --json it writes them to stdout as a JSON array, with the status messages on stderr.
 func ListTrees(c *cli.Context) error {
-	fmt.Println("Retrieving your family trees...")
-	fmt.Println()
+	sortBy, err := parseSortSpec(c.String("sort"), []string{SortByName, SortByCreated, SortByModified})
+	if err != nil {
+		return err
+	}
+
+	asJSON := c.Bool("json")
+	out, status := commandOutput(c)
+
+	fmt.Fprintln(status, "Retrieving your family trees...")
+	fmt.Fprintln(status)
 
-	fmt.Println("Creating API client from stored session...")
-	apiClient, err := createAPIClientFromStoredCookies()
+	fmt.Fprintln(status, "Creating API client from stored session...")
+	apiClient, err := createAPIClientFromStoredCookies(c)
 	if err != nil {
 		return err
 	}
 	defer func() {
 		if err := apiClient.Close(); err != nil {
-			fmt.Printf("Error closing API client: %v\n", err)
+			fmt.Fprintf(status, "Error closing API client: %v\n", err)
 		}
 	}()
 
-	fmt.Println("Fetching trees from Ancestry.com...")
-	trees, err := apiClient.ListTrees()
+	fmt.Fprintln(status, "Fetching trees from Ancestry.com...")
+	trees, err := apiClient.ListTrees(c.Context)
 	if err != nil {
 		return fmt.Errorf("failed to retrieve trees: %w\n\nYour session may have expired. Try running 'ancestrydl login' again", err)
 	}
 
-	fmt.Println()
+	fmt.Fprintln(status)
 	if len(trees) == 0 {
-		fmt.Println("No trees found.")
+		fmt.Fprintln(status, "No trees found.")
+		if asJSON {
+			return writeJSON(out, []treeListing{})
+		}
 		return nil
 	}
 
-	fmt.Printf("Found %d tree(s):\n\n", len(trees))
+	sortTrees(trees, sortBy)
+
+	fmt.Fprintf(status, "Found %d tree(s):\n\n", len(trees))
+
+	var details []treeDetails
+	if c.Bool("detailed") {
+		fmt.Fprintf(status, "Fetching details for %d tree(s)...\n\n", len(trees))
+		details = fetchTreeDetails(trees, treeDetailsConcurrency, func(treeID string) treeDetails {
+			return fetchTreeDetailsFromAPI(c.Context, apiClient, treeID)
+		})
+	}
 
+	if asJSON {
+		return writeTreeListings(out, trees, details)
+	}
 	for i, tree := range trees {
-		displayTreeInfo(i, tree)
+		if details != nil {
+			displayTreeInfo(out, i, tree, &details[i])
+		} else {
+			displayTreeInfo(out, i, tree, nil)
+		}
 	}
 
 	return nil
@@ -0,0 +1,77 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+)
+
+func TestFetchTreeDetails(t *testing.T) {
+	trees := []ancestry.Tree{{ID: "1"}, {ID: "2"}, {ID: "3"}, {ID: "4"}, {ID: "5"}}
+	counts := map[string]int{"1": 10, "2": 20, "3": 30, "4": 40, "5": 50}
+
+	var running, peak int32
+	details := fetchTreeDetails(trees, 2, func(treeID string) treeDetails {
+		now := atomic.AddInt32(&running, 1)
+		for {
+			old := atomic.LoadInt32(&peak)
+			if now <= old || atomic.CompareAndSwapInt32(&peak, old, now) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		atomic.AddInt32(&running, -1)
+		return treeDetails{PersonCount: counts[treeID]}
+	})
+
+	if len(details) != len(trees) {
+		t.Fatalf("got %d results, want %d", len(details), len(trees))
+	}
+	for i, tree := range trees {
+		if details[i].PersonCount != counts[tree.ID] {
+			t.Errorf("details[%d].PersonCount = %d, want %d", i, details[i].PersonCount, counts[tree.ID])
+		}
+	}
+	if peak > 2 {
+		t.Errorf("ran %d fetches at once, want at most 2", peak)
+	}
+}
+
+func TestWriteTreeListings(t *testing.T) {
+	created := time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC)
+	trees := []ancestry.Tree{
+		{ID: "1", Name: "Smiths", OwnerUserID: "u1", DateCreated: created, SH: true},
+		{ID: "2", Name: "Joneses"},
+	}
+	details := []treeDetails{
+		{Info: &ancestry.TreeInfo{IsPrivate: true}, PersonCount: 12},
+		{InfoErr: errors.New("timeout"), CountErr: errors.New("timeout")},
+	}
+
+	var buf bytes.Buffer
+	if err := writeTreeListings(&buf, trees, details); err != nil {
+		t.Fatal(err)
+	}
+	var got []map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatalf("output is not a JSON array: %v\n%s", err, buf.String())
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d trees, want 2", len(got))
+	}
+	smiths := got[0]
+	if smiths["id"] != "1" || smiths["owner"] != "u1" || smiths["created"] != "2020-05-01T00:00:00Z" ||
+		smiths["shared"] != true || smiths["personCount"] != 12.0 || smiths["isPrivate"] != true {
+		t.Errorf("Smiths = %v", smiths)
+	}
+	for _, key := range []string{"created", "modified", "personCount", "isPrivate"} {
+		if _, ok := got[1][key]; ok {
+			t.Errorf("Joneses has %q although it's unknown: %v", key, got[1])
+		}
+	}
+}
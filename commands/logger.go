@@ -0,0 +1,82 @@
+package commands
+
+import (
+	"io"
+	"log"
+	"os"
+	"sync"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+	"github.com/urfave/cli/v2"
+)
+
+// loggerMetadataKey is the app metadata key under which SetLogger stores the status logger
+const loggerMetadataKey = "logger"
+
+// outputMu serializes every write made through the loggers, so lines from concurrent workers never interleave
+var outputMu sync.Mutex
+
+// lockedWriter writes to the stream returned by target while holding outputMu.
+// The stream is looked up on each write, so it follows os.Stdout if that is replaced.
+type lockedWriter struct {
+	target func() io.Writer
+}
+
+// Write implements io.Writer
+func (w lockedWriter) Write(p []byte) (int, error) {
+	outputMu.Lock()
+	defer outputMu.Unlock()
+	return w.target().Write(p)
+}
+
+// NewLogger creates the logger for command status output on stdout, adding
+// the date and time to each line when timestamps is set
+func NewLogger(timestamps bool) *log.Logger {
+	flags := 0
+	if timestamps {
+		flags = log.LstdFlags
+	}
+	return log.New(lockedWriter{target: func() io.Writer { return os.Stdout }}, "", flags)
+}
+
+// defaultLogger is used by commands run without SetLogger, e.g. in tests
+var defaultLogger = NewLogger(false)
+
+// SetLogger makes logger the status logger of every command the app runs
+func SetLogger(app *cli.App, logger *log.Logger) {
+	if app.Metadata == nil {
+		app.Metadata = make(map[string]interface{})
+	}
+	app.Metadata[loggerMetadataKey] = logger
+}
+
+// loggerFrom returns the status logger set on the app with SetLogger, or a default one. When
+// the command writes JSON to stdout (see statusToStderr), the logger writes to the command's
+// status stream instead, formatted the same way.
+func loggerFrom(c *cli.Context) *log.Logger {
+	logger := defaultLogger
+	if c == nil {
+		return logger
+	}
+	if c.App != nil {
+		if appLogger, ok := c.App.Metadata[loggerMetadataKey].(*log.Logger); ok {
+			logger = appLogger
+		}
+	}
+	if statusToStderr(c) {
+		_, status := commandOutput(c)
+		return log.New(lockedWriter{target: func() io.Writer { return status }}, logger.Prefix(), logger.Flags())
+	}
+	return logger
+}
+
+// clientLogger returns a logger for an API client's diagnostics. They go to stderr, so they never
+// mix into data written to stdout, but are serialized with and formatted like the status output.
+func clientLogger(logger *log.Logger) *log.Logger {
+	return log.New(lockedWriter{target: func() io.Writer { return os.Stderr }}, logger.Prefix(), logger.Flags())
+}
+
+// setClientLogger points an API client's diagnostics at the command's logger
+func setClientLogger(c *cli.Context, apiClient *ancestry.APIClient) {
+	apiClient.SetLogger(clientLogger(loggerFrom(c)))
+}
@@ -0,0 +1,65 @@
+package commands
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"log"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/urfave/cli/v2"
+)
+
+func TestLockedWriterKeepsLinesWhole(t *testing.T) {
+	var buf bytes.Buffer
+	out := lockedWriter{target: func() io.Writer { return &buf }}
+	status := log.New(out, "", 0)
+	diagnostics := log.New(out, "[APIClient] ", 0)
+
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 50; i++ {
+				status.Printf("   ✓ Found %d media item(s) for worker %d", i, w)
+				diagnostics.Printf("request %d from worker %d", i, w)
+			}
+		}()
+	}
+	wg.Wait()
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 8*50*2 {
+		t.Fatalf("got %d lines, want %d", len(lines), 8*50*2)
+	}
+	for _, line := range lines {
+		if !strings.HasPrefix(line, "   ✓ Found ") && !strings.HasPrefix(line, "[APIClient] request ") {
+			t.Fatalf("interleaved line %q", line)
+		}
+	}
+}
+
+func TestLoggerFrom(t *testing.T) {
+	app := cli.NewApp()
+	c := cli.NewContext(app, flag.NewFlagSet("test", flag.ContinueOnError), nil)
+	if loggerFrom(c) != defaultLogger {
+		t.Error("expected the default logger before SetLogger")
+	}
+
+	logger := NewLogger(true)
+	SetLogger(app, logger)
+	if loggerFrom(c) != logger {
+		t.Error("expected the logger set with SetLogger")
+	}
+	if logger.Flags()&log.LstdFlags != log.LstdFlags {
+		t.Error("expected timestamps on the logger")
+	}
+
+	client := clientLogger(logger)
+	if client.Flags() != logger.Flags() || client.Prefix() != logger.Prefix() {
+		t.Error("client logger should be formatted like the status logger")
+	}
+}
@@ -1,6 +1,7 @@
 package commands
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 
@@ -9,14 +10,19 @@ import (
 	"github.com/urfave/cli/v2"
 )
 
-// Login handles the login command using browser automation to authenticate and extract cookies
+// Login handles the login command using browser automation to authenticate and extract cookies.
+// With --token or --cookie the session is built from the given values instead, without a browser.
 func Login(c *cli.Context) error {
+	if usesTokenLogin(c) {
+		return tokenLogin(c)
+	}
+
 	username := strings.TrimSpace(c.String("username"))
 	password := c.String("password")
 
 	// Validate inputs
 	if username == "" {
-		return fmt.Errorf("username cannot be empty")
+		return fmt.Errorf("username cannot be empty (or log in without a browser using --token or --cookie)")
 	}
 
 	if password == "" {
@@ -29,7 +35,7 @@ func Login(c *cli.Context) error {
 
 	// Create browser client
 	fmt.Println("1. Launching browser...")
-	client, err := ancestry.NewClient()
+	client, err := ancestry.NewClientWithTLS(tlsOptionsFromFlags(c))
 	if err != nil {
 		return fmt.Errorf("failed to create browser client: %w", err)
 	}
@@ -85,7 +91,11 @@ func Login(c *cli.Context) error {
 	fmt.Println("   ✓ Session saved")
 
 	// Also save credentials for reference
-	if err := config.SaveCredentials(username, password); err != nil {
+	if err := config.SaveCredentials(username, password); errors.Is(err, config.ErrKeyringFallback) {
+		fmt.Printf("   Warning: %v\n", err)
+		fmt.Printf("   Credentials were saved to ~/%s/%s instead. It is encrypted with a key kept beside it,\n", config.ConfigDirName, config.CredentialsFileName)
+		fmt.Println("   so it is only as safe as your home directory.")
+	} else if err != nil {
 		// Don't fail if we can't save credentials, cookies are more important
 		fmt.Printf("   Warning: failed to save credentials: %v\n", err)
 	}
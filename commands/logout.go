@@ -25,7 +25,7 @@ func Logout(c *cli.Context) error {
 	}
 
 	fmt.Printf("Successfully logged out user: %s\n", creds.Username)
-	fmt.Println("Your credentials have been removed from the system keyring.")
+	fmt.Println("Your credentials have been removed from the system keyring and ~/.ancestrydl.")
 
 	return nil
 }
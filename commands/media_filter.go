@@ -0,0 +1,72 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+)
+
+// Media categories accepted by --media-categories
+const (
+	MediaCategoryPhoto    = "photo"
+	MediaCategoryDocument = "document"
+	MediaCategoryStory    = "story"
+)
+
+// parseMediaCategories parses a comma-separated --media-categories value.
+// An empty value returns nil, which means every category is downloaded.
+func parseMediaCategories(value string) (map[string]bool, error) {
+	if strings.TrimSpace(value) == "" {
+		return nil, nil
+	}
+
+	categories := make(map[string]bool)
+	for _, part := range strings.Split(value, ",") {
+		category := strings.ToLower(strings.TrimSpace(part))
+		switch category {
+		case "":
+			continue
+		case MediaCategoryPhoto, MediaCategoryDocument, MediaCategoryStory:
+			categories[category] = true
+		default:
+			return nil, fmt.Errorf("invalid media category %q (must be '%s', '%s' or '%s')",
+				category, MediaCategoryPhoto, MediaCategoryDocument, MediaCategoryStory)
+		}
+	}
+
+	return categories, nil
+}
+
+// mediaItemCategory returns the category a media item is filtered by. Photos that
+// Ancestry subcategorizes as documents count as documents, as in the HTML viewer.
+func mediaItemCategory(item ancestry.PrimaryMediaItem) string {
+	category := strings.ToLower(item.Category)
+	if category == MediaCategoryPhoto && strings.EqualFold(item.Subcategory, MediaCategoryDocument) {
+		return MediaCategoryDocument
+	}
+	return category
+}
+
+// includeMediaItem reports whether a media item passes the category filter
+func includeMediaItem(item ancestry.PrimaryMediaItem, categories map[string]bool) bool {
+	return len(categories) == 0 || categories[mediaItemCategory(item)]
+}
+
+// mediaItemsToDownload returns the indexes of the media items to download: those in the wanted
+// categories, and no more than maxItems of them unless maxItems is 0. The API lists the newest
+// items first, so those are kept. It also returns how many items were filtered out by category
+// and how many were over the limit.
+func mediaItemsToDownload(items []ancestry.PrimaryMediaItem, categories map[string]bool, maxItems int) (indexes []int, filtered, overLimit int) {
+	for idx, item := range items {
+		switch {
+		case !includeMediaItem(item, categories):
+			filtered++
+		case maxItems > 0 && len(indexes) >= maxItems:
+			overLimit++
+		default:
+			indexes = append(indexes, idx)
+		}
+	}
+	return indexes, filtered, overLimit
+}
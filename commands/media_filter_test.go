@@ -0,0 +1,87 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+)
+
+func TestIncludeMediaItem(t *testing.T) {
+	photos, err := parseMediaCategories("Photo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	documents, err := parseMediaCategories("document, story")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		item       ancestry.PrimaryMediaItem
+		categories map[string]bool
+		want       bool
+	}{
+		{"no filter", ancestry.PrimaryMediaItem{Category: "document"}, nil, true},
+		{"photo kept", ancestry.PrimaryMediaItem{Category: "photo"}, photos, true},
+		{"document skipped", ancestry.PrimaryMediaItem{Category: "document"}, photos, false},
+		{"photo of a document counts as document", ancestry.PrimaryMediaItem{Category: "photo", Subcategory: "document"}, documents, true},
+		{"story kept", ancestry.PrimaryMediaItem{Category: "story"}, documents, true},
+		{"photo skipped", ancestry.PrimaryMediaItem{Category: "photo", Subcategory: "portrait"}, documents, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := includeMediaItem(tt.item, tt.categories); got != tt.want {
+				t.Errorf("includeMediaItem() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := parseMediaCategories("photo,audio"); err == nil {
+		t.Error("expected an error for an unknown category")
+	}
+}
+
+func TestMediaItemsToDownload(t *testing.T) {
+	photos, err := parseMediaCategories("photo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	items := []ancestry.PrimaryMediaItem{
+		{MediaID: "1", Category: "photo"},
+		{MediaID: "2", Category: "story"},
+		{MediaID: "3", Category: "photo"},
+		{MediaID: "4", Category: "photo"},
+	}
+
+	tests := []struct {
+		name          string
+		categories    map[string]bool
+		maxItems      int
+		wantIndexes   []int
+		wantFiltered  int
+		wantOverLimit int
+	}{
+		{"no limits", nil, 0, []int{0, 1, 2, 3}, 0, 0},
+		{"cap keeps the first items", nil, 2, []int{0, 1}, 0, 2},
+		{"cap counts only wanted categories", photos, 2, []int{0, 2}, 1, 1},
+		{"cap above the item count", photos, 10, []int{0, 2, 3}, 1, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			indexes, filtered, overLimit := mediaItemsToDownload(items, tt.categories, tt.maxItems)
+			if len(indexes) != len(tt.wantIndexes) {
+				t.Fatalf("indexes = %v, want %v", indexes, tt.wantIndexes)
+			}
+			for i := range indexes {
+				if indexes[i] != tt.wantIndexes[i] {
+					t.Fatalf("indexes = %v, want %v", indexes, tt.wantIndexes)
+				}
+			}
+			if filtered != tt.wantFiltered || overLimit != tt.wantOverLimit {
+				t.Errorf("filtered, overLimit = %d, %d; want %d, %d", filtered, overLimit, tt.wantFiltered, tt.wantOverLimit)
+			}
+		})
+	}
+}
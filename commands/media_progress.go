@@ -0,0 +1,47 @@
+package commands
+
+import (
+	"log"
+	"sync"
+)
+
+// mediaProgressInterval is how many saved media files pass between progress lines
+const mediaProgressInterval = 10
+
+// mediaProgress totals the media files and bytes saved by concurrent workers, logging a
+// running total every mediaProgressInterval files. A nil progress ignores them.
+type mediaProgress struct {
+	mu    sync.Mutex
+	files int
+	bytes int64
+	log   *log.Logger
+}
+
+// newMediaProgress returns a progress that logs to logger
+func newMediaProgress(logger *log.Logger) *mediaProgress {
+	return &mediaProgress{log: logger}
+}
+
+// add records a saved media file of size bytes
+func (p *mediaProgress) add(size int) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.files++
+	p.bytes += int64(size)
+	if p.files%mediaProgressInterval == 0 && p.log != nil {
+		p.log.Printf("   %s downloaded across %d files\n", formatByteSize(p.bytes), p.files)
+	}
+}
+
+// totalBytes returns the bytes of all media files saved so far
+func (p *mediaProgress) totalBytes() int64 {
+	if p == nil {
+		return 0
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.bytes
+}
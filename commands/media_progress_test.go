@@ -0,0 +1,39 @@
+package commands
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestMediaProgress(t *testing.T) {
+	var out bytes.Buffer
+	progress := newMediaProgress(log.New(&out, "", 0))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 25; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			progress.add(1024)
+		}()
+	}
+	wg.Wait()
+
+	if got := progress.totalBytes(); got != 25*1024 {
+		t.Errorf("totalBytes() = %d, want %d", got, 25*1024)
+	}
+	lines := strings.Split(strings.TrimRight(out.String(), "\n"), "\n")
+	want := []string{"   10.0 KB downloaded across 10 files", "   20.0 KB downloaded across 20 files"}
+	if strings.Join(lines, "\n") != strings.Join(want, "\n") {
+		t.Errorf("progress lines = %q, want %q", lines, want)
+	}
+
+	var nilProgress *mediaProgress
+	nilProgress.add(100)
+	if nilProgress.totalBytes() != 0 {
+		t.Error("nil progress should ignore files")
+	}
+}
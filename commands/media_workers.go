@@ -0,0 +1,52 @@
+package commands
+
+import "sync"
+
+// DefaultMediaConcurrency is the default number of persons whose media is downloaded in parallel
+const DefaultMediaConcurrency = 4
+
+// keyedMutex hands out a separate lock per key, so goroutines writing the same
+// file are serialized while writes to different files proceed in parallel. A key's
+// lock is dropped once nobody holds or waits for it, so the map doesn't grow with
+// every file written.
+type keyedMutex struct {
+	mu    sync.Mutex
+	locks map[string]*keyedLock
+}
+
+// keyedLock is the lock of one key and the number of goroutines holding or waiting for it
+type keyedLock struct {
+	sync.Mutex
+	refs int
+}
+
+// newKeyedMutex creates an empty keyedMutex
+func newKeyedMutex() *keyedMutex {
+	return &keyedMutex{locks: make(map[string]*keyedLock)}
+}
+
+// Lock locks the mutex for key and returns the function that unlocks it
+func (k *keyedMutex) Lock(key string) func() {
+	k.mu.Lock()
+	lock, ok := k.locks[key]
+	if !ok {
+		lock = &keyedLock{}
+		k.locks[key] = lock
+	}
+	lock.refs++
+	k.mu.Unlock()
+
+	lock.Lock()
+	return func() {
+		lock.Unlock()
+		k.mu.Lock()
+		lock.refs--
+		if lock.refs == 0 {
+			delete(k.locks, key)
+		}
+		k.mu.Unlock()
+	}
+}
+
+// mediaFileLocks serializes the exists-check and write of each media file path
+var mediaFileLocks = newKeyedMutex()
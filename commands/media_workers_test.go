@@ -0,0 +1,48 @@
+package commands
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestKeyedMutexReleasesKeys(t *testing.T) {
+	k := newKeyedMutex()
+
+	var wg sync.WaitGroup
+	inside := make(map[string]int)
+	var insideMu sync.Mutex
+	for i := 0; i < 50; i++ {
+		key := []string{"a.jpg", "b.jpg"}[i%2]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			unlock := k.Lock(key)
+			defer unlock()
+
+			insideMu.Lock()
+			inside[key]++
+			if inside[key] > 1 {
+				t.Errorf("%d goroutines hold the lock of %s", inside[key], key)
+			}
+			insideMu.Unlock()
+
+			insideMu.Lock()
+			inside[key]--
+			insideMu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	if len(k.locks) != 0 {
+		t.Errorf("locks = %d after every holder released, want 0", len(k.locks))
+	}
+
+	unlock := k.Lock("a.jpg")
+	if k.locks["a.jpg"] == nil || k.locks["a.jpg"].refs != 1 {
+		t.Errorf("held key should have one reference: %+v", k.locks["a.jpg"])
+	}
+	unlock()
+	if _, ok := k.locks["a.jpg"]; ok {
+		t.Error("key kept after its last holder released it")
+	}
+}
@@ -0,0 +1,41 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+)
+
+func TestMergeEvents(t *testing.T) {
+	familyView := []ancestry.Event{
+		{Type: Birth, Date: "1 Jan 1900"},
+		{Type: "Marriage", Date: "5 Jun 1925"},
+	}
+	facts := []ancestry.Event{
+		{Type: Birth, Date: "1 Jan 1900", Description: "Born at home",
+			NPS: []map[string]interface{}{{"v": "Springfield, Illinois, USA"}}},
+	}
+
+	merged := mergeEvents(familyView, facts)
+
+	if len(merged) != 2 {
+		t.Fatalf("got %d events, want 2: %+v", len(merged), merged)
+	}
+	if merged[0].Type != Birth || merged[0].Description != "Born at home" || len(merged[0].NPS) != 1 {
+		t.Errorf("birth event should be the facts-page version, got %+v", merged[0])
+	}
+	if merged[1].Type != "Marriage" || merged[1].Date != "5 Jun 1925" {
+		t.Errorf("FamilyView-only marriage event was dropped, got %+v", merged[1])
+	}
+}
+
+func TestMergeEventsDateForms(t *testing.T) {
+	familyView := []ancestry.Event{{Type: Birth, Date: map[string]interface{}{"d": "1 Jan 1900"}}}
+	facts := []ancestry.Event{{Type: Birth, Date: "1 Jan  1900", Description: "Born at home"}}
+
+	merged := mergeEvents(familyView, facts)
+
+	if len(merged) != 1 || merged[0].Description != "Born at home" {
+		t.Errorf("the same date as an object and a string should merge, got %+v", merged)
+	}
+}
@@ -0,0 +1,75 @@
+package commands
+
+import (
+	"encoding/json"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+)
+
+// personsWithoutMedia returns the IDs of the persons the previous download in outputDir checked
+// and found no media for. Persons whose media fetch failed are left out so they are checked again.
+// ok is false when there is no readable previous download to go by.
+func personsWithoutMedia(outputDir string) (map[string]bool, bool) {
+	data, err := readJSONFile(outputDir, "people.json")
+	if err != nil {
+		return nil, false
+	}
+	var previousPersons []struct {
+		PersonID string `json:"personId"`
+	}
+	if err := json.Unmarshal(data, &previousPersons); err != nil {
+		return nil, false
+	}
+
+	withoutMedia := make(map[string]bool, len(previousPersons))
+	for _, person := range previousPersons {
+		if person.PersonID != "" {
+			withoutMedia[person.PersonID] = true
+		}
+	}
+
+	// An unreadable index could hide persons with media, so it counts as no previous download
+	if data, err := readJSONFile(outputDir, "media-index.json"); err == nil {
+		var mediaIndex map[string]PersonMediaInfo
+		if err := json.Unmarshal(data, &mediaIndex); err != nil {
+			return nil, false
+		}
+		for personID, personInfo := range mediaIndex {
+			if len(personInfo.Files) > 0 {
+				delete(withoutMedia, personID)
+			}
+		}
+	}
+
+	failures, err := loadFailures(outputDir)
+	if err != nil {
+		return nil, false
+	}
+	for _, failure := range failures {
+		if failure.Kind == FailureMedia {
+			delete(withoutMedia, failure.PersonID)
+		}
+	}
+
+	return withoutMedia, true
+}
+
+// personsToCheckForMedia drops the persons the previous download found no media for, so only
+// persons who had media and persons new to the tree are checked. Without a previous download
+// every person is checked, and that run is what later runs go by.
+func personsToCheckForMedia(outputDir string, persons []ancestry.Person, opts downloadTreeOptions) []ancestry.Person {
+	withoutMedia, ok := personsWithoutMedia(outputDir)
+	if !ok {
+		opts.Log.Println("   No previous download to go by (--only-with-media); checking every person")
+		return persons
+	}
+
+	toCheck := make([]ancestry.Person, 0, len(persons))
+	for _, person := range persons {
+		if !withoutMedia[person.GetPersonID()] {
+			toCheck = append(toCheck, person)
+		}
+	}
+	opts.Log.Printf("   Skipping %d person(s) with no media last time (--only-with-media)\n", len(persons)-len(toCheck))
+	return toCheck
+}
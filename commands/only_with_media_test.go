@@ -0,0 +1,48 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+)
+
+func TestPersonsToCheckForMedia(t *testing.T) {
+	persons := []ancestry.Person{{PID: "1"}, {PID: "2"}, {PID: "3"}, {PID: "4"}, {PID: "5"}}
+	opts := downloadTreeOptions{Log: defaultLogger}
+
+	dir := t.TempDir()
+	if got := personsToCheckForMedia(dir, persons, opts); len(got) != len(persons) {
+		t.Errorf("first run should check all %d persons, got %d", len(persons), len(got))
+	}
+
+	// Person 1 had media, 2 and 3 had none, 4's media fetch failed and 5 is new
+	files := map[string]string{
+		"people.json":      `[{"personId": "1"}, {"personId": "2"}, {"personId": "3"}, {"personId": "4"}]`,
+		"media-index.json": `{"1": {"personId": "1", "files": [{"filePath": "media/photos/a.jpg"}]}}`,
+		"failures.json":    `[{"kind": "media", "personId": "4", "reason": "timeout"}]`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var got []string
+	for _, person := range personsToCheckForMedia(dir, persons, opts) {
+		got = append(got, person.GetPersonID())
+	}
+	want := []string{"1", "4", "5"}
+	if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] || got[2] != want[2] {
+		t.Errorf("checked %v, want %v", got, want)
+	}
+
+	// A corrupt media index can't tell who had media, so everyone is checked
+	if err := os.WriteFile(filepath.Join(dir, "media-index.json"), []byte("{"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := personsToCheckForMedia(dir, persons, opts); len(got) != len(persons) {
+		t.Errorf("expected every person checked with a corrupt index, got %d", len(got))
+	}
+}
@@ -0,0 +1,63 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+)
+
+// defaultOutputTemplate names the download-tree output directory when --output isn't given
+const defaultOutputTemplate = "./{{.TreeName}}-{{.TreeID}}-{{.Date}}"
+
+// outputDirData holds the placeholders available to --output-template
+type outputDirData struct {
+	TreeName string // Tree name, sanitized for use in a path ("tree" if unknown)
+	TreeID   string
+	Date     string // Download date, YYYY-MM-DD
+	Time     string // Download time, HHMMSS
+}
+
+// parseOutputTemplate parses an --output-template value
+func parseOutputTemplate(value string) (*template.Template, error) {
+	if strings.TrimSpace(value) == "" {
+		value = defaultOutputTemplate
+	}
+	tmpl, err := template.New("output").Option("missingkey=error").Parse(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid output template: %w", err)
+	}
+	// Render once with sample values so unknown placeholders fail before any downloading
+	if _, err := renderOutputDir(tmpl, "0", nil, time.Time{}); err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+// renderOutputDir builds the output directory for a tree from the template
+func renderOutputDir(tmpl *template.Template, treeID string, treeInfo *ancestry.TreeInfo, now time.Time) (string, error) {
+	data := outputDirData{
+		TreeName: "tree",
+		TreeID:   sanitizeFilename(treeID),
+		Date:     now.Format("2006-01-02"),
+		Time:     now.Format("150405"),
+	}
+	if treeInfo != nil {
+		if name := sanitizeFilename(treeInfo.TreeName); name != "" {
+			data.TreeName = name
+		}
+	}
+
+	var sb strings.Builder
+	if err := tmpl.Execute(&sb, data); err != nil {
+		return "", fmt.Errorf("invalid output template: %w", err)
+	}
+
+	outputDir := strings.TrimSpace(sb.String())
+	if outputDir == "" {
+		return "", fmt.Errorf("output template produced an empty directory name")
+	}
+	return outputDir, nil
+}
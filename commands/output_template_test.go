@@ -0,0 +1,48 @@
+package commands
+
+import (
+	"testing"
+	"time"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+)
+
+func TestRenderOutputDir(t *testing.T) {
+	now := time.Date(2024, 3, 9, 14, 5, 6, 0, time.UTC)
+	tests := []struct {
+		name     string
+		template string
+		treeInfo *ancestry.TreeInfo
+		want     string
+		wantErr  bool
+	}{
+		{name: "default", treeInfo: &ancestry.TreeInfo{TreeName: "Smith Family: 1800s"}, want: "./Smith_Family-_1800s-123-2024-03-09"},
+		{name: "unknown tree name", treeInfo: nil, want: "./tree-123-2024-03-09"},
+		{name: "custom with time", template: "backups/{{.TreeID}}_{{.Date}}_{{.Time}}", want: "backups/123_2024-03-09_140506"},
+		{name: "unknown placeholder", template: "{{.Owner}}", wantErr: true},
+		{name: "bad syntax", template: "{{.TreeID", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := parseOutputTemplate(tt.template)
+			if err != nil {
+				if !tt.wantErr {
+					t.Fatalf("parseOutputTemplate() error = %v", err)
+				}
+				return
+			}
+			if tt.wantErr {
+				t.Fatal("parseOutputTemplate() error = nil, want error")
+			}
+
+			got, err := renderOutputDir(tmpl, "123", tt.treeInfo, now)
+			if err != nil {
+				t.Fatalf("renderOutputDir() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("renderOutputDir() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,25 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+	"github.com/urfave/cli/v2"
+)
+
+// validatePageSize checks a --page-size value
+func validatePageSize(pageSize int) error {
+	if pageSize < 1 || pageSize > ancestry.MaxPageSize {
+		return fmt.Errorf("--page-size must be between 1 and %d", ancestry.MaxPageSize)
+	}
+	return nil
+}
+
+// parsePageSize reads and validates --page-size, the number of persons fetched per page
+func parsePageSize(c *cli.Context) (int, error) {
+	pageSize := c.Int("page-size")
+	if err := validatePageSize(pageSize); err != nil {
+		return 0, err
+	}
+	return pageSize, nil
+}
@@ -0,0 +1,26 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+)
+
+func TestValidatePageSize(t *testing.T) {
+	tests := []struct {
+		pageSize int
+		wantErr  bool
+	}{
+		{0, true},
+		{-5, true},
+		{1, false},
+		{ancestry.DefaultPageSize, false},
+		{ancestry.MaxPageSize, false},
+		{ancestry.MaxPageSize + 1, true},
+	}
+	for _, tt := range tests {
+		if err := validatePageSize(tt.pageSize); (err != nil) != tt.wantErr {
+			t.Errorf("validatePageSize(%d) error = %v, wantErr %v", tt.pageSize, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,309 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"html"
+	"os"
+	"strings"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	// defaultPedigreeDepth is the number of generations in a pedigree chart, the root person included
+	defaultPedigreeDepth = 4
+	// maxPedigreeDepth caps --depth; the SVG chart doubles in height with every generation
+	maxPedigreeDepth = 10
+)
+
+// pedigreeNode is one person in an ancestor chart, with their parents when known
+type pedigreeNode struct {
+	PersonID string
+	Name     string
+	Lifespan string
+	Father   *pedigreeNode
+	Mother   *pedigreeNode
+}
+
+// label returns the name and lifespan shown for the person in a chart
+func (n *pedigreeNode) label() string {
+	if n.Lifespan == "" {
+		return n.Name
+	}
+	return fmt.Sprintf("%s (%s)", n.Name, n.Lifespan)
+}
+
+// generations returns the number of generations in the chart rooted at the node
+func (n *pedigreeNode) generations() int {
+	if n == nil {
+		return 0
+	}
+	return 1 + max(n.Father.generations(), n.Mother.generations())
+}
+
+// familyViewFetcher fetches the family view of a person with genUp generations of ancestors
+type familyViewFetcher func(personNumber string, genUp int) (*ancestry.FamilyViewResponse, error)
+
+// pedigreeBuilder walks up a tree's family views, fetching more whenever it reaches
+// ancestors at the edge of the views fetched so far
+type pedigreeBuilder struct {
+	fetch   familyViewFetcher
+	depth   int
+	persons map[string]ancestry.Person
+	calls   int
+}
+
+// newPedigreeBuilder creates a builder for charts of depth generations
+func newPedigreeBuilder(depth int, fetch familyViewFetcher) *pedigreeBuilder {
+	return &pedigreeBuilder{fetch: fetch, depth: depth, persons: make(map[string]ancestry.Person)}
+}
+
+// load fetches a person's family view reaching up to the top of the chart from the given generation
+func (b *pedigreeBuilder) load(personNumber string, generation int) error {
+	genUp := max(min(b.depth-1-generation, maxFamilyViewGenerations), 1)
+	familyView, err := b.fetch(personNumber, genUp)
+	b.calls++
+	if err != nil {
+		return err
+	}
+	for _, person := range familyView.Persons {
+		if personID := person.GetPersonID(); personID != "" {
+			b.persons[personID] = person
+		}
+	}
+	return nil
+}
+
+// findPerson returns a fetched person by full ID, or by person number when the ID is short
+func (b *pedigreeBuilder) findPerson(personID string) (ancestry.Person, bool) {
+	if person, ok := b.persons[personID]; ok {
+		return person, true
+	}
+	personNumber := extractPersonNumber(personID)
+	for _, person := range b.persons {
+		if person.GetShortPersonID() == personNumber {
+			return person, true
+		}
+	}
+	return ancestry.Person{}, false
+}
+
+// parentIDs returns the IDs of a person's father and mother, "" when not in the tree
+func parentIDs(person ancestry.Person) (father, mother string) {
+	for _, member := range person.Family {
+		targetID, ok := member.TGID["v"].(string)
+		if !ok {
+			continue
+		}
+		switch member.Type {
+		case "F":
+			if father == "" {
+				father = targetID
+			}
+		case "M":
+			if mother == "" {
+				mother = targetID
+			}
+		}
+	}
+	return father, mother
+}
+
+// pedigreeLifespan returns a person's years for a chart, from their events or their lifespan field
+func pedigreeLifespan(person ancestry.Person) string {
+	if years := lifespanYears(person); years != "" {
+		return years
+	}
+	birth, death := person.Lifespan()
+	if birth == "" && death == "" {
+		return ""
+	}
+	return birth + "–" + death
+}
+
+// build returns the chart node for a person at the given generation (0 for the root person),
+// fetching their family view if an earlier one didn't include them
+func (b *pedigreeBuilder) build(personID string, generation int) (*pedigreeNode, error) {
+	person, ok := b.findPerson(personID)
+	if !ok {
+		if err := b.load(extractPersonNumber(personID), generation); err != nil {
+			return nil, fmt.Errorf("failed to get family view for %s: %w", personID, err)
+		}
+		if person, ok = b.findPerson(personID); !ok {
+			return nil, fmt.Errorf("person %s not found in family view", personID)
+		}
+	}
+
+	node := &pedigreeNode{
+		PersonID: person.GetPersonID(),
+		Name:     person.GetDisplayName(),
+		Lifespan: pedigreeLifespan(person),
+	}
+	if node.Name == "" {
+		node.Name = node.PersonID
+	}
+	if generation+1 >= b.depth {
+		return node, nil
+	}
+
+	father, mother := parentIDs(person)
+	node.Father = b.buildParent(father, generation+1)
+	node.Mother = b.buildParent(mother, generation+1)
+	return node, nil
+}
+
+// buildParent builds a parent's part of the chart, leaving it out with a warning if it can't be fetched
+func (b *pedigreeBuilder) buildParent(personID string, generation int) *pedigreeNode {
+	if personID == "" {
+		return nil
+	}
+	node, err := b.build(personID, generation)
+	if err != nil {
+		fmt.Printf("   [Warning] %v\n", err)
+		return nil
+	}
+	return node
+}
+
+// renderPedigreeASCII draws the chart sideways: the root person on the left,
+// each father above and each mother below the person they are the parent of
+func renderPedigreeASCII(root *pedigreeNode) string {
+	var b strings.Builder
+	writePedigreeNode(&b, root, "", "", "")
+	return b.String()
+}
+
+// writePedigreeNode writes a person's line between their father's and mother's ancestors.
+// upper and lower prefix the lines drawn above and below the person's own line.
+func writePedigreeNode(b *strings.Builder, node *pedigreeNode, line, upper, lower string) {
+	if node.Father != nil {
+		writePedigreeNode(b, node.Father, upper+"┌── ", upper+"    ", upper+"│   ")
+	}
+	b.WriteString(line + node.label() + "\n")
+	if node.Mother != nil {
+		writePedigreeNode(b, node.Mother, lower+"└── ", lower+"│   ", lower+"    ")
+	}
+}
+
+// SVG chart layout, in pixels
+const (
+	pedigreeBoxWidth  = 220
+	pedigreeBoxHeight = 36
+	pedigreeColumnGap = 40
+	pedigreeRowHeight = 48
+	pedigreeMargin    = 10
+)
+
+// renderPedigreeSVG draws the chart as boxes in one column per generation, each
+// person centred on the rows of their ancestors in the last generation
+func renderPedigreeSVG(root *pedigreeNode) string {
+	generations := root.generations()
+	width := 2*pedigreeMargin + generations*pedigreeBoxWidth + (generations-1)*pedigreeColumnGap
+	height := 2*pedigreeMargin + (1<<(generations-1))*pedigreeRowHeight
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d" font-family="sans-serif" font-size="13">`+"\n",
+		width, height, width, height)
+	writePedigreeSVGNode(&b, root, 0, 0, float64(height-2*pedigreeMargin))
+	b.WriteString("</svg>\n")
+	return b.String()
+}
+
+// writePedigreeSVGNode draws a person's box in the vertical band [top, top+span) and their parents in its halves
+func writePedigreeSVGNode(b *strings.Builder, node *pedigreeNode, generation int, top, span float64) {
+	x := pedigreeMargin + generation*(pedigreeBoxWidth+pedigreeColumnGap)
+	centre := pedigreeMargin + top + span/2
+
+	for i, parent := range []*pedigreeNode{node.Father, node.Mother} {
+		if parent == nil {
+			continue
+		}
+		parentTop := top + float64(i)*span/2
+		parentCentre := pedigreeMargin + parentTop + span/4
+		elbow := x + pedigreeBoxWidth + pedigreeColumnGap/2
+		fmt.Fprintf(b, `  <polyline points="%d,%.1f %d,%.1f %d,%.1f %d,%.1f" fill="none" stroke="#888"/>`+"\n",
+			x+pedigreeBoxWidth, centre, elbow, centre, elbow, parentCentre, elbow+pedigreeColumnGap/2, parentCentre)
+		writePedigreeSVGNode(b, parent, generation+1, parentTop, span/2)
+	}
+
+	fmt.Fprintf(b, `  <rect x="%d" y="%.1f" width="%d" height="%d" rx="4" fill="#f5f5f5" stroke="#555"/>`+"\n",
+		x, centre-pedigreeBoxHeight/2, pedigreeBoxWidth, pedigreeBoxHeight)
+	fmt.Fprintf(b, `  <text x="%d" y="%.1f">%s</text>`+"\n", x+8, centre-2, html.EscapeString(node.Name))
+	if node.Lifespan != "" {
+		fmt.Fprintf(b, `  <text x="%d" y="%.1f" font-size="11" fill="#555">%s</text>`+"\n", x+8, centre+12, html.EscapeString(node.Lifespan))
+	}
+}
+
+// resolvePedigreeRoot returns the person ID given on the command line, or the tree's root person
+func resolvePedigreeRoot(ctx context.Context, apiClient *ancestry.APIClient, treeID, personID string) (string, error) {
+	if personID != "" {
+		return personID, nil
+	}
+	rootPerson, err := apiClient.GetRootPerson(ctx, treeID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get root person: %w", err)
+	}
+	if rootPerson.GetPersonID() == "" {
+		return "", fmt.Errorf("tree %s has no root person; pass a person ID", treeID)
+	}
+	return rootPerson.GetPersonID(), nil
+}
+
+// Pedigree prints an ancestor chart for a person, by default the tree's root person
+func Pedigree(c *cli.Context) error {
+	if c.Args().Get(0) == "" {
+		return fmt.Errorf("tree ID is required\n\nUsage: ancestrydl pedigree <tree-id> [person-id]")
+	}
+	treeID, err := ancestry.NormalizeTreeID(c.Args().Get(0))
+	if err != nil {
+		return err
+	}
+	personID := c.Args().Get(1)
+	if personID != "" {
+		if personID, err = ancestry.NormalizePersonID(personID); err != nil {
+			return err
+		}
+	}
+	depth := c.Int("depth")
+	if depth < 1 || depth > maxPedigreeDepth {
+		return fmt.Errorf("--depth must be between 1 and %d", maxPedigreeDepth)
+	}
+
+	fmt.Println("1. Creating API client...")
+	apiClient, err := createAPIClientFromStoredCookies(c)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := apiClient.Close(); err != nil {
+			fmt.Printf("Error closing API client: %v\n", err)
+		}
+	}()
+
+	personID, err = resolvePedigreeRoot(c.Context, apiClient, treeID, personID)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("2. Fetching %d generation(s) of ancestors...\n", depth)
+	builder := newPedigreeBuilder(depth, func(personNumber string, genUp int) (*ancestry.FamilyViewResponse, error) {
+		return apiClient.GetFamilyView(c.Context, treeID, personNumber, genUp, 0)
+	})
+	root, err := builder.build(personID, 0)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("   ✓ Made %d family view request(s)\n\n", builder.calls)
+
+	fmt.Print(renderPedigreeASCII(root))
+
+	if svgPath := c.String("svg"); svgPath != "" {
+		if err := os.WriteFile(svgPath, []byte(renderPedigreeSVG(root)), 0644); err != nil {
+			return fmt.Errorf("failed to write SVG chart: %w", err)
+		}
+		fmt.Printf("\n✅ SVG chart saved to %s\n", svgPath)
+	}
+	return nil
+}
@@ -0,0 +1,130 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+)
+
+// fakePedigreeTree serves family views from a fixed set of persons, including
+// ancestors up to genUp generations above the focus person
+type fakePedigreeTree map[string]ancestry.Person
+
+func (tree fakePedigreeTree) add(number, name, father, mother string) {
+	person := ancestry.Person{PID: number + ":1030:99", GivenName: name}
+	for kind, parent := range map[string]string{"F": father, "M": mother} {
+		if parent != "" {
+			person.Family = append(person.Family, ancestry.FamilyMember{Type: kind, TGID: map[string]interface{}{"v": parent + ":1030:99"}})
+		}
+	}
+	tree[number] = person
+}
+
+func (tree fakePedigreeTree) familyView(personNumber string, genUp int) (*ancestry.FamilyViewResponse, error) {
+	focus, ok := tree[personNumber]
+	if !ok {
+		return nil, fmt.Errorf("unknown person %s", personNumber)
+	}
+	view := &ancestry.FamilyViewResponse{}
+	level := []ancestry.Person{focus}
+	for generation := 0; generation <= genUp && len(level) > 0; generation++ {
+		view.Persons = append(view.Persons, level...)
+		var next []ancestry.Person
+		for _, person := range level {
+			father, mother := parentIDs(person)
+			for _, parentID := range []string{father, mother} {
+				if parent, ok := tree[extractPersonNumber(parentID)]; ok {
+					next = append(next, parent)
+				}
+			}
+		}
+		level = next
+	}
+	return view, nil
+}
+
+func TestBuildPedigree(t *testing.T) {
+	tree := fakePedigreeTree{}
+	tree.add("1", "Root", "2", "3")
+	tree.add("2", "Father", "4", "")
+	tree.add("3", "Mother", "", "")
+	tree.add("4", "Grandfather", "", "")
+
+	root, err := newPedigreeBuilder(2, tree.familyView).build("1", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if root.Father == nil || root.Father.Name != "Father" || root.Mother == nil || root.Mother.Name != "Mother" {
+		t.Fatalf("unexpected parents: %+v", root)
+	}
+	if root.Father.Father != nil {
+		t.Error("depth 2 should stop at the parents")
+	}
+
+	want := "┌── Father\nRoot\n└── Mother\n"
+	if got := renderPedigreeASCII(root); got != want {
+		t.Errorf("renderPedigreeASCII() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestBuildPedigreeFetchesBeyondFamilyView(t *testing.T) {
+	// A single line of seven generations is deeper than one family view reaches
+	tree := fakePedigreeTree{}
+	for i := 1; i <= 7; i++ {
+		father := ""
+		if i < 7 {
+			father = fmt.Sprint(i + 1)
+		}
+		tree.add(fmt.Sprint(i), fmt.Sprintf("Gen %d", i), father, "")
+	}
+
+	builder := newPedigreeBuilder(7, tree.familyView)
+	root, err := builder.build("1", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := root.generations(); got != 7 {
+		t.Errorf("generations() = %d, want 7", got)
+	}
+	if builder.calls != 2 {
+		t.Errorf("made %d family view requests, want 2", builder.calls)
+	}
+}
+
+func TestRenderPedigree(t *testing.T) {
+	root := &pedigreeNode{
+		Name:     "John Smith",
+		Lifespan: "1850–1920",
+		Father: &pedigreeNode{
+			Name:   "William Smith",
+			Father: &pedigreeNode{Name: "George Smith"},
+			Mother: &pedigreeNode{Name: "Ann Lee"},
+		},
+		Mother: &pedigreeNode{Name: "Mary <Jones>"},
+	}
+
+	want := strings.Join([]string{
+		"    ┌── George Smith",
+		"┌── William Smith",
+		"│   └── Ann Lee",
+		"John Smith (1850–1920)",
+		"└── Mary <Jones>",
+		"",
+	}, "\n")
+	if got := renderPedigreeASCII(root); got != want {
+		t.Errorf("renderPedigreeASCII() =\n%s\nwant\n%s", got, want)
+	}
+
+	svg := renderPedigreeSVG(root)
+	if strings.Count(svg, "<rect") != 5 {
+		t.Errorf("expected 5 boxes in SVG:\n%s", svg)
+	}
+	if !strings.Contains(svg, "Mary &lt;Jones&gt;") {
+		t.Error("SVG names should be escaped")
+	}
+	if !strings.Contains(svg, `height="212"`) {
+		t.Errorf("expected a chart 4 rows high:\n%s", svg)
+	}
+}
@@ -0,0 +1,99 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+	"github.com/chrisrob11/ancestrydl/pkg/config"
+	"github.com/urfave/cli/v2"
+)
+
+const (
+	// personCacheFileName is the file in a tree's cache directory holding its person list
+	personCacheFileName = "persons.json"
+	// personCacheTTL is how long a cached person list is reused before it is fetched again
+	personCacheTTL = 15 * time.Minute
+)
+
+// cachedPersonList is the content of a tree's persons.json cache file
+type cachedPersonList struct {
+	TreeID    string            `json:"treeId"`
+	FetchedAt time.Time         `json:"fetchedAt"`
+	Persons   []ancestry.Person `json:"persons"`
+}
+
+// personCache reuses a tree's person list between commands run in quick succession
+type personCache struct {
+	Dir     string        // The tree's cache directory; empty when caching is off (--no-cache)
+	Refresh bool          // Fetch the list even if a fresh one is cached, and cache it (--refresh)
+	TTL     time.Duration // How long a cached list is reused
+}
+
+// personCacheFromFlags sets up the person list cache of a tree from --no-cache and --refresh.
+// Only whole-tree lists are cached, so caching is off when listing by tags, and it is also off
+// if the cache directory can't be created.
+func personCacheFromFlags(c *cli.Context, treeID string, tags []string) personCache {
+	if c.Bool("no-cache") || len(tags) > 0 {
+		return personCache{}
+	}
+	dir, err := config.GetTreeCacheDir(treeID)
+	if err != nil {
+		_, status := commandOutput(c)
+		fmt.Fprintf(status, "   [Warning] Person list won't be cached: %v\n", err)
+		return personCache{}
+	}
+	return personCache{Dir: dir, Refresh: c.Bool("refresh"), TTL: personCacheTTL}
+}
+
+// load returns the cached person list and when it was fetched, if one is cached and younger than the TTL
+func (pc personCache) load(now time.Time) ([]ancestry.Person, time.Time, bool) {
+	if pc.Dir == "" || pc.Refresh {
+		return nil, time.Time{}, false
+	}
+	data, err := os.ReadFile(filepath.Join(pc.Dir, personCacheFileName))
+	if err != nil {
+		return nil, time.Time{}, false
+	}
+	var cached cachedPersonList
+	if err := json.Unmarshal(data, &cached); err != nil || now.Sub(cached.FetchedAt) > pc.TTL {
+		return nil, time.Time{}, false
+	}
+	return cached.Persons, cached.FetchedAt, true
+}
+
+// save caches a freshly fetched person list
+func (pc personCache) save(treeID string, persons []ancestry.Person, now time.Time) error {
+	if pc.Dir == "" {
+		return nil
+	}
+	data, err := json.Marshal(cachedPersonList{TreeID: treeID, FetchedAt: now, Persons: persons})
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(pc.Dir, personCacheFileName), data, 0600)
+}
+
+// persons returns the tree's person list from the cache if a fresh one is there, and otherwise
+// fetches it and caches it for the next command. Cache hits and failures are reported to status.
+func (pc personCache) persons(treeID string, status io.Writer, fetch func() ([]ancestry.Person, error)) ([]ancestry.Person, error) {
+	now := time.Now()
+	if persons, fetchedAt, ok := pc.load(now); ok {
+		fmt.Fprintf(status, "   ✓ Using the list of %d persons cached %s ago (--refresh fetches it again)\n",
+			len(persons), now.Sub(fetchedAt).Round(time.Second))
+		return persons, nil
+	}
+
+	persons, err := fetch()
+	if err != nil {
+		return nil, err
+	}
+	if err := pc.save(treeID, persons, now); err != nil {
+		fmt.Fprintf(status, "   [Warning] Failed to cache the person list: %v\n", err)
+	}
+	return persons, nil
+}
@@ -0,0 +1,68 @@
+package commands
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+)
+
+func TestPersonCacheLoad(t *testing.T) {
+	dir := t.TempDir()
+	fetchedAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	cache := personCache{Dir: dir, TTL: personCacheTTL}
+	if err := cache.save("tree1", []ancestry.Person{{PID: "1"}, {PID: "2"}}, fetchedAt); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		cache  personCache
+		now    time.Time
+		wantOK bool
+	}{
+		{"fresh", cache, fetchedAt.Add(5 * time.Minute), true},
+		{"expired", cache, fetchedAt.Add(personCacheTTL + time.Second), false},
+		{"refresh", personCache{Dir: dir, Refresh: true, TTL: personCacheTTL}, fetchedAt, false},
+		{"disabled", personCache{}, fetchedAt, false},
+		{"empty dir", personCache{Dir: t.TempDir(), TTL: personCacheTTL}, fetchedAt, false},
+	}
+	for _, tt := range tests {
+		persons, gotFetchedAt, ok := tt.cache.load(tt.now)
+		if ok != tt.wantOK {
+			t.Errorf("%s: load ok = %v, want %v", tt.name, ok, tt.wantOK)
+			continue
+		}
+		if ok && (len(persons) != 2 || !gotFetchedAt.Equal(fetchedAt)) {
+			t.Errorf("%s: load = %d persons fetched %v", tt.name, len(persons), gotFetchedAt)
+		}
+	}
+}
+
+func TestPersonCachePersonsFetchesOnce(t *testing.T) {
+	cache := personCache{Dir: t.TempDir(), TTL: personCacheTTL}
+	fetches := 0
+	fetch := func() ([]ancestry.Person, error) {
+		fetches++
+		return []ancestry.Person{{PID: "1"}}, nil
+	}
+
+	for i := 0; i < 2; i++ {
+		persons, err := cache.persons("tree1", io.Discard, fetch)
+		if err != nil || len(persons) != 1 {
+			t.Fatalf("persons() = %v, %v", persons, err)
+		}
+	}
+	if fetches != 1 {
+		t.Errorf("fetched %d times, want 1", fetches)
+	}
+
+	cache.Refresh = true
+	if _, err := cache.persons("tree1", io.Discard, fetch); err != nil {
+		t.Fatal(err)
+	}
+	if fetches != 2 {
+		t.Errorf("--refresh should fetch again, fetched %d times", fetches)
+	}
+}
@@ -0,0 +1,56 @@
+package commands
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+	"github.com/urfave/cli/v2"
+)
+
+// personFilter selects the listed persons worth showing
+type personFilter struct {
+	IDPrefix    string         // Keep only persons whose short ID starts with this; empty keeps all
+	NamePattern *regexp.Regexp // Keep only persons whose display name matches; nil keeps all
+}
+
+// parsePersonFilter reads the --id-prefix and --grep flags
+func parsePersonFilter(c *cli.Context) (personFilter, error) {
+	filter := personFilter{IDPrefix: strings.TrimSpace(c.String("id-prefix"))}
+	if pattern := c.String("grep"); pattern != "" {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return personFilter{}, fmt.Errorf("invalid --grep pattern %q: %w", pattern, err)
+		}
+		filter.NamePattern = re
+	}
+	return filter, nil
+}
+
+// matches reports whether a person passes the filter
+func (f personFilter) matches(person ancestry.Person) bool {
+	if f.IDPrefix != "" && !strings.HasPrefix(person.GetShortPersonID(), f.IDPrefix) {
+		return false
+	}
+	return f.NamePattern == nil || f.NamePattern.MatchString(person.GetDisplayName())
+}
+
+// active reports whether the filter leaves anyone out
+func (f personFilter) active() bool {
+	return f.IDPrefix != "" || f.NamePattern != nil
+}
+
+// apply returns the persons that pass the filter, in order
+func (f personFilter) apply(persons []ancestry.Person) []ancestry.Person {
+	if !f.active() {
+		return persons
+	}
+	var kept []ancestry.Person
+	for _, person := range persons {
+		if f.matches(person) {
+			kept = append(kept, person)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,36 @@
+package commands
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+)
+
+func TestPersonFilter(t *testing.T) {
+	john := ancestry.Person{PID: "232573524428:1030:42", GivenName: "John", Surname: "Smith"}
+	johanna := ancestry.Person{PID: "232599:1030:42", GivenName: "Johanna", Surname: "Smith"}
+	mary := ancestry.Person{PID: "999:1030:42", GivenName: "Mary", Surname: "Jones"}
+	persons := []ancestry.Person{john, johanna, mary}
+
+	tests := []struct {
+		name   string
+		filter personFilter
+		want   []ancestry.Person
+	}{
+		{"no filter", personFilter{}, persons},
+		{"id prefix", personFilter{IDPrefix: "2325"}, []ancestry.Person{john, johanna}},
+		{"id prefix ignores the tree part", personFilter{IDPrefix: "1030"}, nil},
+		{"name pattern", personFilter{NamePattern: regexp.MustCompile(`^Joh?n\b`)}, []ancestry.Person{john}},
+		{"case-insensitive pattern", personFilter{NamePattern: regexp.MustCompile(`(?i)smith`)}, []ancestry.Person{john, johanna}},
+		{"both", personFilter{IDPrefix: "23259", NamePattern: regexp.MustCompile(`Smith`)}, []ancestry.Person{johanna}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.apply(persons); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("apply() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
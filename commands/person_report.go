@@ -0,0 +1,259 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+	"github.com/urfave/cli/v2"
+)
+
+// personReport holds everything needed to write a person's Markdown report
+type personReport struct {
+	Person        ancestry.Person
+	Relationships PersonRelationship
+	Sources       []ancestry.PersonSourceDetail
+}
+
+// fetchPersonReport fetches a person with their immediate family and facts-page events and sources,
+// writing progress to status
+func fetchPersonReport(ctx context.Context, apiClient *ancestry.APIClient, treeID, personID string, status io.Writer) (*personReport, error) {
+	personNumber := extractPersonNumber(personID)
+
+	fmt.Fprintln(status, "2. Fetching person and family...")
+	familyView, err := apiClient.GetFamilyView(ctx, treeID, personNumber, 1, 1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get family view: %w", err)
+	}
+
+	person, found := findFamilyViewPerson(familyView, personNumber)
+	if !found {
+		return nil, fmt.Errorf("person %s not found in tree %s", personID, treeID)
+	}
+	fullID := person.GetPersonID()
+
+	report := &personReport{Person: person}
+	if rel, events, ok := processFamilyView(fullID, familyView); ok {
+		report.Relationships = rel
+		if len(events) > 0 {
+			report.Person.Events = events
+		}
+	}
+	fmt.Fprintf(status, "   ✓ Found %s\n", report.Person.GetDisplayName())
+
+	fmt.Fprintln(status, "3. Fetching facts and sources...")
+	researchData, err := apiClient.GetPersonFactsFromHTML(ctx, treeID, fullID)
+	if err != nil {
+		fmt.Fprintf(status, "   [Warning] Failed to get facts: %v\n", err)
+	} else if researchData != nil {
+		report.Person.Events = mergeEvents(report.Person.Events, factsToEvents(researchData.PersonFacts))
+		report.Sources = researchData.PersonSources
+	}
+	fmt.Fprintf(status, "   ✓ %d event(s), %d source(s)\n", len(report.Person.Events), len(report.Sources))
+
+	// Infer untyped events from the relatives' events in the family view
+	persons := make([]ancestry.Person, 0, len(familyView.Persons))
+	for _, p := range familyView.Persons {
+		if p.GetPersonID() != fullID {
+			persons = append(persons, p)
+		}
+	}
+	persons = append(persons, report.Person)
+	inferEventTypes(persons, map[string]PersonRelationship{fullID: report.Relationships})
+	report.Person = persons[len(persons)-1]
+
+	sortEventsByDate(report.Person.Events)
+
+	return report, nil
+}
+
+// lifespanYears returns the birth and death years of a person for a heading, or "" if unknown
+func lifespanYears(person ancestry.Person) string {
+	var birth, death string
+	for _, event := range person.Events {
+		date, err := ancestry.ParseGenealogyDate(event.Date)
+		if err != nil {
+			continue
+		}
+		switch event.Type {
+		case Birth:
+			if birth == "" {
+				birth = fmt.Sprintf("%d", date.Year)
+			}
+		case Death:
+			if death == "" {
+				death = fmt.Sprintf("%d", date.Year)
+			}
+		}
+	}
+
+	if birth == "" && death == "" {
+		return ""
+	}
+	if birth == "" {
+		birth = "?"
+	}
+	if death == "" && !person.IsLiving {
+		death = "?"
+	}
+	return birth + "–" + death
+}
+
+// markdownEscape escapes characters that Markdown would treat as formatting
+func markdownEscape(text string) string {
+	return strings.NewReplacer("\\", "\\\\", "*", "\\*", "_", "\\_", "[", "\\[", "]", "\\]", "#", "\\#").Replace(text)
+}
+
+// formatRelativeNames returns the relatives' names as a comma-separated list
+func formatRelativeNames(refs []RelationshipReference) string {
+	names := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		name := ref.Name
+		if name == "" {
+			name = ref.PersonID
+		}
+		names = append(names, markdownEscape(name))
+	}
+	return strings.Join(names, ", ")
+}
+
+// writePersonReportEvents writes the chronological life events section
+func writePersonReportEvents(b *strings.Builder, events []ancestry.Event) {
+	b.WriteString("## Life Events\n\n")
+	if len(events) == 0 {
+		b.WriteString("_No events recorded._\n\n")
+		return
+	}
+
+	birth, hasBirth := birthDate(events)
+	for _, event := range events {
+		// Skip metadata events that aren't real life events
+		if event.Type == "Name" || event.Type == "Gender" {
+			continue
+		}
+
+		eventType := event.Type
+		if eventType == "" {
+			eventType = "Life Event"
+		}
+
+		b.WriteString("- ")
+		if date, _ := ancestry.ParseGenealogyDate(event.Date); date.Original != "" {
+			fmt.Fprintf(b, "**%s** — ", markdownEscape(date.Original))
+		}
+		b.WriteString(markdownEscape(eventType))
+		if age, ok := eventAgeAt(birth, event.Type, event.Date); hasBirth && ok {
+			fmt.Fprintf(b, ", age %s", age)
+		}
+		if place, ok := ancestry.ParseNPS(event.NPS); ok {
+			fmt.Fprintf(b, ", %s ([map](%s))", markdownEscape(place.Name), place.MapURL)
+		}
+		if event.Description != "" {
+			fmt.Fprintf(b, ". _%s_", markdownEscape(event.Description))
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+}
+
+// renderPersonReport renders a person's report as Markdown
+func renderPersonReport(report *personReport) string {
+	var b strings.Builder
+
+	name := report.Person.GetDisplayName()
+	if name == "" {
+		name = report.Person.GetPersonID()
+	}
+	b.WriteString("# " + markdownEscape(name))
+	if years := lifespanYears(report.Person); years != "" {
+		b.WriteString(" (" + years + ")")
+	}
+	b.WriteString("\n\n")
+
+	writePersonReportEvents(&b, report.Person.Events)
+
+	rel := report.Relationships
+	if len(rel.Parents)+len(rel.Spouses)+len(rel.Children) > 0 {
+		b.WriteString("## Family\n\n")
+		for _, group := range []struct {
+			label string
+			refs  []RelationshipReference
+		}{{"Parents", rel.Parents}, {"Spouses", rel.Spouses}, {"Children", rel.Children}} {
+			if len(group.refs) > 0 {
+				fmt.Fprintf(&b, "- **%s:** %s\n", group.label, formatRelativeNames(group.refs))
+			}
+		}
+		b.WriteString("\n")
+	}
+
+	if len(report.Sources) > 0 {
+		b.WriteString("## Sources\n\n")
+		for i, source := range report.Sources {
+			title := source.Title
+			if title == "" {
+				title = "Source " + source.CitationId
+			}
+			if source.ViewRecordUrl != "" {
+				fmt.Fprintf(&b, "%d. [%s](%s)\n", i+1, markdownEscape(title), source.ViewRecordUrl)
+			} else {
+				fmt.Fprintf(&b, "%d. %s\n", i+1, markdownEscape(title))
+			}
+		}
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+// PersonReport writes a Markdown report of one person's life events, family and sources
+func PersonReport(c *cli.Context) error {
+	if c.Args().Get(0) == "" || c.Args().Get(1) == "" {
+		return fmt.Errorf("tree ID and person ID are required\n\nUsage: ancestrydl person-report <tree-id> <person-id>")
+	}
+	treeID, err := ancestry.NormalizeTreeID(c.Args().Get(0))
+	if err != nil {
+		return err
+	}
+	personID, err := ancestry.NormalizePersonID(c.Args().Get(1))
+	if err != nil {
+		return err
+	}
+
+	output := c.String("output")
+	reportOut, status := commandOutput(c)
+
+	fmt.Fprintln(status, "1. Creating API client...")
+	apiClient, err := createAPIClientFromStoredCookies(c)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := apiClient.Close(); err != nil {
+			fmt.Fprintf(status, "Error closing API client: %v\n", err)
+		}
+	}()
+
+	report, err := fetchPersonReport(c.Context, apiClient, treeID, personID, status)
+	if err != nil {
+		return err
+	}
+	markdown := renderPersonReport(report)
+
+	if isStdoutOutput(output) {
+		_, err := io.WriteString(reportOut, markdown)
+		return err
+	}
+
+	if output == "" {
+		output = fmt.Sprintf("%s-%s.md", sanitizeFilename(report.Person.GetDisplayName()), extractPersonNumber(personID))
+	}
+	if err := os.WriteFile(output, []byte(markdown), 0644); err != nil {
+		return fmt.Errorf("failed to write report: %w", err)
+	}
+
+	fmt.Fprintf(status, "\n✅ Report saved to %s\n", output)
+	return nil
+}
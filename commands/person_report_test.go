@@ -0,0 +1,52 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+)
+
+func TestRenderPersonReport(t *testing.T) {
+	events := []ancestry.Event{
+		{Type: Death, Date: "3 Feb 1920"},
+		{Type: "Residence"},
+		{Type: Birth, Date: "12 Mar 1850", NPS: []map[string]interface{}{{"v": "Springfield, Illinois, USA"}}},
+		{Type: "Marriage", Date: "Abt 1875", Description: "Married in church"},
+	}
+	sortEventsByDate(events)
+
+	report := &personReport{
+		Person: ancestry.Person{
+			Names:  []ancestry.Name{{GivenName: "John", Surname: "Smith"}},
+			Events: events,
+		},
+		Relationships: PersonRelationship{
+			Parents: []RelationshipReference{{PersonID: "1", Name: "William Smith"}},
+		},
+		Sources: []ancestry.PersonSourceDetail{{Title: "1880 United States Federal Census"}},
+	}
+
+	markdown := renderPersonReport(report)
+
+	for _, want := range []string{
+		"# John Smith (1850–1920)",
+		"- **12 Mar 1850** — Birth, Springfield, Illinois, USA ([map](https://www.openstreetmap.org/search?query=",
+		"- **Abt 1875** — Marriage, age about 25. _Married in church_",
+		"- **3 Feb 1920** — Death, age 69",
+		"- **Parents:** William Smith",
+		"1. 1880 United States Federal Census",
+	} {
+		if !strings.Contains(markdown, want) {
+			t.Errorf("report missing %q:\n%s", want, markdown)
+		}
+	}
+
+	birth := strings.Index(markdown, "Birth")
+	marriage := strings.Index(markdown, "Marriage")
+	death := strings.Index(markdown, "Death")
+	residence := strings.Index(markdown, "Residence")
+	if !(birth < marriage && marriage < death && death < residence) {
+		t.Errorf("events not in chronological order with undated last:\n%s", markdown)
+	}
+}
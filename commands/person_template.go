@@ -5,7 +5,7 @@ import (
 )
 
 // generatePersonPageTemplate creates a single person page that uses URL parameters
-func generatePersonPageTemplate(peopleJSON, metadataJSON string) string {
+func generatePersonPageTemplate(peopleJSON, metadataJSON, theme string) string {
 	return fmt.Sprintf(`<!DOCTYPE html>
 <html lang="en">
 <head>
@@ -13,7 +13,7 @@ func generatePersonPageTemplate(peopleJSON, metadataJSON string) string {
     <meta name="viewport" content="width=device-width, initial-scale=1.0">
     <title>Person - Family Tree</title>
     <style>
-        * {
+%s        * {
             margin: 0;
             padding: 0;
             box-sizing: border-box;
@@ -21,14 +21,14 @@ func generatePersonPageTemplate(peopleJSON, metadataJSON string) string {
 
         body {
             font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, Oxygen, Ubuntu, Cantarell, sans-serif;
-            background: #f5f5f5;
+            background: var(--page-bg);
             padding: 20px;
         }
 
         .container {
             max-width: 1000px;
             margin: 0 auto;
-            background: white;
+            background: var(--surface);
             border-radius: 8px;
             box-shadow: 0 2px 8px rgba(0,0,0,0.1);
             padding: 30px;
@@ -49,13 +49,13 @@ func generatePersonPageTemplate(peopleJSON, metadataJSON string) string {
         }
 
         h1 {
-            color: #2c3e50;
+            color: var(--text);
             margin-bottom: 5px;
             font-size: 2.5em;
         }
 
         .person-id {
-            color: #95a5a6;
+            color: var(--text-faint);
             font-size: 0.9em;
             margin-bottom: 20px;
         }
@@ -63,12 +63,12 @@ func generatePersonPageTemplate(peopleJSON, metadataJSON string) string {
         .hero-section {
             margin-bottom: 30px;
             padding-bottom: 20px;
-            border-bottom: 2px solid #e0e0e0;
+            border-bottom: 2px solid var(--border);
         }
 
         .vital-stats {
             font-size: 1.2em;
-            color: #555;
+            color: var(--text-body);
             margin: 10px 0;
         }
 
@@ -78,19 +78,19 @@ func generatePersonPageTemplate(peopleJSON, metadataJSON string) string {
 
         .location-info {
             font-size: 1em;
-            color: #666;
+            color: var(--text-soft);
             margin: 8px 0;
         }
 
         .section {
             margin: 30px 0;
             padding: 20px;
-            background: #f8f9fa;
+            background: var(--surface-subtle);
             border-radius: 4px;
         }
 
         .section h2 {
-            color: #2c3e50;
+            color: var(--text);
             margin-bottom: 15px;
             font-size: 1.5em;
         }
@@ -104,11 +104,11 @@ func generatePersonPageTemplate(peopleJSON, metadataJSON string) string {
 
         .info-label {
             font-weight: bold;
-            color: #2c3e50;
+            color: var(--text);
         }
 
         .info-value {
-            color: #555;
+            color: var(--text-body);
         }
 
         .relationship-link {
@@ -133,10 +133,10 @@ func generatePersonPageTemplate(peopleJSON, metadataJSON string) string {
         }
 
         .media-item {
-            border: 1px solid #ddd;
+            border: 1px solid var(--border-strong);
             border-radius: 4px;
             overflow: hidden;
-            background: white;
+            background: var(--surface);
         }
 
         .media-item img {
@@ -156,13 +156,13 @@ func generatePersonPageTemplate(peopleJSON, metadataJSON string) string {
 
         .media-title {
             font-weight: bold;
-            color: #2c3e50;
+            color: var(--text);
             margin-bottom: 5px;
         }
 
         .media-description {
             font-size: 0.9em;
-            color: #666;
+            color: var(--text-soft);
         }
 
         .badge {
@@ -198,8 +198,8 @@ func generatePersonPageTemplate(peopleJSON, metadataJSON string) string {
             align-items: flex-start;
             padding: 15px;
             margin-bottom: 10px;
-            background: white;
-            border: 1px solid #e0e0e0;
+            background: var(--surface);
+            border: 1px solid var(--border);
             border-radius: 6px;
             transition: box-shadow 0.2s;
         }
@@ -212,7 +212,7 @@ func generatePersonPageTemplate(peopleJSON, metadataJSON string) string {
             width: 48px;
             height: 48px;
             min-width: 48px;
-            background: #f0f0f0;
+            background: var(--surface-hover);
             border-radius: 4px;
             display: flex;
             align-items: center;
@@ -222,12 +222,12 @@ func generatePersonPageTemplate(peopleJSON, metadataJSON string) string {
         }
 
         .source-icon.document {
-            background: #fff4e6;
+            background: var(--highlight-warm);
             color: #f39c12;
         }
 
         .source-icon.tree {
-            background: #e8f5e9;
+            background: var(--success-bg);
             color: #4caf50;
         }
 
@@ -237,14 +237,14 @@ func generatePersonPageTemplate(peopleJSON, metadataJSON string) string {
 
         .source-title {
             font-weight: 600;
-            color: #2c3e50;
+            color: var(--text);
             margin-bottom: 5px;
             font-size: 1.05em;
         }
 
         .source-meta {
             font-size: 0.9em;
-            color: #666;
+            color: var(--text-soft);
             margin-bottom: 8px;
         }
 
@@ -253,7 +253,7 @@ func generatePersonPageTemplate(peopleJSON, metadataJSON string) string {
             margin-left: 15px;
             cursor: pointer;
             border-radius: 4px;
-            border: 2px solid #e0e0e0;
+            border: 2px solid var(--border);
             transition: transform 0.2s;
         }
 
@@ -264,7 +264,7 @@ func generatePersonPageTemplate(peopleJSON, metadataJSON string) string {
 
         .source-count {
             font-size: 0.9em;
-            color: #666;
+            color: var(--text-soft);
             margin-top: 5px;
         }
 
@@ -288,10 +288,16 @@ func generatePersonPageTemplate(peopleJSON, metadataJSON string) string {
         .event-item {
             padding: 10px;
             margin: 5px 0;
-            background: white;
+            background: var(--surface);
             border-left: 3px solid #3498db;
         }
 
+        .map-link {
+            font-size: 0.85em;
+            color: #3498db;
+            margin-left: 4px;
+        }
+
         /* Lightbox for images */
         .lightbox {
             display: none;
@@ -544,11 +550,7 @@ func generatePersonPageTemplate(peopleJSON, metadataJSON string) string {
                 if (!person.media || person.media.length === 0) return [];
 
                 let matches = [];
-                let eventYear = null;
-                if (event.date) {
-                    let yearMatch = event.date.toString().match(/\d{4}/);
-                    if (yearMatch) eventYear = yearMatch[0];
-                }
+                let eventYear = event.year ? String(event.year) : null;
 
                 person.media.forEach(mediaItem => {
                     let score = 0;
@@ -584,16 +586,10 @@ func generatePersonPageTemplate(peopleJSON, metadataJSON string) string {
                 return matches.map(m => m.media);
             }
 
-            // Helper function to extract year from date for sorting
-            function extractYear(dateStr) {
-                if (!dateStr) return 9999; // Put events with no date at end
-                let match = dateStr.toString().match(/\d{4}/);
-                return match ? parseInt(match[0]) : 9999;
-            }
-
-            // Sort events chronologically by date
+            // Sort events chronologically by date; events without a sort key go at the end
+            const noSortKey = 99999999;
             let sortedEvents = [...person.events].sort((a, b) => {
-                return extractYear(a.date) - extractYear(b.date);
+                return (a.sortKey || noSortKey) - (b.sortKey || noSortKey);
             });
 
             let eventsHTML = '<h2>Life Events</h2><ul class="event-list">';
@@ -619,9 +615,12 @@ func generatePersonPageTemplate(peopleJSON, metadataJSON string) string {
                 eventsHTML += '<strong>' + eventType + '</strong>';
                 if (event.date) {
                     eventsHTML += '<br>Date: ' + formatDate(event.date);
+                    if (event.ageAtEvent !== undefined) {
+                        eventsHTML += ', age ' + (event.ageAtEventApproximate ? 'about ' : '') + event.ageAtEvent;
+                    }
                 }
                 if (event.place) {
-                    eventsHTML += '<br>Place: ' + event.place;
+                    eventsHTML += '<br>Place: ' + formatPlace(event);
                 }
                 if (event.description) {
                     eventsHTML += '<br><em>' + event.description + '</em>';
@@ -635,7 +634,7 @@ func generatePersonPageTemplate(peopleJSON, metadataJSON string) string {
                     eventMedia.forEach(media => {
                         let tooltip = [media.title, media.subcategory].filter(x => x).join(' - ');
                         let metadataText = [media.title, media.date, media.subcategory, media.description].filter(x => x).join(' | ');
-                        eventsHTML += '<img src="' + media.filePath + '" alt="' + (tooltip || '') + '" title="' + tooltip + '" onclick=\'event.stopPropagation(); openLightbox("' + media.filePath + '", ' + JSON.stringify(metadataText).replace(/'/g, "&apos;") + ')\' style="width: 50px; height: 50px; object-fit: cover; border-radius: 4px; cursor: pointer; border: 1px solid #ddd;">';
+                        eventsHTML += '<img src="' + media.filePath + '" alt="' + (tooltip || '') + '" title="' + tooltip + '" onclick=\'event.stopPropagation(); openLightbox("' + media.filePath + '", ' + JSON.stringify(metadataText).replace(/'/g, "&apos;") + ')\' style="width: 50px; height: 50px; object-fit: cover; border-radius: 4px; cursor: pointer; border: 1px solid var(--border-strong);">';
                     });
                     eventsHTML += '</div>';
                 }
@@ -735,6 +734,19 @@ func generatePersonPageTemplate(peopleJSON, metadataJSON string) string {
             return date;
         }
 
+        // Shows the place hierarchy and a map link when the place could be parsed
+        function formatPlace(event) {
+            const detail = event.placeDetail;
+            if (!detail || !detail.components || detail.components.length === 0) {
+                return event.place;
+            }
+            let html = detail.components.join(' &rsaquo; ');
+            if (detail.mapUrl) {
+                html += ' <a class="map-link" href="' + detail.mapUrl + '" target="_blank" rel="noopener" onclick="event.stopPropagation()">map</a>';
+            }
+            return html;
+        }
+
         function openLightbox(imagePath, metadata = '') {
             document.getElementById('lightbox-img').src = imagePath;
             const metadataEl = document.getElementById('lightbox-metadata');
@@ -761,5 +773,5 @@ func generatePersonPageTemplate(peopleJSON, metadataJSON string) string {
         });
     </script>
 </body>
-</html>`, peopleJSON, metadataJSON)
+</html>`, themeCSS(theme), peopleJSON, metadataJSON)
 }
@@ -0,0 +1,16 @@
+//go:build windows
+
+package commands
+
+import "os"
+
+// processAlive reports whether a process with the given PID is running.
+// On Windows, FindProcess opens a handle and fails if the process doesn't exist.
+func processAlive(pid int) bool {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	_ = process.Release()
+	return true
+}
@@ -0,0 +1,93 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+	"github.com/chrisrob11/ancestrydl/pkg/config"
+	"github.com/urfave/cli/v2"
+)
+
+// recentTree is the tree the user last viewed on the site
+type recentTree struct {
+	ID   string
+	Name string // Empty if the tree's info couldn't be fetched
+}
+
+// label returns the tree ID with its name, e.g. "123456789 (Smith Family Tree)"
+func (t recentTree) label() string {
+	if t.Name == "" {
+		return t.ID
+	}
+	return fmt.Sprintf("%s (%s)", t.ID, t.Name)
+}
+
+// fetchRecentTree looks up the tree the user last viewed on the site, returning false if
+// there is none or the session can't be used
+func fetchRecentTree(ctx context.Context, apiClient *ancestry.APIClient) (recentTree, bool) {
+	userData, err := apiClient.GetUserData(ctx)
+	if err != nil {
+		return recentTree{}, false
+	}
+	tree := recentTree{ID: userData.GetMostRecentlyViewedTreeID()}
+	if tree.ID == "" {
+		return recentTree{}, false
+	}
+	if info, err := apiClient.GetTreeInfo(ctx, tree.ID); err == nil {
+		tree.Name = info.TreeName
+	}
+	return tree, true
+}
+
+// recentTreeSuggestion is the error for a command run without a tree ID or default tree when
+// the user's most recently viewed tree is known
+func recentTreeSuggestion(tree recentTree) error {
+	return fmt.Errorf("no default tree set; your most recently viewed tree is %s\n\n"+
+		"Use --use-recent to download it, or set it as the default with: ancestrydl config set-default-tree %s", tree.label(), tree.ID)
+}
+
+// getTreeIDArgOrDefault returns the tree ID argument (normalized with ancestry.NormalizeTreeID), the
+// default tree, or with --use-recent the tree the user last viewed on the site. Without any of them,
+// noTreeErr is returned, or a suggestion to use the most recently viewed tree if there is one.
+func getTreeIDArgOrDefault(c *cli.Context, noTreeErr error) (string, error) {
+	return treeIDOrDefault(c, c.Args().First(), noTreeErr)
+}
+
+// treeIDOrDefault is getTreeIDArgOrDefault for a tree ID that isn't the first argument,
+// falling back the same way when treeID is empty
+func treeIDOrDefault(c *cli.Context, treeID string, noTreeErr error) (string, error) {
+	if treeID != "" {
+		return ancestry.NormalizeTreeID(treeID)
+	}
+
+	_, status := commandOutput(c)
+	defaultTreeID, err := config.GetDefaultTreeID()
+	if err != nil {
+		return "", fmt.Errorf("failed to get default tree: %w", err)
+	}
+	if defaultTreeID != "" {
+		fmt.Fprintf(status, "Using default tree: %s\n", defaultTreeID)
+		return defaultTreeID, nil
+	}
+
+	apiClient, err := createAPIClientFromStoredCookies(c)
+	if err != nil {
+		return "", noTreeErr
+	}
+	defer func() {
+		if err := apiClient.Close(); err != nil {
+			fmt.Fprintf(status, "Error closing API client: %v\n", err)
+		}
+	}()
+
+	tree, ok := fetchRecentTree(c.Context, apiClient)
+	if !ok {
+		return "", noTreeErr
+	}
+	if !c.Bool("use-recent") {
+		return "", recentTreeSuggestion(tree)
+	}
+	fmt.Fprintf(status, "Using your most recently viewed tree: %s\n", tree.label())
+	return tree.ID, nil
+}
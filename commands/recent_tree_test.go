@@ -0,0 +1,23 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRecentTreeSuggestion(t *testing.T) {
+	err := recentTreeSuggestion(recentTree{ID: "178123456", Name: "Smith Family Tree"})
+	for _, want := range []string{
+		"your most recently viewed tree is 178123456 (Smith Family Tree)",
+		"--use-recent",
+		"ancestrydl config set-default-tree 178123456",
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("suggestion %q missing %q", err, want)
+		}
+	}
+
+	if got := (recentTree{ID: "178123456"}).label(); got != "178123456" {
+		t.Errorf("label() without a name = %q", got)
+	}
+}
@@ -0,0 +1,134 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+)
+
+// redactDate reduces an event date to its decade, e.g. "12 Mar 1850" to "1850s". Dates
+// without a year are dropped.
+func redactDate(raw interface{}) interface{} {
+	parsed, err := ancestry.ParseGenealogyDate(raw)
+	if err != nil || parsed.Year == 0 {
+		return nil
+	}
+	return fmt.Sprintf("%ds", parsed.Year/10*10)
+}
+
+// initials reduces given names to their initials, e.g. "John William" to "J. W."
+func initials(givenNames string) string {
+	var parts []string
+	for _, name := range strings.Fields(givenNames) {
+		for _, r := range name {
+			if unicode.IsLetter(r) {
+				parts = append(parts, string(unicode.ToUpper(r))+".")
+				break
+			}
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// redactFullName reduces every name but the last (the surname) to its initial, for names
+// known only as a single string
+func redactFullName(name string) string {
+	words := strings.Fields(name)
+	if len(words) < 2 {
+		return name
+	}
+	return strings.TrimSpace(initials(strings.Join(words[:len(words)-1], " ")) + " " + words[len(words)-1])
+}
+
+// redactPerson replaces the person's given names with initials, reduces event dates to
+// decades, and drops event descriptions, notes, lifespans and timestamps. Surnames,
+// gender and places are kept so the tree's shape stays meaningful.
+func redactPerson(person *ancestry.Person) {
+	for i := range person.Names {
+		person.Names[i].GivenName = initials(person.Names[i].GivenName)
+	}
+	person.GivenName = initials(person.GivenName)
+
+	for i := range person.Events {
+		event := &person.Events[i]
+		event.Date = redactDate(event.Date)
+		event.Description = ""
+		event.DescriptionTruncated = false
+	}
+
+	person.Notes = nil
+	person.EventsSummary = nil
+	person.L, person.Lus = nil, nil
+	person.MD, person.CD = "", ""
+}
+
+// redactedName returns the redacted name of a person in names, falling back to
+// redactFullName for persons outside the export
+func redactedName(names map[string]string, personID, name string) string {
+	if redacted, ok := names[personID]; ok {
+		return redacted
+	}
+	return redactFullName(name)
+}
+
+// redactRelationshipNames replaces the names in relationships with the persons' redacted names
+func redactRelationshipNames(relationships map[string]PersonRelationship, names map[string]string) {
+	for id, rel := range relationships {
+		rel.Name = redactedName(names, rel.PersonID, rel.Name)
+		for _, refs := range [][]RelationshipReference{rel.Parents, rel.Spouses, rel.Children} {
+			for i := range refs {
+				refs[i].Name = redactedName(names, refs[i].PersonID, refs[i].Name)
+			}
+		}
+		relationships[id] = rel
+	}
+}
+
+// redactNames replaces the recorded person names with their redacted names
+func (l *failureLog) redactNames(names map[string]string) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for i := range l.failures {
+		l.failures[i].PersonName = redactedName(names, l.failures[i].PersonID, l.failures[i].PersonName)
+	}
+}
+
+// redactNames replaces the recorded person names with their redacted names
+func (l *restrictedLog) redactNames(names map[string]string) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for i := range l.persons {
+		l.persons[i].PersonName = redactedName(names, l.persons[i].PersonID, l.persons[i].PersonName)
+	}
+}
+
+// redactTree anonymizes a downloaded tree for sharing publicly (--redact). Persons are
+// redacted with redactPerson, the names in relationships and in opts' failure and restricted
+// logs to match, and the tree description is dropped. Media and record images, which carry
+// the source citations, are left out through ExcludeMedia (see parseDownloadTreeOptions).
+// It does nothing unless opts.Redact is set.
+func redactTree(persons []ancestry.Person, relationships map[string]PersonRelationship, treeInfo *ancestry.TreeInfo,
+	opts downloadTreeOptions) {
+	if !opts.Redact {
+		return
+	}
+	names := make(map[string]string, len(persons))
+	for i := range persons {
+		redactPerson(&persons[i])
+		names[persons[i].GetPersonID()] = persons[i].GetDisplayName()
+	}
+	redactRelationshipNames(relationships, names)
+	opts.Failures.redactNames(names)
+	opts.Restricted.redactNames(names)
+	if treeInfo != nil {
+		treeInfo.TreeDescription = ""
+	}
+}
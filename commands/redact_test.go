@@ -0,0 +1,128 @@
+package commands
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+)
+
+func TestRedactDate(t *testing.T) {
+	tests := []struct {
+		raw  interface{}
+		want interface{}
+	}{
+		{"12 Mar 1850", "1850s"},
+		{"1857", "1850s"},
+		{"abt. 1903", "1900s"},
+		{"", nil},
+		{"unknown", nil},
+	}
+	for _, tt := range tests {
+		if got := redactDate(tt.raw); got != tt.want {
+			t.Errorf("redactDate(%q) = %v, want %v", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestInitials(t *testing.T) {
+	tests := []struct {
+		given string
+		want  string
+	}{
+		{"John William", "J. W."},
+		{"ada", "A."},
+		{"  Mary-Ann  ", "M."},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := initials(tt.given); got != tt.want {
+			t.Errorf("initials(%q) = %q, want %q", tt.given, got, tt.want)
+		}
+	}
+}
+
+func TestRedactFullName(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"John William Smith", "J. W. Smith"},
+		{"Smith", "Smith"},
+		{"", ""},
+	}
+	for _, tt := range tests {
+		if got := redactFullName(tt.name); got != tt.want {
+			t.Errorf("redactFullName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestRedactPerson(t *testing.T) {
+	person := ancestry.Person{
+		Names:     []ancestry.Name{{GivenName: "John William", Surname: "Smith"}},
+		GivenName: "John William",
+		Surname:   "Smith",
+		Events: []ancestry.Event{{
+			Type: "Birth", Date: "12 Mar 1850", Place: "Boston", Description: "at home",
+			DescriptionTruncated: true,
+		}},
+		Notes: []ancestry.PersonNote{{}},
+		MD:    "2020-01-01",
+	}
+	redactPerson(&person)
+
+	if got := person.GetDisplayName(); got != "J. W. Smith" {
+		t.Errorf("display name = %q, want %q", got, "J. W. Smith")
+	}
+	event := person.Events[0]
+	if event.Date != "1850s" || event.Place != "Boston" || event.Description != "" || event.DescriptionTruncated {
+		t.Errorf("unexpected redacted event: %+v", event)
+	}
+	if person.Notes != nil || person.MD != "" {
+		t.Errorf("notes and timestamps should be dropped: %+v", person)
+	}
+}
+
+func TestRedactTree(t *testing.T) {
+	persons := []ancestry.Person{
+		{PID: "1", GivenName: "John", Surname: "Smith"},
+		{PID: "2", GivenName: "Mary Ann", Surname: "Smith"},
+	}
+	relationships := map[string]PersonRelationship{
+		"1": {PersonID: "1", Name: "John Smith", Children: []RelationshipReference{{PersonID: "2", Name: "Mary Ann Smith"}}},
+		"2": {PersonID: "2", Name: "Mary Ann Smith", Parents: []RelationshipReference{
+			{PersonID: "1", Name: "John Smith"}, {PersonID: "9", Name: "Jane Doe"},
+		}},
+	}
+	treeInfo := &ancestry.TreeInfo{TreeName: "Smiths", TreeDescription: "Our family"}
+	opts := downloadTreeOptions{Redact: true, Failures: &failureLog{}, Restricted: &restrictedLog{}}
+	opts.Failures.add("facts", persons[1], errors.New("timeout"))
+	opts.Restricted.add(RestrictedFacts, persons[0])
+
+	redactTree(persons, relationships, treeInfo, opts)
+
+	if got := relationships["1"].Children[0].Name; got != "M. A. Smith" {
+		t.Errorf("child name = %q, want %q", got, "M. A. Smith")
+	}
+	if got := relationships["2"].Parents[1].Name; got != "J. Doe" {
+		t.Errorf("name of a person outside the export = %q, want %q", got, "J. Doe")
+	}
+	if got := opts.Failures.list()[0].PersonName; got != "M. A. Smith" {
+		t.Errorf("failure name = %q, want %q", got, "M. A. Smith")
+	}
+	if got := opts.Restricted.list()[0].PersonName; got != "J. Smith" {
+		t.Errorf("restricted name = %q, want %q", got, "J. Smith")
+	}
+	if treeInfo.TreeDescription != "" || treeInfo.TreeName != "Smiths" {
+		t.Errorf("unexpected tree info: %+v", treeInfo)
+	}
+}
+
+func TestRedactTreeDisabled(t *testing.T) {
+	persons := []ancestry.Person{{PID: "1", GivenName: "John", Surname: "Smith"}}
+	redactTree(persons, map[string]PersonRelationship{}, nil, downloadTreeOptions{})
+	if persons[0].GivenName != "John" {
+		t.Errorf("persons should be left alone without --redact, got %q", persons[0].GivenName)
+	}
+}
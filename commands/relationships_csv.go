@@ -0,0 +1,81 @@
+package commands
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+)
+
+const (
+	// TreeFormatRelationshipsCSV writes every parent, spouse and child edge to relationships.csv
+	TreeFormatRelationshipsCSV = "relationships-csv"
+	// relationshipsCSVFile is the file the relationships-csv format writes
+	relationshipsCSVFile = "relationships.csv"
+)
+
+// relationshipsCSVHeader names the columns of relationships.csv. The relationship says what
+// person2 is to person1.
+var relationshipsCSVHeader = []string{"person1_id", "person1_name", "relationship", "person2_id", "person2_name"}
+
+// relationshipEdgeRows returns one row per parent, spouse and child edge, in person order
+func relationshipEdgeRows(persons []ancestry.Person, relationships map[string]PersonRelationship) [][]string {
+	var rows [][]string
+	for _, person := range persons {
+		personID := person.GetPersonID()
+		rel, ok := relationships[personID]
+		if !ok {
+			continue
+		}
+		name := person.GetDisplayName()
+		for _, group := range []struct {
+			relationship string
+			refs         []RelationshipReference
+		}{
+			{"parent", rel.Parents},
+			{"spouse", rel.Spouses},
+			{"child", rel.Children},
+		} {
+			for _, ref := range group.refs {
+				rows = append(rows, []string{personID, name, group.relationship, ref.PersonID, ref.Name})
+			}
+		}
+	}
+	return rows
+}
+
+// relationshipsCSVTreeWriter writes the relationship edges to relationships.csv
+type relationshipsCSVTreeWriter struct {
+	outputDir string
+}
+
+// Write implements TreeWriter
+func (w relationshipsCSVTreeWriter) Write(_ context.Context, export *TreeExport, relationships map[string]PersonRelationship, _ map[string]PersonMediaInfo) (err error) {
+	file, err := os.Create(filepath.Join(w.outputDir, relationshipsCSVFile))
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", relationshipsCSVFile, err)
+	}
+	defer func() {
+		if closeErr := file.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("failed to write %s: %w", relationshipsCSVFile, closeErr)
+		}
+	}()
+
+	writer := csv.NewWriter(file)
+	if err := writer.Write(relationshipsCSVHeader); err != nil {
+		return fmt.Errorf("failed to write %s: %w", relationshipsCSVFile, err)
+	}
+	if err := writer.WriteAll(relationshipEdgeRows(export.Persons, relationships)); err != nil {
+		return fmt.Errorf("failed to write %s: %w", relationshipsCSVFile, err)
+	}
+	return nil
+}
+
+func init() {
+	RegisterTreeWriter(TreeFormatRelationshipsCSV, func(outputDir string) TreeWriter {
+		return relationshipsCSVTreeWriter{outputDir: outputDir}
+	})
+}
@@ -0,0 +1,56 @@
+package commands
+
+import (
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+)
+
+func TestRelationshipsCSVTreeWriter(t *testing.T) {
+	dir := t.TempDir()
+	export := &TreeExport{
+		TreeID: "42",
+		Persons: []ancestry.Person{
+			{PID: "1", GivenName: "Ada", Surname: "Byron"},
+			{PID: "2", GivenName: "George", Surname: "Byron"},
+			{PID: "3", GivenName: "William", Surname: "King"},
+			{PID: "4", GivenName: "Nobody"},
+		},
+	}
+	relationships := map[string]PersonRelationship{
+		"1": {
+			Parents: []RelationshipReference{{PersonID: "2", Name: "George Byron"}},
+			Spouses: []RelationshipReference{{PersonID: "3", Name: "William King"}},
+		},
+		"2": {Children: []RelationshipReference{{PersonID: "1", Name: "Ada Byron"}}},
+		"3": {Spouses: []RelationshipReference{{PersonID: "1", Name: "Ada Byron"}}},
+	}
+
+	if err := (relationshipsCSVTreeWriter{outputDir: dir}).Write(t.Context(), export, relationships, nil); err != nil {
+		t.Fatalf("Write() error = %v", err)
+	}
+	file, err := os.Open(filepath.Join(dir, relationshipsCSVFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	rows, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatalf("invalid CSV: %v", err)
+	}
+
+	want := [][]string{
+		relationshipsCSVHeader,
+		{"1", "Ada Byron", "parent", "2", "George Byron"},
+		{"1", "Ada Byron", "spouse", "3", "William King"},
+		{"2", "George Byron", "child", "1", "Ada Byron"},
+		{"3", "William King", "spouse", "1", "Ada Byron"},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("rows = %v, want %v", rows, want)
+	}
+}
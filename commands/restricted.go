@@ -0,0 +1,74 @@
+package commands
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+)
+
+// Parts of a person that a shared tree can withhold
+const (
+	RestrictedFamilyView = "familyView" // Relationships and family view events
+	RestrictedFacts      = "facts"      // Facts page events
+)
+
+// restrictedPerson records a person the tree's sharing settings didn't let the user fully see
+type restrictedPerson struct {
+	PersonID   string   `json:"personId"`
+	PersonName string   `json:"personName,omitempty"`
+	Parts      []string `json:"parts"` // What was withheld: RestrictedFamilyView, RestrictedFacts
+}
+
+// isRestricted reports whether a per-person fetch was refused (403), which in a shared tree
+// means the person is hidden from the user rather than that the session expired
+func isRestricted(err error) bool {
+	var statusErr *ancestry.StatusError
+	return errors.As(err, &statusErr) && statusErr.Code == http.StatusForbidden
+}
+
+// restrictedLog collects restricted persons from concurrent workers. A nil log ignores them.
+type restrictedLog struct {
+	mu      sync.Mutex
+	persons []restrictedPerson
+	index   map[string]int
+}
+
+// add records that part of a person was withheld
+func (l *restrictedLog) add(part string, person ancestry.Person) {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	personID := person.GetPersonID()
+	if i, ok := l.index[personID]; ok {
+		l.persons[i].Parts = append(l.persons[i].Parts, part)
+		return
+	}
+	if l.index == nil {
+		l.index = make(map[string]int)
+	}
+	l.index[personID] = len(l.persons)
+	l.persons = append(l.persons, restrictedPerson{
+		PersonID:   personID,
+		PersonName: person.GetDisplayName(),
+		Parts:      []string{part},
+	})
+}
+
+// list returns the restricted persons in the order they were first seen
+func (l *restrictedLog) list() []restrictedPerson {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	persons := make([]restrictedPerson, len(l.persons))
+	for i, person := range l.persons {
+		person.Parts = append([]string(nil), person.Parts...)
+		persons[i] = person
+	}
+	return persons
+}
@@ -0,0 +1,51 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+)
+
+func TestIsRestricted(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{fmt.Errorf("API request failed with %w", &ancestry.StatusError{Code: http.StatusForbidden, Body: "Forbidden"}), true},
+		{fmt.Errorf("facts page request failed with %w (URL: x)", &ancestry.StatusError{Code: http.StatusForbidden}), true},
+		{fmt.Errorf("API request failed with %w", &ancestry.StatusError{Code: http.StatusNotFound}), false},
+		{errors.New("API request failed with status 403: Forbidden"), false}, // only the typed error counts
+		{errors.New("timeout"), false},
+	}
+	for _, tt := range tests {
+		if got := isRestricted(tt.err); got != tt.want {
+			t.Errorf("isRestricted(%q) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+func TestRestrictedLogMergesParts(t *testing.T) {
+	var log restrictedLog
+	ada := ancestry.Person{PID: "1", GivenName: "Ada"}
+	log.add(RestrictedFamilyView, ada)
+	log.add(RestrictedFacts, ancestry.Person{PID: "2"})
+	log.add(RestrictedFacts, ada)
+
+	got := log.list()
+	if len(got) != 2 {
+		t.Fatalf("got %d restricted persons, want 2: %+v", len(got), got)
+	}
+	if got[0].PersonID != "1" || got[0].PersonName != "Ada" || len(got[0].Parts) != 2 ||
+		got[0].Parts[0] != RestrictedFamilyView || got[0].Parts[1] != RestrictedFacts {
+		t.Errorf("unexpected first person: %+v", got[0])
+	}
+
+	var nilLog *restrictedLog
+	nilLog.add(RestrictedFacts, ada)
+	if nilLog.list() != nil {
+		t.Error("a nil log should ignore additions")
+	}
+}
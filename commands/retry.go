@@ -0,0 +1,31 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+)
+
+// retrySleep waits between attempts; tests replace it to run without waiting
+var retrySleep = time.Sleep
+
+// withRetry calls fn up to attempts times, waiting backoff before the first retry and doubling
+// the wait after each one. Errors for which permanent reports true are returned at once, as are
+// errors while the circuit breaker is open; permanent may be nil. what names the request in the
+// retry message written to status, e.g. "Session check".
+func withRetry(what string, attempts int, backoff time.Duration, permanent func(error) bool, status io.Writer, fn func() error) error {
+	var err error
+	for attempt := 1; attempt <= attempts; attempt++ {
+		err = fn()
+		if err == nil || (permanent != nil && permanent(err)) || errors.Is(err, ancestry.ErrCircuitOpen) || attempt == attempts {
+			break
+		}
+		fmt.Fprintf(status, "   %s failed (%v), retrying in %s...\n", what, err, backoff)
+		retrySleep(backoff)
+		backoff *= 2
+	}
+	return err
+}
@@ -0,0 +1,383 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+	"github.com/urfave/cli/v2"
+)
+
+// savedExport is the part of a previous download that retry-failed patches in place
+type savedExport struct {
+	Metadata   map[string]interface{}
+	Persons    []map[string]interface{}
+	MediaIndex map[string]PersonMediaInfo
+	Compressed bool // The download was written with --compress
+	Redacted   bool // The download was written with --redact, so retried data must be redacted too
+}
+
+// personByID returns the readable person with the given ID, or nil
+func (e *savedExport) personByID(personID string) map[string]interface{} {
+	for _, person := range e.Persons {
+		if id, _ := person["personId"].(string); id == personID {
+			return person
+		}
+	}
+	return nil
+}
+
+// loadSavedExport reads metadata.json, people.json and media-index.json from a download directory,
+// or their gzipped forms
+func loadSavedExport(outputDir string) (*savedExport, error) {
+	export := &savedExport{}
+	files := []struct {
+		name string
+		out  interface{}
+	}{
+		{"metadata.json", &export.Metadata},
+		{"people.json", &export.Persons},
+	}
+	for _, file := range files {
+		data, err := readJSONFile(outputDir, file.name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s (retry-failed needs a download written with the json format): %w", file.name, err)
+		}
+		if err := json.Unmarshal(data, file.out); err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", file.name, err)
+		}
+	}
+
+	export.MediaIndex = make(map[string]PersonMediaInfo)
+	data, err := readJSONFile(outputDir, "media-index.json")
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read media-index.json: %w", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, &export.MediaIndex); err != nil {
+			return nil, fmt.Errorf("failed to parse media-index.json: %w", err)
+		}
+	}
+
+	export.Compressed = jsonFileCompressed(outputDir, "people.json")
+	export.Redacted, _ = export.Metadata["redacted"].(bool)
+	return export, nil
+}
+
+// save writes people.json and media-index.json, and regenerates the HTML viewer with the
+// given theme if the download had one
+func (e *savedExport) save(outputDir, theme string) error {
+	if err := writeJSONFile(outputDir, "people.json", e.Persons, e.Compressed); err != nil {
+		return fmt.Errorf("failed to write %s: %w", jsonFileName("people.json", e.Compressed), err)
+	}
+
+	if err := saveMediaIndex(outputDir, e.MediaIndex, e.Compressed); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(filepath.Join(outputDir, "index.html")); err != nil {
+		return nil
+	}
+	viewerMetadata := make(map[string]interface{})
+	for _, key := range []string{"treeId", "treeName", "exportDate", "personCount"} {
+		viewerMetadata[key] = e.Metadata[key]
+	}
+	return writeHTMLViewer(outputDir, e.Persons, viewerMetadata, theme)
+}
+
+// mergeReadableEvents merges facts-page events into a person's readable events the way
+// mergeEvents does: the facts-page version of an event wins, other existing events are kept.
+// The result is in chronological order, like the events of people.json.
+func mergeReadableEvents(existing interface{}, factsEvents []ancestry.Event) []interface{} {
+	merged := make([]interface{}, 0, len(factsEvents))
+	seen := make(map[string]bool, len(factsEvents))
+	for _, event := range factsEvents {
+		merged = append(merged, convertEventToReadableFormat(event))
+		seen[eventKey(event)] = true
+	}
+
+	existingEvents, _ := existing.([]interface{})
+	for _, item := range existingEvents {
+		event, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		eventType, _ := event["type"].(string)
+		key := eventKey(ancestry.Event{Type: eventType, Date: event["date"]})
+		if seen[key] {
+			continue
+		}
+		merged = append(merged, event)
+		seen[key] = true
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		di, _ := ancestry.ParseGenealogyDate(merged[i].(map[string]interface{})["date"])
+		dj, _ := ancestry.ParseGenealogyDate(merged[j].(map[string]interface{})["date"])
+		return di.SortKey() < dj.SortKey()
+	})
+	return merged
+}
+
+// mergeMediaFiles adds retried media files to a person's previous files, replacing files with the same media ID
+func mergeMediaFiles(previous, retried []MediaFileInfo) []MediaFileInfo {
+	replaced := make(map[string]bool, len(retried))
+	for _, file := range retried {
+		if file.MediaID != "" {
+			replaced[file.MediaID] = true
+		}
+	}
+
+	merged := make([]MediaFileInfo, 0, len(previous)+len(retried))
+	for _, file := range previous {
+		if file.MediaID == "" || !replaced[file.MediaID] {
+			merged = append(merged, file)
+		}
+	}
+	return append(merged, retried...)
+}
+
+// mergeRecordImages adds retried record images to a person's readable record images, replacing same-citation entries
+func mergeRecordImages(existing interface{}, retried []RecordImageInfo) []interface{} {
+	replaced := make(map[string]bool, len(retried))
+	for _, record := range retried {
+		replaced[record.CitationID] = true
+	}
+
+	var merged []interface{}
+	existingRecords, _ := existing.([]interface{})
+	for _, item := range existingRecords {
+		record, _ := item.(map[string]interface{})
+		if citationID, _ := record["citationId"].(string); !replaced[citationID] {
+			merged = append(merged, item)
+		}
+	}
+	for _, record := range retried {
+		merged = append(merged, record)
+	}
+	return merged
+}
+
+// retriedFactsEvents returns the events of a re-fetched facts page, redacted like the rest of
+// the export if it was written with --redact
+func retriedFactsEvents(facts []ancestry.PersonFactDetail, redact bool) []ancestry.Event {
+	events := factsToEvents(facts)
+	if !redact {
+		return events
+	}
+	person := ancestry.Person{Events: events}
+	redactPerson(&person)
+	return person.Events
+}
+
+// retryFacts re-fetches the facts pages of persons whose facts failed and merges their events
+func retryFacts(ctx context.Context, apiClient *ancestry.APIClient, treeID string, persons []ancestry.Person, export *savedExport,
+	failures *failureLog, logger *log.Logger) int {
+	recovered := 0
+	for _, person := range persons {
+		readable := export.personByID(person.GetPersonID())
+		if readable == nil {
+			continue
+		}
+
+		researchData, err := apiClient.GetPersonFactsFromHTML(ctx, treeID, person.GetPersonID())
+		if err != nil {
+			logger.Printf("   [Warning] Failed to get facts for %s: %v\n", person.GetDisplayName(), err)
+			failures.add(FailureFacts, person, err)
+			continue
+		}
+
+		if researchData != nil {
+			if events := retriedFactsEvents(researchData.PersonFacts, export.Redacted); len(events) > 0 {
+				readable["events"] = mergeReadableEvents(readable["events"], events)
+				addReadableAges(readable)
+			}
+		}
+		recovered++
+	}
+	return recovered
+}
+
+// retryMedia re-downloads the media of persons whose media failed and merges it into the media index
+func retryMedia(ctx context.Context, apiClient *ancestry.APIClient, treeID, outputDir string, persons []ancestry.Person,
+	export *savedExport, opts downloadTreeOptions) int {
+	previousFiles := loadPreviousMediaFiles(outputDir, opts.Log)
+	recovered := 0
+	for _, person := range persons {
+		personID := person.GetPersonID()
+		readable := export.personByID(personID)
+		if readable == nil {
+			continue
+		}
+
+		before := opts.Failures.count()
+		personInfo, _, _, err := processPersonMedia(ctx, apiClient, treeID, person, outputDir, previousFiles, opts)
+		if err != nil {
+			opts.Log.Printf("   [Warning] %v\n", err)
+			opts.Failures.add(FailureMedia, person, err)
+			continue
+		}
+
+		files := mergeMediaFiles(export.MediaIndex[personID].Files, personInfo.Files)
+		if len(files) > 0 {
+			personInfo.Files = files
+			export.MediaIndex[personID] = personInfo
+			readable["media"] = files
+		}
+		if opts.Failures.count() == before {
+			recovered++
+		}
+	}
+	return recovered
+}
+
+// retryRecords re-downloads the record images of persons whose records failed
+func retryRecords(ctx context.Context, apiClient *ancestry.APIClient, treeID, outputDir string, persons []ancestry.Person,
+	export *savedExport, failures *failureLog, logger *log.Logger) int {
+	recordMediaDir := filepath.Join(outputDir, "media", "records")
+	if err := os.MkdirAll(recordMediaDir, 0755); err != nil {
+		logger.Printf("   [Warning] Failed to create records directory: %v\n", err)
+		for _, person := range persons {
+			failures.add(FailureRecords, person, err)
+		}
+		return 0
+	}
+
+	recovered := 0
+	for _, person := range persons {
+		readable := export.personByID(person.GetPersonID())
+		if readable == nil {
+			continue
+		}
+
+		before := failures.count()
+		records, _ := downloadPersonRecordImages(ctx, apiClient, treeID, person, recordMediaDir, failures)
+		if len(records) > 0 {
+			readable["recordImages"] = mergeRecordImages(readable["recordImages"], records)
+		}
+		if failures.count() == before {
+			recovered++
+		}
+	}
+	return recovered
+}
+
+// parseRetryOptions reads the media and viewer settings retry-failed needs to match the original download
+func parseRetryOptions(c *cli.Context) (downloadTreeOptions, error) {
+	opts := downloadTreeOptions{
+		NameCollisionStrategy: strings.ToLower(c.String("name-collision-strategy")),
+		Failures:              &failureLog{},
+		Theme:                 strings.ToLower(c.String("theme")),
+		Log:                   loggerFrom(c),
+	}
+	if err := validateNameCollisionStrategy(opts.NameCollisionStrategy); err != nil {
+		return opts, err
+	}
+	if err := validateTheme(opts.Theme); err != nil {
+		return opts, err
+	}
+
+	categories, err := parseMediaCategories(c.String("media-categories"))
+	if err != nil {
+		return opts, err
+	}
+	opts.MediaCategories = categories
+	return opts, nil
+}
+
+// retryAllFailures re-attempts every recorded failure, patching export, and returns the failures that remain
+func retryAllFailures(ctx context.Context, apiClient *ancestry.APIClient, treeID, outputDir string, failures []downloadFailure,
+	export *savedExport, opts downloadTreeOptions) []downloadFailure {
+	byKind := failedPersons(failures)
+
+	opts.Log.Printf("2. Retrying facts for %d person(s)...\n", len(byKind[FailureFacts]))
+	recovered := retryFacts(ctx, apiClient, treeID, byKind[FailureFacts], export, opts.Failures, opts.Log)
+	opts.Log.Printf("   ✓ Recovered facts for %d person(s)\n", recovered)
+
+	opts.Log.Printf("3. Retrying media for %d person(s)...\n", len(byKind[FailureMedia]))
+	recovered = retryMedia(ctx, apiClient, treeID, outputDir, byKind[FailureMedia], export, opts)
+	opts.Log.Printf("   ✓ Recovered media for %d person(s)\n", recovered)
+
+	opts.Log.Printf("4. Retrying record images for %d person(s)...\n", len(byKind[FailureRecords]))
+	recovered = retryRecords(ctx, apiClient, treeID, outputDir, byKind[FailureRecords], export, opts.Failures, opts.Log)
+	opts.Log.Printf("   ✓ Recovered record images for %d person(s)\n", recovered)
+
+	return opts.Failures.list()
+}
+
+// RetryFailed re-attempts the facts, media and record fetches that failed during a previous
+// download-tree run, as listed in its failures.json, and merges what succeeds into the export
+func RetryFailed(c *cli.Context) error {
+	outputDir := c.Args().First()
+	if outputDir == "" {
+		return cli.Exit("Output directory is required\n\nUsage: ancestrydl retry-failed <output-dir>", 1)
+	}
+
+	opts, err := parseRetryOptions(c)
+	if err != nil {
+		return cli.Exit(err.Error(), 1)
+	}
+
+	failures, err := loadFailures(outputDir)
+	if err != nil {
+		return cli.Exit(err.Error(), 1)
+	}
+	if len(failures) == 0 {
+		opts.Log.Printf("No failures recorded in %s; nothing to retry\n", outputDir)
+		return nil
+	}
+
+	unlock, err := acquireDownloadLock(outputDir, c.Bool("force-unlock"), opts.Log)
+	if err != nil {
+		return cli.Exit(err.Error(), 1)
+	}
+	defer unlock()
+
+	export, err := loadSavedExport(outputDir)
+	if err != nil {
+		return cli.Exit(err.Error(), 1)
+	}
+	treeID, _ := export.Metadata["treeId"].(string)
+	if treeID == "" {
+		return cli.Exit("metadata.json does not contain a tree ID", 1)
+	}
+
+	opts.Log.Printf("Retrying %d failed fetch(es) for tree %s in %s\n", len(failures), treeID, outputDir)
+	opts.Log.Println()
+
+	apiClient, err := setupAPIClientForDownload(c)
+	if err != nil {
+		return cli.Exit(err.Error(), 1)
+	}
+	defer func() {
+		if err := apiClient.Close(); err != nil {
+			opts.Log.Printf("Error closing API client: %v\n", err)
+		}
+	}()
+
+	remaining := retryAllFailures(c.Context, apiClient, treeID, outputDir, failures, export, opts)
+
+	opts.Log.Println("5. Saving merged export...")
+	if err := export.save(outputDir, opts.Theme); err != nil {
+		return cli.Exit(err.Error(), 1)
+	}
+	if err := saveFailures(outputDir, remaining); err != nil {
+		return cli.Exit(err.Error(), 1)
+	}
+	opts.Log.Println("   ✓ Export updated")
+
+	opts.Log.Println()
+	if len(remaining) > 0 {
+		opts.Log.Printf("%d fetch(es) still failed and remain in %s; run retry-failed again later\n", len(remaining), failuresFile)
+	} else {
+		opts.Log.Println("✅ All failed items recovered")
+	}
+	return nil
+}
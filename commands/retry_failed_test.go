@@ -0,0 +1,130 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+)
+
+func TestMergeReadableEvents(t *testing.T) {
+	// Events as they come back from people.json
+	var existing interface{}
+	if err := json.Unmarshal([]byte(`[
+		{"type": "Birth", "date": "1 Jan 1900"},
+		{"type": "Marriage", "date": "5 Jun 1925"}
+	]`), &existing); err != nil {
+		t.Fatal(err)
+	}
+	facts := []ancestry.Event{
+		{Type: Birth, Date: "1 Jan 1900", Description: "Born at home",
+			NPS: []map[string]interface{}{{"v": "Springfield, Illinois, USA"}}},
+	}
+
+	merged := mergeReadableEvents(existing, facts)
+
+	if len(merged) != 2 {
+		t.Fatalf("got %d events, want 2: %+v", len(merged), merged)
+	}
+	birth := merged[0].(map[string]interface{})
+	if birth["description"] != "Born at home" || birth["place"] != "Springfield, Illinois, USA" {
+		t.Errorf("birth event should be the facts-page version, got %+v", birth)
+	}
+	if marriage := merged[1].(map[string]interface{}); marriage["type"] != "Marriage" {
+		t.Errorf("existing marriage event was dropped, got %+v", marriage)
+	}
+}
+
+func TestMergeMediaFiles(t *testing.T) {
+	previous := []MediaFileInfo{
+		{MediaID: "m1", FilePath: "media/photos/old-1.jpg"},
+		{MediaID: "m2", FilePath: "media/photos/old-2.jpg"},
+	}
+	retried := []MediaFileInfo{
+		{MediaID: "m2", FilePath: "media/photos/new-2.jpg"},
+		{MediaID: "m3", FilePath: "media/photos/new-3.jpg"},
+	}
+
+	merged := mergeMediaFiles(previous, retried)
+
+	want := []string{"media/photos/old-1.jpg", "media/photos/new-2.jpg", "media/photos/new-3.jpg"}
+	if len(merged) != len(want) {
+		t.Fatalf("got %d files, want %d: %+v", len(merged), len(want), merged)
+	}
+	for i, path := range want {
+		if merged[i].FilePath != path {
+			t.Errorf("file %d = %q, want %q", i, merged[i].FilePath, path)
+		}
+	}
+}
+
+func TestRetryFactsRedactsRedactedExport(t *testing.T) {
+	const factsPage = `<script>window.researchData = {"PersonFacts":[` +
+		`{"Type":1,"TypeString":"Birth","Date":"12 Mar 1851","Place":"York, England","Description":"Born at 4 Mill Lane"},` +
+		`{"Type":2,"TypeString":"Death","Date":"3 Feb 1920","Description":"Buried beside his wife"}` +
+		`],"PersonSources":[]};</script>`
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(factsPage))
+	}))
+	defer server.Close()
+
+	apiClient, err := ancestry.NewAPIClient(nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	apiClient.SetBaseURL(server.URL)
+
+	export := &savedExport{
+		Metadata: map[string]interface{}{"treeId": "42", "redacted": true},
+		Persons: []map[string]interface{}{{
+			"personId": "1001:1030:42",
+			"name":     "J. Smith",
+			"events":   []interface{}{map[string]interface{}{"type": "Birth", "date": "1850s"}},
+		}},
+		Redacted: true,
+	}
+	persons := []ancestry.Person{{PID: "1001:1030:42", GivenName: "J. Smith"}}
+
+	if recovered := retryFacts(context.Background(), apiClient, "42", persons, export, &failureLog{}, defaultLogger); recovered != 1 {
+		t.Fatalf("recovered %d person(s), want 1", recovered)
+	}
+
+	data, err := json.Marshal(export.Persons)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, private := range []string{"12 Mar 1851", "1851", "3 Feb 1920", "Mill Lane", "Buried"} {
+		if strings.Contains(string(data), private) {
+			t.Errorf("retried export contains %q: %s", private, data)
+		}
+	}
+	events, _ := export.Persons[0]["events"].([]interface{})
+	if len(events) != 2 {
+		t.Fatalf("got %d events, want the redacted birth merged with the existing one and a death: %s", len(events), data)
+	}
+	if death := events[1].(map[string]interface{}); death["type"] != Death || death["date"] != "1920s" {
+		t.Errorf("death = %+v, want a redacted 1920s death", death)
+	}
+}
+
+func TestLoadSavedExportRedacted(t *testing.T) {
+	dir := t.TempDir()
+	if err := writeJSONFile(dir, "metadata.json", map[string]interface{}{"treeId": "42", "redacted": true}, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeJSONFile(dir, "people.json", []interface{}{}, false); err != nil {
+		t.Fatal(err)
+	}
+
+	export, err := loadSavedExport(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !export.Redacted {
+		t.Error("Redacted = false for an export whose metadata.json says redacted")
+	}
+}
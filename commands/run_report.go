@@ -0,0 +1,96 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// runReportFile records what a download-tree run did, for users and automation
+const runReportFile = "run-report.json"
+
+// runReport is the machine-readable summary of a download-tree run
+type runReport struct {
+	TreeID                 string            `json:"treeId"`
+	StartTime              time.Time         `json:"startTime"`
+	EndTime                time.Time         `json:"endTime"`
+	PersonsDownloaded      int               `json:"personsDownloaded"`
+	RelationshipsBuilt     int               `json:"relationshipsBuilt"`
+	FactsFetched           int               `json:"factsFetched"`
+	FactsSkipped           bool              `json:"factsSkipped,omitempty"`
+	Media                  runReportMedia    `json:"media"`
+	RecordImagesDownloaded int               `json:"recordImagesDownloaded"`
+	Warnings               []downloadFailure `json:"warnings"`
+	Error                  string            `json:"error,omitempty"`
+}
+
+// runReportMedia counts what happened to the tree's media files
+type runReportMedia struct {
+	Downloaded int   `json:"downloaded"`
+	Bytes      int64 `json:"bytes"`     // Total size of the downloaded files
+	Unchanged  int   `json:"unchanged"` // Already up to date from a previous run
+	Filtered   int   `json:"filtered"`  // Skipped by --media-categories
+	OverLimit  int   `json:"overLimit"` // Skipped by --max-media-per-person
+	Failed     int   `json:"failed"`
+}
+
+// countFailures returns how many failures are of the given kind
+func countFailures(failures []downloadFailure, kind string) int {
+	n := 0
+	for _, failure := range failures {
+		if failure.Kind == kind {
+			n++
+		}
+	}
+	return n
+}
+
+// newRunReport builds the report of a run that started at start. runErr is the error
+// that ended the run early, if any.
+func newRunReport(treeID string, start time.Time, personCount, relationshipCount int,
+	counts downloadCounts, opts downloadTreeOptions, runErr error) runReport {
+	failures := opts.Failures.list()
+	if failures == nil {
+		failures = []downloadFailure{}
+	}
+
+	report := runReport{
+		TreeID:             treeID,
+		StartTime:          start,
+		EndTime:            time.Now(),
+		PersonsDownloaded:  personCount,
+		RelationshipsBuilt: relationshipCount,
+		FactsSkipped:       opts.NoFacts,
+		Media: runReportMedia{
+			Downloaded: counts.Media,
+			Bytes:      counts.MediaBytes,
+			Unchanged:  counts.MediaUnchanged,
+			Filtered:   counts.FilteredMedia,
+			OverLimit:  counts.OverLimitMedia,
+			Failed:     countFailures(failures, FailureMedia),
+		},
+		RecordImagesDownloaded: counts.Records,
+		Warnings:               failures,
+	}
+	if !opts.NoFacts {
+		report.FactsFetched = personCount - countFailures(failures, FailureFacts)
+	}
+	if runErr != nil {
+		report.Error = runErr.Error()
+	}
+	return report
+}
+
+// saveRunReport writes run-report.json into the output directory. A report that can't be
+// written only warns, so it never fails a download that otherwise succeeded.
+func saveRunReport(outputDir string, report runReport) {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err == nil {
+		err = os.WriteFile(filepath.Join(outputDir, runReportFile), data, 0644)
+	}
+	if err != nil {
+		fmt.Printf("   Warning: Failed to write %s: %v\n", runReportFile, err)
+	}
+}
@@ -0,0 +1,81 @@
+package commands
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+)
+
+func TestNewRunReport(t *testing.T) {
+	opts := downloadTreeOptions{Failures: &failureLog{}}
+	opts.Failures.add(FailureFacts, ancestry.Person{PID: "1"}, errors.New("status 503"))
+	opts.Failures.add(FailureMedia, ancestry.Person{PID: "2"}, errors.New("timeout"))
+	opts.Failures.add(FailureMedia, ancestry.Person{PID: "2"}, errors.New("timeout"))
+	counts := downloadCounts{Media: 5, MediaBytes: 2048, MediaUnchanged: 3, FilteredMedia: 2, Records: 4}
+	start := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	report := newRunReport("42", start, 10, 9, counts, opts, nil)
+
+	want := runReportMedia{Downloaded: 5, Bytes: 2048, Unchanged: 3, Filtered: 2, Failed: 2}
+	if report.Media != want {
+		t.Errorf("Media = %+v, want %+v", report.Media, want)
+	}
+	if report.FactsFetched != 9 || report.PersonsDownloaded != 10 || report.RelationshipsBuilt != 9 {
+		t.Errorf("facts/persons/relationships = %d/%d/%d, want 9/10/9",
+			report.FactsFetched, report.PersonsDownloaded, report.RelationshipsBuilt)
+	}
+	if len(report.Warnings) != 3 || report.Error != "" || report.EndTime.Before(start) {
+		t.Errorf("unexpected report: %+v", report)
+	}
+
+	opts.NoFacts = true
+	report = newRunReport("42", start, 10, 9, counts, opts, errors.New("disk full"))
+	if report.FactsFetched != 0 || !report.FactsSkipped || report.Error != "disk full" {
+		t.Errorf("--no-facts report = %+v", report)
+	}
+}
+
+func TestSaveRunReport(t *testing.T) {
+	dir := t.TempDir()
+	saveRunReport(dir, newRunReport("42", time.Now(), 1, 0, downloadCounts{}, downloadTreeOptions{}, nil))
+
+	data, err := os.ReadFile(filepath.Join(dir, runReportFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded["treeId"] != "42" || decoded["warnings"] == nil {
+		t.Errorf("unexpected %s: %s", runReportFile, data)
+	}
+}
+
+func TestPhaseTimings(t *testing.T) {
+	var off *phaseTimings
+	off.start(phaseFactsFetch)()
+	if got := off.list(); got != nil {
+		t.Errorf("nil timings recorded %v", got)
+	}
+
+	timings := &phaseTimings{}
+	timings.start(phasePersonFetch)()
+	end := timings.start(phaseMediaDownload)
+	end()
+
+	phases := timings.list()
+	if len(phases) != 2 || phases[0].Phase != phasePersonFetch || phases[1].Phase != phaseMediaDownload {
+		t.Errorf("phases = %+v", phases)
+	}
+
+	report := newTimingReport("42", time.Now(), downloadTreeOptions{Concurrency: 8, Timings: timings}, nil)
+	if report.Concurrency != 8 || len(report.Phases) != 2 {
+		t.Errorf("report = %+v", report)
+	}
+}
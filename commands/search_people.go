@@ -0,0 +1,89 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+	"github.com/urfave/cli/v2"
+)
+
+// searchOptionsFromFlags returns the name filters and page size of search-people
+func searchOptionsFromFlags(c *cli.Context) (ancestry.SearchOptions, error) {
+	pageSize, err := parsePageSize(c)
+	if err != nil {
+		return ancestry.SearchOptions{}, err
+	}
+	return ancestry.SearchOptions{
+		FirstName: strings.TrimSpace(c.String("first-name")),
+		LastName:  strings.TrimSpace(c.String("last-name")),
+		Name:      strings.TrimSpace(c.String("name")),
+		PageSize:  pageSize,
+	}, nil
+}
+
+// describeSearch returns the name filters of a search for the status line, or "" if there are none
+func describeSearch(opts ancestry.SearchOptions) string {
+	var terms []string
+	for _, term := range []struct{ label, value string }{
+		{"first name", opts.FirstName}, {"last name", opts.LastName}, {"name", opts.Name},
+	} {
+		if term.value != "" {
+			terms = append(terms, fmt.Sprintf("%s %q", term.label, term.value))
+		}
+	}
+	return strings.Join(terms, ", ")
+}
+
+// SearchPeople lists the people in a tree matching --first-name, --last-name and --name, in the
+// list-people format. Ancestry does the matching, so the rest of the tree isn't downloaded.
+// Without any of the flags everyone is listed.
+func SearchPeople(c *cli.Context) error {
+	asJSON := c.Bool("json")
+	out, status := commandOutput(c)
+
+	treeID, err := getTreeIDArgOrDefault(c, fmt.Errorf("tree ID is required\n\nUsage: ancestrydl search-people <tree-id> [--first-name <name>] [--last-name <name>] [--name <text>]\n\nOr set a default tree with: ancestrydl config set-default-tree <tree-id>"))
+	if err != nil {
+		return err
+	}
+	opts, err := searchOptionsFromFlags(c)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(status, "Creating API client from stored session...")
+	apiClient, err := createAPIClientFromStoredCookies(c)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := apiClient.Close(); err != nil {
+			fmt.Fprintf(status, "Error closing API client: %v\n", err)
+		}
+	}()
+
+	if search := describeSearch(opts); search != "" {
+		fmt.Fprintf(status, "Searching tree %s for %s...\n", treeID, search)
+	} else {
+		fmt.Fprintf(status, "No name given, listing everyone in tree %s...\n", treeID)
+	}
+	persons, err := apiClient.SearchPersons(c.Context, treeID, opts)
+	if err != nil {
+		return fmt.Errorf("failed to search people: %w\n\nYour session may have expired. Try running 'ancestrydl login' again", err)
+	}
+
+	fmt.Fprintln(status)
+	if asJSON {
+		fmt.Fprintf(status, "Found %d person(s)\n", len(persons))
+		return writePersonListings(out, persons)
+	}
+	if len(persons) == 0 {
+		fmt.Fprintln(status, "No matching people found.")
+		return nil
+	}
+	fmt.Fprintf(status, "Found %d person(s):\n\n", len(persons))
+	for i, person := range persons {
+		displayPerson(out, i, person)
+	}
+	return nil
+}
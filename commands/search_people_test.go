@@ -0,0 +1,23 @@
+package commands
+
+import (
+	"testing"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+)
+
+func TestDescribeSearch(t *testing.T) {
+	tests := []struct {
+		opts ancestry.SearchOptions
+		want string
+	}{
+		{ancestry.SearchOptions{}, ""},
+		{ancestry.SearchOptions{FirstName: "John"}, `first name "John"`},
+		{ancestry.SearchOptions{FirstName: "John", LastName: "Smith", Name: "Jr"}, `first name "John", last name "Smith", name "Jr"`},
+	}
+	for _, tt := range tests {
+		if got := describeSearch(tt.opts); got != tt.want {
+			t.Errorf("describeSearch(%+v) = %q, want %q", tt.opts, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,209 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	// Decoders for the photo formats Ancestry serves
+	_ "image/gif"
+	_ "image/png"
+)
+
+// TreeFormatSelfContained writes tree.html, a single-file viewer with the media thumbnails embedded
+const TreeFormatSelfContained = "self-contained"
+
+const (
+	// selfContainedFile is the single HTML file written by the self-contained format
+	selfContainedFile = "tree.html"
+	// thumbnailMaxSize is the longest side, in pixels, of an embedded thumbnail
+	thumbnailMaxSize = 200
+	// thumbnailQuality is the JPEG quality of embedded thumbnails
+	thumbnailQuality = 75
+	// largeSelfContainedSize is the size above which tree.html is too big for most email attachments
+	largeSelfContainedSize = 25 << 20
+)
+
+// embeddedMediaFile is a media file with its thumbnail embedded as a data URI
+type embeddedMediaFile struct {
+	MediaFileInfo
+	Thumbnail string `json:"thumbnail,omitempty"`
+}
+
+// dataURI encodes data as a data URI, detecting its content type
+func dataURI(data []byte) string {
+	return "data:" + http.DetectContentType(data) + ";base64," + base64.StdEncoding.EncodeToString(data)
+}
+
+// scaleDown shrinks img so its longest side is at most maxSize, averaging a grid of up
+// to 4x4 source pixels per destination pixel. Smaller images are returned unchanged.
+func scaleDown(img image.Image, maxSize int) image.Image {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= maxSize && height <= maxSize {
+		return img
+	}
+
+	dstWidth, dstHeight := maxSize, height*maxSize/width
+	if height > width {
+		dstWidth, dstHeight = width*maxSize/height, maxSize
+	}
+	dstWidth, dstHeight = max(dstWidth, 1), max(dstHeight, 1)
+
+	dst := image.NewRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+	for y := 0; y < dstHeight; y++ {
+		y0, y1 := bounds.Min.Y+y*height/dstHeight, bounds.Min.Y+(y+1)*height/dstHeight
+		for x := 0; x < dstWidth; x++ {
+			x0, x1 := bounds.Min.X+x*width/dstWidth, bounds.Min.X+(x+1)*width/dstWidth
+			dst.Set(x, y, averageColor(img, x0, y0, max(x1, x0+1), max(y1, y0+1)))
+		}
+	}
+	return dst
+}
+
+// averageColor averages up to 4x4 evenly spaced pixels of the rectangle [x0,x1) x [y0,y1)
+func averageColor(img image.Image, x0, y0, x1, y1 int) color.RGBA {
+	const samples = 4
+	stepX, stepY := max((x1-x0)/samples, 1), max((y1-y0)/samples, 1)
+
+	var r, g, b, a, n uint32
+	for y := y0; y < y1; y += stepY {
+		for x := x0; x < x1; x += stepX {
+			pr, pg, pb, pa := img.At(x, y).RGBA()
+			r, g, b, a, n = r+pr, g+pg, b+pb, a+pa, n+1
+		}
+	}
+	return color.RGBA{R: uint8(r / n >> 8), G: uint8(g / n >> 8), B: uint8(b / n >> 8), A: uint8(a / n >> 8)}
+}
+
+// makeThumbnail decodes an image and re-encodes it as a small JPEG
+func makeThumbnail(data []byte) ([]byte, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, scaleDown(img, thumbnailMaxSize), &jpeg.Options{Quality: thumbnailQuality}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// selfContainedTreeWriter writes tree.html, which works on its own when shared. Thumbnails are
+// embedded and full-size files stay external, unless the export asks for all media to be embedded.
+type selfContainedTreeWriter struct {
+	outputDir string
+}
+
+// readMedia reads a media file saved under the output directory
+func (w selfContainedTreeWriter) readMedia(relPath string) ([]byte, error) {
+	return os.ReadFile(filepath.Join(w.outputDir, filepath.FromSlash(relPath)))
+}
+
+// embedMedia returns the person's media with thumbnails, and full files too when embedAll is set.
+// Files that can't be read or decoded keep their external path.
+func (w selfContainedTreeWriter) embedMedia(files []MediaFileInfo, embedAll bool) []embeddedMediaFile {
+	embedded := make([]embeddedMediaFile, 0, len(files))
+	for _, file := range files {
+		entry := embeddedMediaFile{MediaFileInfo: file}
+		data, err := w.readMedia(file.FilePath)
+		if err != nil {
+			fmt.Printf("   [Warning] Could not embed %s: %v\n", file.FilePath, err)
+			embedded = append(embedded, entry)
+			continue
+		}
+		if thumbnail, err := makeThumbnail(data); err == nil {
+			entry.Thumbnail = dataURI(thumbnail)
+		}
+		if embedAll {
+			entry.FilePath = dataURI(data)
+		}
+		embedded = append(embedded, entry)
+	}
+	return embedded
+}
+
+// embedRecords returns the person's record images with each file embedded
+func (w selfContainedTreeWriter) embedRecords(records []RecordImageInfo) []RecordImageInfo {
+	embedded := make([]RecordImageInfo, 0, len(records))
+	for _, record := range records {
+		if data, err := w.readMedia(record.FilePath); err == nil {
+			record.FilePath = dataURI(data)
+		} else {
+			fmt.Printf("   [Warning] Could not embed %s: %v\n", record.FilePath, err)
+		}
+		embedded = append(embedded, record)
+	}
+	return embedded
+}
+
+// Write implements TreeWriter
+func (w selfContainedTreeWriter) Write(_ context.Context, export *TreeExport, relationships map[string]PersonRelationship, mediaIndex map[string]PersonMediaInfo) error {
+	readablePersons := buildReadablePersons(export.Persons, relationships, nil, nil)
+	for _, person := range readablePersons {
+		personID, _ := person["personId"].(string)
+		if mediaInfo, ok := mediaIndex[personID]; ok && len(mediaInfo.Files) > 0 {
+			person["media"] = w.embedMedia(mediaInfo.Files, export.EmbedFullMedia)
+		}
+		if recordInfo, ok := export.RecordIndex[personID]; ok && len(recordInfo.Records) > 0 {
+			records := recordInfo.Records
+			if export.EmbedFullMedia {
+				records = w.embedRecords(records)
+			}
+			person["recordImages"] = records
+		}
+	}
+
+	peopleJSON, err := json.MarshalIndent(readablePersons, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal readable persons data: %w", err)
+	}
+	metadataJSON, _ := json.Marshal(map[string]interface{}{
+		"treeId":        export.TreeID,
+		"treeName":      export.TreeName,
+		"exportDate":    export.ExportDate,
+		"personCount":   export.PersonCount,
+		"selfContained": true,
+	})
+
+	htmlContent := generateHTMLTemplate(string(peopleJSON), string(metadataJSON), export.Theme)
+	if err := os.WriteFile(filepath.Join(w.outputDir, selfContainedFile), []byte(htmlContent), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", selfContainedFile, err)
+	}
+
+	if len(htmlContent) > largeSelfContainedSize {
+		fmt.Printf("   Warning: %s is %s; many email providers reject attachments over %s\n",
+			selfContainedFile, formatByteSize(int64(len(htmlContent))), formatByteSize(largeSelfContainedSize))
+	}
+	return nil
+}
+
+// formatByteSize formats a byte count for display, e.g. "12.3 MB"
+func formatByteSize(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), strings.ToUpper("kmgtpe")[exp])
+}
+
+func init() {
+	RegisterTreeWriter(TreeFormatSelfContained, func(outputDir string) TreeWriter {
+		return selfContainedTreeWriter{outputDir: outputDir}
+	})
+}
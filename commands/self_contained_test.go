@@ -0,0 +1,111 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+)
+
+// testPNG returns a width x height PNG filled with one color
+func testPNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			img.Set(x, y, color.RGBA{R: 200, G: 100, B: 50, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestScaleDown(t *testing.T) {
+	tests := []struct {
+		width, height int
+		wantW, wantH  int
+	}{
+		{800, 400, 200, 100},
+		{300, 900, 66, 200},
+		{120, 80, 120, 80},
+		{5000, 10, 200, 1},
+	}
+
+	for _, tt := range tests {
+		img := image.NewRGBA(image.Rect(0, 0, tt.width, tt.height))
+		got := scaleDown(img, thumbnailMaxSize).Bounds()
+		if got.Dx() != tt.wantW || got.Dy() != tt.wantH {
+			t.Errorf("scaleDown(%dx%d) = %dx%d, want %dx%d", tt.width, tt.height, got.Dx(), got.Dy(), tt.wantW, tt.wantH)
+		}
+	}
+}
+
+func TestMakeThumbnail(t *testing.T) {
+	thumbnail, err := makeThumbnail(testPNG(t, 640, 480))
+	if err != nil {
+		t.Fatalf("makeThumbnail: %v", err)
+	}
+	img, format, err := image.Decode(bytes.NewReader(thumbnail))
+	if err != nil {
+		t.Fatalf("decode thumbnail: %v", err)
+	}
+	if format != "jpeg" || img.Bounds().Dx() != 200 || img.Bounds().Dy() != 150 {
+		t.Errorf("thumbnail is %s %dx%d, want jpeg 200x150", format, img.Bounds().Dx(), img.Bounds().Dy())
+	}
+
+	if _, err := makeThumbnail([]byte("%PDF-1.4")); err == nil {
+		t.Error("expected an error for a non-image file")
+	}
+}
+
+func TestSelfContainedTreeWriter(t *testing.T) {
+	for _, embedAll := range []bool{false, true} {
+		dir := t.TempDir()
+		if err := os.MkdirAll(filepath.Join(dir, "media", "photos"), 0755); err != nil {
+			t.Fatal(err)
+		}
+		photoPath := "media/photos/Ada_1.png"
+		if err := os.WriteFile(filepath.Join(dir, filepath.FromSlash(photoPath)), testPNG(t, 400, 400), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		export := &TreeExport{
+			TreeID:         "42",
+			Persons:        []ancestry.Person{{PID: "1", GivenName: "Ada"}},
+			EmbedFullMedia: embedAll,
+		}
+		mediaIndex := map[string]PersonMediaInfo{
+			"1": {PersonID: "1", Files: []MediaFileInfo{{MediaID: "m1", FilePath: photoPath}}},
+		}
+
+		writer := selfContainedTreeWriter{outputDir: dir}
+		if err := writer.Write(context.Background(), export, nil, mediaIndex); err != nil {
+			t.Fatalf("Write: %v", err)
+		}
+
+		html, err := os.ReadFile(filepath.Join(dir, selfContainedFile))
+		if err != nil {
+			t.Fatal(err)
+		}
+		content := string(html)
+		if !strings.Contains(content, `"thumbnail": "data:image/jpeg;base64,`) {
+			t.Errorf("embedAll=%v: tree.html has no embedded thumbnail", embedAll)
+		}
+		if got := strings.Contains(content, `"filePath": "`+photoPath+`"`); got == embedAll {
+			t.Errorf("embedAll=%v: external file path present = %v", embedAll, got)
+		}
+		if !strings.Contains(content, `"selfContained":true`) {
+			t.Errorf("embedAll=%v: metadata does not mark the viewer self-contained", embedAll)
+		}
+	}
+}
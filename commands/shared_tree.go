@@ -0,0 +1,45 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+)
+
+// isSharedTree reports whether the tree is one shared with the user rather than their own.
+// A tree list that can't be fetched counts as not shared, since owned trees need no setup, and
+// is reported to status.
+func isSharedTree(ctx context.Context, listTrees func(context.Context) ([]ancestry.Tree, error), treeID string, status io.Writer) bool {
+	trees, err := listTrees(ctx)
+	if err != nil {
+		fmt.Fprintf(status, "   Warning: Could not check whether tree %s is shared with you: %v\n", treeID, err)
+		return false
+	}
+	tree, found := findTree(trees, treeID)
+	return found && tree.SH
+}
+
+// openIfSharedTree opens a tree shared with the user through an invitation, so the tree and
+// person APIs accept requests for it. Owned trees are left alone.
+func openIfSharedTree(ctx context.Context, apiClient *ancestry.APIClient, treeID string, status io.Writer) error {
+	if !isSharedTree(ctx, apiClient.ListTrees, treeID, status) {
+		return nil
+	}
+	fmt.Fprintln(status, "   Opening tree shared with you...")
+	if err := apiClient.OpenSharedTree(ctx, treeID); err != nil {
+		return fmt.Errorf("%w\n\nCheck that the tree is still listed under \"Trees shared with me\" on Ancestry, or ask its owner to invite you again", err)
+	}
+	return nil
+}
+
+// treeAccessError explains a 403 from the tree APIs, which otherwise surfaces as an opaque
+// API error. Other errors are returned unchanged.
+func treeAccessError(treeID string, err error) error {
+	if !isRestricted(err) {
+		return err
+	}
+	return fmt.Errorf("no access to tree %s: %w\n\nIf the tree was shared with you, the invitation may have been withdrawn or may have expired; "+
+		"check that it is still listed under \"Trees shared with me\" on Ancestry", treeID, err)
+}
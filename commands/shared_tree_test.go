@@ -0,0 +1,51 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+)
+
+func TestIsSharedTree(t *testing.T) {
+	trees := []ancestry.Tree{{ID: "1", Name: "Mine"}, {ID: "2", Name: "Cousin's", SH: true}}
+	list := func(context.Context) ([]ancestry.Tree, error) { return trees, nil }
+	failing := func(context.Context) ([]ancestry.Tree, error) { return nil, errors.New("status 500") }
+
+	tests := []struct {
+		name      string
+		listTrees func(context.Context) ([]ancestry.Tree, error)
+		treeID    string
+		want      bool
+	}{
+		{"owned tree", list, "1", false},
+		{"shared tree", list, "2", true},
+		{"unknown tree", list, "3", false},
+		{"list unavailable", failing, "2", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isSharedTree(context.Background(), tt.listTrees, tt.treeID, io.Discard); got != tt.want {
+				t.Errorf("isSharedTree(ctx, %s) = %v, want %v", tt.treeID, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTreeAccessError(t *testing.T) {
+	forbidden := fmt.Errorf("failed to get person count: API request failed with %w", &ancestry.StatusError{Code: http.StatusForbidden})
+	err := treeAccessError("42", forbidden)
+	if !errors.Is(err, forbidden) || !strings.Contains(err.Error(), "no access to tree 42") {
+		t.Errorf("treeAccessError(403) = %v", err)
+	}
+
+	other := errors.New("API request failed with status 500")
+	if got := treeAccessError("42", other); got != other {
+		t.Errorf("treeAccessError(500) = %v, want the error unchanged", got)
+	}
+}
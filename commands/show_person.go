@@ -0,0 +1,228 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+	"github.com/urfave/cli/v2"
+)
+
+// showPersonUsage is the usage line of show-person
+const showPersonUsage = "ancestrydl show-person [tree-id] <person-id> [--json]"
+
+// personDetails is everything show-person prints about one person, and its --json output
+type personDetails struct {
+	Person        ancestry.Person               `json:"person"`
+	Relationships PersonRelationship            `json:"relationships"`
+	Sources       []ancestry.PersonSourceDetail `json:"sources"`
+	Media         []ancestry.PrimaryMediaItem   `json:"media"`
+}
+
+// showPersonArgs returns the tree and person IDs of show-person. Given only a person ID,
+// the tree is the default tree.
+func showPersonArgs(c *cli.Context) (string, string, error) {
+	treeArg, personArg := c.Args().Get(0), c.Args().Get(1)
+	if c.NArg() == 1 {
+		treeArg, personArg = "", c.Args().Get(0)
+	}
+	if personArg == "" {
+		return "", "", fmt.Errorf("person ID is required\n\nUsage: %s", showPersonUsage)
+	}
+	personID, err := ancestry.NormalizePersonID(personArg)
+	if err != nil {
+		return "", "", err
+	}
+	treeID, err := treeIDOrDefault(c, treeArg, fmt.Errorf("tree ID is required (or set a default tree)\n\nUsage: %s", showPersonUsage))
+	if err != nil {
+		return "", "", err
+	}
+	return treeID, personID, nil
+}
+
+// fetchPersonRelationships returns the person's parents, spouses and children with their names
+// from a family view one generation up and down. If it can't be fetched, the relatives are
+// listed by ID from the person's own family links, with a warning written to status.
+func fetchPersonRelationships(ctx context.Context, apiClient *ancestry.APIClient, treeID string, person ancestry.Person, status io.Writer) PersonRelationship {
+	fullID := person.GetPersonID()
+	familyView, err := apiClient.GetFamilyView(ctx, treeID, person.GetShortPersonID(), 1, 1)
+	if err != nil {
+		fmt.Fprintf(status, "   [Warning] Failed to get family, listing relatives by ID: %v\n", err)
+		familyView = &ancestry.FamilyViewResponse{Persons: []ancestry.Person{person}}
+	}
+	rel, _, _ := processFamilyView(fullID, familyView)
+	return rel
+}
+
+// fetchPersonDetails fetches a person with their relatives, Facts page events and sources, and
+// media, writing progress to status
+func fetchPersonDetails(ctx context.Context, apiClient *ancestry.APIClient, treeID, personID string, status io.Writer) (*personDetails, error) {
+	fmt.Fprintln(status, "2. Fetching person...")
+	person, err := apiClient.GetPerson(ctx, treeID, personID)
+	if errors.Is(err, ancestry.ErrPersonNotFound) {
+		return nil, fmt.Errorf("person %s not found in tree %s", personID, treeID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get person: %w", err)
+	}
+	details := &personDetails{Person: *person}
+	details.Relationships = fetchPersonRelationships(ctx, apiClient, treeID, *person, status)
+	fmt.Fprintf(status, "   ✓ Found %s\n", person.GetDisplayName())
+
+	fmt.Fprintln(status, "3. Fetching facts, sources and media...")
+	fullID := person.GetPersonID()
+	researchData, err := apiClient.GetPersonFactsFromHTML(ctx, treeID, fullID)
+	if err != nil {
+		fmt.Fprintf(status, "   [Warning] Failed to get facts: %v\n", err)
+	} else if researchData != nil {
+		details.Person.Events = mergeEvents(details.Person.Events, factsToEvents(researchData.PersonFacts))
+		details.Sources = researchData.PersonSources
+	}
+	if details.Media, err = apiClient.GetPersonMediaFromAPI(ctx, treeID, fullID); err != nil {
+		fmt.Fprintf(status, "   [Warning] Failed to get media: %v\n", err)
+	}
+	fmt.Fprintf(status, "   ✓ %d event(s), %d source(s), %d media item(s)\n",
+		len(details.Person.Events), len(details.Sources), len(details.Media))
+
+	persons := []ancestry.Person{details.Person}
+	inferEventTypes(persons, map[string]PersonRelationship{fullID: details.Relationships})
+	details.Person = persons[0]
+	sortEventsByDate(details.Person.Events)
+
+	return details, nil
+}
+
+// genderLabel returns a person's gender spelled out
+func genderLabel(person ancestry.Person) string {
+	switch gedcomSex(person) {
+	case "M":
+		return "Male"
+	case "F":
+		return "Female"
+	}
+	return "Unknown"
+}
+
+// relativeNames returns the relatives' names, or IDs if unnamed, as a comma-separated list
+func relativeNames(refs []RelationshipReference) string {
+	names := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		name := ref.Name
+		if name == "" {
+			name = ref.PersonID
+		}
+		names = append(names, name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// writePersonDetailsEvents writes the person's life events, one per line
+func writePersonDetailsEvents(b *strings.Builder, events []ancestry.Event) {
+	b.WriteString("Life Events:\n")
+	written := 0
+	for _, event := range events {
+		// Skip metadata events that aren't real life events
+		if event.Type == "Name" || event.Type == "Gender" {
+			continue
+		}
+		written++
+
+		eventType := event.Type
+		if eventType == "" {
+			eventType = "Life Event"
+		}
+		b.WriteString("  - ")
+		if date, _ := ancestry.ParseGenealogyDate(event.Date); date.Original != "" {
+			b.WriteString(date.Original + " — ")
+		}
+		b.WriteString(eventType)
+		if place := eventPlace(event); place != "" {
+			b.WriteString(", " + place)
+		}
+		if event.Description != "" {
+			b.WriteString(". " + event.Description)
+		}
+		b.WriteString("\n")
+	}
+	if written == 0 {
+		b.WriteString("  (none recorded)\n")
+	}
+}
+
+// renderPersonDetails renders the person's details as text for the terminal
+func renderPersonDetails(details *personDetails) string {
+	var b strings.Builder
+
+	person := details.Person
+	name := person.GetDisplayName()
+	if name == "" {
+		name = person.GetPersonID()
+	}
+	b.WriteString(name)
+	if years := lifespanYears(person); years != "" {
+		b.WriteString(" (" + years + ")")
+	}
+	b.WriteString("\n")
+	fmt.Fprintf(&b, "  Person ID: %s\n", person.GetPersonID())
+	fmt.Fprintf(&b, "  Gender:    %s\n", genderLabel(person))
+	living := "No"
+	if person.IsLiving {
+		living = "Yes"
+	}
+	fmt.Fprintf(&b, "  Living:    %s\n\n", living)
+
+	writePersonDetailsEvents(&b, person.Events)
+
+	b.WriteString("\nFamily:\n")
+	rel := details.Relationships
+	for _, group := range []struct {
+		label string
+		refs  []RelationshipReference
+	}{{"Parents", rel.Parents}, {"Spouses", rel.Spouses}, {"Children", rel.Children}} {
+		names := "(none)"
+		if len(group.refs) > 0 {
+			names = relativeNames(group.refs)
+		}
+		fmt.Fprintf(&b, "  %-9s %s\n", group.label+":", names)
+	}
+
+	fmt.Fprintf(&b, "\nMedia: %d, Sources: %d\n", len(details.Media), len(details.Sources))
+	return b.String()
+}
+
+// ShowPerson prints one person's details: gender and living status, life events, relatives,
+// and how many media items and sources are attached. With --json it prints the fetched data.
+func ShowPerson(c *cli.Context) error {
+	asJSON := c.Bool("json")
+	out, status := commandOutput(c)
+
+	treeID, personID, err := showPersonArgs(c)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(status, "1. Creating API client...")
+	apiClient, err := createAPIClientFromStoredCookies(c)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := apiClient.Close(); err != nil {
+			fmt.Fprintf(status, "Error closing API client: %v\n", err)
+		}
+	}()
+
+	details, err := fetchPersonDetails(c.Context, apiClient, treeID, personID, status)
+	if err != nil {
+		return err
+	}
+
+	if asJSON {
+		return writeJSON(out, details)
+	}
+	fmt.Fprintf(out, "\n%s", renderPersonDetails(details))
+	return nil
+}
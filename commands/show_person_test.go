@@ -0,0 +1,78 @@
+package commands
+
+import (
+	"flag"
+	"strings"
+	"testing"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+	"github.com/urfave/cli/v2"
+)
+
+func TestRenderPersonDetails(t *testing.T) {
+	details := &personDetails{
+		Person: ancestry.Person{
+			PID:    "10:1030:42",
+			Names:  []ancestry.Name{{GivenName: "John", Surname: "Smith_Jr"}},
+			Gender: "m",
+			Events: []ancestry.Event{
+				{Type: Birth, Date: "12 Mar 1850", NPS: []map[string]interface{}{{"v": "York, England"}}},
+				{Type: "Occupation", Description: "Farmer"},
+				{Type: "Gender", Description: "Male"},
+			},
+		},
+		Relationships: PersonRelationship{
+			Parents:  []RelationshipReference{{PersonID: "1:1030:42", Name: "William Smith"}, {PersonID: "2:1030:42"}},
+			Children: []RelationshipReference{{PersonID: "3:1030:42", Name: "Ann Smith"}},
+		},
+		Sources: []ancestry.PersonSourceDetail{{Title: "1851 England Census"}, {Title: "Parish Register"}},
+		Media:   []ancestry.PrimaryMediaItem{{}},
+	}
+
+	text := renderPersonDetails(details)
+
+	for _, want := range []string{
+		"John Smith_Jr (1850–?)\n",
+		"  Person ID: 10:1030:42\n  Gender:    Male\n  Living:    No\n",
+		"  - 12 Mar 1850 — Birth, York, England\n",
+		"  - Occupation. Farmer\n",
+		"  Parents:  William Smith, 2:1030:42\n  Spouses:  (none)\n  Children: Ann Smith\n",
+		"Media: 1, Sources: 2\n",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("details missing %q:\n%s", want, text)
+		}
+	}
+	if strings.Contains(text, "Gender, Male") || strings.Contains(text, "- Gender") {
+		t.Errorf("metadata events should not be listed:\n%s", text)
+	}
+}
+
+func TestShowPersonArgs(t *testing.T) {
+	tests := []struct {
+		args       []string
+		wantTree   string
+		wantPerson string
+		wantErr    bool
+	}{
+		{[]string{"42", "10"}, "42", "10", false},
+		{[]string{"42", "10:1030:42"}, "42", "10", false},
+		{nil, "", "", true},
+	}
+	for _, tt := range tests {
+		set := flag.NewFlagSet("test", flag.ContinueOnError)
+		if err := set.Parse(tt.args); err != nil {
+			t.Fatal(err)
+		}
+		c := cli.NewContext(cli.NewApp(), set, nil)
+
+		treeID, personID, err := showPersonArgs(c)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("showPersonArgs(%v) error = %v, wantErr %v", tt.args, err, tt.wantErr)
+			continue
+		}
+		if treeID != tt.wantTree || personID != tt.wantPerson {
+			t.Errorf("showPersonArgs(%v) = %q, %q, want %q, %q", tt.args, treeID, personID, tt.wantTree, tt.wantPerson)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package commands
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+)
+
+// Sort fields accepted by --sort
+const (
+	SortByName      = "name"
+	SortByBirthYear = "birth-year"
+	SortByDeathYear = "death-year"
+	SortByCreated   = "created"
+	SortByModified  = "modified"
+)
+
+// sortSpec is a parsed --sort value such as "birth-year" or "name:desc"
+type sortSpec struct {
+	Field string
+	Desc  bool
+}
+
+// parseSortSpec parses a --sort value of the form "<field>[:asc|:desc]".
+// An empty value returns a zero sortSpec, meaning the server order is kept.
+func parseSortSpec(value string, allowed []string) (sortSpec, error) {
+	value = strings.ToLower(strings.TrimSpace(value))
+	if value == "" {
+		return sortSpec{}, nil
+	}
+
+	field, direction, _ := strings.Cut(value, ":")
+	spec := sortSpec{Field: field}
+	switch direction {
+	case "", "asc":
+	case "desc":
+		spec.Desc = true
+	default:
+		return sortSpec{}, fmt.Errorf("invalid sort direction %q (use asc or desc)", direction)
+	}
+
+	for _, a := range allowed {
+		if field == a {
+			return spec, nil
+		}
+	}
+	return sortSpec{}, fmt.Errorf("invalid sort field %q (use one of: %s)", field, strings.Join(allowed, ", "))
+}
+
+// personSortName returns the surname-first, lowercased name used to sort persons
+func personSortName(person ancestry.Person) string {
+	given, surname := person.GivenName, person.Surname
+	if given == "" && surname == "" && len(person.Names) > 0 {
+		given, surname = person.Names[0].GivenName, person.Names[0].Surname
+	}
+	return strings.ToLower(strings.TrimSpace(surname + " " + given))
+}
+
+// personEventDate parses the date of the first dated event of the given type
+func personEventDate(person ancestry.Person, eventType string) ancestry.ParsedDate {
+	for _, event := range person.Events {
+		if event.Type == eventType && event.Date != nil {
+			parsed, _ := ancestry.ParseGenealogyDate(event.Date)
+			return parsed
+		}
+	}
+	return ancestry.ParsedDate{}
+}
+
+// sortPersons sorts persons in place. Persons without the sorted date stay last
+// whichever direction is used.
+func sortPersons(persons []ancestry.Person, spec sortSpec) {
+	if spec.Field == "" {
+		return
+	}
+
+	sort.SliceStable(persons, func(i, j int) bool {
+		if spec.Field == SortByName {
+			a, b := personSortName(persons[i]), personSortName(persons[j])
+			if spec.Desc {
+				return a > b
+			}
+			return a < b
+		}
+
+		eventType := "Birth"
+		if spec.Field == SortByDeathYear {
+			eventType = "Death"
+		}
+		a, b := personEventDate(persons[i], eventType), personEventDate(persons[j], eventType)
+		if a.Year == 0 || b.Year == 0 {
+			return b.Year == 0 && a.Year != 0
+		}
+		if spec.Desc {
+			return a.SortKey() > b.SortKey()
+		}
+		return a.SortKey() < b.SortKey()
+	})
+}
+
+// sortTrees sorts trees in place. Trees without the sorted date stay last
+// whichever direction is used.
+func sortTrees(trees []ancestry.Tree, spec sortSpec) {
+	if spec.Field == "" {
+		return
+	}
+
+	sort.SliceStable(trees, func(i, j int) bool {
+		if spec.Field == SortByName {
+			a, b := strings.ToLower(trees[i].Name), strings.ToLower(trees[j].Name)
+			if spec.Desc {
+				return a > b
+			}
+			return a < b
+		}
+
+		dateOf := getTreeCreatedDate
+		if spec.Field == SortByModified {
+			dateOf = getTreeModifiedDate
+		}
+		a, b := dateOf(trees[i]), dateOf(trees[j])
+		if a.IsZero() || b.IsZero() {
+			return b.IsZero() && !a.IsZero()
+		}
+		if spec.Desc {
+			return a.After(b)
+		}
+		return a.Before(b)
+	})
+}
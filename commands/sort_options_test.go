@@ -0,0 +1,89 @@
+package commands
+
+import (
+	"testing"
+	"time"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+)
+
+func TestParseSortSpec(t *testing.T) {
+	allowed := []string{SortByName, SortByBirthYear}
+	tests := []struct {
+		value   string
+		want    sortSpec
+		wantErr bool
+	}{
+		{value: "", want: sortSpec{}},
+		{value: "name", want: sortSpec{Field: SortByName}},
+		{value: "Birth-Year:DESC", want: sortSpec{Field: SortByBirthYear, Desc: true}},
+		{value: "name:asc", want: sortSpec{Field: SortByName}},
+		{value: "name:up", wantErr: true},
+		{value: "death-year", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseSortSpec(tt.value, allowed)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseSortSpec(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseSortSpec(%q) = %+v, want %+v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func personBorn(given, date string) ancestry.Person {
+	person := ancestry.Person{GivenName: given}
+	if date != "" {
+		person.Events = []ancestry.Event{{Type: "Birth", Date: date}}
+	}
+	return person
+}
+
+func TestSortPersons(t *testing.T) {
+	tests := []struct {
+		name string
+		spec sortSpec
+		want []string
+	}{
+		{name: "birth ascending", spec: sortSpec{Field: SortByBirthYear}, want: []string{"Ann", "Cal", "Bob", "Dee"}},
+		{name: "birth descending keeps undated last", spec: sortSpec{Field: SortByBirthYear, Desc: true}, want: []string{"Bob", "Cal", "Ann", "Dee"}},
+		{name: "name descending", spec: sortSpec{Field: SortByName, Desc: true}, want: []string{"Dee", "Cal", "Bob", "Ann"}},
+		{name: "no sort keeps order", spec: sortSpec{}, want: []string{"Bob", "Dee", "Ann", "Cal"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			persons := []ancestry.Person{
+				personBorn("Bob", "1900"),
+				personBorn("Dee", ""),
+				personBorn("Ann", "Abt 1850"),
+				personBorn("Cal", "12 Mar 1875"),
+			}
+			sortPersons(persons, tt.spec)
+			for i, want := range tt.want {
+				if persons[i].GivenName != want {
+					t.Fatalf("position %d = %s, want %s", i, persons[i].GivenName, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSortTrees(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2020, 1, d, 0, 0, 0, 0, time.UTC) }
+	trees := []ancestry.Tree{
+		{Name: "b", ModifiedOn: day(2)},
+		{Name: "a"},
+		{Name: "c", DateModified: day(5)},
+	}
+
+	sortTrees(trees, sortSpec{Field: SortByModified, Desc: true})
+	got := trees[0].Name + trees[1].Name + trees[2].Name
+	if got != "cba" {
+		t.Errorf("sortTrees(modified:desc) order = %s, want cba", got)
+	}
+}
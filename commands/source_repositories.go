@@ -0,0 +1,107 @@
+package commands
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+)
+
+// repositoriesFileName is the file download-sources --full-citations writes a tree's repositories to
+const repositoriesFileName = "repositories.json"
+
+// sourceRepository is an archive, library or website holding sources, listed once however many
+// sources cite it. The call number stays on each source, as it locates that source in the repository.
+type sourceRepository struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name,omitempty"`
+	Address     string   `json:"address,omitempty"`
+	Email       string   `json:"email,omitempty"`
+	Phone       string   `json:"phone,omitempty"`
+	URL         string   `json:"url,omitempty"`
+	Note        string   `json:"note,omitempty"`
+	CitationIDs []string `json:"citationIds"`
+}
+
+// repositoryID returns the source's RepositoryId, or for sources without one an ID derived from
+// the repository's name and address, so the same repository gets the same ID on every download
+func repositoryID(source *ancestry.FactEditData) string {
+	if source.RepositoryID != "" {
+		return source.RepositoryID
+	}
+	key := strings.ToLower(strings.TrimSpace(source.RepositoryName) + "\n" + strings.TrimSpace(source.RepositoryAddress))
+	return fmt.Sprintf("repo-%x", sha1.Sum([]byte(key)))[:13]
+}
+
+// hasRepository reports whether the source names a repository at all
+func hasRepository(source *ancestry.FactEditData) bool {
+	return source.RepositoryID != "" || strings.TrimSpace(source.RepositoryName) != ""
+}
+
+// mergeRepositoryDetails fills the repository's missing details from a source citing it
+func mergeRepositoryDetails(repo *sourceRepository, source *ancestry.FactEditData) {
+	fields := []struct {
+		dst *string
+		src string
+	}{
+		{&repo.Name, source.RepositoryName},
+		{&repo.Address, source.RepositoryAddress},
+		{&repo.Email, source.RepositoryEmail},
+		{&repo.Phone, source.RepositoryPhone},
+		{&repo.URL, source.RepositoryURL},
+		{&repo.Note, source.RepositoryNote},
+	}
+	for _, field := range fields {
+		if *field.dst == "" {
+			*field.dst = strings.TrimSpace(field.src)
+		}
+	}
+}
+
+// clearRepositoryDetails leaves only the repository reference and call number on a source
+func clearRepositoryDetails(source *ancestry.FactEditData) {
+	source.RepositoryName = ""
+	source.RepositoryAddress = ""
+	source.RepositoryEmail = ""
+	source.RepositoryPhone = ""
+	source.RepositoryURL = ""
+	source.RepositoryNote = ""
+}
+
+// normalizeRepositories lists each repository cited by the sources once, sorted by ID, and
+// replaces the repository details on each source with its RepositoryId
+func normalizeRepositories(sources map[string]*ancestry.FactEditData) []sourceRepository {
+	citationIDs := make([]string, 0, len(sources))
+	for cid := range sources {
+		citationIDs = append(citationIDs, cid)
+	}
+	sort.Strings(citationIDs)
+
+	byID := make(map[string]*sourceRepository)
+	for _, cid := range citationIDs {
+		source := sources[cid]
+		if !hasRepository(source) {
+			continue
+		}
+		id := repositoryID(source)
+		repo, ok := byID[id]
+		if !ok {
+			repo = &sourceRepository{ID: id}
+			byID[id] = repo
+		}
+		mergeRepositoryDetails(repo, source)
+		repo.CitationIDs = append(repo.CitationIDs, cid)
+
+		source.RepositoryID = id
+		clearRepositoryDetails(source)
+	}
+
+	repositories := make([]sourceRepository, 0, len(byID))
+	for _, repo := range byID {
+		repositories = append(repositories, *repo)
+	}
+	sort.Slice(repositories, func(i, j int) bool { return repositories[i].ID < repositories[j].ID })
+	return repositories
+}
@@ -0,0 +1,44 @@
+package commands
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+)
+
+func TestNormalizeRepositories(t *testing.T) {
+	sources := map[string]*ancestry.FactEditData{
+		"c1": {CitationID: "c1", RepositoryID: "r1", RepositoryName: "Ancestry.com", RepositoryCallNumber: "A-1"},
+		"c2": {CitationID: "c2", RepositoryID: "r1", RepositoryName: "Ancestry.com", RepositoryURL: "https://www.ancestry.com"},
+		"c3": {CitationID: "c3", RepositoryName: "County Archive", RepositoryAddress: "1 High St"},
+		"c4": {CitationID: "c4", RepositoryName: " county archive ", RepositoryAddress: "1 High St"},
+		"c5": {CitationID: "c5"},
+	}
+
+	repositories := normalizeRepositories(sources)
+
+	if len(repositories) != 2 {
+		t.Fatalf("got %d repositories, want 2: %+v", len(repositories), repositories)
+	}
+	archiveID := repositoryID(&ancestry.FactEditData{RepositoryName: "County Archive", RepositoryAddress: "1 High St"})
+	want := map[string]sourceRepository{
+		"r1":      {ID: "r1", Name: "Ancestry.com", URL: "https://www.ancestry.com", CitationIDs: []string{"c1", "c2"}},
+		archiveID: {ID: archiveID, Name: "County Archive", Address: "1 High St", CitationIDs: []string{"c3", "c4"}},
+	}
+	for _, repo := range repositories {
+		if !reflect.DeepEqual(repo, want[repo.ID]) {
+			t.Errorf("repository %s = %+v, want %+v", repo.ID, repo, want[repo.ID])
+		}
+	}
+
+	if got := sources["c1"]; got.RepositoryID != "r1" || got.RepositoryName != "" || got.RepositoryCallNumber != "A-1" {
+		t.Errorf("c1 should reference r1 and keep its call number: %+v", got)
+	}
+	if got := sources["c4"]; got.RepositoryID != archiveID || got.RepositoryAddress != "" {
+		t.Errorf("c4 should reference %s: %+v", archiveID, got)
+	}
+	if sources["c5"].RepositoryID != "" {
+		t.Errorf("a source without a repository should not get one: %+v", sources["c5"])
+	}
+}
@@ -0,0 +1,60 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/urfave/cli/v2"
+)
+
+// stdoutOutput is the --output value that writes the JSON export to stdout for piping
+const stdoutOutput = "-"
+
+// isStdoutOutput reports whether the --output value asks for JSON on stdout
+func isStdoutOutput(output string) bool {
+	return output == stdoutOutput
+}
+
+// statusToStderr reports whether the command writes its JSON or report to stdout (--json or
+// --output -), so its status messages have to go to stderr
+func statusToStderr(c *cli.Context) bool {
+	return c.Bool("json") || isStdoutOutput(c.String("output"))
+}
+
+// commandOutput returns where the command writes its results and its status messages: the
+// app's Writer, except that with statusToStderr the status messages go to its ErrWriter
+// so stdout holds only the JSON.
+func commandOutput(c *cli.Context) (out, status io.Writer) {
+	out, status = io.Writer(os.Stdout), io.Writer(os.Stdout)
+	if c.App != nil && c.App.Writer != nil {
+		out, status = c.App.Writer, c.App.Writer
+	}
+	if statusToStderr(c) {
+		status = os.Stderr
+		if c.App != nil && c.App.ErrWriter != nil {
+			status = c.App.ErrWriter
+		}
+	}
+	return out, status
+}
+
+// writeTreeJSON writes the tree metadata, with the same fields as metadata.json, and the
+// persons, in the same readable format as people.json, as a single JSON document. Media is
+// not downloaded in this mode.
+func writeTreeJSON(w io.Writer, treeExport *TreeExport, relationships map[string]PersonRelationship) error {
+	export := treeMetadata(treeExport)
+	export["persons"] = buildReadablePersons(treeExport.Persons, relationships, nil, nil)
+	return writeJSON(w, export)
+}
+
+// writeJSON writes v to w as indented JSON
+func writeJSON(w io.Writer, v interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(v); err != nil {
+		return fmt.Errorf("failed to write JSON output: %w", err)
+	}
+	return nil
+}
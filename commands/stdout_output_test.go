@@ -0,0 +1,93 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"testing"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+	"github.com/urfave/cli/v2"
+)
+
+func TestCommandOutput(t *testing.T) {
+	tests := []struct {
+		name         string
+		args         []string
+		wantStdout   string
+		wantStderr   string
+		wantToStderr bool
+	}{
+		{"text output", nil, "status\nresult\n", "", false},
+		{"--json", []string{"--json"}, "result\n", "status\n", true},
+		{"--output -", []string{"--output", "-"}, "result\n", "status\n", true},
+		{"--output to a directory", []string{"--output", "tree"}, "status\nresult\n", "", false},
+	}
+	for _, tt := range tests {
+		set := flag.NewFlagSet("test", flag.ContinueOnError)
+		set.Bool("json", false, "")
+		set.String("output", "", "")
+		if err := set.Parse(tt.args); err != nil {
+			t.Fatal(err)
+		}
+		var stdout, stderr bytes.Buffer
+		app := cli.NewApp()
+		app.Writer, app.ErrWriter = &stdout, &stderr
+		c := cli.NewContext(app, set, nil)
+
+		if got := statusToStderr(c); got != tt.wantToStderr {
+			t.Errorf("%s: statusToStderr() = %v, want %v", tt.name, got, tt.wantToStderr)
+		}
+		out, status := commandOutput(c)
+		fmt.Fprintln(status, "status")
+		fmt.Fprintln(out, "result")
+
+		if stdout.String() != tt.wantStdout || stderr.String() != tt.wantStderr {
+			t.Errorf("%s: stdout %q, stderr %q, want %q, %q", tt.name, stdout.String(), stderr.String(), tt.wantStdout, tt.wantStderr)
+		}
+	}
+}
+
+func TestLoggerFromWritesStatusToErrWriter(t *testing.T) {
+	set := flag.NewFlagSet("test", flag.ContinueOnError)
+	set.Bool("json", true, "")
+	var stderr bytes.Buffer
+	app := cli.NewApp()
+	app.ErrWriter = &stderr
+	SetLogger(app, NewLogger(false))
+	c := cli.NewContext(app, set, nil)
+
+	loggerFrom(c).Println("1. Creating API client...")
+	if stderr.String() != "1. Creating API client...\n" {
+		t.Errorf("stderr = %q, want the status line", stderr.String())
+	}
+}
+
+func TestWriteTreeJSONIncludesMetadataMarkers(t *testing.T) {
+	treeExport := &TreeExport{
+		TreeID:      "42",
+		PersonCount: 1,
+		Persons:     []ancestry.Person{{PID: "1", GivenName: "Ada"}},
+		Restricted:  []restrictedPerson{{PersonID: "2", Parts: []string{RestrictedFacts}}},
+		Redacted:    true,
+		Incomplete:  true,
+	}
+	var buf bytes.Buffer
+	if err := writeTreeJSON(&buf, treeExport, map[string]PersonRelationship{}); err != nil {
+		t.Fatalf("writeTreeJSON() error = %v", err)
+	}
+
+	var export map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &export); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"treeId", "redacted", "incomplete", "restrictedPersons", "persons"} {
+		if _, ok := export[key]; !ok {
+			t.Errorf("export has no %q: %s", key, buf.String())
+		}
+	}
+	if _, ok := export["mediaExcluded"]; ok {
+		t.Errorf("export has mediaExcluded without --exclude-media: %s", buf.String())
+	}
+}
@@ -0,0 +1,38 @@
+package commands
+
+import (
+	"strings"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+	"golang.org/x/net/html"
+)
+
+// stripHTML removes HTML tags from s and decodes its entities. Line breaks and block
+// elements become spaces so words on either side aren't joined.
+func stripHTML(s string) string {
+	if !strings.ContainsAny(s, "<&") {
+		return s
+	}
+
+	var sb strings.Builder
+	tokenizer := html.NewTokenizer(strings.NewReader(s))
+	for {
+		switch tokenizer.Next() {
+		case html.ErrorToken:
+			return strings.Join(strings.Fields(sb.String()), " ")
+		case html.TextToken:
+			sb.Write(tokenizer.Text())
+		case html.StartTagToken, html.EndTagToken, html.SelfClosingTagToken:
+			sb.WriteString(" ")
+		}
+	}
+}
+
+// stripEventDescriptionsHTML strips HTML from the description of every person's events
+func stripEventDescriptionsHTML(persons []ancestry.Person) {
+	for i := range persons {
+		for j := range persons[i].Events {
+			persons[i].Events[j].Description = stripHTML(persons[i].Events[j].Description)
+		}
+	}
+}
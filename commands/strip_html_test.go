@@ -0,0 +1,25 @@
+package commands
+
+import "testing"
+
+func TestStripHTML(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "Farmer", want: "Farmer"},
+		{in: "<b>Farmer</b> &amp; miller", want: "Farmer & miller"},
+		{in: "Line one<br>Line two", want: "Line one Line two"},
+		{in: "<p>Lived at <a href=\"x\">Elm St</a></p>", want: "Lived at Elm St"},
+		{in: "Fish &lt; 5 lbs", want: "Fish < 5 lbs"},
+		{in: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			if got := stripHTML(tt.in); got != tt.want {
+				t.Errorf("stripHTML(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,15 @@
+package commands
+
+import "strings"
+
+// parseTags splits a comma-separated --tags value into tag names, dropping empty entries.
+// Tags are matched by the server, so their case is kept as given.
+func parseTags(value string) []string {
+	var tags []string
+	for _, part := range strings.Split(value, ",") {
+		if tag := strings.TrimSpace(part); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
@@ -0,0 +1,24 @@
+package commands
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseTags(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []string
+	}{
+		{"", nil},
+		{"Research", []string{"Research"}},
+		{" Research , Verified,", []string{"Research", "Verified"}},
+		{",,", nil},
+	}
+
+	for _, tt := range tests {
+		if got := parseTags(tt.input); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseTags(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
@@ -27,7 +27,7 @@ func TestBrowser(c *cli.Context) error {
 
 	// Create a new client
 	fmt.Println("1. Creating browser client...")
-	client, err := ancestry.NewClient()
+	client, err := ancestry.NewClientWithTLS(tlsOptionsFromFlags(c))
 	if err != nil {
 		return fmt.Errorf("failed to create client: %w", err)
 	}
@@ -195,9 +195,16 @@ func extractAndDisplayCookies(client *ancestry.Client) error {
 // displayCapturedRequests displays and optionally saves captured network requests
 func displayCapturedRequests(client *ancestry.Client, c *cli.Context) error {
 	requests := client.GetCapturedRequests()
+	filter := captureFilter{URLContains: c.String("filter"), APIOnly: c.Bool("api-only")}
+	captured := len(requests)
+	requests = filter.apply(requests)
 
 	fmt.Println("\n=== CAPTURED NETWORK REQUESTS ===")
-	fmt.Printf("Total requests captured: %d\n\n", len(requests))
+	if len(requests) != captured {
+		fmt.Printf("Total requests captured: %d (%d matching --filter/--api-only)\n\n", captured, len(requests))
+	} else {
+		fmt.Printf("Total requests captured: %d\n\n", captured)
+	}
 
 	if len(requests) == 0 {
 		fmt.Println("No API requests were captured.")
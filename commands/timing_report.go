@@ -0,0 +1,96 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+)
+
+// timingsFile records how long each phase of a download-tree run and each endpoint took (--timing-report)
+const timingsFile = "timings.json"
+
+// Phases of a tree download timed for timings.json
+const (
+	phasePersonFetch       = "person fetch"
+	phaseRelationshipBuild = "relationship build"
+	phaseFactsFetch        = "facts fetch"
+	phaseMediaDownload     = "media download"
+)
+
+// phaseTiming is how long one phase of a download took
+type phaseTiming struct {
+	Phase   string  `json:"phase"`
+	Seconds float64 `json:"seconds"`
+}
+
+// phaseTimings collects the duration of each phase of a download. A nil *phaseTimings
+// records nothing, so phases can be timed unconditionally.
+type phaseTimings struct {
+	mu     sync.Mutex
+	phases []phaseTiming
+}
+
+// start begins timing a phase and returns the function that ends it
+func (t *phaseTimings) start(phase string) func() {
+	if t == nil {
+		return func() {}
+	}
+	begin := time.Now()
+	return func() {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		t.phases = append(t.phases, phaseTiming{Phase: phase, Seconds: time.Since(begin).Seconds()})
+	}
+}
+
+// list returns the timed phases in the order they ended
+func (t *phaseTimings) list() []phaseTiming {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]phaseTiming{}, t.phases...)
+}
+
+// timingReport is the content of timings.json: the settings that affect speed, the duration
+// of each phase, and the latency of each endpoint. It is only written locally, for tuning
+// --concurrency and --media-concurrency to the connection.
+type timingReport struct {
+	TreeID           string                    `json:"treeId"`
+	TotalSeconds     float64                   `json:"totalSeconds"`
+	Concurrency      int                       `json:"concurrency"`
+	MediaConcurrency int                       `json:"mediaConcurrency"`
+	PageSize         int                       `json:"pageSize"`
+	Phases           []phaseTiming             `json:"phases"`
+	Endpoints        []ancestry.EndpointTiming `json:"endpoints"`
+}
+
+// newTimingReport builds the timing report of a run that started at start
+func newTimingReport(treeID string, start time.Time, opts downloadTreeOptions, endpoints []ancestry.EndpointTiming) timingReport {
+	return timingReport{
+		TreeID:           treeID,
+		TotalSeconds:     time.Since(start).Seconds(),
+		Concurrency:      opts.Concurrency,
+		MediaConcurrency: opts.MediaConcurrency,
+		PageSize:         opts.PageSize,
+		Phases:           opts.Timings.list(),
+		Endpoints:        endpoints,
+	}
+}
+
+// saveTimingReport writes timings.json into the output directory, only warning if it can't
+func saveTimingReport(outputDir string, report timingReport) {
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err == nil {
+		err = os.WriteFile(filepath.Join(outputDir, timingsFile), data, 0644)
+	}
+	if err != nil {
+		fmt.Printf("   Warning: Failed to write %s: %v\n", timingsFile, err)
+	}
+}
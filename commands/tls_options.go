@@ -0,0 +1,46 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+	"github.com/urfave/cli/v2"
+)
+
+// insecureWarning is printed whenever --insecure turns off certificate verification
+const insecureWarning = `
+   ***************************************************************************
+   WARNING: --insecure disables TLS certificate verification. Anyone on the
+   network path can read or alter your Ancestry session and data. Prefer
+   --ca-cert with your proxy's CA certificate.
+   ***************************************************************************
+`
+
+// tlsOptionsFromFlags reads the global --ca-cert and --insecure flags, warning loudly about --insecure
+func tlsOptionsFromFlags(c *cli.Context) ancestry.TLSOptions {
+	opts := ancestry.TLSOptions{
+		CACertFile: c.String("ca-cert"),
+		Insecure:   c.Bool("insecure"),
+	}
+	if opts.Insecure {
+		// Status output may be going to stdout as data, so the warning goes to stderr
+		fmt.Fprint(os.Stderr, insecureWarning)
+	}
+	return opts
+}
+
+// configureAPIClient applies the global --user-agent, --ca-cert and --insecure flags and the
+// command's logger to a new API client. The client is closed if the TLS settings can't be applied.
+func configureAPIClient(c *cli.Context, apiClient *ancestry.APIClient) error {
+	apiClient.SetUserAgent(c.String("user-agent"))
+	setClientLogger(c, apiClient)
+	if err := apiClient.SetTLSOptions(tlsOptionsFromFlags(c)); err != nil {
+		if closeErr := apiClient.Close(); closeErr != nil {
+			_, status := commandOutput(c)
+			fmt.Fprintf(status, "Error closing API client: %v\n", closeErr)
+		}
+		return err
+	}
+	return nil
+}
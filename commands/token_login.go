@@ -0,0 +1,38 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+	"github.com/urfave/cli/v2"
+)
+
+// usesTokenLogin reports whether login was given a token or cookies instead of a username and password
+func usesTokenLogin(c *cli.Context) bool {
+	return c.String("token") != "" || len(c.StringSlice("cookie")) > 0
+}
+
+// tokenLogin stores a session built from --token and --cookie values, without launching a
+// browser, so login works where Chromium can't run
+func tokenLogin(c *cli.Context) error {
+	if c.String("username") != "" || c.String("password") != "" {
+		return fmt.Errorf("--token and --cookie can't be combined with --username or --password")
+	}
+
+	fmt.Println("1. Building session cookies...")
+	cookies, err := ancestry.BuildSessionCookies(c.String("token"), c.StringSlice("cookie"))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("   ✓ Built %d cookie(s)\n", len(cookies))
+
+	hint := fmt.Sprintf("Copy a fresh %s value (or other session cookies) from a logged-in browser session", ancestry.SessionTokenCookie)
+	if err := saveValidatedCookies(c, cookies, hint); err != nil {
+		return err
+	}
+
+	fmt.Println()
+	fmt.Println("✅ Authentication completed successfully!")
+	fmt.Println()
+	return nil
+}
@@ -0,0 +1,49 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+)
+
+const (
+	// treeInfoAttempts is how many times the tree info endpoint is tried before falling back to the tree list
+	treeInfoAttempts = 3
+	// treeInfoBackoff is the wait before the first retry of the tree info endpoint; it doubles after each retry
+	treeInfoBackoff = 2 * time.Second
+)
+
+// lookupTreeInfo fetches the tree's metadata, retrying the info endpoint on transient errors.
+// If it keeps failing, the tree name is taken from the tree list instead, so the export is
+// still named. It always returns tree info, with just the ID if neither source answers. What
+// was found is reported to status.
+func lookupTreeInfo(ctx context.Context, getTreeInfo func(context.Context, string) (*ancestry.TreeInfo, error), listTrees func(context.Context) ([]ancestry.Tree, error),
+	treeID string, status io.Writer) *ancestry.TreeInfo {
+	var treeInfo *ancestry.TreeInfo
+	err := withRetry("Tree info", treeInfoAttempts, treeInfoBackoff, isAuthFailure, status, func() error {
+		var err error
+		treeInfo, err = getTreeInfo(ctx, treeID)
+		return err
+	})
+	if err == nil {
+		fmt.Fprintf(status, "   ✓ Tree: %s\n", treeInfo.TreeName)
+		return treeInfo
+	}
+
+	fmt.Fprintf(status, "   Warning: Could not fetch tree info: %v\n", err)
+	trees, listErr := listTrees(ctx)
+	if listErr != nil {
+		fmt.Fprintf(status, "   Warning: Could not look up the tree name in your tree list: %v\n", listErr)
+		return &ancestry.TreeInfo{TreeID: treeID}
+	}
+	tree, found := findTree(trees, treeID)
+	if !found {
+		fmt.Fprintf(status, "   Warning: Tree %s is not in your tree list\n", treeID)
+		return &ancestry.TreeInfo{TreeID: treeID}
+	}
+	fmt.Fprintf(status, "   ✓ Tree: %s (from your tree list)\n", tree.Name)
+	return &ancestry.TreeInfo{TreeID: treeID, TreeName: tree.Name, TreeDescription: tree.Description}
+}
@@ -0,0 +1,103 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+)
+
+// noRetrySleep makes withRetry retry without waiting for the rest of the test
+func noRetrySleep(t *testing.T) {
+	t.Helper()
+	retrySleep = func(time.Duration) {}
+	t.Cleanup(func() { retrySleep = time.Sleep })
+}
+
+func TestWithRetry(t *testing.T) {
+	noRetrySleep(t)
+	transient := errors.New("API request failed with status 503")
+	tests := []struct {
+		name      string
+		errs      []error
+		wantCalls int
+		wantErr   bool
+	}{
+		{"succeeds first time", []error{nil}, 1, false},
+		{"succeeds after retries", []error{transient, transient, nil}, 3, false},
+		{"gives up after attempts", []error{transient, transient, transient, nil}, 3, true},
+		{"stops on permanent error", []error{&ancestry.StatusError{Code: http.StatusForbidden}, nil}, 1, true},
+		{"stops while circuit is open", []error{ancestry.ErrCircuitOpen, nil}, 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			err := withRetry("Test", 3, time.Second, isAuthFailure, io.Discard, func() error {
+				err := tt.errs[calls]
+				calls++
+				return err
+			})
+			if calls != tt.wantCalls {
+				t.Errorf("got %d calls, want %d", calls, tt.wantCalls)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("got error %v, want error: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLookupTreeInfo(t *testing.T) {
+	noRetrySleep(t)
+	failing := func(context.Context, string) (*ancestry.TreeInfo, error) { return nil, errors.New("timeout") }
+	trees := []ancestry.Tree{{ID: "1", Name: "Other"}, {ID: "2", Name: "Smith Family", Description: "Our tree"}}
+
+	tests := []struct {
+		name        string
+		getTreeInfo func(context.Context, string) (*ancestry.TreeInfo, error)
+		listTrees   func(context.Context) ([]ancestry.Tree, error)
+		want        ancestry.TreeInfo
+	}{
+		{
+			name: "info endpoint",
+			getTreeInfo: func(_ context.Context, id string) (*ancestry.TreeInfo, error) {
+				return &ancestry.TreeInfo{TreeID: id, TreeName: "From info"}, nil
+			},
+			listTrees: func(context.Context) ([]ancestry.Tree, error) {
+				t.Fatal("tree list should not be fetched")
+				return nil, nil
+			},
+			want: ancestry.TreeInfo{TreeID: "2", TreeName: "From info"},
+		},
+		{
+			name:        "falls back to tree list",
+			getTreeInfo: failing,
+			listTrees:   func(context.Context) ([]ancestry.Tree, error) { return trees, nil },
+			want:        ancestry.TreeInfo{TreeID: "2", TreeName: "Smith Family", TreeDescription: "Our tree"},
+		},
+		{
+			name:        "tree missing from list",
+			getTreeInfo: failing,
+			listTrees:   func(context.Context) ([]ancestry.Tree, error) { return trees[:1], nil },
+			want:        ancestry.TreeInfo{TreeID: "2"},
+		},
+		{
+			name:        "tree list fails too",
+			getTreeInfo: failing,
+			listTrees:   func(context.Context) ([]ancestry.Tree, error) { return nil, errors.New("timeout") },
+			want:        ancestry.TreeInfo{TreeID: "2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := lookupTreeInfo(context.Background(), tt.getTreeInfo, tt.listTrees, "2", io.Discard)
+			if got == nil || *got != tt.want {
+				t.Errorf("lookupTreeInfo(ctx, ) = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Built-in output formats for --formats
+const (
+	// TreeFormatJSON writes people.json and metadata.json
+	TreeFormatJSON = "json"
+	// TreeFormatHTML writes the index.html and person.html viewer
+	TreeFormatHTML = "html"
+)
+
+// defaultTreeFormats are written when no --formats are given
+var defaultTreeFormats = []string{TreeFormatJSON, TreeFormatHTML}
+
+// TreeWriter writes a downloaded tree in one output format
+type TreeWriter interface {
+	Write(ctx context.Context, export *TreeExport, relationships map[string]PersonRelationship, mediaIndex map[string]PersonMediaInfo) error
+}
+
+// TreeWriterFactory creates a TreeWriter that writes its files into outputDir
+type TreeWriterFactory func(outputDir string) TreeWriter
+
+var (
+	treeWritersMu sync.RWMutex
+	treeWriters   = make(map[string]TreeWriterFactory)
+)
+
+// RegisterTreeWriter makes an output format available to --formats under the given name.
+// Registering a name twice replaces the earlier writer.
+func RegisterTreeWriter(format string, factory TreeWriterFactory) {
+	treeWritersMu.Lock()
+	defer treeWritersMu.Unlock()
+	treeWriters[strings.ToLower(format)] = factory
+}
+
+// lookupTreeWriter returns the writer factory registered for format
+func lookupTreeWriter(format string) (TreeWriterFactory, bool) {
+	treeWritersMu.RLock()
+	defer treeWritersMu.RUnlock()
+	factory, ok := treeWriters[format]
+	return factory, ok
+}
+
+// registeredTreeFormats returns the names of all registered formats, sorted
+func registeredTreeFormats() []string {
+	treeWritersMu.RLock()
+	defer treeWritersMu.RUnlock()
+	formats := make([]string, 0, len(treeWriters))
+	for format := range treeWriters {
+		formats = append(formats, format)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+// printTreeFormats lists the registered formats for --list-formats to w, marking the defaults
+func printTreeFormats(w io.Writer) {
+	fmt.Fprintln(w, "Output formats for --formats:")
+	for _, format := range registeredTreeFormats() {
+		if hasTreeFormat(defaultTreeFormats, format) {
+			fmt.Fprintf(w, "  %s (default)\n", format)
+		} else {
+			fmt.Fprintf(w, "  %s\n", format)
+		}
+	}
+}
+
+// parseTreeFormats parses a comma-separated --formats value, dropping duplicates.
+// An empty value selects the default formats.
+func parseTreeFormats(value string) ([]string, error) {
+	if strings.TrimSpace(value) == "" {
+		return append([]string(nil), defaultTreeFormats...), nil
+	}
+
+	var formats []string
+	seen := make(map[string]bool)
+	for _, part := range strings.Split(value, ",") {
+		format := strings.ToLower(strings.TrimSpace(part))
+		if format == "" || seen[format] {
+			continue
+		}
+		if _, ok := lookupTreeWriter(format); !ok {
+			return nil, fmt.Errorf("unknown format '%s'; supported: %s\n\nRun 'ancestrydl download-tree --list-formats' to list them",
+				format, strings.Join(registeredTreeFormats(), ", "))
+		}
+		seen[format] = true
+		formats = append(formats, format)
+	}
+	return formats, nil
+}
+
+// hasTreeFormat reports whether format is one of formats
+func hasTreeFormat(formats []string, format string) bool {
+	for _, f := range formats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// writeTreeFormats runs the writer of each format, reporting to logger what it wrote and, as warnings, what failed.
+// It returns an error if any writer failed.
+func writeTreeFormats(ctx context.Context, outputDir string, formats []string, export *TreeExport,
+	relationships map[string]PersonRelationship, mediaIndex map[string]PersonMediaInfo, logger *log.Logger) error {
+	failed := 0
+	for _, format := range formats {
+		factory, ok := lookupTreeWriter(format)
+		if !ok {
+			logger.Printf("   Warning: No writer registered for format %q\n", format)
+			failed++
+			continue
+		}
+		if err := factory(outputDir).Write(ctx, export, relationships, mediaIndex); err != nil {
+			logger.Printf("   Warning: Failed to write %s output: %v\n", format, err)
+			failed++
+			continue
+		}
+		logger.Printf("   ✓ Wrote %s output\n", format)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to write %d of %d output format(s)", failed, len(formats))
+	}
+	return nil
+}
+
+// jsonTreeWriter writes people.json and metadata.json
+type jsonTreeWriter struct {
+	outputDir string
+}
+
+// Write implements TreeWriter
+func (w jsonTreeWriter) Write(_ context.Context, export *TreeExport, relationships map[string]PersonRelationship, mediaIndex map[string]PersonMediaInfo) error {
+	if err := savePersonsData(w.outputDir, export.Persons, relationships, mediaIndex, export.RecordIndex, export.Compress); err != nil {
+		return err
+	}
+	return saveMetadata(w.outputDir, export)
+}
+
+// htmlTreeWriter writes the self-contained HTML viewer
+type htmlTreeWriter struct {
+	outputDir string
+}
+
+// Write implements TreeWriter
+func (w htmlTreeWriter) Write(_ context.Context, export *TreeExport, relationships map[string]PersonRelationship, mediaIndex map[string]PersonMediaInfo) error {
+	return generateHTMLViewer(w.outputDir, export, relationships, mediaIndex)
+}
+
+// graphTreeWriter writes the relationship graph in GraphML or DOT
+type graphTreeWriter struct {
+	outputDir string
+	format    string
+}
+
+// Write implements TreeWriter
+func (w graphTreeWriter) Write(_ context.Context, export *TreeExport, relationships map[string]PersonRelationship, _ map[string]PersonMediaInfo) error {
+	_, err := saveRelationshipGraph(w.outputDir, w.format, export.Persons, relationships)
+	return err
+}
+
+func init() {
+	RegisterTreeWriter(TreeFormatJSON, func(outputDir string) TreeWriter { return jsonTreeWriter{outputDir: outputDir} })
+	RegisterTreeWriter(TreeFormatHTML, func(outputDir string) TreeWriter { return htmlTreeWriter{outputDir: outputDir} })
+	for _, format := range []string{GraphFormatGraphML, GraphFormatDOT} {
+		RegisterTreeWriter(format, func(outputDir string) TreeWriter {
+			return graphTreeWriter{outputDir: outputDir, format: format}
+		})
+	}
+}
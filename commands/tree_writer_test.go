@@ -0,0 +1,109 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseTreeFormats(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    []string
+		wantErr bool
+	}{
+		{value: "", want: []string{TreeFormatJSON, TreeFormatHTML}},
+		{value: "JSON", want: []string{TreeFormatJSON}},
+		{value: "html, json,html", want: []string{TreeFormatHTML, TreeFormatJSON}},
+		{value: "json,dot", want: []string{TreeFormatJSON, GraphFormatDOT}},
+		{value: "json,pdf", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseTreeFormats(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseTreeFormats(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseTreeFormats(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+// recordingTreeWriter records the export it was asked to write
+type recordingTreeWriter struct {
+	outputDir string
+	written   *[]string
+	err       error
+}
+
+func (w recordingTreeWriter) Write(_ context.Context, export *TreeExport, _ map[string]PersonRelationship, _ map[string]PersonMediaInfo) error {
+	*w.written = append(*w.written, w.outputDir+":"+export.TreeID)
+	return w.err
+}
+
+// registerTestTreeWriter registers a writer for the duration of the test, so it doesn't
+// show up in the formats other tests see
+func registerTestTreeWriter(t *testing.T, format string, factory TreeWriterFactory) {
+	t.Helper()
+	RegisterTreeWriter(format, factory)
+	t.Cleanup(func() {
+		treeWritersMu.Lock()
+		defer treeWritersMu.Unlock()
+		delete(treeWriters, format)
+	})
+}
+
+func TestWriteTreeFormatsCustomWriter(t *testing.T) {
+	var written []string
+	registerTestTreeWriter(t, "test-ok", func(outputDir string) TreeWriter {
+		return recordingTreeWriter{outputDir: outputDir, written: &written}
+	})
+	registerTestTreeWriter(t, "test-fail", func(outputDir string) TreeWriter {
+		return recordingTreeWriter{outputDir: outputDir, written: &written, err: errors.New("disk full")}
+	})
+
+	formats, err := parseTreeFormats("test-ok")
+	if err != nil {
+		t.Fatalf("parseTreeFormats() error = %v", err)
+	}
+
+	export := &TreeExport{TreeID: "123"}
+	if err := writeTreeFormats(context.Background(), "out", formats, export, nil, nil, defaultLogger); err != nil {
+		t.Fatalf("writeTreeFormats() error = %v", err)
+	}
+	var logged bytes.Buffer
+	if err := writeTreeFormats(context.Background(), "out", []string{"test-fail", "test-ok"}, export, nil, nil, log.New(&logged, "", 0)); err == nil {
+		t.Error("writeTreeFormats() error = nil, want error from failing writer")
+	}
+	if !strings.Contains(logged.String(), "Failed to write test-fail output: disk full") {
+		t.Errorf("logged %q, want the failed writer reported to the logger", logged.String())
+	}
+
+	want := []string{"out:123", "out:123", "out:123"}
+	if !reflect.DeepEqual(written, want) {
+		t.Errorf("writers called with %v, want %v", written, want)
+	}
+}
+
+func TestParseTreeFormatsListsSupported(t *testing.T) {
+	_, err := parseTreeFormats("json,xml")
+	if err == nil {
+		t.Fatal("parseTreeFormats() error = nil, want unknown format error")
+	}
+	msg := err.Error()
+	if !strings.Contains(msg, "unknown format 'xml'; supported: ") {
+		t.Errorf("error %q does not name the unknown format", msg)
+	}
+	for _, format := range []string{TreeFormatJSON, TreeFormatHTML, GraphFormatDOT} {
+		if !strings.Contains(msg, format) {
+			t.Errorf("error %q does not list %s", msg, format)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package commands
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+	"github.com/urfave/cli/v2"
+)
+
+// truncationEllipsis ends text shortened by truncateText
+const truncationEllipsis = "…"
+
+// truncateText shortens s to at most maxLen characters, the last being an ellipsis, and
+// reports whether it was shortened. A maxLen of 0 or less keeps s whole.
+func truncateText(s string, maxLen int) (string, bool) {
+	if maxLen <= 0 || utf8.RuneCountInString(s) <= maxLen {
+		return s, false
+	}
+	runes := []rune(s)
+	return strings.TrimRightFunc(string(runes[:maxLen-1]), unicode.IsSpace) + truncationEllipsis, true
+}
+
+// truncateEventDescriptions shortens event descriptions longer than maxLen characters, marking
+// each shortened event. It returns the number of descriptions shortened.
+func truncateEventDescriptions(persons []ancestry.Person, maxLen int) int {
+	truncated := 0
+	for i := range persons {
+		for j := range persons[i].Events {
+			event := &persons[i].Events[j]
+			if description, ok := truncateText(event.Description, maxLen); ok {
+				event.Description = description
+				event.DescriptionTruncated = true
+				truncated++
+			}
+		}
+	}
+	return truncated
+}
+
+// truncateText shortens the media file's title and description to at most maxLen characters each
+func (m *MediaFileInfo) truncateText(maxLen int) {
+	m.Title, m.TitleTruncated = truncateText(m.Title, maxLen)
+	m.Description, m.DescriptionTruncated = truncateText(m.Description, maxLen)
+}
+
+// parseMaxDescriptionLength reads --max-description-length, returning 0 (no limit) with --full-descriptions
+func parseMaxDescriptionLength(c *cli.Context) (int, error) {
+	if c.Bool("full-descriptions") {
+		return 0, nil
+	}
+	maxLen := c.Int("max-description-length")
+	if maxLen < 1 {
+		return 0, fmt.Errorf("--max-description-length must be at least 1; use --full-descriptions to keep descriptions whole")
+	}
+	return maxLen, nil
+}
@@ -0,0 +1,68 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+)
+
+func TestTruncateText(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		maxLen    int
+		want      string
+		truncated bool
+	}{
+		{"short text kept", "Born at home", 20, "Born at home", false},
+		{"exactly the limit", "abcde", 5, "abcde", false},
+		{"long text shortened", "abcdefghij", 5, "abcd…", true},
+		{"trailing space dropped", "abc defghij", 5, "abc…", true},
+		{"counts characters not bytes", "éééééé", 4, "ééé…", true},
+		{"no limit", strings.Repeat("x", 5000), 0, strings.Repeat("x", 5000), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, truncated := truncateText(tt.input, tt.maxLen)
+			if got != tt.want || truncated != tt.truncated {
+				t.Errorf("truncateText(%q, %d) = %q, %v; want %q, %v", tt.input, tt.maxLen, got, truncated, tt.want, tt.truncated)
+			}
+		})
+	}
+}
+
+func TestTruncateEventDescriptions(t *testing.T) {
+	persons := []ancestry.Person{{Events: []ancestry.Event{
+		{Description: "short"},
+		{Description: strings.Repeat("a", 30)},
+	}}}
+
+	if got := truncateEventDescriptions(persons, 10); got != 1 {
+		t.Errorf("truncateEventDescriptions() = %d, want 1", got)
+	}
+	events := persons[0].Events
+	if events[0].DescriptionTruncated || events[0].Description != "short" {
+		t.Errorf("short description changed: %+v", events[0])
+	}
+	if !events[1].DescriptionTruncated || events[1].Description != strings.Repeat("a", 9)+"…" {
+		t.Errorf("long description not shortened: %+v", events[1])
+	}
+
+	readable := convertEventToReadableFormat(events[1])
+	if readable["descriptionTruncated"] != true {
+		t.Errorf("readable event missing descriptionTruncated: %v", readable)
+	}
+}
+
+func TestMediaFileInfoTruncateText(t *testing.T) {
+	info := MediaFileInfo{Title: strings.Repeat("t", 12), Description: "fine"}
+	info.truncateText(10)
+	if !info.TitleTruncated || len([]rune(info.Title)) != 10 {
+		t.Errorf("title not shortened: %+v", info)
+	}
+	if info.DescriptionTruncated || info.Description != "fine" {
+		t.Errorf("short description changed: %+v", info)
+	}
+}
@@ -2,6 +2,7 @@ package commands
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 	"os"
@@ -25,9 +26,12 @@ func ExtractMediaDetailsFromURL(mediaURL string) (namespace, mediaGUID string, o
 	return "", "", false
 }
 
-// DownloadAndSaveRecordImage downloads a record image and saves it to the media directory.
-// It handles filename generation and error logging.
-func DownloadAndSaveRecordImage(writer, errWriter io.Writer, client *ancestry.APIClient, recordImageUrl, sourceID, mediaDir, relativePathPrefix string) (string, error) {
+// DownloadAndSaveRecordImage downloads the record image of one of the owner's sources and saves it to the
+// media directory. It handles filename generation and error logging. An image URL whose security token
+// has expired is refreshed from the source once.
+func DownloadAndSaveRecordImage(ctx context.Context, writer, errWriter io.Writer, client *ancestry.APIClient, owner ancestry.MediaOwner,
+	source ancestry.PersonSourceDetail, mediaDir, relativePathPrefix string) (string, error) {
+	recordImageUrl, sourceID := source.RecordImageUrl, source.CitationId
 	if recordImageUrl == "" {
 		return "", nil
 	}
@@ -36,7 +40,7 @@ func DownloadAndSaveRecordImage(writer, errWriter io.Writer, client *ancestry.AP
 		_, _ = fmt.Fprintf(writer, "Downloading record image for source %s...\n", sourceID)
 	}
 
-	imageData, err := client.DownloadRecordImage(recordImageUrl)
+	imageData, err := client.DownloadRecordImageWithRefresh(ctx, owner, source)
 	if err != nil {
 		if errWriter != nil {
 			_, _ = fmt.Fprintf(errWriter, "[Warning] Failed to download record image for source %s: %v\n", sourceID, err)
@@ -0,0 +1,116 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	_ "image/gif"  // Register the GIF decoder for image.DecodeConfig
+	_ "image/jpeg" // Register the JPEG decoder for image.DecodeConfig
+	_ "image/png"  // Register the PNG decoder for image.DecodeConfig
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+)
+
+// imageDimensions decodes the header of downloaded media, returning the image's width and height.
+// PDFs and WebP images can't be decoded here, so they report zero dimensions without an error.
+func imageDimensions(data []byte) (width, height int, err error) {
+	switch DetectFileExtension(data) {
+	case ".pdf", ".webp":
+		return 0, 0, nil
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return 0, 0, fmt.Errorf("not a valid image (starts with %q): %w", mediaDataPrefix(data), err)
+	}
+	if config.Width == 0 || config.Height == 0 {
+		return 0, 0, fmt.Errorf("image has no dimensions (%dx%d)", config.Width, config.Height)
+	}
+	return config.Width, config.Height, nil
+}
+
+// mediaDataPrefix returns the start of data as text, which shows what an error page was
+func mediaDataPrefix(data []byte) string {
+	prefix := string(data[:min(len(data), 32)])
+	return strings.ToValidUTF8(prefix, "?")
+}
+
+// setImageDimensions records the width and height of data in info when it is an image that decodes
+func (info *MediaFileInfo) setImageDimensions(data []byte) {
+	if width, height, err := imageDimensions(data); err == nil {
+		info.Width, info.Height = width, height
+	}
+}
+
+// validMediaFile reports whether a file saved by an earlier run decodes as an image, recording its
+// dimensions in info. Files that fail are removed so they are downloaded again.
+func validMediaFile(outputDir string, info *MediaFileInfo, opts downloadTreeOptions) bool {
+	path := filepath.Join(outputDir, info.FilePath)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return false
+	}
+	width, height, err := imageDimensions(data)
+	if err != nil {
+		opts.Log.Printf("   [Warning] %s is %v; downloading it again (--validate-media)\n", info.FilePath, err)
+		if err := os.Remove(path); err != nil {
+			opts.Log.Printf("   [Warning] Failed to remove %s: %v\n", info.FilePath, err)
+		}
+		return false
+	}
+	info.Width, info.Height = width, height
+	return true
+}
+
+// validatedMediaData checks that downloaded media decodes as an image, downloading it once more
+// if it doesn't, since a 200 response can carry an HTML error page instead of the image
+func validatedMediaData(data []byte, refetch func() ([]byte, error), mediaURL string, opts downloadTreeOptions) ([]byte, error) {
+	_, _, err := imageDimensions(data)
+	if err == nil {
+		return data, nil
+	}
+	opts.Log.Printf("   [Warning] Media from %s is %v; downloading it again (--validate-media)\n", mediaURL, err)
+
+	data, err = refetch()
+	if err != nil {
+		return nil, fmt.Errorf("re-download of invalid media %s failed: %w", mediaURL, err)
+	}
+	if _, _, err := imageDimensions(data); err != nil {
+		return nil, fmt.Errorf("media %s is still invalid after downloading it again: %w", mediaURL, err)
+	}
+	return data, nil
+}
+
+// mediaRefetcher returns a function that downloads a media item again, without cache validators
+func mediaRefetcher(ctx context.Context, apiClient *ancestry.APIClient, owner ancestry.MediaOwner, mediaItem ancestry.PrimaryMediaItem) func() ([]byte, error) {
+	return func() ([]byte, error) {
+		if namespace, mediaGUID, ok := ExtractMediaDetailsFromURL(mediaItem.URL); ok {
+			if data, err := apiClient.GetMediaImage(ctx, namespace, mediaGUID, 0, 0); err == nil {
+				return data, nil
+			}
+		}
+		return apiClient.DownloadFile(ctx, owner, mediaItem.URL)
+	}
+}
+
+// previousValidators returns the cache validators of the media file downloaded by the previous
+// run, or none when that file is gone (or with --validate-media, isn't a valid image) and the
+// media has to be downloaded in full
+func previousValidators(outputDir string, previous *MediaFileInfo, opts downloadTreeOptions) ancestry.CacheValidators {
+	if previous == nil || (previous.ETag == "" && previous.LastModified == "") {
+		return ancestry.CacheValidators{}
+	}
+	if _, err := os.Stat(filepath.Join(outputDir, previous.FilePath)); err != nil {
+		return ancestry.CacheValidators{}
+	}
+	if opts.ValidateMedia {
+		kept := *previous
+		if !validMediaFile(outputDir, &kept, opts) {
+			return ancestry.CacheValidators{}
+		}
+	}
+	return ancestry.CacheValidators{ETag: previous.ETag, LastModified: previous.LastModified}
+}
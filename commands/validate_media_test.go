@@ -0,0 +1,92 @@
+package commands
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestImageDimensions(t *testing.T) {
+	tests := []struct {
+		name          string
+		data          []byte
+		width, height int
+		wantErr       bool
+	}{
+		{"png", testPNG(t, 3, 2), 3, 2, false},
+		{"html error page", []byte("<!DOCTYPE html><html><body>Error</body></html>"), 0, 0, true},
+		{"truncated jpeg", []byte{0xFF, 0xD8, 0xFF, 0xE0}, 0, 0, true},
+		{"pdf not decoded", []byte("%PDF-1.4 ..."), 0, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			width, height, err := imageDimensions(tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("imageDimensions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if width != tt.width || height != tt.height {
+				t.Errorf("imageDimensions() = %dx%d, want %dx%d", width, height, tt.width, tt.height)
+			}
+		})
+	}
+}
+
+func TestValidatedMediaData(t *testing.T) {
+	opts := downloadTreeOptions{Log: log.New(io.Discard, "", 0)}
+	errorPage := []byte("<html>Service Unavailable</html>")
+	img := testPNG(t, 4, 4)
+
+	refetches := 0
+	refetch := func() ([]byte, error) {
+		refetches++
+		return img, nil
+	}
+	data, err := validatedMediaData(img, refetch, "url", opts)
+	if err != nil || !bytes.Equal(data, img) || refetches != 0 {
+		t.Errorf("valid image: err = %v, refetches = %d", err, refetches)
+	}
+
+	data, err = validatedMediaData(errorPage, refetch, "url", opts)
+	if err != nil || !bytes.Equal(data, img) || refetches != 1 {
+		t.Errorf("error page: err = %v, refetches = %d", err, refetches)
+	}
+
+	_, err = validatedMediaData(errorPage, func() ([]byte, error) { return errorPage, nil }, "url", opts)
+	if err == nil {
+		t.Error("expected an error when the media is still invalid")
+	}
+
+	_, err = validatedMediaData(errorPage, func() ([]byte, error) { return nil, errors.New("offline") }, "url", opts)
+	if err == nil {
+		t.Error("expected an error when the re-download fails")
+	}
+}
+
+func TestValidMediaFile(t *testing.T) {
+	outputDir := t.TempDir()
+	opts := downloadTreeOptions{Log: log.New(io.Discard, "", 0)}
+	if err := os.WriteFile(filepath.Join(outputDir, "good.png"), testPNG(t, 5, 6), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(outputDir, "bad.jpg"), []byte("<html></html>"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	good := MediaFileInfo{FilePath: "good.png"}
+	if !validMediaFile(outputDir, &good, opts) || good.Width != 5 || good.Height != 6 {
+		t.Errorf("good file: %+v", good)
+	}
+
+	bad := MediaFileInfo{FilePath: "bad.jpg"}
+	if validMediaFile(outputDir, &bad, opts) {
+		t.Error("error page saved as .jpg should not be valid")
+	}
+	if _, err := os.Stat(filepath.Join(outputDir, "bad.jpg")); !os.IsNotExist(err) {
+		t.Error("invalid file should be removed so it is downloaded again")
+	}
+}
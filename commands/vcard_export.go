@@ -0,0 +1,154 @@
+package commands
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+)
+
+const (
+	// TreeFormatVCard writes every person as a contact in contacts.vcf
+	TreeFormatVCard = "vcard"
+	// vCardFile is the file the vcard format writes
+	vCardFile = "contacts.vcf"
+	// vCardLineLength is the longest line allowed before it is folded, in bytes (RFC 2426)
+	vCardLineLength = 75
+)
+
+// vCardEscaper escapes text values as vCard 3.0 requires
+var vCardEscaper = strings.NewReplacer(`\`, `\\`, ",", `\,`, ";", `\;`, "\r\n", `\n`, "\n", `\n`)
+
+// personNameParts returns a person's given name and surname from Names, or the flat fields
+func personNameParts(person ancestry.Person) (given, surname string) {
+	if len(person.Names) > 0 && (person.Names[0].GivenName != "" || person.Names[0].Surname != "") {
+		return person.Names[0].GivenName, person.Names[0].Surname
+	}
+	return person.GivenName, person.Surname
+}
+
+// vCardBirthday returns the person's birth date as YYYY-MM-DD, or "" unless the exact day is known
+func vCardBirthday(person ancestry.Person) string {
+	for _, event := range person.Events {
+		if event.Type != Birth {
+			continue
+		}
+		date, err := ancestry.ParseGenealogyDate(event.Date)
+		if err != nil || date.Qualifier != "" || date.Month == 0 || date.Day == 0 {
+			continue
+		}
+		return fmt.Sprintf("%04d-%02d-%02d", date.Year, date.Month, date.Day)
+	}
+	return ""
+}
+
+// relationshipNames returns the names of related persons, joined for a note
+func relationshipNames(refs []RelationshipReference) string {
+	names := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		name := ref.Name
+		if name == "" {
+			name = ref.PersonID
+		}
+		names = append(names, name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// vCardNote describes the person's lifespan and family for the contact's note
+func vCardNote(person ancestry.Person, relationship PersonRelationship) string {
+	var lines []string
+	if years := lifespanYears(person); years != "" {
+		lines = append(lines, "Lifespan: "+years)
+	}
+	for _, group := range []struct {
+		label string
+		refs  []RelationshipReference
+	}{
+		{"Parents", relationship.Parents},
+		{"Spouses", relationship.Spouses},
+		{"Children", relationship.Children},
+	} {
+		if len(group.refs) > 0 {
+			lines = append(lines, group.label+": "+relationshipNames(group.refs))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// foldVCardLine splits a content line longer than vCardLineLength bytes into continuation
+// lines starting with a space, without splitting a character
+func foldVCardLine(line string) string {
+	var b strings.Builder
+	limit := vCardLineLength
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut] + "\r\n ")
+		line = line[cut:]
+		limit = vCardLineLength - 1 // The leading space counts toward the limit
+	}
+	b.WriteString(line)
+	return b.String()
+}
+
+// renderVCard returns the vCard 3.0 entry of a person
+func renderVCard(person ancestry.Person, relationship PersonRelationship) string {
+	given, surname := personNameParts(person)
+	fullName := strings.TrimSpace(given + " " + surname)
+	if fullName == "" {
+		fullName = person.GetPersonID()
+	}
+
+	lines := []string{
+		"BEGIN:VCARD",
+		"VERSION:3.0",
+		fmt.Sprintf("N:%s;%s;;;", vCardEscaper.Replace(surname), vCardEscaper.Replace(given)),
+		"FN:" + vCardEscaper.Replace(fullName),
+	}
+	if birthday := vCardBirthday(person); birthday != "" {
+		lines = append(lines, "BDAY:"+birthday)
+	}
+	if note := vCardNote(person, relationship); note != "" {
+		lines = append(lines, "NOTE:"+vCardEscaper.Replace(note))
+	}
+	if personID := person.GetPersonID(); personID != "" {
+		lines = append(lines, "UID:ancestry-"+vCardEscaper.Replace(personID))
+	}
+	lines = append(lines, "END:VCARD")
+
+	var b strings.Builder
+	for _, line := range lines {
+		b.WriteString(foldVCardLine(line) + "\r\n")
+	}
+	return b.String()
+}
+
+// vCardTreeWriter writes every person as a contact in contacts.vcf
+type vCardTreeWriter struct {
+	outputDir string
+}
+
+// Write implements TreeWriter
+func (w vCardTreeWriter) Write(_ context.Context, export *TreeExport, relationships map[string]PersonRelationship, _ map[string]PersonMediaInfo) error {
+	var b strings.Builder
+	for _, person := range export.Persons {
+		b.WriteString(renderVCard(person, relationships[person.GetPersonID()]))
+	}
+	if err := os.WriteFile(filepath.Join(w.outputDir, vCardFile), []byte(b.String()), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", vCardFile, err)
+	}
+	return nil
+}
+
+func init() {
+	RegisterTreeWriter(TreeFormatVCard, func(outputDir string) TreeWriter {
+		return vCardTreeWriter{outputDir: outputDir}
+	})
+}
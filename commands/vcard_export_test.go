@@ -0,0 +1,93 @@
+package commands
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+)
+
+func TestRenderVCard(t *testing.T) {
+	person := ancestry.Person{
+		PID:   "1:1030:99",
+		Names: []ancestry.Name{{GivenName: "Mary Ann", Surname: "O'Brien; Smith"}},
+		Events: []ancestry.Event{
+			{Type: Birth, Date: "12 Mar 1950"},
+		},
+		IsLiving: true,
+	}
+	relationship := PersonRelationship{
+		Parents:  []RelationshipReference{{PersonID: "2", Name: "John O'Brien"}, {PersonID: "3", Name: "Jane Doe"}},
+		Children: []RelationshipReference{{PersonID: "4"}},
+	}
+
+	want := strings.Join([]string{
+		"BEGIN:VCARD",
+		"VERSION:3.0",
+		`N:O'Brien\; Smith;Mary Ann;;;`,
+		`FN:Mary Ann O'Brien\; Smith`,
+		"BDAY:1950-03-12",
+		`NOTE:Lifespan: 1950–\nParents: John O'Brien\, Jane Doe\nChildren: 4`,
+		"UID:ancestry-1:1030:99",
+		"END:VCARD",
+		"",
+	}, "\r\n")
+	if got := renderVCard(person, relationship); got != want {
+		t.Errorf("renderVCard() =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestVCardBirthday(t *testing.T) {
+	tests := []struct {
+		date interface{}
+		want string
+	}{
+		{"12 Mar 1950", "1950-03-12"},
+		{"Mar 1950", ""},
+		{"Abt 12 Mar 1950", ""},
+		{nil, ""},
+	}
+	for _, tt := range tests {
+		person := ancestry.Person{Events: []ancestry.Event{{Type: Birth, Date: tt.date}}}
+		if got := vCardBirthday(person); got != tt.want {
+			t.Errorf("vCardBirthday(%v) = %q, want %q", tt.date, got, tt.want)
+		}
+	}
+}
+
+func TestFoldVCardLine(t *testing.T) {
+	line := "NOTE:" + strings.Repeat("é", 100)
+	folded := foldVCardLine(line)
+	for i, part := range strings.Split(folded, "\r\n") {
+		if len(part) > vCardLineLength {
+			t.Errorf("line %d is %d bytes", i, len(part))
+		}
+		if i > 0 && !strings.HasPrefix(part, " ") {
+			t.Errorf("continuation line %d doesn't start with a space", i)
+		}
+	}
+	if unfolded := strings.ReplaceAll(folded, "\r\n ", ""); unfolded != line {
+		t.Errorf("unfolding changed the line: %q", unfolded)
+	}
+}
+
+func TestVCardTreeWriter(t *testing.T) {
+	dir := t.TempDir()
+	export := &TreeExport{Persons: []ancestry.Person{
+		{PID: "1", GivenName: "Ann"},
+		{PID: "2", Surname: "Lee"},
+	}}
+	if err := (vCardTreeWriter{outputDir: dir}).Write(context.Background(), export, nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, vCardFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(data), "BEGIN:VCARD"); n != 2 {
+		t.Errorf("wrote %d vCards, want 2", n)
+	}
+}
@@ -0,0 +1,146 @@
+package commands
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+	"github.com/chrisrob11/ancestrydl/pkg/config"
+	"github.com/urfave/cli/v2"
+)
+
+// minWatchInterval keeps watch from polling Ancestry too often
+const minWatchInterval = time.Minute
+
+// treeChanged reports whether a tree modified at modified needs downloading, given the
+// modification time of the last download. A tree without a modification time is
+// always downloaded, since there is no way to tell whether it changed.
+func treeChanged(modified, lastSeen time.Time) bool {
+	if modified.IsZero() || lastSeen.IsZero() {
+		return true
+	}
+	return modified.After(lastSeen)
+}
+
+// findTree returns the tree with the given ID from a tree list
+func findTree(trees []ancestry.Tree, treeID string) (ancestry.Tree, bool) {
+	for _, tree := range trees {
+		if tree.ID == treeID {
+			return tree, true
+		}
+	}
+	return ancestry.Tree{}, false
+}
+
+// fetchTreeModified looks up when the tree was last modified
+func fetchTreeModified(c *cli.Context, treeID string) (time.Time, error) {
+	logger := loggerFrom(c)
+	apiClient, err := createAPIClientFromStoredCookies(c)
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer func() {
+		if err := apiClient.Close(); err != nil {
+			logger.Printf("Error closing API client: %v\n", err)
+		}
+	}()
+
+	trees, err := apiClient.ListTrees(c.Context)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to retrieve trees: %w", err)
+	}
+
+	tree, found := findTree(trees, treeID)
+	if !found {
+		return time.Time{}, fmt.Errorf("tree %s not found in your tree list", treeID)
+	}
+	return getTreeModifiedDate(tree), nil
+}
+
+// checkAndDownloadTree downloads the tree if it changed since the last download and
+// records its modification time once the download succeeds
+func checkAndDownloadTree(c *cli.Context, treeID string) error {
+	logger := loggerFrom(c)
+	logger.Printf("[%s] Checking tree %s for changes...\n", time.Now().Format("2006-01-02 15:04:05"), treeID)
+
+	modified, err := fetchTreeModified(c, treeID)
+	if err != nil {
+		return err
+	}
+
+	lastSeen, err := config.GetLastWatchedModified(treeID)
+	if err != nil {
+		return fmt.Errorf("failed to read last download time: %w", err)
+	}
+
+	if !treeChanged(modified, lastSeen) {
+		logger.Printf("   ✓ Tree unchanged since %s\n", lastSeen.Format(time.RFC3339))
+		return nil
+	}
+
+	if modified.IsZero() {
+		logger.Println("   Tree has no modification time, downloading...")
+	} else {
+		logger.Printf("   Tree modified %s, downloading...\n", modified.Format(time.RFC3339))
+	}
+	logger.Println()
+
+	if err := DownloadTree(c); err != nil {
+		return fmt.Errorf("download failed: %w", err)
+	}
+
+	if err := config.SetLastWatchedModified(treeID, modified); err != nil {
+		return fmt.Errorf("failed to save last download time: %w", err)
+	}
+	return nil
+}
+
+// setWatchDownloadFlags adjusts the download-tree flags for downloads started by watch.
+// Downloads only follow a change to the tree, which a cached person list wouldn't
+// include, and they are incremental from the output directory's .last-run unless
+// --since sets the time explicitly.
+func setWatchDownloadFlags(c *cli.Context) error {
+	if err := c.Set("refresh", "true"); err != nil {
+		return err
+	}
+	if c.IsSet("since") {
+		return nil
+	}
+	return c.Set("incremental", "true")
+}
+
+// Watch periodically checks a tree and downloads it whenever it has changed
+func Watch(c *cli.Context) error {
+	logger := loggerFrom(c)
+	treeID, err := getTreeIDForDownload(c)
+	if err != nil {
+		return err
+	}
+
+	interval := c.Duration("interval")
+	if interval < minWatchInterval {
+		return fmt.Errorf("--interval must be at least %s", minWatchInterval)
+	}
+
+	if err := setWatchDownloadFlags(c); err != nil {
+		return err
+	}
+
+	if c.Bool("once") {
+		return checkAndDownloadTree(c, treeID)
+	}
+
+	logger.Printf("Watching tree %s, checking every %s (press Ctrl+C to stop)\n\n", treeID, interval)
+	for {
+		if err := checkAndDownloadTree(c, treeID); err != nil {
+			logger.Printf("   Warning: %v\n", err)
+		}
+
+		logger.Printf("\nNext check at %s\n\n", time.Now().Add(interval).Format("2006-01-02 15:04:05"))
+		select {
+		case <-c.Context.Done():
+			return nil
+		case <-time.After(interval):
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package commands
+
+import (
+	"flag"
+	"testing"
+	"time"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+	"github.com/urfave/cli/v2"
+)
+
+func TestTreeChanged(t *testing.T) {
+	earlier := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	later := earlier.Add(time.Hour)
+	tests := []struct {
+		name     string
+		modified time.Time
+		lastSeen time.Time
+		want     bool
+	}{
+		{name: "never downloaded", modified: later, want: true},
+		{name: "modified since last download", modified: later, lastSeen: earlier, want: true},
+		{name: "unchanged", modified: earlier, lastSeen: earlier, want: false},
+		{name: "no modification time", lastSeen: earlier, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := treeChanged(tt.modified, tt.lastSeen); got != tt.want {
+				t.Errorf("treeChanged() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindTree(t *testing.T) {
+	trees := []ancestry.Tree{{ID: "1", Name: "One"}, {ID: "2", Name: "Two"}}
+	if tree, ok := findTree(trees, "2"); !ok || tree.Name != "Two" {
+		t.Errorf("findTree(2) = %+v, %v", tree, ok)
+	}
+	if _, ok := findTree(trees, "3"); ok {
+		t.Error("findTree(3) found a tree, want none")
+	}
+}
+
+func TestSetWatchDownloadFlags(t *testing.T) {
+	tests := []struct {
+		name            string
+		args            []string
+		wantIncremental bool
+	}{
+		{name: "incremental from .last-run", wantIncremental: true},
+		{name: "explicit since", args: []string{"--since", "2025-01-31"}, wantIncremental: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			set := flag.NewFlagSet("test", flag.ContinueOnError)
+			set.Bool("refresh", false, "")
+			set.Bool("incremental", false, "")
+			set.String("since", "", "")
+			if err := set.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+			c := cli.NewContext(cli.NewApp(), set, nil)
+
+			if err := setWatchDownloadFlags(c); err != nil {
+				t.Fatalf("setWatchDownloadFlags() error = %v", err)
+			}
+			if !c.Bool("refresh") {
+				t.Error("refresh not set")
+			}
+			if got := c.Bool("incremental"); got != tt.wantIncremental {
+				t.Errorf("incremental = %v, want %v", got, tt.wantIncremental)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+	"github.com/urfave/cli/v2"
+)
+
+// sessionExpiredMessage is shown when the stored session no longer works
+const sessionExpiredMessage = "Your session has expired or is invalid. Run 'ancestrydl login' again"
+
+const (
+	// sessionCheckAttempts is how many times verifySession tries before giving up on transient errors
+	sessionCheckAttempts = 3
+	// sessionCheckBackoff is the wait before the first retry of a session check; it doubles after each retry
+	sessionCheckBackoff = 2 * time.Second
+)
+
+// isAuthFailure reports whether a request error means the session was rejected, as opposed to a transient failure
+func isAuthFailure(err error) bool {
+	var statusErr *ancestry.StatusError
+	if !errors.As(err, &statusErr) {
+		return false
+	}
+	return statusErr.Code == http.StatusUnauthorized || statusErr.Code == http.StatusForbidden
+}
+
+// verifySession checks that the stored session is valid before a long job starts and prints
+// the logged-in user. Transient failures are retried with backoff; a rejected session is not.
+func verifySession(ctx context.Context, apiClient *ancestry.APIClient, status io.Writer) error {
+	var userData *ancestry.UserData
+	err := withRetry("Session check", sessionCheckAttempts, sessionCheckBackoff, isAuthFailure, status, func() error {
+		var err error
+		userData, err = apiClient.GetUserData(ctx)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("session check failed: %w\n\n%s", err, sessionExpiredMessage)
+	}
+	if !userData.IsAuthenticated() {
+		return fmt.Errorf("not logged in\n\n%s", sessionExpiredMessage)
+	}
+
+	if name := userData.GetDisplayName(); name != "" {
+		fmt.Fprintf(status, "   ✓ Logged in as %s\n", name)
+	} else {
+		fmt.Fprintln(status, "   ✓ Session is valid")
+	}
+	return nil
+}
+
+// WhoAmI checks that the stored session is still valid and shows the logged-in user
+func WhoAmI(c *cli.Context) error {
+	logger := loggerFrom(c)
+	apiClient, err := createAPIClientFromStoredCookies(c)
+	if err != nil {
+		return cli.Exit(err.Error(), 1)
+	}
+	defer func() {
+		if err := apiClient.Close(); err != nil {
+			logger.Printf("Error closing API client: %v\n", err)
+		}
+	}()
+
+	logger.Println("Checking session...")
+	userData, err := apiClient.GetUserData(c.Context)
+	if err != nil {
+		return cli.Exit(fmt.Sprintf("✗ Session check failed: %v\n\n%s", err, sessionExpiredMessage), 1)
+	}
+	if !userData.IsAuthenticated() {
+		return cli.Exit(fmt.Sprintf("✗ Not logged in.\n\n%s", sessionExpiredMessage), 1)
+	}
+
+	logger.Println("✓ Session is valid")
+	logger.Println()
+
+	if name := userData.GetDisplayName(); name != "" {
+		logger.Printf("  Name: %s\n", name)
+	}
+	if userID := userData.GetUserID(); userID != "" {
+		logger.Printf("  User ID: %s\n", userID)
+	}
+
+	trees, err := apiClient.ListTrees(c.Context)
+	if err != nil {
+		logger.Printf("  Trees: (unavailable: %v)\n", err)
+	} else {
+		logger.Printf("  Trees: %d\n", len(trees))
+	}
+
+	logger.Printf("  Hints: %d\n", userData.HintCount)
+	if userData.NotificationsCount > 0 {
+		logger.Printf("  Notifications: %d\n", userData.NotificationsCount)
+	}
+	logger.Println()
+
+	return nil
+}
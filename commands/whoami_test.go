@@ -0,0 +1,31 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+)
+
+func TestIsAuthFailure(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{err: fmt.Errorf("API request failed with %w", &ancestry.StatusError{Code: http.StatusUnauthorized}), want: true},
+		{err: fmt.Errorf("API request failed with %w", &ancestry.StatusError{Code: http.StatusForbidden}), want: true},
+		{err: fmt.Errorf("API request failed with %w", &ancestry.StatusError{Code: http.StatusServiceUnavailable}), want: false},
+		{err: errors.New("API request failed with status 401: unauthorized"), want: false}, // only the typed error counts
+		{err: errors.New("failed to make request: connection reset"), want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.err.Error(), func(t *testing.T) {
+			if got := isAuthFailure(tt.err); got != tt.want {
+				t.Errorf("isAuthFailure() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
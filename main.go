@@ -1,11 +1,16 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
+	"time"
 
 	"github.com/chrisrob11/ancestrydl/commands"
+	"github.com/chrisrob11/ancestrydl/pkg/ancestry"
+	"github.com/chrisrob11/ancestrydl/pkg/config"
 	"github.com/urfave/cli/v2"
 )
 
@@ -21,6 +26,36 @@ func main() {
 		Name:    "ancestrydl",
 		Usage:   "Download your family tree data from Ancestry.com",
 		Version: fmt.Sprintf("%s (built %s)", Version, BuildDate),
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:  "user-agent",
+				Usage: "User-Agent header sent with every request",
+				Value: ancestry.DefaultUserAgent,
+			},
+			&cli.StringFlag{
+				Name:  "ca-cert",
+				Usage: "PEM file with an extra root CA to trust, e.g. a TLS-inspecting corporate proxy's CA",
+			},
+			&cli.BoolFlag{
+				Name:  "insecure",
+				Usage: "Skip TLS certificate verification (unsafe; prefer --ca-cert)",
+			},
+			&cli.BoolFlag{
+				Name:  "log-timestamps",
+				Usage: "Prefix each status line with the date and time",
+			},
+			&cli.BoolFlag{
+				Name:  "no-keyring",
+				Usage: "Store login credentials in ~/.ancestrydl/credentials.enc instead of the system keyring (obfuscation only: its key is kept beside it)",
+			},
+		},
+		Before: func(c *cli.Context) error {
+			commands.SetLogger(c.App, commands.NewLogger(c.Bool("log-timestamps")))
+			if c.Bool("no-keyring") {
+				config.DisableKeyring()
+			}
+			return nil
+		},
 		Commands: []*cli.Command{
 			{
 				Name:    "login",
@@ -28,35 +63,75 @@ func main() {
 				Usage:   "Authenticate with Ancestry.com",
 				Flags: []cli.Flag{
 					&cli.StringFlag{
-						Name:     "username",
-						Aliases:  []string{"u"},
-						Usage:    "Ancestry.com email/username",
-						Required: true,
+						Name:    "username",
+						Aliases: []string{"u"},
+						Usage:   "Ancestry.com email/username",
 					},
 					&cli.StringFlag{
-						Name:     "password",
-						Aliases:  []string{"p"},
-						Usage:    "Ancestry.com password",
-						Required: true,
+						Name:    "password",
+						Aliases: []string{"p"},
+						Usage:   "Ancestry.com password",
 					},
 					&cli.StringFlag{
 						Name:  "2fa",
 						Usage: "2FA method to auto-select: 'email' or 'phone' (if account has 2FA enabled)",
 					},
+					&cli.StringFlag{
+						Name:    "token",
+						Usage:   "Log in without a browser using a session token (the SecureATT cookie value)",
+						EnvVars: []string{"ANCESTRYDL_TOKEN"},
+					},
+					&cli.StringSliceFlag{
+						Name:  "cookie",
+						Usage: "Log in without a browser using a session cookie as name=value (repeatable)",
+					},
 				},
 				Action: loginCommand,
 			},
+			{
+				Name:  "import-cookies",
+				Usage: "Import an existing browser session from a cookies.txt or JSON cookie export",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:     "file",
+						Aliases:  []string{"f"},
+						Usage:    "Cookie file exported from your browser (Netscape cookies.txt or JSON)",
+						Required: true,
+					},
+				},
+				Action: importCookiesCommand,
+			},
 			{
 				Name:    "logout",
 				Aliases: []string{"lo"},
 				Usage:   "Remove stored credentials",
 				Action:  logoutCommand,
 			},
+			{
+				Name:    "whoami",
+				Aliases: []string{"check-session"},
+				Usage:   "Check that the stored session is valid and show the logged-in user",
+				Action:  whoAmICommand,
+			},
 			{
 				Name:    "list-trees",
 				Aliases: []string{"ls"},
 				Usage:   "List all available family trees",
 				Action:  listTreesCommand,
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "detailed",
+						Usage: "Also fetch each tree's person count and privacy status",
+					},
+					&cli.StringFlag{
+						Name:  "sort",
+						Usage: "Sort trees by name, created or modified; append :desc for descending order (e.g. modified:desc)",
+					},
+					&cli.BoolFlag{
+						Name:  "json",
+						Usage: "Print the trees as a JSON array, with status messages on stderr",
+					},
+				},
 			},
 			{
 				Name:      "list-people",
@@ -64,6 +139,89 @@ func main() {
 				Usage:     "List all people in a family tree",
 				ArgsUsage: "<tree-id>",
 				Action:    listPeopleCommand,
+				Flags: append([]cli.Flag{
+					&cli.IntFlag{
+						Name:  "page-size",
+						Usage: "Persons fetched per request when listing the tree (1-500); larger pages mean fewer requests, smaller ones lose less when a request fails",
+						Value: ancestry.DefaultPageSize,
+					},
+					&cli.StringFlag{
+						Name:  "sort",
+						Usage: "Sort people by name, birth-year or death-year; append :desc for descending order (e.g. birth-year:desc)",
+					},
+					&cli.BoolFlag{
+						Name:  "use-recent",
+						Usage: "Without a tree ID or default tree, use the tree you most recently viewed on Ancestry",
+					},
+					&cli.StringFlag{
+						Name:  "tags",
+						Usage: "Only list people with at least one of these comma-separated tags (e.g. Research,Verified)",
+					},
+					&cli.StringFlag{
+						Name:  "id-prefix",
+						Usage: "Only list people whose person ID starts with this (e.g. 2325)",
+					},
+					&cli.StringFlag{
+						Name:  "grep",
+						Usage: "Only list people whose name matches this regular expression (e.g. '(?i)^john')",
+					},
+					&cli.BoolFlag{
+						Name:  "json",
+						Usage: "Print the people as a JSON array, with status messages on stderr",
+					},
+				}, personCacheFlags()...),
+			},
+			{
+				Name:      "search-people",
+				Usage:     "List the people in a family tree matching a name, without fetching the whole tree",
+				ArgsUsage: "<tree-id>",
+				Action:    searchPeopleCommand,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "first-name",
+						Usage: "Only list people with this given name",
+					},
+					&cli.StringFlag{
+						Name:  "last-name",
+						Usage: "Only list people with this surname",
+					},
+					&cli.StringFlag{
+						Name:  "name",
+						Usage: "Only list people whose name contains this",
+					},
+					&cli.IntFlag{
+						Name:  "page-size",
+						Usage: "Persons fetched per request (1-500)",
+						Value: ancestry.DefaultPageSize,
+					},
+					&cli.BoolFlag{
+						Name:  "use-recent",
+						Usage: "Without a tree ID or default tree, use the tree you most recently viewed on Ancestry",
+					},
+					&cli.BoolFlag{
+						Name:  "json",
+						Usage: "Print the people as a JSON array, with status messages on stderr",
+					},
+				},
+			},
+			{
+				Name:      "list-media",
+				Usage:     "List every media item in a family tree without downloading it",
+				ArgsUsage: "<tree-id>",
+				Action:    listMediaCommand,
+				Flags: append([]cli.Flag{
+					&cli.IntFlag{
+						Name:  "page-size",
+						Usage: "Persons fetched per request when listing the tree (1-500); larger pages mean fewer requests, smaller ones lose less when a request fails",
+						Value: ancestry.DefaultPageSize,
+					},
+					&cli.StringFlag{
+						Name:    "output",
+						Aliases: []string{"o"},
+						Usage:   "File to write the media manifest (JSON) to",
+						Value:   "media-manifest.json",
+					},
+				}, personCacheFlags()...),
 			},
 			{
 				Name:    "config",
@@ -89,20 +247,260 @@ func main() {
 				Aliases:   []string{"dl"},
 				Usage:     "Download complete family tree with all data and media",
 				ArgsUsage: "[tree-id]",
+				Flags: append(downloadTreeFlags(),
+					&cli.BoolFlag{
+						Name:  "list-formats",
+						Usage: "List the supported output formats and exit",
+					},
+				),
+				Action: downloadTreeCommand,
+			},
+			{
+				Name:      "watch",
+				Usage:     "Periodically check a tree and download it whenever it changes",
+				ArgsUsage: "[tree-id]",
+				Flags: append(downloadTreeFlags(),
+					&cli.DurationFlag{
+						Name:  "interval",
+						Usage: "Time between checks for changes",
+						Value: 24 * time.Hour,
+					},
+					&cli.BoolFlag{
+						Name:  "once",
+						Usage: "Check once, download if changed, and exit (for cron or scheduled tasks)",
+					},
+				),
+				Action: watchCommand,
+			},
+			{
+				Name:      "retry-failed",
+				Usage:     "Re-attempt only the facts, media and record fetches that failed in a previous download-tree run",
+				ArgsUsage: "<output-dir>",
 				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:    "verbose",
+						Aliases: []string{"v"},
+						Usage:   "Enable verbose logging (writes all HTTP requests/responses to http_log.txt)",
+					},
+					&cli.IntFlag{
+						Name:  "breaker-threshold",
+						Usage: "Consecutive request failures before pausing requests for a cooldown (0 disables)",
+						Value: ancestry.DefaultCircuitBreakerThreshold,
+					},
+					&cli.DurationFlag{
+						Name:  "media-timeout",
+						Usage: "Maximum time to download a single media file or record image",
+						Value: ancestry.DefaultMediaTimeout,
+					},
+					&cli.StringFlag{
+						Name:  "keep-html-debug",
+						Usage: "Save the raw HTML of facts pages whose data could not be extracted into this directory, named <tree-id>-<person-id>-<missing>.html",
+					},
+					&cli.StringFlag{
+						Name:  "media-categories",
+						Usage: "Comma-separated media categories to download; use the value given to download-tree (default all)",
+					},
+					&cli.StringFlag{
+						Name:  "name-collision-strategy",
+						Usage: "How to rename a media file whose name is already taken: 'index', 'hash' or 'uuid'",
+						Value: "index",
+					},
+					&cli.StringFlag{
+						Name:  "theme",
+						Usage: "Color theme of the regenerated HTML viewer; use the value given to download-tree: 'light', 'dark' or 'auto'",
+						Value: "light",
+					},
+					&cli.BoolFlag{
+						Name:  "force-unlock",
+						Usage: "Remove the output directory's lock file even if another download appears to be running",
+					},
+				},
+				Action: retryFailedCommand,
+			},
+			{
+				Name:      "find-duplicates",
+				Usage:     "Report persons in a downloaded tree that share a name and birth year, writing duplicates.json",
+				ArgsUsage: "<output-dir>",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "match-place",
+						Usage: "Only group persons whose birth places also match",
+					},
+				},
+				Action: findDuplicatesCommand,
+			},
+			{
+				Name:      "events",
+				Usage:     "Write every event of a type across a downloaded tree to events-<type>.json or .csv",
+				ArgsUsage: "<output-dir>",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "type",
+						Usage: "Event type to export, e.g. Birth, Marriage or Residence",
+					},
+					&cli.BoolFlag{
+						Name:  "all-types",
+						Usage: "Export every event type, one file per type",
+					},
+					&cli.StringFlag{
+						Name:  "format",
+						Usage: "File format: json or csv",
+						Value: "json",
+					},
+				},
+				Action: eventsCommand,
+			},
+			{
+				Name:      "export-gedcom",
+				Usage:     "Write a downloaded tree, or a tree downloaded fresh, as a GEDCOM 5.5.1 file for desktop genealogy software",
+				ArgsUsage: "<output-dir | tree-id>",
+				Flags:     exportFlags("GEDCOM file to write (default tree.ged in the download directory, or tree-<tree-id>.ged)"),
+				Action:    exportGEDCOMCommand,
+			},
+			{
+				Name:      "export-csv",
+				Usage:     "Write a downloaded tree, or a tree downloaded fresh, as a flat CSV file with one row per person",
+				ArgsUsage: "<output-dir | tree-id>",
+				Flags:     exportFlags("CSV file to write (default people.csv, in the download directory when converting one)"),
+				Action:    exportCSVCommand,
+			},
+			{
+				Name:      "download-comments",
+				Usage:     "Save the notes and comments attached to every person in a tree to comments.json",
+				ArgsUsage: "[tree-id]",
+				Flags: append([]cli.Flag{
 					&cli.StringFlag{
 						Name:    "output",
 						Aliases: []string{"o"},
-						Usage:   "Output directory",
-						Value:   "./ancestry-export",
+						Usage:   "Directory to write comments.json to",
+						Value:   ".",
+					},
+					&cli.IntFlag{
+						Name:  "batch-size",
+						Usage: "Persons whose notes are fetched per request; smaller batches keep request URLs short",
+						Value: ancestry.DefaultCommentsBatchSize,
+					},
+					&cli.IntFlag{
+						Name:  "page-size",
+						Usage: "Persons fetched per request when listing the tree (1-500); larger pages mean fewer requests, smaller ones lose less when a request fails",
+						Value: ancestry.DefaultPageSize,
+					},
+				}, personCacheFlags()...),
+				Action: downloadCommentsCommand,
+			},
+			{
+				Name:      "download-activity",
+				Usage:     "Save a tree's activity feed (who changed what, and when) to activity.json",
+				ArgsUsage: "[tree-id]",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "output",
+						Aliases: []string{"o"},
+						Usage:   "Directory to write activity.json to",
+						Value:   ".",
+					},
+				},
+				Action: downloadActivityCommand,
+			},
+			{
+				Name:      "download-people",
+				Aliases:   []string{"dp"},
+				Usage:     "Download only the specified people from a family tree",
+				ArgsUsage: "<tree-id>",
+				Flags: []cli.Flag{
+					&cli.StringSliceFlag{
+						Name:  "id",
+						Usage: "Person ID to download (can be repeated)",
+					},
+					&cli.StringFlag{
+						Name:  "ids-file",
+						Usage: "File containing person IDs to download, one per line",
+					},
+					&cli.StringFlag{
+						Name:    "output",
+						Aliases: []string{"o"},
+						Usage:   "Output directory, or - to write the JSON export to stdout",
 					},
 					&cli.BoolFlag{
 						Name:    "verbose",
 						Aliases: []string{"v"},
 						Usage:   "Enable verbose logging (writes all HTTP requests/responses to http_log.txt)",
 					},
+					&cli.IntFlag{
+						Name:  "breaker-threshold",
+						Usage: "Consecutive request failures before pausing requests for a cooldown (0 disables)",
+						Value: ancestry.DefaultCircuitBreakerThreshold,
+					},
+					&cli.DurationFlag{
+						Name:  "media-timeout",
+						Usage: "Maximum time to download a single media file or record image",
+						Value: ancestry.DefaultMediaTimeout,
+					},
+					&cli.StringFlag{
+						Name:  "keep-html-debug",
+						Usage: "Save the raw HTML of facts pages whose data could not be extracted into this directory, named <tree-id>-<person-id>-<missing>.html",
+					},
+					&cli.BoolFlag{
+						Name:  "human-delay",
+						Usage: "Pause a random 1-4s between facts-page requests to avoid bot detection (slower, more reliable on large trees)",
+					},
+					&cli.IntFlag{
+						Name:  "concurrency",
+						Usage: "Number of people whose Facts pages are fetched in parallel",
+						Value: 4,
+					},
+					&cli.BoolFlag{
+						Name:  "strip-html",
+						Usage: "Strip HTML markup and entities from event descriptions",
+					},
 				},
-				Action: downloadTreeCommand,
+				Action: downloadPeopleCommand,
+			},
+			{
+				Name:      "show-person",
+				Usage:     "Print one person's details, life events, family and media and source counts",
+				ArgsUsage: "[tree-id] <person-id>",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "json",
+						Usage: "Print the fetched person, relationships, sources and media as JSON",
+					},
+					&cli.BoolFlag{
+						Name:  "use-recent",
+						Usage: "Without a tree ID or default tree, use the tree you most recently viewed on Ancestry",
+					},
+				},
+				Action: showPersonCommand,
+			},
+			{
+				Name:      "person-report",
+				Usage:     "Write a Markdown timeline of one person's life events, family and sources",
+				ArgsUsage: "<tree-id> <person-id>",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "output",
+						Aliases: []string{"o"},
+						Usage:   "Markdown file to write, or - for stdout (default: <name>-<person-id>.md)",
+					},
+				},
+				Action: personReportCommand,
+			},
+			{
+				Name:      "pedigree",
+				Usage:     "Print an ancestor chart for a person, by default the tree's root person",
+				ArgsUsage: "<tree-id> [person-id]",
+				Flags: []cli.Flag{
+					&cli.IntFlag{
+						Name:  "depth",
+						Usage: "Number of generations to chart, the person included (1-10)",
+						Value: 4,
+					},
+					&cli.StringFlag{
+						Name:  "svg",
+						Usage: "Also write the chart as an SVG image to this file",
+					},
+				},
+				Action: pedigreeCommand,
 			},
 			{
 				Name:      "download-record",
@@ -127,6 +525,11 @@ func main() {
 						Aliases: []string{"v"},
 						Usage:   "Enable verbose logging (writes all HTTP requests/responses to http_log.txt)",
 					},
+					&cli.DurationFlag{
+						Name:  "media-timeout",
+						Usage: "Maximum time to download a single media file or record image",
+						Value: ancestry.DefaultMediaTimeout,
+					},
 				},
 				Action: downloadRecordCommand,
 			},
@@ -135,7 +538,12 @@ func main() {
 				Aliases:   []string{"ds"},
 				Usage:     "Download all sources for all people in a tree",
 				ArgsUsage: "[tree-id]",
-				Flags: []cli.Flag{
+				Flags: append([]cli.Flag{
+					&cli.IntFlag{
+						Name:  "page-size",
+						Usage: "Persons fetched per request when listing the tree (1-500); larger pages mean fewer requests, smaller ones lose less when a request fails",
+						Value: ancestry.DefaultPageSize,
+					},
 					&cli.StringFlag{
 						Name:    "output",
 						Aliases: []string{"o"},
@@ -146,7 +554,26 @@ func main() {
 						Aliases: []string{"v"},
 						Usage:   "Enable verbose logging",
 					},
-				},
+					&cli.BoolFlag{
+						Name:  "full-citations",
+						Usage: "Fetch each source's full citation, including its repository, and save the repositories once to repositories.json",
+					},
+					&cli.Float64Flag{
+						Name:  "rate",
+						Usage: "Maximum requests per second to Ancestry, media included (0 for no limit)",
+						Value: ancestry.DefaultRequestsPerSecond,
+					},
+					&cli.IntFlag{
+						Name:  "breaker-threshold",
+						Usage: "Consecutive request failures before pausing requests for a cooldown (0 disables)",
+						Value: ancestry.DefaultCircuitBreakerThreshold,
+					},
+					&cli.DurationFlag{
+						Name:  "media-timeout",
+						Usage: "Maximum time to download a single media file or record image",
+						Value: ancestry.DefaultMediaTimeout,
+					},
+				}, personCacheFlags()...),
 				Action: downloadSourcesCommand,
 			},
 			{
@@ -175,6 +602,14 @@ func main() {
 						Name:  "capture-network",
 						Usage: "Capture and display all API network requests",
 					},
+					&cli.StringFlag{
+						Name:  "filter",
+						Usage: "With --capture-network, only keep requests whose URL contains this text (e.g. familyview)",
+					},
+					&cli.BoolFlag{
+						Name:  "api-only",
+						Usage: "With --capture-network, only keep JSON responses from Ancestry API paths",
+					},
 					&cli.StringFlag{
 						Name:    "output",
 						Aliases: []string{"o"},
@@ -186,7 +621,15 @@ func main() {
 		},
 	}
 
-	if err := app.Run(os.Args); err != nil {
+	// Ctrl+C cancels the command's context, so downloads stop between persons and save their
+	// progress instead of dying mid-write. A second Ctrl+C exits at once.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+
+	if err := app.RunContext(ctx, os.Args); err != nil {
 		log.Fatal(err)
 	}
 }
@@ -196,10 +639,30 @@ func loginCommand(c *cli.Context) error {
 	return commands.Login(c)
 }
 
+func importCookiesCommand(c *cli.Context) error {
+	return commands.ImportCookies(c)
+}
+
 func logoutCommand(c *cli.Context) error {
 	return commands.Logout(c)
 }
 
+func showPersonCommand(c *cli.Context) error {
+	return commands.ShowPerson(c)
+}
+
+func personReportCommand(c *cli.Context) error {
+	return commands.PersonReport(c)
+}
+
+func pedigreeCommand(c *cli.Context) error {
+	return commands.Pedigree(c)
+}
+
+func whoAmICommand(c *cli.Context) error {
+	return commands.WhoAmI(c)
+}
+
 func listTreesCommand(c *cli.Context) error {
 	return commands.ListTrees(c)
 }
@@ -208,6 +671,14 @@ func listPeopleCommand(c *cli.Context) error {
 	return commands.ListPeople(c)
 }
 
+func searchPeopleCommand(c *cli.Context) error {
+	return commands.SearchPeople(c)
+}
+
+func listMediaCommand(c *cli.Context) error {
+	return commands.ListMedia(c)
+}
+
 func setDefaultTreeCommand(c *cli.Context) error {
 	return commands.SetDefaultTree(c)
 }
@@ -220,6 +691,42 @@ func downloadTreeCommand(c *cli.Context) error {
 	return commands.DownloadTree(c)
 }
 
+func watchCommand(c *cli.Context) error {
+	return commands.Watch(c)
+}
+
+func retryFailedCommand(c *cli.Context) error {
+	return commands.RetryFailed(c)
+}
+
+func findDuplicatesCommand(c *cli.Context) error {
+	return commands.FindDuplicates(c)
+}
+
+func eventsCommand(c *cli.Context) error {
+	return commands.Events(c)
+}
+
+func exportGEDCOMCommand(c *cli.Context) error {
+	return commands.ExportGEDCOM(c)
+}
+
+func exportCSVCommand(c *cli.Context) error {
+	return commands.ExportCSV(c)
+}
+
+func downloadCommentsCommand(c *cli.Context) error {
+	return commands.DownloadComments(c)
+}
+
+func downloadActivityCommand(c *cli.Context) error {
+	return commands.DownloadActivity(c)
+}
+
+func downloadPeopleCommand(c *cli.Context) error {
+	return commands.DownloadPeople(c)
+}
+
 func downloadRecordCommand(c *cli.Context) error {
 	return commands.DownloadRecord(c)
 }
@@ -231,3 +738,220 @@ func downloadSourcesCommand(c *cli.Context) error {
 func testBrowserCommand(c *cli.Context) error {
 	return commands.TestBrowser(c)
 }
+
+// personCacheFlags returns the flags controlling the cached person list of a tree
+func personCacheFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "no-cache",
+			Usage: "Always fetch the person list, without reading or writing the cache in ~/.ancestrydl/cache",
+		},
+		&cli.BoolFlag{
+			Name:  "refresh",
+			Usage: "Fetch the person list even if it was cached in the last 15 minutes, and cache the new list",
+		},
+	}
+}
+
+// exportFlags returns the flags of the export commands, which can download the tree they export
+func exportFlags(outputUsage string) []cli.Flag {
+	return append([]cli.Flag{
+		&cli.StringFlag{
+			Name:    "output",
+			Aliases: []string{"o"},
+			Usage:   outputUsage,
+		},
+		&cli.BoolFlag{
+			Name:  "no-facts",
+			Usage: "When downloading, skip the Facts pages and export only family view events (much faster)",
+		},
+		&cli.IntFlag{
+			Name:  "concurrency",
+			Usage: "When downloading, number of people whose Facts pages are fetched in parallel",
+			Value: 4,
+		},
+		&cli.BoolFlag{
+			Name:    "verbose",
+			Aliases: []string{"v"},
+			Usage:   "Enable verbose logging (writes all HTTP requests/responses to http_log.txt)",
+		},
+		&cli.IntFlag{
+			Name:  "breaker-threshold",
+			Usage: "Consecutive request failures before pausing requests for a cooldown (0 disables)",
+			Value: ancestry.DefaultCircuitBreakerThreshold,
+		},
+	}, personCacheFlags()...)
+}
+
+// downloadTreeFlags returns the flags of download-tree, which watch shares
+func downloadTreeFlags() []cli.Flag {
+	return append([]cli.Flag{
+		&cli.StringFlag{
+			Name:    "output",
+			Aliases: []string{"o"},
+			Usage:   "Output directory, or - to write the JSON export to stdout (default: named by --output-template)",
+		},
+		&cli.StringFlag{
+			Name:  "output-template",
+			Usage: "Output directory name when --output is not given; placeholders: {{.TreeName}}, {{.TreeID}}, {{.Date}}, {{.Time}}",
+			Value: "./{{.TreeName}}-{{.TreeID}}-{{.Date}}",
+		},
+		&cli.BoolFlag{
+			Name:    "verbose",
+			Aliases: []string{"v"},
+			Usage:   "Enable verbose logging (writes all HTTP requests/responses to http_log.txt)",
+		},
+		&cli.IntFlag{
+			Name:  "breaker-threshold",
+			Usage: "Consecutive request failures before pausing requests for a cooldown (0 disables)",
+			Value: ancestry.DefaultCircuitBreakerThreshold,
+		},
+		&cli.DurationFlag{
+			Name:  "media-timeout",
+			Usage: "Maximum time to download a single media file or record image",
+			Value: ancestry.DefaultMediaTimeout,
+		},
+		&cli.StringFlag{
+			Name:  "keep-html-debug",
+			Usage: "Save the raw HTML of facts pages whose data could not be extracted into this directory, named <tree-id>-<person-id>-<missing>.html",
+		},
+		&cli.BoolFlag{
+			Name:  "human-delay",
+			Usage: "Pause a random 1-4s between facts-page requests to avoid bot detection (slower, more reliable on large trees)",
+		},
+		&cli.IntFlag{
+			Name:  "concurrency",
+			Usage: "Number of people whose family views and Facts pages are fetched in parallel",
+			Value: 4,
+		},
+		&cli.IntFlag{
+			Name:  "media-concurrency",
+			Usage: "Number of people whose media is downloaded in parallel",
+			Value: commands.DefaultMediaConcurrency,
+		},
+		&cli.Float64Flag{
+			Name:  "rate",
+			Usage: "Maximum requests per second to Ancestry, media included (0 for no limit)",
+			Value: ancestry.DefaultRequestsPerSecond,
+		},
+		&cli.BoolFlag{
+			Name:  "timing-report",
+			Usage: "Write timings.json with how long each phase and endpoint took, to tune --concurrency (kept locally, never sent)",
+		},
+		&cli.StringFlag{
+			Name:  "name-collision-strategy",
+			Usage: "How to rename a media file whose name is already taken: 'index', 'hash' or 'uuid'",
+			Value: "index",
+		},
+		&cli.BoolFlag{
+			Name:  "force-unlock",
+			Usage: "Remove the output directory's lock file even if another download appears to be running",
+		},
+		&cli.StringFlag{
+			Name:  "media-categories",
+			Usage: "Comma-separated media categories to download: photo, document, story (default all)",
+		},
+		&cli.StringFlag{
+			Name:  "tags",
+			Usage: "Only download people with at least one of these comma-separated tags (e.g. Research,Verified)",
+		},
+		&cli.BoolFlag{
+			Name:  "use-recent",
+			Usage: "Without a tree ID or default tree, use the tree you most recently viewed on Ancestry",
+		},
+		&cli.IntFlag{
+			Name:  "page-size",
+			Usage: "Persons fetched per request when listing the tree (1-500); larger pages mean fewer requests, smaller ones lose less when a request fails",
+			Value: ancestry.DefaultPageSize,
+		},
+		&cli.StringFlag{
+			Name:  "since",
+			Usage: "Skip the download if the tree hasn't changed since this date or time (e.g. 2025-01-31 or 2025-01-31T18:00:00Z)",
+		},
+		&cli.BoolFlag{
+			Name:  "incremental",
+			Usage: "Like --since, using the time of the last successful download into the output directory (from its .last-run file)",
+		},
+		&cli.BoolFlag{
+			Name:  "compress",
+			Usage: "Gzip the JSON files (people.json.gz, metadata.json.gz, media-index.json.gz); the HTML viewer is unaffected",
+		},
+		&cli.BoolFlag{
+			Name:  "validate-media",
+			Usage: "Check that downloaded and existing images decode, downloading again any that are really error pages",
+		},
+		&cli.IntFlag{
+			Name:  "max-media-per-person",
+			Usage: "Download at most this many media items per person, newest first (0 for no limit)",
+		},
+		&cli.BoolFlag{
+			Name:  "only-with-media",
+			Usage: "On re-runs, only check persons who had media last time (and new persons) for media; much faster on large trees",
+		},
+		&cli.StringFlag{
+			Name:  "graph",
+			Usage: "Also write the relationship graph for visualization tools: 'graphml' or 'dot'",
+		},
+		&cli.StringFlag{
+			Name:  "theme",
+			Usage: "Color theme of the HTML viewer: 'light', 'dark' or 'auto' (follows the reader's system setting)",
+			Value: "light",
+		},
+		&cli.BoolFlag{
+			Name:  "self-contained",
+			Usage: "Also write tree.html, a single shareable file with media thumbnails embedded (full-size media stays in media/)",
+		},
+		&cli.BoolFlag{
+			Name:  "relationships-csv",
+			Usage: "Also write relationships.csv, one row per parent, spouse and child relationship (same as adding relationships-csv to --formats)",
+		},
+		&cli.BoolFlag{
+			Name:  "embed-full-media",
+			Usage: "Embed full-size media and record images in tree.html too (implies --self-contained; the file can get very large)",
+		},
+		&cli.IntFlag{
+			Name:  "family-view-generations",
+			Usage: "Generations up and down fetched per relationship request; 2 or more records everyone in each response and makes far fewer requests",
+			Value: 1,
+		},
+		&cli.BoolFlag{
+			Name:  "redact",
+			Usage: "Anonymize the export for sharing: given names become initials, dates become decades, and descriptions, notes, media and sources are left out",
+		},
+		&cli.BoolFlag{
+			Name:  "exclude-media",
+			Usage: "Skip media files and record images for a much faster data-only backup",
+		},
+		&cli.BoolFlag{
+			Name:  "no-facts",
+			Usage: "Skip the slow Facts page scraping; events then lack places, descriptions and custom event types",
+		},
+		&cli.BoolFlag{
+			Name:  "strip-html",
+			Usage: "Strip HTML markup and entities from event descriptions",
+		},
+		&cli.IntFlag{
+			Name:  "max-description-length",
+			Usage: "Shorten event descriptions and media titles and descriptions longer than this many characters",
+			Value: 2000,
+		},
+		&cli.BoolFlag{
+			Name:  "full-descriptions",
+			Usage: "Keep event descriptions and media titles and descriptions whole, however long",
+		},
+		&cli.BoolFlag{
+			Name:  "include-notes",
+			Usage: "Attach private person notes to the export",
+		},
+		&cli.BoolFlag{
+			Name:  "exclude-living",
+			Usage: "With --include-notes, fetch no notes for living persons",
+		},
+		&cli.StringFlag{
+			Name:    "formats",
+			Aliases: []string{"format"},
+			Usage:   "Comma-separated output formats to write: json, html, graphml, dot, self-contained, vcard, jsonld, relationships-csv, gedcom (see --list-formats)",
+			Value:   "json,html",
+		},
+	}, personCacheFlags()...)
+}
@@ -0,0 +1,111 @@
+package ancestry
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// TreeActivity is one entry in a tree's activity feed: a change someone made to the tree
+type TreeActivity struct {
+	Timestamp   string `json:"timestamp,omitempty"` // RFC 3339 when the feed gives epoch milliseconds, else as given
+	Actor       string `json:"actor,omitempty"`     // Who made the change
+	PersonID    string `json:"personId,omitempty"`  // The person affected, if any
+	PersonName  string `json:"personName,omitempty"`
+	ChangeType  string `json:"changeType,omitempty"` // As the feed names it
+	Description string `json:"description,omitempty"`
+}
+
+// Candidate keys for the activity entry list and each entry field, tried in order. They are
+// unconfirmed: no captured activity response backs them yet, only the spellings guessed from
+// other tree viewer responses. Once one is saved under testdata, these should shrink to the
+// keys it uses.
+var (
+	activityListKeys        = []string{"activities", "Activities", "items", "Items", "feed", "entries"}
+	activityTimestampKeys   = []string{"timestamp", "ts", "date", "createdDate", "cd"}
+	activityActorKeys       = []string{"actorName", "userName", "displayName", "authorName", "author", "actor"}
+	activityActorObjectKeys = []string{"actor", "user", "author"}
+	activityUserNameKeys    = []string{"displayName", "name", "userName"}
+	activityPersonIDKeys    = []string{"personId", "pid", "targetPersonId", "targetId"}
+	activityPersonNameKeys  = []string{"personName", "targetName", "targetPersonName"}
+	activityChangeTypeKeys  = []string{"changeType", "activityType", "action", "type", "t"}
+	activityDescriptionKeys = []string{"description", "message", "text", "summary"}
+)
+
+// GetTreeActivity retrieves the tree's activity feed, the recent edits to the tree and who
+// made them. The endpoint is unconfirmed: it follows the tree viewer API's naming but no
+// captured request backs it, so it may fail until it is checked against a capture. Its
+// entries are read leniently (see extractTreeActivity) so a renamed field loses that field,
+// not the entry.
+func (c *APIClient) GetTreeActivity(ctx context.Context, treeID string) ([]TreeActivity, error) {
+	query := url.Values{}
+	query.Set("ts", timestamp())
+
+	var body interface{}
+	if err := c.getJSON(ctx, fmt.Sprintf("/api/treeviewer/tree/%s/activity", treeID), query, &body); err != nil {
+		return nil, fmt.Errorf("failed to get tree activity: %w", err)
+	}
+
+	return extractTreeActivity(body), nil
+}
+
+// extractTreeActivity reads the entries of an activity response, which is either a bare list
+// or an object holding the list under one of activityListKeys. Entries without a change type
+// or description are skipped.
+func extractTreeActivity(body interface{}) []TreeActivity {
+	items, _ := body.([]interface{})
+	if bodyMap, ok := body.(map[string]interface{}); ok {
+		for _, key := range activityListKeys {
+			if list, ok := bodyMap[key].([]interface{}); ok {
+				items = list
+				break
+			}
+		}
+	}
+
+	var activity []TreeActivity
+	for _, item := range items {
+		itemMap, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		entry := TreeActivity{
+			Timestamp:   activityTimestamp(itemMap),
+			Actor:       activityActor(itemMap),
+			PersonID:    firstStringValue(itemMap, activityPersonIDKeys),
+			PersonName:  firstStringValue(itemMap, activityPersonNameKeys),
+			ChangeType:  firstStringValue(itemMap, activityChangeTypeKeys),
+			Description: firstStringValue(itemMap, activityDescriptionKeys),
+		}
+		if entry.ChangeType == "" && entry.Description == "" {
+			continue
+		}
+		activity = append(activity, entry)
+	}
+
+	return activity
+}
+
+// activityTimestamp returns an entry's timestamp, converting epoch milliseconds to RFC 3339
+func activityTimestamp(item map[string]interface{}) string {
+	for _, key := range activityTimestampKeys {
+		if ms, ok := item[key].(float64); ok && ms > 0 {
+			return time.UnixMilli(int64(ms)).UTC().Format(time.RFC3339)
+		}
+	}
+	return firstStringValue(item, activityTimestampKeys)
+}
+
+// activityActor returns who made a change, given either as a name or as a user object
+func activityActor(item map[string]interface{}) string {
+	if actor := firstStringValue(item, activityActorKeys); actor != "" {
+		return actor
+	}
+	for _, key := range activityActorObjectKeys {
+		if user, ok := item[key].(map[string]interface{}); ok {
+			return firstStringValue(user, activityUserNameKeys)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,76 @@
+package ancestry
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+// The bodies below are made up to match the candidate keys, not captured responses
+func TestExtractTreeActivity(t *testing.T) {
+	tests := []struct {
+		name string
+		body interface{}
+		want []TreeActivity
+	}{
+		{
+			name: "bare list",
+			body: []interface{}{
+				map[string]interface{}{
+					"ts": float64(1700000000000), "userName": "Jo", "pid": float64(123),
+					"personName": "Ada Smith", "type": "FactEdited", "message": "Edited birth",
+				},
+			},
+			want: []TreeActivity{{
+				Timestamp: "2023-11-14T22:13:20Z", Actor: "Jo", PersonID: "123",
+				PersonName: "Ada Smith", ChangeType: "FactEdited", Description: "Edited birth",
+			}},
+		},
+		{
+			name: "wrapped list with user object",
+			body: map[string]interface{}{
+				"activities": []interface{}{
+					map[string]interface{}{"date": "2024-01-02", "user": map[string]interface{}{"name": "Sam"}, "action": "PersonAdded"},
+					map[string]interface{}{"date": "2024-01-03"},
+					"not an entry",
+				},
+			},
+			want: []TreeActivity{{Timestamp: "2024-01-02", Actor: "Sam", ChangeType: "PersonAdded"}},
+		},
+		{
+			name: "no activity",
+			body: map[string]interface{}{"items": []interface{}{}},
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := extractTreeActivity(tt.body); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("extractTreeActivity() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetTreeActivity(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/treeviewer/tree/t1/activity" {
+			http.Error(w, "unexpected URL "+r.URL.String(), http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`{"items":[{"type":"MediaAttached","actor":"Jo"}]}`))
+	}))
+	defer server.Close()
+
+	activity, err := newTestClient(server).GetTreeActivity(context.Background(), "t1")
+	if err != nil {
+		t.Fatalf("GetTreeActivity() error = %v", err)
+	}
+	want := []TreeActivity{{Actor: "Jo", ChangeType: "MediaAttached"}}
+	if !reflect.DeepEqual(activity, want) {
+		t.Errorf("GetTreeActivity() = %+v, want %+v", activity, want)
+	}
+}
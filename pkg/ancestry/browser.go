@@ -3,12 +3,14 @@ package ancestry
 import (
 	"encoding/json"
 	"fmt"
+	"math"
 	"net/http"
 	"sync"
 	"time"
 
 	"github.com/go-rod/rod"
 	"github.com/go-rod/rod/lib/launcher"
+	"github.com/go-rod/rod/lib/launcher/flags"
 	"github.com/go-rod/rod/lib/proto"
 )
 
@@ -22,8 +24,27 @@ type Client struct {
 
 // NewClient creates a new Client with a headful browser
 func NewClient() (*Client, error) {
+	return NewClientWithTLS(TLSOptions{})
+}
+
+// NewClientWithTLS creates a new Client with a headful browser whose certificate
+// checking follows opts
+func NewClientWithTLS(opts TLSOptions) (*Client, error) {
+	chromeFlags, err := opts.browserFlags()
+	if err != nil {
+		return nil, err
+	}
+
 	// Launch headful browser so user can see/interact if needed (e.g. for CAPTCHA)
-	u := launcher.New().Headless(false).MustLaunch()
+	l := launcher.New().Headless(false)
+	for name, value := range chromeFlags {
+		if value == "" {
+			l = l.Set(flags.Flag(name))
+		} else {
+			l = l.Set(flags.Flag(name), value)
+		}
+	}
+	u := l.MustLaunch()
 	browser := rod.New().ControlURL(u).MustConnect()
 
 	// Create a new page
@@ -156,20 +177,28 @@ func DeserializeCookies(data string) ([]*proto.NetworkCookie, error) {
 	return cookies, nil
 }
 
+// epochSecondsToTime converts fractional seconds since the epoch to a time, keeping the fraction.
+// Unlike proto.TimeSinceEpoch.Time it doesn't overflow for expiry dates centuries away.
+func epochSecondsToTime(seconds float64) time.Time {
+	whole, frac := math.Modf(seconds)
+	return time.Unix(int64(whole), int64(frac*float64(time.Second)))
+}
+
 // CookiesToHTTPCookies converts rod cookies to http.Cookie
 func CookiesToHTTPCookies(rodCookies []*proto.NetworkCookie) []*http.Cookie {
 	var httpCookies []*http.Cookie
 	for _, rc := range rodCookies {
 		c := &http.Cookie{
-			Name:   rc.Name,
-			Value:  rc.Value,
-			Domain: rc.Domain,
-			Path:   rc.Path,
-			Secure: rc.Secure,
+			Name:     rc.Name,
+			Value:    rc.Value,
+			Domain:   rc.Domain,
+			Path:     rc.Path,
+			Secure:   rc.Secure,
+			HttpOnly: rc.HTTPOnly,
 		}
-		// Expires is float64 seconds since epoch
+		// Expires is float64 seconds since epoch; session cookies have -1 or 0 and keep a zero Expires
 		if rc.Expires > 0 {
-			c.Expires = time.Unix(int64(rc.Expires), 0)
+			c.Expires = epochSecondsToTime(float64(rc.Expires))
 		}
 		httpCookies = append(httpCookies, c)
 	}
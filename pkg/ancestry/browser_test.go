@@ -0,0 +1,78 @@
+package ancestry
+
+import (
+	"testing"
+	"time"
+
+	"github.com/go-rod/rod/lib/proto"
+)
+
+func TestCookieRoundTrip(t *testing.T) {
+	farFuture := time.Date(9999, time.December, 31, 23, 59, 59, 0, time.UTC)
+	tests := []struct {
+		name        string
+		expires     proto.TimeSinceEpoch
+		wantExpires time.Time
+	}{
+		{name: "session cookie", expires: -1},
+		{name: "zero expiry", expires: 0},
+		{name: "whole seconds", expires: 1767225600, wantExpires: time.Unix(1767225600, 0)},
+		{name: "fractional seconds", expires: 1767225600.25, wantExpires: time.Unix(1767225600, 250_000_000)},
+		{name: "far future", expires: proto.TimeSinceEpoch(farFuture.Unix()), wantExpires: farFuture},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original := []*proto.NetworkCookie{{
+				Name:     "ANCSESSIONID",
+				Value:    "abc=123; def",
+				Domain:   ".ancestry.com",
+				Path:     "/",
+				Expires:  tt.expires,
+				HTTPOnly: true,
+				Secure:   true,
+			}}
+
+			serialized, err := SerializeCookies(original)
+			if err != nil {
+				t.Fatalf("SerializeCookies: %v", err)
+			}
+			restored, err := DeserializeCookies(serialized)
+			if err != nil {
+				t.Fatalf("DeserializeCookies: %v", err)
+			}
+			if len(restored) != 1 || *restored[0] != *original[0] {
+				t.Fatalf("cookie changed in round trip: %+v", restored)
+			}
+
+			httpCookies := CookiesToHTTPCookies(restored)
+			if len(httpCookies) != 1 {
+				t.Fatalf("got %d http cookies", len(httpCookies))
+			}
+			c := httpCookies[0]
+			if c.Name != "ANCSESSIONID" || c.Value != "abc=123; def" || c.Domain != ".ancestry.com" || c.Path != "/" {
+				t.Errorf("unexpected cookie %+v", c)
+			}
+			if !c.Secure || !c.HttpOnly {
+				t.Errorf("secure/httpOnly lost: %+v", c)
+			}
+			if !c.Expires.Equal(tt.wantExpires) {
+				t.Errorf("Expires = %v, want %v", c.Expires, tt.wantExpires)
+			}
+		})
+	}
+}
+
+func TestDeserializeCookiesInvalid(t *testing.T) {
+	if _, err := DeserializeCookies("not json"); err == nil {
+		t.Error("expected an error for invalid JSON")
+	}
+
+	cookies, err := DeserializeCookies("[]")
+	if err != nil || len(cookies) != 0 {
+		t.Errorf("expected no cookies, got %v, %v", cookies, err)
+	}
+	if httpCookies := CookiesToHTTPCookies(cookies); len(httpCookies) != 0 {
+		t.Errorf("expected no http cookies, got %v", httpCookies)
+	}
+}
@@ -0,0 +1,158 @@
+package ancestry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultCircuitBreakerThreshold is the number of consecutive failures before the breaker opens
+	DefaultCircuitBreakerThreshold = 5
+	// circuitBreakerCooldown is how long the breaker stays open before allowing a test request
+	circuitBreakerCooldown = 60 * time.Second
+)
+
+// ErrCircuitOpen is returned when a request is rejected because the circuit breaker is open
+var ErrCircuitOpen = errors.New("circuit breaker is open: Ancestry.com appears to be unavailable")
+
+type breakerState int
+
+const (
+	breakerClosed breakerState = iota
+	breakerOpen
+	breakerHalfOpen
+)
+
+// circuitBreakerTransport is an http.RoundTripper that stops sending requests after
+// too many consecutive failures. Once open, requests fail fast until the cooldown
+// expires, after which a single test request is let through (half-open). A success
+// closes the breaker again; a failure re-opens it for another cooldown.
+type circuitBreakerTransport struct {
+	transport http.RoundTripper
+
+	mu        sync.Mutex
+	log       *log.Logger
+	threshold int // 0 or less disables the breaker
+	cooldown  time.Duration
+	now       func() time.Time // The clock, replaced in tests
+	state     breakerState
+	failures  int
+	openedAt  time.Time
+	probing   bool // true while the half-open test request is in flight
+}
+
+// newCircuitBreakerTransport creates a new circuitBreakerTransport.
+func newCircuitBreakerTransport(transport http.RoundTripper, threshold int, logger *log.Logger) *circuitBreakerTransport {
+	return &circuitBreakerTransport{
+		transport: transport,
+		log:       logger,
+		threshold: threshold,
+		cooldown:  circuitBreakerCooldown,
+		now:       time.Now,
+	}
+}
+
+// setThreshold updates the number of consecutive failures that opens the breaker.
+func (t *circuitBreakerTransport) setThreshold(threshold int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.threshold = threshold
+}
+
+// setLogger replaces the logger the breaker reports state changes to.
+func (t *circuitBreakerTransport) setLogger(logger *log.Logger) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.log = logger
+}
+
+// RoundTrip executes a single HTTP transaction unless the breaker is open.
+func (t *circuitBreakerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.allow(); err != nil {
+		return nil, err
+	}
+
+	resp, err := t.transport.RoundTrip(req)
+	if errors.Is(err, context.Canceled) {
+		// A cancelled request says nothing about whether the site is up
+		t.abandon()
+		return resp, err
+	}
+	// Server errors and throttling count as failures, client errors (404 etc.) do not
+	t.record(err == nil && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests)
+
+	return resp, err
+}
+
+// allow reports whether a request may be sent, moving an expired open breaker to half-open.
+func (t *circuitBreakerTransport) allow() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.threshold <= 0 {
+		return nil
+	}
+
+	switch t.state {
+	case breakerOpen:
+		remaining := t.cooldown - t.now().Sub(t.openedAt)
+		if remaining > 0 {
+			return fmt.Errorf("%w (retrying in %s)", ErrCircuitOpen, remaining.Round(time.Second))
+		}
+		t.state = breakerHalfOpen
+		t.probing = true
+		t.log.Println("Cooldown elapsed, sending a test request (half-open)")
+	case breakerHalfOpen:
+		// Only one test request at a time
+		if t.probing {
+			return ErrCircuitOpen
+		}
+		t.probing = true
+	}
+
+	return nil
+}
+
+// abandon lets another test request through after a half-open one was cancelled, leaving the
+// breaker state otherwise unchanged.
+func (t *circuitBreakerTransport) abandon() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.state == breakerHalfOpen {
+		t.probing = false
+	}
+}
+
+// record updates the breaker state with the outcome of a request.
+func (t *circuitBreakerTransport) record(success bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.threshold <= 0 {
+		return
+	}
+
+	if success {
+		if t.state != breakerClosed {
+			t.log.Println("Request succeeded, circuit breaker closed")
+		}
+		t.state = breakerClosed
+		t.failures = 0
+		t.probing = false
+		return
+	}
+
+	t.failures++
+	if t.state == breakerHalfOpen || (t.state == breakerClosed && t.failures >= t.threshold) {
+		t.state = breakerOpen
+		t.openedAt = t.now()
+		t.probing = false
+		t.log.Printf("Circuit breaker opened after %d consecutive failures, pausing requests for %s\n",
+			t.failures, t.cooldown)
+	}
+}
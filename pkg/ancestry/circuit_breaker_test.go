@@ -0,0 +1,185 @@
+package ancestry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// stubRoundTripper answers every request with the next of its responses: its error
+// if it has one, otherwise a response with its status
+type stubRoundTripper struct {
+	responses []stubResponse
+	calls     int
+}
+
+type stubResponse struct {
+	status int
+	err    error
+}
+
+// RoundTrip implements http.RoundTripper
+func (s *stubRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	next := s.responses[s.calls]
+	s.calls++
+	if next.err != nil {
+		return nil, next.err
+	}
+	return &http.Response{StatusCode: next.status, Body: http.NoBody, Request: req}, nil
+}
+
+// newTestBreaker returns a breaker over stub with a fake clock that only moves when
+// the returned function advances it
+func newTestBreaker(stub *stubRoundTripper, threshold int, cooldown time.Duration) (*circuitBreakerTransport, func(time.Duration)) {
+	breaker := newCircuitBreakerTransport(stub, threshold, log.New(io.Discard, "", 0))
+	breaker.cooldown = cooldown
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	breaker.now = func() time.Time { return now }
+	return breaker, func(d time.Duration) { now = now.Add(d) }
+}
+
+// roundTrip sends a request through the breaker and returns its error
+func roundTrip(t *testing.T, breaker *circuitBreakerTransport) error {
+	t.Helper()
+	req, err := http.NewRequest(http.MethodGet, "https://www.ancestry.com/", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = breaker.RoundTrip(req)
+	return err
+}
+
+func repeat(response stubResponse, n int) []stubResponse {
+	responses := make([]stubResponse, n)
+	for i := range responses {
+		responses[i] = response
+	}
+	return responses
+}
+
+var (
+	stubServerError = stubResponse{status: http.StatusServiceUnavailable}
+	stubOK          = stubResponse{status: http.StatusOK}
+)
+
+func TestCircuitBreakerOpensAfterThreshold(t *testing.T) {
+	stub := &stubRoundTripper{responses: repeat(stubServerError, 3)}
+	breaker, _ := newTestBreaker(stub, 3, time.Minute)
+
+	for i := 0; i < 3; i++ {
+		if breaker.state != breakerClosed {
+			t.Fatalf("state = %v after %d failures, want closed", breaker.state, i)
+		}
+		if err := roundTrip(t, breaker); err != nil {
+			t.Fatalf("request %d: %v", i+1, err)
+		}
+	}
+	if breaker.state != breakerOpen {
+		t.Fatalf("state = %v after 3 failures, want open", breaker.state)
+	}
+}
+
+func TestCircuitBreakerFailsFastWhileOpen(t *testing.T) {
+	stub := &stubRoundTripper{responses: repeat(stubServerError, 2)}
+	breaker, advance := newTestBreaker(stub, 2, time.Minute)
+	_ = roundTrip(t, breaker)
+	_ = roundTrip(t, breaker)
+
+	advance(30 * time.Second)
+	if err := roundTrip(t, breaker); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("error = %v during the cooldown, want %v", err, ErrCircuitOpen)
+	}
+	if stub.calls != 2 {
+		t.Errorf("%d requests reached the server, want 2", stub.calls)
+	}
+}
+
+func TestCircuitBreakerAllowsOneHalfOpenProbe(t *testing.T) {
+	stub := &stubRoundTripper{responses: repeat(stubServerError, 1)}
+	breaker, advance := newTestBreaker(stub, 1, time.Minute)
+	_ = roundTrip(t, breaker)
+
+	advance(time.Minute)
+	// The probe is still in flight when the next request asks
+	if err := breaker.allow(); err != nil {
+		t.Fatalf("probe not allowed after the cooldown: %v", err)
+	}
+	if breaker.state != breakerHalfOpen {
+		t.Fatalf("state = %v after the cooldown, want half-open", breaker.state)
+	}
+	if err := breaker.allow(); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("second request during the probe: error = %v, want %v", err, ErrCircuitOpen)
+	}
+}
+
+func TestCircuitBreakerClosesOnSuccess(t *testing.T) {
+	stub := &stubRoundTripper{responses: append(repeat(stubServerError, 2), stubOK, stubServerError)}
+	breaker, advance := newTestBreaker(stub, 2, time.Minute)
+	_ = roundTrip(t, breaker)
+	_ = roundTrip(t, breaker)
+
+	advance(time.Minute)
+	if err := roundTrip(t, breaker); err != nil {
+		t.Fatalf("probe: %v", err)
+	}
+	if breaker.state != breakerClosed {
+		t.Fatalf("state = %v after a successful probe, want closed", breaker.state)
+	}
+
+	// The failure count starts over, so one failure doesn't reopen it
+	_ = roundTrip(t, breaker)
+	if breaker.state != breakerClosed {
+		t.Errorf("state = %v after one failure, want closed", breaker.state)
+	}
+}
+
+func TestCircuitBreakerReopensOnFailedProbe(t *testing.T) {
+	stub := &stubRoundTripper{responses: repeat(stubServerError, 3)}
+	breaker, advance := newTestBreaker(stub, 2, time.Minute)
+	_ = roundTrip(t, breaker)
+	_ = roundTrip(t, breaker)
+
+	advance(time.Minute)
+	if err := roundTrip(t, breaker); err != nil {
+		t.Fatalf("probe: %v", err)
+	}
+	if breaker.state != breakerOpen {
+		t.Fatalf("state = %v after a failed probe, want open", breaker.state)
+	}
+
+	// The cooldown starts over from the failed probe
+	advance(30 * time.Second)
+	if err := roundTrip(t, breaker); !errors.Is(err, ErrCircuitOpen) {
+		t.Errorf("error = %v after the probe failed, want %v", err, ErrCircuitOpen)
+	}
+}
+
+func TestCircuitBreakerIgnoresCancellation(t *testing.T) {
+	canceled := stubResponse{err: context.Canceled}
+	stub := &stubRoundTripper{responses: append(repeat(canceled, 3), stubServerError, canceled, stubOK)}
+	breaker, advance := newTestBreaker(stub, 1, time.Minute)
+
+	for i := 0; i < 3; i++ {
+		_ = roundTrip(t, breaker)
+	}
+	if breaker.state != breakerClosed {
+		t.Fatalf("state = %v after cancelled requests, want closed", breaker.state)
+	}
+
+	// A cancelled probe lets the next request probe instead of blocking the breaker
+	_ = roundTrip(t, breaker)
+	advance(time.Minute)
+	if err := roundTrip(t, breaker); !errors.Is(err, context.Canceled) {
+		t.Fatalf("probe: error = %v, want %v", err, context.Canceled)
+	}
+	if err := roundTrip(t, breaker); err != nil {
+		t.Fatalf("probe after a cancelled one: %v", err)
+	}
+	if breaker.state != breakerClosed {
+		t.Errorf("state = %v after a successful probe, want closed", breaker.state)
+	}
+}
@@ -1,6 +1,7 @@
 package ancestry
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"log"
@@ -9,19 +10,35 @@ import (
 	"net/url"
 	"os"
 	"regexp"
+	"strings"
 	"time"
 
 	"github.com/go-rod/rod/lib/proto"
 	"golang.org/x/net/publicsuffix"
 )
 
+// DefaultUserAgent is the browser-like User-Agent sent with every request unless overridden
+const DefaultUserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/124.0.0.0 Safari/537.36"
+
+// DefaultMediaTimeout is how long a single media or record image download may take.
+// It is longer than the API timeout because full-size record images can be large.
+const DefaultMediaTimeout = 2 * time.Minute
+
 // APIClient handles HTTP requests to Ancestry.com APIs
 type APIClient struct {
 	httpClient       *http.Client
+	mediaClient      *http.Client    // Same session as httpClient, with the media timeout
+	transport        *http.Transport // Base transport shared by both clients
 	baseURL          string
-	loggingTransport *loggingTransport // For verbose mode
-	userID           string            // Added: Stores the authenticated user's ID
-	log              *log.Logger       // Added: Logger for client-specific messages
+	loggingTransport *loggingTransport        // For verbose mode
+	breaker          *circuitBreakerTransport // Fails fast during outages
+	limiter          *rateLimitTransport      // Spaces requests out to avoid throttling
+	timings          *timingTransport         // Records per-endpoint latency once enabled
+	userAgent        string                   // User-Agent header sent with every request
+	userID           string                   // Added: Stores the authenticated user's ID
+	log              *log.Logger              // Added: Logger for client-specific messages
+	htmlDebugDir     string                   // Where facts pages that fail extraction are saved, empty for nowhere
+	factsRetryDelay  time.Duration            // Pause before a failed Facts page request is retried
 }
 
 // NewAPIClient creates a new API client with the given cookies
@@ -59,8 +76,8 @@ func NewAPIClient(cookies []*proto.NetworkCookie, verbose bool) (*APIClient, err
 		// Another common place is a "AMCV_###@AdobeOrg" cookie, but s_vi is often more reliable
 	}
 
-	// Create base HTTP transport
-	baseTransport := http.DefaultTransport
+	// Create base HTTP transport; it is cloned so TLS settings don't leak into http.DefaultTransport
+	baseTransport := http.DefaultTransport.(*http.Transport).Clone()
 
 	// Wrap with logging transport if verbose mode is enabled
 	var logTransport *loggingTransport
@@ -73,18 +90,44 @@ func NewAPIClient(cookies []*proto.NetworkCookie, verbose bool) (*APIClient, err
 		finalTransport = logTransport
 	}
 
+	// Time requests inside the breaker, so requests it rejects don't count as fast responses
+	timings := newTimingTransport(finalTransport)
+	finalTransport = timings
+
+	// Pace requests outside the timings, so waiting for a turn doesn't count as latency
+	limiter := newRateLimitTransport(finalTransport, 0, DefaultRequestBurst)
+	finalTransport = limiter
+
+	// Wrap with a circuit breaker so an outage fails fast instead of retrying every request
+	breakerLogger := log.New(os.Stderr, "[CircuitBreaker] ", log.LstdFlags)
+	breaker := newCircuitBreakerTransport(finalTransport, DefaultCircuitBreakerThreshold, breakerLogger)
+	finalTransport = breaker
+
 	client := &http.Client{
 		Jar:       jar,
 		Timeout:   30 * time.Second,
 		Transport: finalTransport,
 	}
 
+	mediaClient := &http.Client{
+		Jar:       jar,
+		Timeout:   DefaultMediaTimeout,
+		Transport: finalTransport,
+	}
+
 	return &APIClient{
 		httpClient:       client,
+		mediaClient:      mediaClient,
+		transport:        baseTransport,
 		baseURL:          "https://www.ancestry.com",
 		loggingTransport: logTransport,
+		breaker:          breaker,
+		limiter:          limiter,
+		timings:          timings,
+		userAgent:        DefaultUserAgent,
 		userID:           extractedUserID, // Initialized userID
 		log:              clientLogger,    // Initialized logger
+		factsRetryDelay:  factsRetryDelay,
 	}, nil
 }
 
@@ -97,8 +140,137 @@ func NewAPIClientFromJSON(cookiesJSON string, verbose bool) (*APIClient, error)
 	return NewAPIClient(cookies, verbose)
 }
 
+// SetCircuitBreakerThreshold sets the number of consecutive failures after which
+// requests fail fast for a cooldown period. A threshold of 0 disables the breaker.
+func (c *APIClient) SetCircuitBreakerThreshold(threshold int) {
+	c.breaker.setThreshold(threshold)
+}
+
+// SetMediaTimeout sets the timeout for each media and record image download.
+// A zero or negative value keeps DefaultMediaTimeout.
+func (c *APIClient) SetMediaTimeout(timeout time.Duration) {
+	if timeout > 0 {
+		c.mediaClient.Timeout = timeout
+	}
+}
+
+// SetUserAgent sets the User-Agent header sent with every request.
+// An empty value keeps DefaultUserAgent.
+func (c *APIClient) SetUserAgent(userAgent string) {
+	if userAgent != "" {
+		c.userAgent = userAgent
+	}
+}
+
+// SetBaseURL sets the site requests are sent to instead of https://www.ancestry.com,
+// such as a local test server. An empty value keeps the current one.
+func (c *APIClient) SetBaseURL(baseURL string) {
+	if baseURL != "" {
+		c.baseURL = strings.TrimSuffix(baseURL, "/")
+	}
+}
+
+// SetLogger sends the client's and its circuit breaker's messages to logger, each with its
+// own prefix after the logger's. Client messages stay suppressed unless the client is verbose.
+func (c *APIClient) SetLogger(logger *log.Logger) {
+	if c.log.Writer() != io.Discard {
+		c.log = log.New(logger.Writer(), logger.Prefix()+"[APIClient] ", logger.Flags())
+	}
+	c.breaker.setLogger(log.New(logger.Writer(), logger.Prefix()+"[CircuitBreaker] ", logger.Flags()))
+}
+
+// newRequest creates a request sent with ctx, the client's User-Agent and the given Accept
+// and Referer headers. Empty accept or referer values leave that header unset.
+func (c *APIClient) newRequest(ctx context.Context, method, rawURL, accept, referer string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("User-Agent", c.userAgent)
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	if referer != "" {
+		req.Header.Set("Referer", referer)
+	}
+
+	return req, nil
+}
+
+// StatusError is returned when Ancestry answers a request with a status other than the one
+// expected. Check for it with errors.As to tell e.g. a rejected session (401, 403) from an outage.
+type StatusError struct {
+	Code int    // HTTP status code of the response
+	Body string // Response body, if it was read
+}
+
+// Error implements error
+func (e *StatusError) Error() string {
+	if e.Body != "" {
+		return fmt.Sprintf("status %d: %s", e.Code, e.Body)
+	}
+	return fmt.Sprintf("status %d", e.Code)
+}
+
+// statusError reads the body of an unexpected response into a StatusError
+func statusError(resp *http.Response) *StatusError {
+	body, _ := io.ReadAll(resp.Body)
+	return &StatusError{Code: resp.StatusCode, Body: string(body)}
+}
+
+// defaultReferer is the Referer sent with JSON API requests
+const defaultReferer = "https://www.ancestry.com/"
+
+// getJSON sends a GET request for path (relative to the base URL) with the given query
+// parameters and decodes the JSON response into out. Any status other than 200 is an error.
+func (c *APIClient) getJSON(ctx context.Context, path string, query url.Values, out interface{}) error {
+	return c.getJSONWithReferer(ctx, path, query, defaultReferer, out)
+}
+
+// getJSONWithReferer is getJSON for endpoints that expect a page-specific Referer
+func (c *APIClient) getJSONWithReferer(ctx context.Context, path string, query url.Values, referer string, out interface{}) error {
+	reqURL, err := url.Parse(c.baseURL + path)
+	if err != nil {
+		return fmt.Errorf("failed to parse URL: %w", err)
+	}
+	if len(query) > 0 {
+		reqURL.RawQuery = query.Encode()
+	}
+
+	req, err := c.newRequest(ctx, "GET", reqURL.String(), "*/*", referer)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make request: %w", err)
+	}
+	defer func() {
+		if err := resp.Body.Close(); err != nil {
+			c.log.Printf("Error closing response body: %v\n", err)
+		}
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("API request failed with %w", statusError(resp))
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if err := c.decodeJSON(body, path, out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	return nil
+}
+
 // GetUserID retrieves the authenticated user's ID, fetching it if not already known.
-func (c *APIClient) GetUserID() (string, error) {
+func (c *APIClient) GetUserID(ctx context.Context) (string, error) {
 	if c.userID != "" {
 		return c.userID, nil
 	}
@@ -111,12 +283,10 @@ func (c *APIClient) GetUserID() (string, error) {
 	// Let's try /myancestry as it should be light.
 	endpoint := fmt.Sprintf("%s/myancestry", c.baseURL)
 
-	req, err := http.NewRequest("GET", endpoint, nil)
+	req, err := c.newRequest(ctx, "GET", endpoint, "text/html,application/xhtml+xml,application/xml;q=0.9,image/webp,*/*;q=0.8", "")
 	if err != nil {
 		return "", fmt.Errorf("failed to create request for userID retrieval: %w", err)
 	}
-	// Mimic a browser request
-	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,image/webp,*/*;q=0.8")
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -129,8 +299,7 @@ func (c *APIClient) GetUserID() (string, error) {
 	}()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("failed to fetch page for userID retrieval, status %d: %s", resp.StatusCode, string(body))
+		return "", fmt.Errorf("failed to fetch page for userID retrieval, %w", statusError(resp))
 	}
 
 	html, err := io.ReadAll(resp.Body)
@@ -0,0 +1,114 @@
+package ancestry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+)
+
+// newTestClient returns an API client that sends its requests to server
+func newTestClient(server *httptest.Server) *APIClient {
+	return &APIClient{
+		httpClient: server.Client(),
+		baseURL:    server.URL,
+		userAgent:  DefaultUserAgent,
+		log:        log.New(io.Discard, "", 0),
+	}
+}
+
+func TestGetJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		if got := r.Header.Get("User-Agent"); got != DefaultUserAgent {
+			t.Errorf("User-Agent = %q", got)
+		}
+		if got := r.Header.Get("Referer"); got != defaultReferer {
+			t.Errorf("Referer = %q", got)
+		}
+		_, _ = w.Write([]byte(`{"name":"` + r.URL.Query().Get("name") + `"}`))
+	}))
+	defer server.Close()
+
+	client := newTestClient(server)
+
+	var out struct {
+		Name string `json:"name"`
+	}
+	if err := client.getJSON(context.Background(), "/echo", url.Values{"name": {"Smith"}}, &out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Name != "Smith" {
+		t.Errorf("decoded name = %q, want Smith", out.Name)
+	}
+
+	err := client.getJSON(context.Background(), "/missing", nil, &out)
+	if err == nil || !strings.Contains(err.Error(), "status 404") {
+		t.Errorf("expected a status 404 error, got %v", err)
+	}
+	var statusErr *StatusError
+	if !errors.As(err, &statusErr) || statusErr.Code != http.StatusNotFound {
+		t.Errorf("expected a *StatusError with code 404, got %v", err)
+	}
+}
+
+func TestRequestsCanceledWithContext(t *testing.T) {
+	started := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		close(started)
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	client := newTestClient(server)
+	client.mediaClient = server.Client()
+	go func() {
+		<-started
+		cancel()
+	}()
+
+	if _, err := client.GetPersonsCount(ctx, "42"); !errors.Is(err, context.Canceled) {
+		t.Errorf("in-flight request error = %v, want %v", err, context.Canceled)
+	}
+	if _, err := client.DownloadFile(ctx, MediaOwner{}, "/a.jpg"); !errors.Is(err, context.Canceled) {
+		t.Errorf("request after cancel error = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestSetLogger(t *testing.T) {
+	var buf strings.Builder
+	logger := log.New(&buf, "run: ", 0)
+
+	quiet, err := NewAPIClient(nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	quiet.SetLogger(logger)
+	quiet.log.Println("scraping userID")
+	quiet.breaker.log.Println("opened")
+	if got := buf.String(); got != "run: [CircuitBreaker] opened\n" {
+		t.Errorf("non-verbose client logged %q", got)
+	}
+
+	buf.Reset()
+	verbose, err := NewAPIClient(nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = verbose.Close(); _ = os.Remove("http_log.txt") })
+	verbose.SetLogger(logger)
+	verbose.log.Println("scraping userID")
+	if got := buf.String(); got != "run: [APIClient] scraping userID\n" {
+		t.Errorf("verbose client logged %q", got)
+	}
+}
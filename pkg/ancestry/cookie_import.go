@@ -0,0 +1,186 @@
+package ancestry
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-rod/rod/lib/proto"
+	"golang.org/x/net/publicsuffix"
+)
+
+// netscapeHTTPOnlyPrefix marks HttpOnly cookies in Netscape cookies.txt files
+const netscapeHTTPOnlyPrefix = "#HttpOnly_"
+
+// ParseCookieFile parses an exported cookie file, detecting whether it is a JSON
+// export (an array of cookie objects) or a Netscape cookies.txt file.
+// Only cookies for Ancestry domains are returned.
+func ParseCookieFile(data []byte) ([]*proto.NetworkCookie, error) {
+	var cookies []*proto.NetworkCookie
+	var err error
+
+	if trimmed := strings.TrimSpace(string(data)); strings.HasPrefix(trimmed, "[") {
+		cookies, err = ParseJSONCookieExport(data)
+	} else {
+		cookies, err = ParseNetscapeCookies(string(data))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var ancestryCookies []*proto.NetworkCookie
+	for _, cookie := range cookies {
+		if isAncestryDomain(cookie.Domain) {
+			ancestryCookies = append(ancestryCookies, cookie)
+		}
+	}
+
+	if len(ancestryCookies) == 0 {
+		return nil, fmt.Errorf("no ancestry.com cookies found in %d cookie(s)", len(cookies))
+	}
+
+	return ancestryCookies, nil
+}
+
+// isAncestryDomain reports whether a cookie domain belongs to an Ancestry site, i.e. its
+// registrable domain is ancestry.<public suffix> such as ancestry.com or ancestry.co.uk
+func isAncestryDomain(domain string) bool {
+	domain = strings.ToLower(strings.TrimPrefix(domain, "."))
+	registrable, err := publicsuffix.EffectiveTLDPlusOne(domain)
+	if err != nil {
+		return false
+	}
+	return strings.HasPrefix(registrable, "ancestry.")
+}
+
+// ParseNetscapeCookies parses the Netscape cookies.txt format used by curl, wget and
+// most "export cookies" browser extensions. Each line holds seven tab-separated fields:
+// domain, include subdomains, path, secure, expiry (unix seconds), name and value.
+func ParseNetscapeCookies(data string) ([]*proto.NetworkCookie, error) {
+	var cookies []*proto.NetworkCookie
+
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimRight(scanner.Text(), "\r")
+
+		httpOnly := false
+		if strings.HasPrefix(line, netscapeHTTPOnlyPrefix) {
+			httpOnly = true
+			line = strings.TrimPrefix(line, netscapeHTTPOnlyPrefix)
+		}
+
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) < 7 {
+			return nil, fmt.Errorf("line %d: expected 7 tab-separated fields, got %d", lineNumber, len(fields))
+		}
+
+		expires, err := strconv.ParseFloat(fields[4], 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid expiry %q: %w", lineNumber, fields[4], err)
+		}
+
+		cookies = append(cookies, &proto.NetworkCookie{
+			Domain:   fields[0],
+			Path:     fields[2],
+			Secure:   strings.EqualFold(fields[3], "TRUE"),
+			Expires:  proto.TimeSinceEpoch(expires),
+			Name:     fields[5],
+			Value:    strings.Join(fields[6:], "\t"),
+			HTTPOnly: httpOnly,
+		})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read cookies: %w", err)
+	}
+
+	return cookies, nil
+}
+
+// exportedCookie is a cookie as written by browser cookie-export extensions
+type exportedCookie struct {
+	Domain         string  `json:"domain"`
+	Name           string  `json:"name"`
+	Value          string  `json:"value"`
+	Path           string  `json:"path"`
+	Secure         bool    `json:"secure"`
+	HTTPOnly       bool    `json:"httpOnly"`
+	ExpirationDate float64 `json:"expirationDate"` // Browser extensions
+	Expires        float64 `json:"expires"`        // Cookies saved by ancestrydl
+}
+
+// ParseJSONCookieExport parses a JSON array of cookies, either from a browser
+// export extension or a cookies.json file saved by ancestrydl
+func ParseJSONCookieExport(data []byte) ([]*proto.NetworkCookie, error) {
+	var exported []exportedCookie
+	if err := json.Unmarshal(data, &exported); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON cookie export: %w", err)
+	}
+
+	cookies := make([]*proto.NetworkCookie, 0, len(exported))
+	for _, ec := range exported {
+		expires := ec.ExpirationDate
+		if expires == 0 {
+			expires = ec.Expires
+		}
+		cookies = append(cookies, &proto.NetworkCookie{
+			Domain:   ec.Domain,
+			Name:     ec.Name,
+			Value:    ec.Value,
+			Path:     ec.Path,
+			Secure:   ec.Secure,
+			HTTPOnly: ec.HTTPOnly,
+			Expires:  proto.TimeSinceEpoch(expires),
+		})
+	}
+
+	return cookies, nil
+}
+
+// SessionTokenCookie is the cookie holding Ancestry's authentication token, which is
+// enough on its own for most API requests
+const SessionTokenCookie = "SecureATT"
+
+// sessionCookie returns a cookie for all Ancestry hosts that lasts until the session ends
+func sessionCookie(name, value string) *proto.NetworkCookie {
+	return &proto.NetworkCookie{
+		Domain:   ".ancestry.com",
+		Path:     "/",
+		Name:     name,
+		Value:    value,
+		Secure:   true,
+		HTTPOnly: true,
+		Expires:  -1,
+	}
+}
+
+// BuildSessionCookies builds session cookies from a token and "name=value" pairs, for logging in
+// without a browser. The token, when given, is stored as SessionTokenCookie.
+func BuildSessionCookies(token string, pairs []string) ([]*proto.NetworkCookie, error) {
+	var cookies []*proto.NetworkCookie
+	if token = strings.TrimSpace(token); token != "" {
+		cookies = append(cookies, sessionCookie(SessionTokenCookie, token))
+	}
+
+	for _, pair := range pairs {
+		name, value, ok := strings.Cut(pair, "=")
+		name = strings.TrimSpace(name)
+		if !ok || name == "" {
+			return nil, fmt.Errorf("invalid cookie %q: expected name=value", pair)
+		}
+		cookies = append(cookies, sessionCookie(name, strings.TrimSpace(value)))
+	}
+
+	if len(cookies) == 0 {
+		return nil, fmt.Errorf("no token or cookies given")
+	}
+	return cookies, nil
+}
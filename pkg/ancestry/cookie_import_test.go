@@ -0,0 +1,68 @@
+package ancestry
+
+import "testing"
+
+func TestBuildSessionCookies(t *testing.T) {
+	tests := []struct {
+		name    string
+		token   string
+		pairs   []string
+		want    map[string]string
+		wantErr bool
+	}{
+		{name: "token", token: " abc123 ", want: map[string]string{SessionTokenCookie: "abc123"}},
+		{name: "cookies", pairs: []string{"ANCSESSIONID=xyz", "OMNITURE= a=b "}, want: map[string]string{"ANCSESSIONID": "xyz", "OMNITURE": "a=b"}},
+		{name: "token and cookies", token: "abc", pairs: []string{"ANCSESSIONID=xyz"}, want: map[string]string{SessionTokenCookie: "abc", "ANCSESSIONID": "xyz"}},
+		{name: "missing value separator", pairs: []string{"ANCSESSIONID"}, wantErr: true},
+		{name: "missing name", pairs: []string{"=xyz"}, wantErr: true},
+		{name: "nothing given", token: "  ", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cookies, err := BuildSessionCookies(tt.token, tt.pairs)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("BuildSessionCookies() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(cookies) != len(tt.want) {
+				t.Fatalf("got %d cookies, want %d", len(cookies), len(tt.want))
+			}
+			for _, cookie := range cookies {
+				if tt.want[cookie.Name] != cookie.Value {
+					t.Errorf("cookie %s = %q, want %q", cookie.Name, cookie.Value, tt.want[cookie.Name])
+				}
+				if cookie.Domain != ".ancestry.com" || cookie.Path != "/" || !cookie.Secure {
+					t.Errorf("cookie %s not scoped to ancestry.com: %+v", cookie.Name, cookie)
+				}
+			}
+		})
+	}
+}
+
+func TestIsAncestryDomain(t *testing.T) {
+	tests := []struct {
+		domain string
+		want   bool
+	}{
+		{domain: ".ancestry.com", want: true},
+		{domain: "www.ancestry.com", want: true},
+		{domain: "ancestry.co.uk", want: true},
+		{domain: ".www.ancestry.com.au", want: true},
+		{domain: "notancestry.evil.com", want: false},
+		{domain: "ancestry.evil.com", want: false},
+		{domain: "ancestry.com.evil.net", want: false},
+		{domain: "myancestry.com", want: false},
+		{domain: "", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.domain, func(t *testing.T) {
+			if got := isAncestryDomain(tt.domain); got != tt.want {
+				t.Errorf("isAncestryDomain(%q) = %v, want %v", tt.domain, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,205 @@
+package ancestry
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Date qualifiers recognized by ParseGenealogyDate
+const (
+	DateQualifierAbout   = "about"
+	DateQualifierBefore  = "before"
+	DateQualifierAfter   = "after"
+	DateQualifierBetween = "between"
+)
+
+// ErrNoDate is returned by ParseGenealogyDate when there is no date to parse
+var ErrNoDate = errors.New("no date")
+
+// ParsedDate is a genealogy date parsed from an event. Fields that aren't known
+// (for example the day in "Mar 1850") are zero.
+type ParsedDate struct {
+	Year      int    `json:"year,omitempty"`
+	Month     int    `json:"month,omitempty"`
+	Day       int    `json:"day,omitempty"`
+	EndYear   int    `json:"endYear,omitempty"` // End of a range such as "Bet 1850 and 1860"
+	EndMonth  int    `json:"endMonth,omitempty"`
+	EndDay    int    `json:"endDay,omitempty"`
+	Qualifier string `json:"qualifier,omitempty"` // "about", "before", "after" or "between"
+	Original  string `json:"original"`            // The date text as Ancestry returned it
+}
+
+// dateQualifiers maps the qualifier words Ancestry uses to a normalized qualifier
+var dateQualifiers = map[string]string{
+	"abt":     DateQualifierAbout,
+	"about":   DateQualifierAbout,
+	"circa":   DateQualifierAbout,
+	"ca":      DateQualifierAbout,
+	"c":       DateQualifierAbout,
+	"est":     DateQualifierAbout,
+	"cal":     DateQualifierAbout,
+	"bef":     DateQualifierBefore,
+	"before":  DateQualifierBefore,
+	"aft":     DateQualifierAfter,
+	"after":   DateQualifierAfter,
+	"bet":     DateQualifierBetween,
+	"between": DateQualifierBetween,
+	"from":    DateQualifierBetween,
+}
+
+// monthNames maps month name prefixes to month numbers
+var monthNames = []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}
+
+// ParseGenealogyDate parses an event date as returned by Ancestry. It accepts strings
+// like "12 Mar 1850", "Mar 1850", "1850", "1850-03-12", qualified dates such as
+// "Abt 1850" or "Bef 12 Mar 1850", ranges ("Bet 1850 and 1860", "1850-1860"), bare
+// year numbers, and the object form whose text is under one of the usual keys.
+// On failure the returned ParsedDate still holds the original text.
+func ParseGenealogyDate(raw interface{}) (ParsedDate, error) {
+	text := strings.Join(strings.Fields(dateText(raw)), " ")
+	parsed := ParsedDate{Original: text}
+	if text == "" {
+		return parsed, ErrNoDate
+	}
+
+	words := strings.Fields(strings.ToLower(strings.NewReplacer(",", " ", ".", " ").Replace(text)))
+	if qualifier, ok := dateQualifiers[words[0]]; ok && len(words) > 1 {
+		parsed.Qualifier = qualifier
+		words = words[1:]
+	}
+
+	start, end := splitDateRange(words)
+	if err := parseSingleDate(start, &parsed); err != nil {
+		return ParsedDate{Original: text}, fmt.Errorf("unrecognized date %q: %w", text, err)
+	}
+	if len(end) > 0 {
+		var endDate ParsedDate
+		if err := parseSingleDate(end, &endDate); err != nil {
+			return ParsedDate{Original: text}, fmt.Errorf("unrecognized date range %q: %w", text, err)
+		}
+		parsed.EndYear, parsed.EndMonth, parsed.EndDay = endDate.Year, endDate.Month, endDate.Day
+		parsed.Qualifier = DateQualifierBetween
+	}
+
+	return parsed, nil
+}
+
+// SortKey returns a number that orders dates chronologically (YYYYMMDD).
+// Dates without a year sort after all others.
+func (d ParsedDate) SortKey() int {
+	if d.Year == 0 {
+		return 99999999
+	}
+	return d.Year*10000 + d.Month*100 + d.Day
+}
+
+// dateText extracts the date text from the forms an event date can take
+func dateText(raw interface{}) string {
+	switch v := raw.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case float64:
+		return strconv.Itoa(int(v))
+	case int:
+		return strconv.Itoa(v)
+	case map[string]interface{}:
+		for _, key := range []string{"normalized", "n", "d", "date", "text", "original", "v"} {
+			if text, ok := v[key].(string); ok && strings.TrimSpace(text) != "" {
+				return text
+			}
+		}
+		return ""
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
+
+// splitDateRange splits "X and Y", "X to Y" and "YYYY-YYYY" ranges into their two dates
+func splitDateRange(words []string) (start, end []string) {
+	for i, word := range words {
+		if word == "and" || word == "to" || word == "-" {
+			return words[:i], words[i+1:]
+		}
+	}
+
+	if len(words) == 1 {
+		for _, dash := range []string{"-", "–"} {
+			if parts := strings.Split(words[0], dash); len(parts) == 2 && len(parts[0]) == 4 && len(parts[1]) == 4 {
+				return parts[:1], parts[1:]
+			}
+		}
+	}
+
+	return words, nil
+}
+
+// parseSingleDate parses one date ("12 mar 1850", "mar 12 1850", "mar 1850", "1850", "1850-03-12")
+func parseSingleDate(words []string, parsed *ParsedDate) error {
+	if len(words) == 1 && strings.Count(words[0], "-") > 0 {
+		return parseISODate(words[0], parsed)
+	}
+
+	for _, word := range words {
+		if month := monthNumber(word); month > 0 && parsed.Month == 0 {
+			parsed.Month = month
+			continue
+		}
+
+		number, err := strconv.Atoi(word)
+		if err != nil {
+			return fmt.Errorf("unexpected %q", word)
+		}
+		switch {
+		case len(word) >= 3 && parsed.Year == 0:
+			parsed.Year = number
+		case number >= 1 && number <= 31 && parsed.Day == 0:
+			parsed.Day = number
+		default:
+			return fmt.Errorf("unexpected %q", word)
+		}
+	}
+
+	if parsed.Year == 0 {
+		return errors.New("no year")
+	}
+	return nil
+}
+
+// parseISODate parses "YYYY-MM-DD" and "YYYY-MM"
+func parseISODate(word string, parsed *ParsedDate) error {
+	parts := strings.Split(word, "-")
+	if len(parts) > 3 || len(parts[0]) != 4 {
+		return fmt.Errorf("unexpected %q", word)
+	}
+
+	fields := []*int{&parsed.Year, &parsed.Month, &parsed.Day}
+	for i, part := range parts {
+		number, err := strconv.Atoi(part)
+		if err != nil {
+			return fmt.Errorf("unexpected %q", word)
+		}
+		*fields[i] = number
+	}
+
+	if parsed.Month > 12 || parsed.Day > 31 {
+		return fmt.Errorf("unexpected %q", word)
+	}
+	return nil
+}
+
+// monthNumber returns the month number for a month name or abbreviation, or 0
+func monthNumber(word string) int {
+	if len(word) < 3 {
+		return 0
+	}
+	for i, name := range monthNames {
+		if strings.HasPrefix(word, name) {
+			return i + 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,56 @@
+package ancestry
+
+import "testing"
+
+func TestParseGenealogyDate(t *testing.T) {
+	tests := []struct {
+		raw     interface{}
+		want    ParsedDate
+		wantErr bool
+	}{
+		{"12 Mar 1850", ParsedDate{Year: 1850, Month: 3, Day: 12}, false},
+		{"March 12, 1850", ParsedDate{Year: 1850, Month: 3, Day: 12}, false},
+		{"Mar 1850", ParsedDate{Year: 1850, Month: 3}, false},
+		{"1850", ParsedDate{Year: 1850}, false},
+		{"1850-03-12", ParsedDate{Year: 1850, Month: 3, Day: 12}, false},
+		{"Abt 1850", ParsedDate{Year: 1850, Qualifier: DateQualifierAbout}, false},
+		{"Bef. 12 Mar 1850", ParsedDate{Year: 1850, Month: 3, Day: 12, Qualifier: DateQualifierBefore}, false},
+		{"aft 1900", ParsedDate{Year: 1900, Qualifier: DateQualifierAfter}, false},
+		{"Bet 1850 and 1860", ParsedDate{Year: 1850, EndYear: 1860, Qualifier: DateQualifierBetween}, false},
+		{"1850-1860", ParsedDate{Year: 1850, EndYear: 1860, Qualifier: DateQualifierBetween}, false},
+		{float64(1875), ParsedDate{Year: 1875}, false},
+		{map[string]interface{}{"d": "5 Jun 1925"}, ParsedDate{Year: 1925, Month: 6, Day: 5}, false},
+		{map[string]interface{}{"n": "", "v": "Abt 1900"}, ParsedDate{Year: 1900, Qualifier: DateQualifierAbout}, false},
+		{1901, ParsedDate{Year: 1901}, false},
+		{"Abt. Mar 1850", ParsedDate{Year: 1850, Month: 3, Qualifier: DateQualifierAbout}, false},
+		{"Bet Mar 1850 and Jun 1851", ParsedDate{Year: 1850, Month: 3, EndYear: 1851, EndMonth: 6, Qualifier: DateQualifierBetween}, false},
+		{"From 1850 to 1860", ParsedDate{Year: 1850, EndYear: 1860, Qualifier: DateQualifierBetween}, false},
+		{"Bef 1850-03", ParsedDate{Year: 1850, Month: 3, Qualifier: DateQualifierBefore}, false},
+		{"1850–1860", ParsedDate{Year: 1850, EndYear: 1860, Qualifier: DateQualifierBetween}, false},
+		{"Abt", ParsedDate{}, true},
+		{map[string]interface{}{"t": "B"}, ParsedDate{}, true},
+		{"unknown", ParsedDate{}, true},
+		{nil, ParsedDate{}, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseGenealogyDate(tt.raw)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseGenealogyDate(%v) error = %v, wantErr %v", tt.raw, err, tt.wantErr)
+			continue
+		}
+		got.Original = ""
+		if got != tt.want {
+			t.Errorf("ParseGenealogyDate(%v) = %+v, want %+v", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestParsedDateSortKey(t *testing.T) {
+	early, _ := ParseGenealogyDate("Mar 1850")
+	late, _ := ParseGenealogyDate("12 Mar 1850")
+	undated, _ := ParseGenealogyDate("")
+	if !(early.SortKey() < late.SortKey() && late.SortKey() < undated.SortKey()) {
+		t.Errorf("unexpected order: %d, %d, %d", early.SortKey(), late.SortKey(), undated.SortKey())
+	}
+}
@@ -0,0 +1,53 @@
+package ancestry
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// rawCaptureDir is where undecodable response bodies are written in verbose mode
+const rawCaptureDir = "raw"
+
+// sensitiveJSONValue matches string values of keys that may hold personal or session data
+var sensitiveJSONValue = regexp.MustCompile(`(?i)("(?:[a-z_]*email[a-z_]*|[a-z_]*token[a-z_]*|password|cookie|session[a-z_]*|userid|ucdmid|username|phone[a-z_]*)"\s*:\s*)"(?:[^"\\]|\\.)*"`)
+
+// unsafeFileChars matches characters not allowed in capture file names
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// redactBody masks the values of sensitive keys in a response body before it is logged
+func redactBody(body []byte) []byte {
+	return sensitiveJSONValue.ReplaceAll(body, []byte(`${1}"[REDACTED]"`))
+}
+
+// decodeJSON unmarshals body into out. In verbose mode a body that fails to decode is
+// logged (redacted) to http_log.txt and saved under raw/ so the schema drift can be inspected.
+func (c *APIClient) decodeJSON(body []byte, endpoint string, out interface{}) error {
+	err := json.Unmarshal(body, out)
+	if err != nil && c.loggingTransport != nil {
+		c.loggingTransport.captureDecodeFailure(endpoint, body, err)
+	}
+	return err
+}
+
+// captureDecodeFailure records an undecodable response body in the log and under raw/
+func (t *loggingTransport) captureDecodeFailure(endpoint string, body []byte, decodeErr error) {
+	redacted := redactBody(body)
+	t.log(fmt.Sprintf("=== DECODE FAILURE: %s ===\nTime: %s\nError: %v\n%s\n========================================\n\n",
+		endpoint, time.Now().Format(time.RFC3339), decodeErr, string(redacted)))
+
+	if err := os.MkdirAll(rawCaptureDir, 0755); err != nil {
+		t.log(fmt.Sprintf("--- Failed to create %s directory: %v ---\n\n", rawCaptureDir, err))
+		return
+	}
+
+	name := strings.Trim(unsafeFileChars.ReplaceAllString(endpoint, "_"), "_")
+	path := filepath.Join(rawCaptureDir, fmt.Sprintf("%s-%s.json", time.Now().Format("20060102-150405.000"), name))
+	if err := os.WriteFile(path, redacted, 0644); err != nil {
+		t.log(fmt.Sprintf("--- Failed to write %s: %v ---\n\n", path, err))
+	}
+}
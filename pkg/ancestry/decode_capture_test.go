@@ -0,0 +1,35 @@
+package ancestry
+
+import "testing"
+
+func TestRedactBody(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{
+			name: "masks email and tokens",
+			body: `{"email":"a@b.com","accessToken":"abc","name":"Jane"}`,
+			want: `{"email":"[REDACTED]","accessToken":"[REDACTED]","name":"Jane"}`,
+		},
+		{
+			name: "handles whitespace and escaped quotes",
+			body: `{"userId" : "x\"y", "treeId": "123"}`,
+			want: `{"userId" : "[REDACTED]", "treeId": "123"}`,
+		},
+		{
+			name: "leaves non-string values alone",
+			body: `{"sessionCount": 3, "data": [1,2]}`,
+			want: `{"sessionCount": 3, "data": [1,2]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(redactBody([]byte(tt.body))); got != tt.want {
+				t.Errorf("redactBody() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,135 @@
+package ancestry
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// findEmbeddedJSON returns the JSON object assigned to variable (for example
+// "window.researchData") by an inline script on a page.
+//
+// The raw HTML is scanned for the assignment first, which is fast. If that fails
+// the page is parsed and each <script> element is searched instead, so small
+// markup changes (spacing, attribute order, script placement) don't break
+// extraction. An empty string with no error means the page has no assignment.
+func findEmbeddedJSON(page []byte, variable string) (string, error) {
+	if jsonStr, ok := scanForAssignment(string(page), variable+" = "); ok && json.Valid([]byte(jsonStr)) {
+		return jsonStr, nil
+	}
+
+	if jsonStr, ok := findAssignmentInScripts(page, variable); ok {
+		return jsonStr, nil
+	}
+
+	if bytes.Contains(page, []byte(variable)) {
+		return "", fmt.Errorf("found %s but could not extract its JSON", variable)
+	}
+
+	return "", nil
+}
+
+// scanForAssignment finds marker in content and returns the JSON object that follows it
+func scanForAssignment(content, marker string) (string, bool) {
+	start := strings.Index(content, marker)
+	if start == -1 {
+		return "", false
+	}
+	return extractJSONObject(content[start+len(marker):])
+}
+
+// extractJSONObject returns the JSON object at the start of s by counting braces,
+// ignoring any braces inside strings
+func extractJSONObject(s string) (string, bool) {
+	s = strings.TrimLeft(s, " \t\r\n")
+	if !strings.HasPrefix(s, "{") {
+		return "", false
+	}
+
+	depth := 0
+	inString := false
+	escaped := false
+	for i := 0; i < len(s); i++ {
+		switch ch := s[i]; {
+		case escaped:
+			escaped = false
+		case ch == '\\':
+			escaped = true
+		case ch == '"':
+			inString = !inString
+		case inString:
+		case ch == '{':
+			depth++
+		case ch == '}':
+			depth--
+			if depth == 0 {
+				return s[:i+1], true
+			}
+		}
+	}
+
+	return "", false
+}
+
+// findAssignmentInScripts parses the page and looks for the assignment in the
+// text of each <script> element
+func findAssignmentInScripts(page []byte, variable string) (string, bool) {
+	doc, err := html.Parse(bytes.NewReader(page))
+	if err != nil {
+		return "", false
+	}
+
+	var result string
+	var found bool
+	var walk func(n *html.Node)
+	walk = func(n *html.Node) {
+		if found {
+			return
+		}
+		if n.Type == html.ElementNode && n.DataAtom == atom.Script {
+			result, found = parseAssignment(scriptText(n), variable)
+			return
+		}
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			walk(child)
+		}
+	}
+	walk(doc)
+
+	return result, found
+}
+
+// scriptText returns the text content of a <script> element
+func scriptText(n *html.Node) string {
+	var b strings.Builder
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		if child.Type == html.TextNode {
+			b.WriteString(child.Data)
+		}
+	}
+	return b.String()
+}
+
+// parseAssignment finds "variable = {...}" in a script, allowing any whitespace around the "="
+func parseAssignment(script, variable string) (string, bool) {
+	offset := 0
+	for {
+		idx := strings.Index(script[offset:], variable)
+		if idx == -1 {
+			return "", false
+		}
+		offset += idx + len(variable)
+
+		rest := strings.TrimLeft(script[offset:], " \t\r\n")
+		if !strings.HasPrefix(rest, "=") {
+			continue
+		}
+		if jsonStr, ok := extractJSONObject(rest[1:]); ok && json.Valid([]byte(jsonStr)) {
+			return jsonStr, true
+		}
+	}
+}
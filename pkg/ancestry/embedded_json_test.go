@@ -0,0 +1,54 @@
+package ancestry
+
+import "testing"
+
+func TestFindEmbeddedJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		page    string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "standard assignment",
+			page: `<html><script>window.researchData = {"a":"}{","b":{"c":1}};</script></html>`,
+			want: `{"a":"}{","b":{"c":1}}`,
+		},
+		{
+			name: "changed spacing falls back to script parsing",
+			page: `<html><head><script type="text/javascript">
+				var x = 1;
+				window.researchData={"personFacts":[]}
+			</script></head></html>`,
+			want: `{"personFacts":[]}`,
+		},
+		{
+			name: "comparison before assignment is skipped",
+			page: `<script>if (window.researchData == null) {} window.researchData =
+				{"ok":true};</script>`,
+			want: `{"ok":true}`,
+		},
+		{
+			name: "missing assignment",
+			page: `<html><script>window.other = {};</script></html>`,
+			want: "",
+		},
+		{
+			name:    "unterminated object",
+			page:    `<html><script>window.researchData = {"a":1`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := findEmbeddedJSON([]byte(tt.page), "window.researchData")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
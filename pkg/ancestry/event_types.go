@@ -0,0 +1,26 @@
+package ancestry
+
+// eventTypeCodes maps the numeric PersonFactDetail.Type codes to the event names used
+// elsewhere in the export (the same strings Ancestry returns in TypeString). Only add a
+// code after seeing it next to its TypeString in a captured Facts page, and add that page
+// to testdata so TestEventTypeCodesMatchFixtures checks it; a fact with an unknown code and
+// no TypeString is left for inferEventTypes to name. Code 0 is used for custom events.
+var eventTypeCodes = map[int]string{
+	1: "Birth",
+	2: "Death",
+}
+
+// EventTypeFromCode returns the event name for a numeric fact type code,
+// or an empty string if the code is not known
+func EventTypeFromCode(code int) string {
+	return eventTypeCodes[code]
+}
+
+// GetEventType returns the fact's event name, falling back to the numeric type code
+// when Ancestry left TypeString empty
+func (f *PersonFactDetail) GetEventType() string {
+	if f.TypeString != "" {
+		return f.TypeString
+	}
+	return EventTypeFromCode(f.Type)
+}
@@ -0,0 +1,60 @@
+package ancestry
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGetEventType(t *testing.T) {
+	tests := []struct {
+		name string
+		fact PersonFactDetail
+		want string
+	}{
+		{"type string", PersonFactDetail{Type: 1, TypeString: "Birth"}, "Birth"},
+		{"custom event", PersonFactDetail{TypeString: "CustomEvent", Title: "Prison"}, "CustomEvent"},
+		{"known code without a type string", PersonFactDetail{Type: 2}, "Death"},
+		{"unknown code without a type string", PersonFactDetail{Type: 99}, ""},
+		{"no type", PersonFactDetail{}, ""},
+	}
+	for _, tt := range tests {
+		if got := tt.fact.GetEventType(); got != tt.want {
+			t.Errorf("%s: GetEventType() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestEventTypeCodesMatchFixtures checks every code in eventTypeCodes against the captured
+// Facts pages: each must be seen there with the same TypeString, and a fact from them
+// with its TypeString removed must still get its event name from the code
+func TestEventTypeCodesMatchFixtures(t *testing.T) {
+	seen := make(map[int]string)
+	for _, fixture := range []string{"facts_page.html", "facts_page_spacing.html"} {
+		page, err := os.ReadFile(filepath.Join("testdata", fixture))
+		if err != nil {
+			t.Fatal(err)
+		}
+		data, err := parseResearchData(page)
+		if err != nil {
+			t.Fatalf("%s: %v", fixture, err)
+		}
+		for _, fact := range data.PersonFacts {
+			if name, known := eventTypeCodes[fact.Type]; known && name != fact.TypeString {
+				t.Errorf("%s: code %d is %q, table says %q", fixture, fact.Type, fact.TypeString, name)
+			}
+			seen[fact.Type] = fact.TypeString
+
+			fact.TypeString = ""
+			if got := fact.GetEventType(); got != eventTypeCodes[fact.Type] {
+				t.Errorf("%s: code %d without a type string gives %q", fixture, fact.Type, got)
+			}
+		}
+	}
+
+	for code, name := range eventTypeCodes {
+		if seen[code] != name {
+			t.Errorf("code %d (%s) is not in any captured Facts page", code, name)
+		}
+	}
+}
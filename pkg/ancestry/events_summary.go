@@ -0,0 +1,72 @@
+package ancestry
+
+// Keys accepted for each field of an events summary entry. The list API abbreviates keys
+// the way the detailed Events do ("t", "d", "p"), but spelled-out keys are read as well.
+var (
+	summaryTypeKeys  = []string{"t", "type", "Type"}
+	summaryDateKeys  = []string{"d", "date", "Date"}
+	summaryPlaceKeys = []string{"p", "place", "Place"}
+	// summaryValueKeys are where a date or place given as an object holds its text
+	summaryValueKeys = []string{"v", "o", "original", "normalized", "n"}
+)
+
+// summaryEventTypes maps the one-letter type codes of summary entries to event names
+var summaryEventTypes = map[string]string{
+	"B": "Birth",
+	"D": "Death",
+}
+
+// SummaryLifeEvents returns the events in the person's events summary (the lowercase "events"
+// field of the list API) as Events with a type, date and place, so a person has at least basic
+// dates before GetFamilyView or the facts page fill in Events. Entries are objects whose type
+// is a name, a one-letter code ("B", "D") or a numeric fact type code (see EventTypeFromCode),
+// and whose date and place are strings or objects holding one. Entries without a known type
+// are skipped.
+func (p *Person) SummaryLifeEvents() []Event {
+	var events []Event
+	for _, item := range p.EventsSummary {
+		itemMap, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		eventType := summaryEventType(itemMap)
+		if eventType == "" {
+			continue
+		}
+		event := Event{Type: eventType, Place: summaryText(itemMap, summaryPlaceKeys)}
+		if date := summaryText(itemMap, summaryDateKeys); date != "" {
+			event.Date = date
+		}
+		events = append(events, event)
+	}
+	return events
+}
+
+// summaryEventType returns the event name of a summary entry
+func summaryEventType(item map[string]interface{}) string {
+	for _, key := range summaryTypeKeys {
+		if code, ok := item[key].(float64); ok {
+			return EventTypeFromCode(int(code))
+		}
+	}
+	eventType := firstStringValue(item, summaryTypeKeys)
+	if name, ok := summaryEventTypes[eventType]; ok {
+		return name
+	}
+	return eventType
+}
+
+// summaryText returns the first of keys holding text, either directly or in an object
+func summaryText(item map[string]interface{}, keys []string) string {
+	if text := firstStringValue(item, keys); text != "" {
+		return text
+	}
+	for _, key := range keys {
+		if obj, ok := item[key].(map[string]interface{}); ok {
+			if text := firstStringValue(obj, summaryValueKeys); text != "" {
+				return text
+			}
+		}
+	}
+	return ""
+}
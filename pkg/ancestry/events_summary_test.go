@@ -0,0 +1,64 @@
+package ancestry
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestSummaryLifeEvents(t *testing.T) {
+	tests := []struct {
+		name    string
+		summary string
+		want    []Event
+	}{
+		{
+			name:    "abbreviated keys",
+			summary: `[{"t":"Birth","d":"12 Mar 1850","p":"York, England"},{"t":"Death","d":"1920"}]`,
+			want: []Event{
+				{Type: "Birth", Date: "12 Mar 1850", Place: "York, England"},
+				{Type: "Death", Date: "1920"},
+			},
+		},
+		{
+			name:    "one-letter codes and numeric years",
+			summary: `[{"t":"B","d":1850},{"t":"D","d":1920}]`,
+			want:    []Event{{Type: "Birth", Date: "1850"}, {Type: "Death", Date: "1920"}},
+		},
+		{
+			name:    "nested values",
+			summary: `[{"type":"Birth","date":{"normalized":"1850"},"place":{"v":"Leeds"}}]`,
+			want:    []Event{{Type: "Birth", Date: "1850", Place: "Leeds"}},
+		},
+		{
+			name:    "numeric fact type codes",
+			summary: `[{"type":1,"date":"1850"}]`,
+			want:    []Event{{Type: "Birth", Date: "1850"}},
+		},
+		{
+			name:    "unknown fact type codes are skipped",
+			summary: `[{"type":99,"date":"1850"}]`,
+		},
+		{
+			name:    "undated event keeps a nil date",
+			summary: `[{"t":"Death"}]`,
+			want:    []Event{{Type: "Death"}},
+		},
+		{
+			name:    "entries without a type are skipped",
+			summary: `[{"d":"1850"},"1850",null]`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var person Person
+			if err := json.Unmarshal([]byte(`{"events":`+tt.summary+`}`), &person); err != nil {
+				t.Fatal(err)
+			}
+			if got := person.SummaryLifeEvents(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("SummaryLifeEvents() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package ancestry
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// SetHTMLDebugDir makes the client save the raw HTML of facts pages it can't extract data
+// from into dir, so the extractor can be updated when Ancestry changes its pages. An empty
+// dir turns this off.
+func (c *APIClient) SetHTMLDebugDir(dir string) {
+	c.htmlDebugDir = dir
+}
+
+// htmlDebugFileName names a saved page after its tree, person and the data missing from it,
+// e.g. "123-456-researchData.html"
+func htmlDebugFileName(treeID, personID, missing string) string {
+	shortPersonID, _, _ := strings.Cut(personID, ":")
+	return fmt.Sprintf("%s-%s-%s.html", treeID, shortPersonID, missing)
+}
+
+// keepFailedPage saves a page whose data couldn't be extracted, if SetHTMLDebugDir was given
+// a directory. A page that can't be saved is only reported, since it is just a diagnostic.
+func (c *APIClient) keepFailedPage(treeID, personID, missing string, html []byte) {
+	if c.htmlDebugDir == "" {
+		return
+	}
+
+	path := filepath.Join(c.htmlDebugDir, htmlDebugFileName(treeID, personID, missing))
+	err := os.MkdirAll(c.htmlDebugDir, 0755)
+	if err == nil {
+		err = os.WriteFile(path, html, 0644)
+	}
+	if err != nil {
+		c.log.Printf("   [Debug] Could not save page without %s: %v\n", missing, err)
+		return
+	}
+	c.log.Printf("   [Debug] Saved page without %s to %s\n", missing, path)
+}
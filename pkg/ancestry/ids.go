@@ -0,0 +1,122 @@
+package ancestry
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Query parameters Ancestry pages use to name the focused person
+var personIDQueryKeys = []string{"cfpid", "pid"}
+
+// NormalizeTreeID turns a tree ID as a user might paste it into the numeric ID the API
+// expects. Surrounding whitespace is trimmed, the ID is taken from an ancestry.com tree or
+// person URL, and a composite person ID ("<person>:1030:<tree>") is reduced to its tree.
+// Anything that doesn't leave a number is an error.
+func NormalizeTreeID(input string) (string, error) {
+	id := strings.TrimSpace(input)
+	if id == "" {
+		return "", fmt.Errorf("tree ID is empty")
+	}
+	if isAncestryURL(id) {
+		treeID, ok := urlPathSegmentAfter(id, "tree")
+		if !ok {
+			return "", fmt.Errorf("no tree ID found in URL %q; copy the link of a page inside the tree", id)
+		}
+		id = treeID
+	} else if parts := strings.Split(id, ":"); len(parts) == 3 {
+		id = parts[2]
+	}
+	if !isNumericID(id) {
+		return "", fmt.Errorf("invalid tree ID %q: expected a number such as 123456789, or a tree URL", strings.TrimSpace(input))
+	}
+	return id, nil
+}
+
+// NormalizePersonID turns a person ID as a user might paste it into the short numeric ID.
+// Surrounding whitespace is trimmed, the ID is taken from an ancestry.com person URL (or its
+// cfpid/pid parameter), and a composite ID ("<person>:1030:<tree>") is reduced to its person.
+// Anything that doesn't leave a number is an error.
+func NormalizePersonID(input string) (string, error) {
+	id := strings.TrimSpace(input)
+	if id == "" {
+		return "", fmt.Errorf("person ID is empty")
+	}
+	if isAncestryURL(id) {
+		personID, ok := personIDFromURL(id)
+		if !ok {
+			return "", fmt.Errorf("no person ID found in URL %q; copy the link of the person's page", id)
+		}
+		id = personID
+	} else if parts := strings.Split(id, ":"); len(parts) == 3 {
+		id = parts[0]
+	}
+	if !isNumericID(id) {
+		return "", fmt.Errorf("invalid person ID %q: expected a number such as 232573524428, or a person URL", strings.TrimSpace(input))
+	}
+	return id, nil
+}
+
+// isAncestryURL reports whether input looks like a pasted Ancestry link, with or without
+// its scheme (any regional domain, such as ancestry.co.uk)
+func isAncestryURL(input string) bool {
+	lower := strings.ToLower(input)
+	return strings.Contains(lower, "ancestry.") && strings.Contains(lower, "/")
+}
+
+// parseAncestryURL parses a pasted link, adding the scheme if it was left off
+func parseAncestryURL(input string) (*url.URL, bool) {
+	if !strings.Contains(input, "://") {
+		input = "https://" + input
+	}
+	parsed, err := url.Parse(input)
+	return parsed, err == nil
+}
+
+// urlPathSegmentAfter returns the numeric path segment following name, e.g. the tree ID in
+// /family-tree/person/tree/123/person/456/facts
+func urlPathSegmentAfter(rawURL, name string) (string, bool) {
+	parsed, ok := parseAncestryURL(rawURL)
+	if !ok {
+		return "", false
+	}
+	segments := strings.Split(strings.Trim(parsed.Path, "/"), "/")
+	for i := 0; i+1 < len(segments); i++ {
+		if strings.EqualFold(segments[i], name) && isNumericID(segments[i+1]) {
+			return segments[i+1], true
+		}
+	}
+	return "", false
+}
+
+// personIDFromURL returns the person ID of a person page URL, or of a tree page URL that
+// focuses a person through a query parameter
+func personIDFromURL(rawURL string) (string, bool) {
+	if id, ok := urlPathSegmentAfter(rawURL, "person"); ok {
+		return id, true
+	}
+	parsed, ok := parseAncestryURL(rawURL)
+	if !ok {
+		return "", false
+	}
+	query := parsed.Query()
+	for _, key := range personIDQueryKeys {
+		if id := query.Get(key); isNumericID(id) {
+			return id, true
+		}
+	}
+	return "", false
+}
+
+// isNumericID reports whether id is a non-empty string of digits
+func isNumericID(id string) bool {
+	if id == "" {
+		return false
+	}
+	for _, r := range id {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,63 @@
+package ancestry
+
+import "testing"
+
+func TestNormalizeTreeID(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{name: "plain", input: "123456789", want: "123456789"},
+		{name: "whitespace", input: "  123456789\n", want: "123456789"},
+		{name: "tree URL", input: "https://www.ancestry.com/family-tree/tree/123456789/family?cfpid=232573524428", want: "123456789"},
+		{name: "person URL", input: "https://www.ancestry.com/family-tree/person/tree/123456789/person/232573524428/facts", want: "123456789"},
+		{name: "URL without scheme", input: "www.ancestry.co.uk/family-tree/tree/42/family", want: "42"},
+		{name: "composite person ID", input: "232573524428:1030:123456789", want: "123456789"},
+		{name: "URL without a tree", input: "https://www.ancestry.com/search/", wantErr: true},
+		{name: "not a number", input: "my-tree", wantErr: true},
+		{name: "empty", input: "  ", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeTreeID(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NormalizeTreeID(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("NormalizeTreeID(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizePersonID(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{name: "plain", input: "232573524428", want: "232573524428"},
+		{name: "whitespace", input: "\t232573524428 ", want: "232573524428"},
+		{name: "person URL", input: "https://www.ancestry.com/family-tree/person/tree/123456789/person/232573524428/facts", want: "232573524428"},
+		{name: "tree URL focusing a person", input: "https://www.ancestry.com/family-tree/tree/123456789/family?cfpid=232573524428", want: "232573524428"},
+		{name: "composite ID", input: "232573524428:1030:123456789", want: "232573524428"},
+		{name: "tree URL without a person", input: "https://www.ancestry.com/family-tree/tree/123456789/family", wantErr: true},
+		{name: "not a number", input: "John Smith", wantErr: true},
+		{name: "malformed composite", input: "12:34", wantErr: true},
+		{name: "empty", input: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizePersonID(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NormalizePersonID(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("NormalizePersonID(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package ancestry
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// lifespanYear matches a four-digit year in a lifespan string
+var lifespanYear = regexp.MustCompile(`\d{4}`)
+
+// Lifespan returns the birth and death years encoded in the person's "l" or "lus"
+// fields, which the list and family view APIs fill in even when no events are
+// returned. Observed formats are "1850–1920" (en dash), "1850-1920", "1850–" and
+// "–1920" (one year unknown), "b. 1850" and "d. 1920", and "Living"; the value may
+// be a plain string or an object holding the string under "v". "lus" is only used
+// when "l" holds nothing. Unknown years are returned as "".
+func (p *Person) Lifespan() (birthYear, deathYear string) {
+	for _, raw := range []interface{}{p.L, p.Lus} {
+		birthYear, deathYear = parseLifespan(lifespanText(raw))
+		if birthYear != "" || deathYear != "" {
+			return birthYear, deathYear
+		}
+	}
+	return "", ""
+}
+
+// lifespanText extracts the lifespan string from the forms the "l" and "lus" fields take
+func lifespanText(raw interface{}) string {
+	switch v := raw.(type) {
+	case string:
+		return v
+	case float64:
+		return fmt.Sprintf("%.0f", v)
+	case map[string]interface{}:
+		if s, ok := v["v"].(string); ok {
+			return s
+		}
+	}
+	return ""
+}
+
+// parseLifespan splits a lifespan string into birth and death years
+func parseLifespan(text string) (birthYear, deathYear string) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return "", ""
+	}
+
+	lower := strings.ToLower(text)
+	switch {
+	case strings.HasPrefix(lower, "b."):
+		return lifespanYear.FindString(text), ""
+	case strings.HasPrefix(lower, "d."):
+		return "", lifespanYear.FindString(text)
+	}
+
+	sep := strings.IndexAny(text, "–—-")
+	if sep < 0 {
+		return lifespanYear.FindString(text), ""
+	}
+
+	_, width := utf8.DecodeRuneInString(text[sep:])
+	return lifespanYear.FindString(text[:sep]), lifespanYear.FindString(text[sep+width:])
+}
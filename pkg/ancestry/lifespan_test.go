@@ -0,0 +1,32 @@
+package ancestry
+
+import "testing"
+
+func TestPersonLifespan(t *testing.T) {
+	tests := []struct {
+		name      string
+		person    Person
+		wantBirth string
+		wantDeath string
+	}{
+		{name: "en dash range", person: Person{L: "1850–1920"}, wantBirth: "1850", wantDeath: "1920"},
+		{name: "hyphen range with spaces", person: Person{L: "1850 - 1920"}, wantBirth: "1850", wantDeath: "1920"},
+		{name: "open death", person: Person{L: "1850–"}, wantBirth: "1850"},
+		{name: "open birth", person: Person{L: "–1920"}, wantDeath: "1920"},
+		{name: "born prefix", person: Person{L: "b. 1850"}, wantBirth: "1850"},
+		{name: "died prefix", person: Person{L: "d. 1920"}, wantDeath: "1920"},
+		{name: "living", person: Person{L: "Living"}},
+		{name: "object form", person: Person{L: map[string]interface{}{"v": "1801–1870"}}, wantBirth: "1801", wantDeath: "1870"},
+		{name: "falls back to lus", person: Person{L: "", Lus: "1790–1850"}, wantBirth: "1790", wantDeath: "1850"},
+		{name: "nothing", person: Person{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			birth, death := tt.person.Lifespan()
+			if birth != tt.wantBirth || death != tt.wantDeath {
+				t.Errorf("Lifespan() = (%q, %q), want (%q, %q)", birth, death, tt.wantBirth, tt.wantDeath)
+			}
+		})
+	}
+}
@@ -1,39 +1,38 @@
 package ancestry
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
 	"net/url"
 	"strings"
 )
 
 // GetPersonMedia retrieves all media attached to a person
-func (c *APIClient) GetPersonMedia(treeID, personID string) (*PersonMedia, error) {
+func (c *APIClient) GetPersonMedia(ctx context.Context, treeID, personID string) (*PersonMedia, error) {
 	endpoint := fmt.Sprintf("%s/api/media/viewer/v1/trees/%s/people/%s", c.baseURL, treeID, personID)
 
-	req, err := http.NewRequest("GET", endpoint, nil)
+	req, err := c.newRequest(ctx, "GET", endpoint, "application/json", personPageReferer(treeID, personID))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("Referer", fmt.Sprintf("https://www.ancestry.com/family-tree/person/tree/%s/person/%s", treeID, personID))
-
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to make request: %w", err)
 	}
 	defer func() {
 		if err := resp.Body.Close(); err != nil {
-			fmt.Printf("Error closing response body: %v\n", err)
+			c.log.Printf("Error closing response body: %v\n", err)
 		}
 	}()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+		return nil, fmt.Errorf("API request failed with %w", statusError(resp))
 	}
 
 	bodyBytes, err := io.ReadAll(resp.Body)
@@ -104,7 +103,7 @@ func extractPersonMedia(personMedia *PersonMedia, result map[string]interface{})
 }
 
 // GetPersonMediaFromAPI fetches media items using the media viewer API
-func (c *APIClient) GetPersonMediaFromAPI(treeID, personID string) ([]PrimaryMediaItem, error) {
+func (c *APIClient) GetPersonMediaFromAPI(ctx context.Context, treeID, personID string) ([]PrimaryMediaItem, error) {
 	// Extract just the person ID (first part before colon)
 	shortPersonID := personID
 	if parts := strings.Split(personID, ":"); len(parts) > 0 {
@@ -128,11 +127,10 @@ func (c *APIClient) GetPersonMediaFromAPI(treeID, personID string) ([]PrimaryMed
 	query.Set("sort", "-created")
 	reqURL.RawQuery = query.Encode()
 
-	req, err := http.NewRequest("GET", reqURL.String(), nil)
+	req, err := c.newRequest(ctx, "GET", reqURL.String(), "application/json", personPageReferer(treeID, personID))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
-	req.Header.Set("Accept", "application/json")
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -140,17 +138,21 @@ func (c *APIClient) GetPersonMediaFromAPI(treeID, personID string) ([]PrimaryMed
 	}
 	defer func() {
 		if err := resp.Body.Close(); err != nil {
-			fmt.Printf("Error closing response body: %v\n", err)
+			c.log.Printf("Error closing response body: %v\n", err)
 		}
 	}()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("media API request failed with status %d: %s", resp.StatusCode, string(body))
+		return nil, fmt.Errorf("media API request failed with %w", statusError(resp))
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read media response: %w", err)
 	}
 
 	var mediaResp MediaViewerResponse
-	if err := json.NewDecoder(resp.Body).Decode(&mediaResp); err != nil {
+	if err := c.decodeJSON(body, reqURL.Path, &mediaResp); err != nil {
 		// If no media, return empty slice instead of error
 		if strings.Contains(err.Error(), "cannot unmarshal") {
 			return []PrimaryMediaItem{}, nil
@@ -189,7 +191,7 @@ func (c *APIClient) GetPersonMediaFromAPI(treeID, personID string) ([]PrimaryMed
 }
 
 // GetPersonFactsAndMedia scrapes the person's facts page to find media URLs (DEPRECATED - use GetPersonMediaFromAPI)
-func (c *APIClient) GetPersonFactsAndMedia(treeID, personID string) ([]PrimaryMediaItem, error) {
+func (c *APIClient) GetPersonFactsAndMedia(ctx context.Context, treeID, personID string) ([]PrimaryMediaItem, error) {
 	// Extract just the person ID (first part before colon)
 	// Person IDs come as "232573524428:1030:197283789" but URLs only need "232573524428"
 	shortPersonID := personID
@@ -199,12 +201,10 @@ func (c *APIClient) GetPersonFactsAndMedia(treeID, personID string) ([]PrimaryMe
 
 	endpoint := fmt.Sprintf("%s/family-tree/person/tree/%s/person/%s/facts", c.baseURL, treeID, shortPersonID)
 
-	req, err := http.NewRequest("GET", endpoint, nil)
+	req, err := c.newRequest(ctx, "GET", endpoint, "text/html,application/xhtml+xml,application/xml;q=0.9,image/webp,*/*;q=0.8", fmt.Sprintf("https://www.ancestry.com/family-tree/tree/%s/family/familyview", treeID))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request for facts page: %w", err)
 	}
-	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,image/webp,*/*;q=0.8")
-	req.Header.Set("Referer", fmt.Sprintf("https://www.ancestry.com/family-tree/tree/%s/family/familyview", treeID))
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -212,12 +212,12 @@ func (c *APIClient) GetPersonFactsAndMedia(treeID, personID string) ([]PrimaryMe
 	}
 	defer func() {
 		if err := resp.Body.Close(); err != nil {
-			fmt.Printf("Error closing response body: %v\n", err)
+			c.log.Printf("Error closing response body: %v\n", err)
 		}
 	}()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("facts page request failed with status %d (URL: %s)", resp.StatusCode, endpoint)
+		return nil, fmt.Errorf("facts page request failed with %w (URL: %s)", &StatusError{Code: resp.StatusCode}, endpoint)
 	}
 
 	html, err := io.ReadAll(resp.Body)
@@ -226,34 +226,27 @@ func (c *APIClient) GetPersonFactsAndMedia(treeID, personID string) ([]PrimaryMe
 	}
 
 	// Extract the INITIAL_STATE JSON from the HTML
-	htmlContent := string(html)
-	startMarker := "window.INITIAL_STATE = "
-	startIndex := strings.Index(htmlContent, startMarker)
-	if startIndex == -1 {
-		fmt.Println("   [Debug] Could not find INITIAL_STATE in HTML content")
-		return nil, nil // Return empty slice instead of error
+	jsonStr, err := findEmbeddedJSON(html, "window.INITIAL_STATE")
+	if err != nil {
+		c.keepFailedPage(treeID, shortPersonID, "INITIAL_STATE", html)
+		return nil, err
 	}
-
-	// Find the end of the JSON object
-	endMarker := `</script>`
-	jsonStartIndex := startIndex + len(startMarker)
-	jsonEndIndex := strings.Index(htmlContent[jsonStartIndex:], endMarker)
-	if jsonEndIndex == -1 {
-		return nil, fmt.Errorf("could not find end of INITIAL_STATE script tag")
+	if jsonStr == "" {
+		c.log.Println("   [Debug] Could not find INITIAL_STATE in HTML content")
+		c.keepFailedPage(treeID, shortPersonID, "INITIAL_STATE", html)
+		return nil, nil // Return empty slice instead of error
 	}
-	jsonEndIndex += jsonStartIndex
-	// The JSON blob is terminated by a semicolon
-	jsonStr := strings.TrimRight(htmlContent[jsonStartIndex:jsonEndIndex], ";")
 
 	var initialState InitialState
 	if err := json.Unmarshal([]byte(jsonStr), &initialState); err != nil {
+		c.keepFailedPage(treeID, shortPersonID, "INITIAL_STATE", html)
 		return nil, fmt.Errorf("failed to unmarshal INITIAL_STATE JSON: %w", err)
 	}
 
 	// Extract media items
 	mediaItems := extractMediaItems(initialState)
 
-	fmt.Printf("   [Debug] Found %d media items for person %s\n", len(mediaItems), personID)
+	c.log.Printf("   [Debug] Found %d media items for person %s\n", len(mediaItems), personID)
 	return mediaItems, nil
 }
 
@@ -291,27 +284,62 @@ func extractMediaItems(initialState InitialState) []PrimaryMediaItem {
 	return mediaItems
 }
 
-// DownloadFile downloads a file from a given URL
-func (c *APIClient) DownloadFile(fileURL string) ([]byte, error) {
-	req, err := http.NewRequest("GET", "http://ancestry.com/"+fileURL, nil)
+// MediaOwner identifies the tree and person a media file or record image belongs to.
+// Downloads send that person's page as the Referer, as the Ancestry UI does; some media
+// endpoints reject requests without it. The zero MediaOwner sends the home page instead.
+type MediaOwner struct {
+	TreeID   string
+	PersonID string
+}
+
+// referer returns the Referer for downloads of the owner's media
+func (o MediaOwner) referer() string {
+	if o.TreeID == "" || o.PersonID == "" {
+		return defaultReferer
+	}
+	return personPageReferer(o.TreeID, o.PersonID)
+}
+
+// resolveMediaURL resolves a media URL against the base URL. Host-relative URLs ("/api/...")
+// and relative paths use the base URL's host, protocol-relative URLs
+// ("//mediasvc.ancestry.com/...") keep their host and take the base URL's scheme, and
+// absolute URLs are used as they are.
+func resolveMediaURL(baseURL, mediaURL string) (*url.URL, error) {
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse base URL: %w", err)
+	}
+	ref, err := url.Parse(strings.TrimSpace(mediaURL))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse media URL: %w", err)
+	}
+	return base.ResolveReference(ref), nil
+}
+
+// DownloadFile downloads a file from a given URL, such as PrimaryMediaItem.URL. Relative
+// URLs are resolved against the Ancestry site.
+func (c *APIClient) DownloadFile(ctx context.Context, owner MediaOwner, fileURL string) ([]byte, error) {
+	reqURL, err := resolveMediaURL(c.baseURL, fileURL)
+	if err != nil {
+		return nil, err
+	}
+	req, err := c.newRequest(ctx, "GET", reqURL.String(), "image/webp,image/apng,image/*,*/*;q=0.8", owner.referer())
 	if err != nil {
 		return nil, fmt.Errorf("failed to create download request: %w", err)
 	}
-	req.Header.Set("Accept", "image/webp,image/apng,image/*,*/*;q=0.8")
-	req.Header.Set("Referer", c.baseURL+"/")
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.mediaClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to download file: %w", err)
 	}
 	defer func() {
 		if err := resp.Body.Close(); err != nil {
-			fmt.Printf("Error closing response body: %v\n", err)
+			c.log.Printf("Error closing response body: %v\n", err)
 		}
 	}()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("download failed with status %d for URL %s", resp.StatusCode, fileURL)
+		return nil, fmt.Errorf("download failed with %w for URL %s", &StatusError{Code: resp.StatusCode}, fileURL)
 	}
 
 	fileData, err := io.ReadAll(resp.Body)
@@ -323,7 +351,18 @@ func (c *APIClient) DownloadFile(fileURL string) ([]byte, error) {
 }
 
 // GetMediaImage downloads an image from Ancestry media storage
-func (c *APIClient) GetMediaImage(namespace, mediaGUID string, maxWidth, maxHeight int) ([]byte, error) {
+func (c *APIClient) GetMediaImage(ctx context.Context, namespace, mediaGUID string, maxWidth, maxHeight int) ([]byte, error) {
+	download, err := c.GetMediaImageIfModified(ctx, namespace, mediaGUID, maxWidth, maxHeight, CacheValidators{})
+	if err != nil {
+		return nil, err
+	}
+	return download.Data, nil
+}
+
+// GetMediaImageIfModified downloads an image from Ancestry media storage, sending the
+// cached validators as If-None-Match/If-Modified-Since. When the server reports the image
+// is unchanged (304), the returned MediaDownload has NotModified set and no data.
+func (c *APIClient) GetMediaImageIfModified(ctx context.Context, namespace, mediaGUID string, maxWidth, maxHeight int, cached CacheValidators) (*MediaDownload, error) {
 	endpoint := fmt.Sprintf("%s/api/media/retrieval/v2/image/namespaces/%s/media/%s.jpg",
 		c.baseURL, namespace, mediaGUID)
 
@@ -342,23 +381,33 @@ func (c *APIClient) GetMediaImage(namespace, mediaGUID string, maxWidth, maxHeig
 	}
 	reqURL.RawQuery = query.Encode()
 
-	req, err := http.NewRequest("GET", reqURL.String(), nil)
+	req, err := c.newRequest(ctx, "GET", reqURL.String(), "", "")
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
+	if cached.ETag != "" {
+		req.Header.Set("If-None-Match", cached.ETag)
+	}
+	if cached.LastModified != "" {
+		req.Header.Set("If-Modified-Since", cached.LastModified)
+	}
 
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.mediaClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to make request: %w", err)
 	}
 	defer func() {
 		if err := resp.Body.Close(); err != nil {
-			fmt.Printf("Error closing response body: %v\n", err)
+			c.log.Printf("Error closing response body: %v\n", err)
 		}
 	}()
 
+	if resp.StatusCode == http.StatusNotModified {
+		return &MediaDownload{Validators: cached, NotModified: true}, nil
+	}
+
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("API request failed with status %d", resp.StatusCode)
+		return nil, fmt.Errorf("API request failed with %w", &StatusError{Code: resp.StatusCode})
 	}
 
 	imageData, err := io.ReadAll(resp.Body)
@@ -366,58 +415,56 @@ func (c *APIClient) GetMediaImage(namespace, mediaGUID string, maxWidth, maxHeig
 		return nil, fmt.Errorf("failed to read image data: %w", err)
 	}
 
-	return imageData, nil
+	return &MediaDownload{
+		Data: imageData,
+		Validators: CacheValidators{
+			ETag:         resp.Header.Get("ETag"),
+			LastModified: resp.Header.Get("Last-Modified"),
+		},
+	}, nil
+}
+
+// removeImageSizeLimits removes an image URL's size restrictions to get the full-size image
+func removeImageSizeLimits(imageURL *url.URL) {
+	query := imageURL.Query()
+	query.Del("maxWidth")
+	query.Del("maxHeight")
+	query.Del("maxSide")
+	imageURL.RawQuery = query.Encode()
 }
 
 // DownloadRecordImage downloads an image from a RecordImageUrl with security token
 // This is the preferred method for downloading census images and other record images
 // that require authentication. The recordImageURL should be the URL from PersonSourceDetail.RecordImageUrl
 // which includes the security token. This function removes size restrictions to get full-size images.
-func (c *APIClient) DownloadRecordImage(recordImageURL string) ([]byte, error) {
+// The request's Referer is the page of the person owning the record (see MediaOwner).
+func (c *APIClient) DownloadRecordImage(ctx context.Context, owner MediaOwner, recordImageURL string) ([]byte, error) {
 	// The recordImageURL is typically a relative URL like:
 	// "/api/media/retrieval/v2/image/namespaces/62308/media/43290879-Connecticut-023376-0010.jpg?client=PersonUI&securityToken=xwd2f659e76cf58bfb8201982a2c0435f4e8de3ba50c962c00&maxHeight=250"
-
-	// Parse the URL to modify query parameters
-	var fullURL string
-	if strings.HasPrefix(recordImageURL, "http") {
-		fullURL = recordImageURL
-	} else {
-		fullURL = c.baseURL + recordImageURL
-	}
-
-	reqURL, err := url.Parse(fullURL)
+	// but some come from another media host, e.g. "//mediasvc.ancestry.com/v2/image/..."
+	reqURL, err := resolveMediaURL(c.baseURL, recordImageURL)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse record image URL: %w", err)
+		return nil, err
 	}
+	removeImageSizeLimits(reqURL)
 
-	// Remove size restrictions to get full-size image
-	query := reqURL.Query()
-	query.Del("maxWidth")
-	query.Del("maxHeight")
-	query.Del("maxSide")
-	reqURL.RawQuery = query.Encode()
-
-	req, err := http.NewRequest("GET", reqURL.String(), nil)
+	req, err := c.newRequest(ctx, "GET", reqURL.String(), "image/webp,image/apng,image/*,*/*;q=0.8", owner.referer())
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.Header.Set("Accept", "image/webp,image/apng,image/*,*/*;q=0.8")
-	req.Header.Set("Referer", c.baseURL+"/")
-
-	resp, err := c.httpClient.Do(req)
+	resp, err := c.mediaClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to download record image: %w", err)
 	}
 	defer func() {
 		if err := resp.Body.Close(); err != nil {
-			fmt.Printf("Error closing response body: %v\n", err)
+			c.log.Printf("Error closing response body: %v\n", err)
 		}
 	}()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("record image download failed with status %d: %s", resp.StatusCode, string(body))
+		return nil, fmt.Errorf("record image download failed with %w", statusError(resp))
 	}
 
 	imageData, err := io.ReadAll(resp.Body)
@@ -425,5 +472,55 @@ func (c *APIClient) DownloadRecordImage(recordImageURL string) ([]byte, error) {
 		return nil, fmt.Errorf("failed to read record image data: %w", err)
 	}
 
+	if isWebPage(resp.Header.Get("Content-Type"), imageData) {
+		return nil, ErrRecordImageNotImage
+	}
+
 	return imageData, nil
 }
+
+// ErrRecordImageNotImage is returned by DownloadRecordImage when a 200 response holds a web page
+// rather than the image, which usually means the URL's security token has expired
+var ErrRecordImageNotImage = errors.New("record image response is a web page, not an image (the security token may have expired)")
+
+// isWebPage reports whether a response is an HTML page, going by its Content-Type or, without
+// one, by sniffing the body
+func isWebPage(contentType string, body []byte) bool {
+	if contentType == "" {
+		contentType = http.DetectContentType(body)
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "text/html" || mediaType == "application/xhtml+xml"
+}
+
+// DownloadRecordImageWithRefresh downloads a source's record image like DownloadRecordImage. If the
+// response is a web page instead of the image, the source is fetched again with GetSource for a
+// RecordImageUrl with a new security token, and the download is retried once with it.
+func (c *APIClient) DownloadRecordImageWithRefresh(ctx context.Context, owner MediaOwner, source PersonSourceDetail) ([]byte, error) {
+	data, err := c.DownloadRecordImage(ctx, owner, source.RecordImageUrl)
+	if !errors.Is(err, ErrRecordImageNotImage) {
+		return data, err
+	}
+
+	freshURL, refreshErr := c.freshRecordImageURL(ctx, owner, source)
+	if refreshErr != nil {
+		return nil, fmt.Errorf("%w; getting a new image URL failed: %v", err, refreshErr)
+	}
+	c.log.Printf("Retrying record image for source %s with a refreshed URL\n", source.CitationId)
+	return c.DownloadRecordImage(ctx, owner, freshURL)
+}
+
+// freshRecordImageURL fetches a source's page again for a RecordImageUrl with a new security token
+func (c *APIClient) freshRecordImageURL(ctx context.Context, owner MediaOwner, source PersonSourceDetail) (string, error) {
+	factEditData, err := c.GetSource(ctx, owner.TreeID, owner.PersonID, source.CitationId, source.DatabaseId, source.RecordId)
+	if err != nil {
+		return "", err
+	}
+	if factEditData.RecordImageUrl == "" {
+		return "", fmt.Errorf("source %s has no record image URL", source.CitationId)
+	}
+	return factEditData.RecordImageUrl, nil
+}
@@ -0,0 +1,280 @@
+package ancestry
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRemoveImageSizeLimits(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+		want string
+	}{
+		{
+			name: "host-relative API path",
+			url:  "/api/media/retrieval/v2/image/namespaces/62308/media/a.jpg?client=PersonUI&securityToken=tok&maxHeight=250",
+			want: "https://www.ancestry.com/api/media/retrieval/v2/image/namespaces/62308/media/a.jpg?client=PersonUI&securityToken=tok",
+		},
+		{
+			name: "protocol-relative media host",
+			url:  "//mediasvc.ancestry.com/v2/image/namespaces/1234/media/b.jpg?maxWidth=100&securityToken=tok",
+			want: "https://mediasvc.ancestry.com/v2/image/namespaces/1234/media/b.jpg?securityToken=tok",
+		},
+		{
+			name: "absolute cross-host",
+			url:  "https://mediasvc.ancestry.com/v2/image/c.jpg?maxSide=300",
+			want: "https://mediasvc.ancestry.com/v2/image/c.jpg",
+		},
+		{
+			name: "absolute same host",
+			url:  "https://www.ancestry.com/api/media/d.jpg?securityToken=tok",
+			want: "https://www.ancestry.com/api/media/d.jpg?securityToken=tok",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveMediaURL("https://www.ancestry.com", tt.url)
+			if err != nil {
+				t.Fatalf("resolveMediaURL() error = %v", err)
+			}
+			removeImageSizeLimits(got)
+			if got.String() != tt.want {
+				t.Errorf("removeImageSizeLimits() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDownloadRecordImageRelativeURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/media/a.jpg" || r.URL.Query().Get("maxHeight") != "" {
+			http.Error(w, "unexpected URL "+r.URL.String(), http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte("image"))
+	}))
+	defer server.Close()
+
+	client := newTestClient(server)
+	client.mediaClient = server.Client()
+
+	data, err := client.DownloadRecordImage(context.Background(), MediaOwner{}, "/api/media/a.jpg?securityToken=tok&maxHeight=250")
+	if err != nil {
+		t.Fatalf("DownloadRecordImage() error = %v", err)
+	}
+	if string(data) != "image" {
+		t.Errorf("DownloadRecordImage() = %q, want %q", data, "image")
+	}
+}
+
+func TestResolveMediaURL(t *testing.T) {
+	tests := []struct {
+		name string
+		url  string
+		want string
+	}{
+		{"absolute https", "https://mediasvc.ancestry.com/v2/image/a.jpg?maxSide=300", "https://mediasvc.ancestry.com/v2/image/a.jpg?maxSide=300"},
+		{"host-relative path", "/api/media/b.jpg?client=Trees", "https://www.ancestry.com/api/media/b.jpg?client=Trees"},
+		{"path without leading slash", "api/media/c.jpg", "https://www.ancestry.com/api/media/c.jpg"},
+		{"protocol-relative", "//mediasvc.ancestry.com/d.jpg", "https://mediasvc.ancestry.com/d.jpg"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveMediaURL("https://www.ancestry.com", tt.url)
+			if err != nil {
+				t.Fatalf("resolveMediaURL() error = %v", err)
+			}
+			if got.String() != tt.want {
+				t.Errorf("resolveMediaURL() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDownloadFile(t *testing.T) {
+	var gotPaths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPaths = append(gotPaths, r.URL.RequestURI())
+		_, _ = w.Write([]byte("image"))
+	}))
+	defer server.Close()
+
+	client := newTestClient(server)
+	client.mediaClient = server.Client()
+
+	for _, fileURL := range []string{server.URL + "/absolute/a.jpg?x=1", "/relative/b.jpg"} {
+		data, err := client.DownloadFile(context.Background(), MediaOwner{}, fileURL)
+		if err != nil {
+			t.Fatalf("DownloadFile(%q) error = %v", fileURL, err)
+		}
+		if string(data) != "image" {
+			t.Errorf("DownloadFile(%q) = %q, want %q", fileURL, data, "image")
+		}
+	}
+
+	want := []string{"/absolute/a.jpg?x=1", "/relative/b.jpg"}
+	if !reflect.DeepEqual(gotPaths, want) {
+		t.Errorf("requested %q, want %q", gotPaths, want)
+	}
+}
+
+func TestMediaRequestReferer(t *testing.T) {
+	const personReferer = "https://www.ancestry.com/family-tree/person/tree/42/person/1001"
+	var gotReferers []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotReferers = append(gotReferers, r.Header.Get("Referer"))
+		if strings.HasPrefix(r.URL.Path, "/api/media/viewer/") {
+			_, _ = w.Write([]byte(`{"objects": []}`))
+			return
+		}
+		_, _ = w.Write([]byte("image"))
+	}))
+	defer server.Close()
+
+	client := newTestClient(server)
+	client.mediaClient = server.Client()
+
+	if _, err := client.GetPersonMediaFromAPI(context.Background(), "42", "1001:1030:42"); err != nil {
+		t.Fatalf("GetPersonMediaFromAPI() error = %v", err)
+	}
+	if _, err := client.DownloadRecordImage(context.Background(), MediaOwner{TreeID: "42", PersonID: "1001:1030:42"}, "/api/media/a.jpg"); err != nil {
+		t.Fatalf("DownloadRecordImage() error = %v", err)
+	}
+	if _, err := client.DownloadRecordImage(context.Background(), MediaOwner{}, "/api/media/b.jpg"); err != nil {
+		t.Fatalf("DownloadRecordImage() error = %v", err)
+	}
+
+	want := []string{personReferer, personReferer, defaultReferer}
+	if !reflect.DeepEqual(gotReferers, want) {
+		t.Errorf("Referers = %q, want %q", gotReferers, want)
+	}
+}
+
+func TestDownloadRecordImageRejectsWebPages(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		body        string
+		wantErr     bool
+	}{
+		{"jpeg", "image/jpeg", "\xff\xd8\xff\xe0", false},
+		{"html error page", "text/html; charset=utf-8", "<html>Session expired</html>", true},
+		{"html without content type", "", "<!DOCTYPE html><html></html>", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header()["Content-Type"] = []string{tt.contentType}
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			client := newTestClient(server)
+			client.mediaClient = server.Client()
+
+			_, err := client.DownloadRecordImage(context.Background(), MediaOwner{}, "/api/media/a.jpg")
+			if got := errors.Is(err, ErrRecordImageNotImage); got != tt.wantErr {
+				t.Errorf("DownloadRecordImage() error = %v, want ErrRecordImageNotImage: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDownloadRecordImageWithRefresh(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.Contains(r.URL.Path, "/factedit/"):
+			if r.URL.Path != "/family-tree/person/factedit/user/u1/tree/42/person/1001/source/c1" || r.URL.Query().Get("recordId") != "r1" {
+				http.Error(w, "unexpected URL "+r.URL.String(), http.StatusBadRequest)
+				return
+			}
+			_, _ = w.Write([]byte(`<script>window.getFactEditData = {"CitationId":"c1","RecordImageUrl":"/api/media/a.jpg?securityToken=fresh"};</script>`))
+		case r.URL.Query().Get("securityToken") == "fresh":
+			w.Header().Set("Content-Type", "image/jpeg")
+			_, _ = w.Write([]byte("image"))
+		default:
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = w.Write([]byte("<html>Please sign in</html>"))
+		}
+	}))
+	defer server.Close()
+
+	client := newTestClient(server)
+	client.mediaClient = server.Client()
+	client.userID = "u1"
+	client.log = log.New(io.Discard, "", 0)
+
+	owner := MediaOwner{TreeID: "42", PersonID: "1001:1030:42"}
+	source := PersonSourceDetail{CitationId: "c1", DatabaseId: "d1", RecordId: "r1", RecordImageUrl: "/api/media/a.jpg?securityToken=expired"}
+	data, err := client.DownloadRecordImageWithRefresh(context.Background(), owner, source)
+	if err != nil {
+		t.Fatalf("DownloadRecordImageWithRefresh() error = %v", err)
+	}
+	if string(data) != "image" {
+		t.Errorf("DownloadRecordImageWithRefresh() = %q, want %q", data, "image")
+	}
+}
+
+func TestGetMediaImageIfModified(t *testing.T) {
+	const (
+		etag         = `"v1"`
+		lastModified = "Mon, 06 Jan 2025 10:00:00 GMT"
+	)
+	var gotHeaders []http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotHeaders = append(gotHeaders, r.Header.Clone())
+		if r.Header.Get("If-None-Match") == etag {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v2"`)
+		w.Header().Set("Last-Modified", "Tue, 07 Jan 2025 10:00:00 GMT")
+		_, _ = w.Write([]byte("new image"))
+	}))
+	defer server.Close()
+
+	client := newTestClient(server)
+	client.mediaClient = server.Client()
+	ctx := context.Background()
+
+	cached := CacheValidators{ETag: etag, LastModified: lastModified}
+	unchanged, err := client.GetMediaImageIfModified(ctx, "1093", "abc", 0, 0, cached)
+	if err != nil {
+		t.Fatalf("unchanged image: %v", err)
+	}
+	if got := gotHeaders[0].Get("If-None-Match"); got != etag {
+		t.Errorf("If-None-Match = %q, want %q", got, etag)
+	}
+	if got := gotHeaders[0].Get("If-Modified-Since"); got != lastModified {
+		t.Errorf("If-Modified-Since = %q, want %q", got, lastModified)
+	}
+	if !unchanged.NotModified || unchanged.Data != nil || unchanged.Validators != cached {
+		t.Errorf("304 response = %+v, want NotModified with the cached validators", unchanged)
+	}
+
+	changed, err := client.GetMediaImageIfModified(ctx, "1093", "abc", 0, 0, CacheValidators{ETag: `"v0"`})
+	if err != nil {
+		t.Fatalf("changed image: %v", err)
+	}
+	if changed.NotModified || string(changed.Data) != "new image" || changed.Validators.ETag != `"v2"` {
+		t.Errorf("200 response = %+v, want the new image and its validators", changed)
+	}
+
+	if _, err := client.GetMediaImage(ctx, "1093", "abc", 0, 0); err != nil {
+		t.Fatal(err)
+	}
+	if got := gotHeaders[2].Get("If-None-Match") + gotHeaders[2].Get("If-Modified-Since"); got != "" {
+		t.Errorf("request without validators sent conditional headers %q", got)
+	}
+}
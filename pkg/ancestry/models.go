@@ -48,6 +48,7 @@ type Person struct {
 	Surname       string                 `json:"sname,omitempty"` // Flat field for surname
 	Gender        string                 `json:"gender,omitempty"`
 	EventsSummary []interface{}          `json:"events,omitempty"`
+	Notes         []PersonNote           `json:"notes,omitempty"` // Filled in by GetPersonNotes, not returned with the person
 }
 
 // FamilyMember represents a family relationship
@@ -130,6 +131,13 @@ type Event struct {
 	Date        interface{}              `json:"d,omitempty"`
 	NPS         []map[string]interface{} `json:"nps,omitempty"`  // Nested place structure
 	Description string                   `json:"desc,omitempty"` // Event description/notes
+
+	// Place and PlaceStd are the place as entered and its standardized form, filled in from NPS by ResolvePlaces
+	Place    string `json:"place,omitempty"`
+	PlaceStd string `json:"placeStd,omitempty"`
+
+	// DescriptionTruncated is set when Description was shortened for the export
+	DescriptionTruncated bool `json:"descriptionTruncated,omitempty"`
 }
 
 // FamilyViewResponse represents the response from the newfamilyview API
@@ -169,6 +177,53 @@ type UserData struct {
 	HintCount                int                    `json:"hintcount"`
 }
 
+// userField returns the first non-empty string value found under the given keys of the user map
+func (u *UserData) userField(keys ...string) string {
+	for _, key := range keys {
+		switch v := u.User[key].(type) {
+		case string:
+			if v != "" {
+				return v
+			}
+		case float64:
+			return fmt.Sprintf("%.0f", v)
+		}
+	}
+	return ""
+}
+
+// GetDisplayName returns the logged-in user's display name
+func (u *UserData) GetDisplayName() string {
+	if name := u.userField("displayName", "name", "userName"); name != "" {
+		return name
+	}
+	return strings.TrimSpace(fmt.Sprintf("%s %s", u.userField("firstName", "givenName"), u.userField("lastName", "surname")))
+}
+
+// GetUserID returns the logged-in user's ID
+func (u *UserData) GetUserID() string {
+	return u.userField("userId", "id", "ucdmid")
+}
+
+// GetMostRecentlyViewedTreeID returns the ID of the tree the user last viewed on the site, or "" if none.
+// The API returns it as a string or a number.
+func (u *UserData) GetMostRecentlyViewedTreeID() string {
+	switch v := u.MostRecentlyViewedTreeID.(type) {
+	case string:
+		return strings.TrimSpace(v)
+	case float64:
+		if v > 0 {
+			return fmt.Sprintf("%.0f", v)
+		}
+	}
+	return ""
+}
+
+// IsAuthenticated reports whether the response describes a logged-in user
+func (u *UserData) IsAuthenticated() bool {
+	return len(u.User) > 0 && (u.GetUserID() != "" || u.GetDisplayName() != "")
+}
+
 // PersonMedia represents media attached to a person
 type PersonMedia struct {
 	PersonID   string      `json:"personId,omitempty"`
@@ -188,6 +243,19 @@ type MediaItem struct {
 	Raw         map[string]interface{} `json:"-"` // Store raw response for debugging
 }
 
+// CacheValidators holds the HTTP cache validators returned with a downloaded file
+type CacheValidators struct {
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"lastModified,omitempty"`
+}
+
+// MediaDownload is the result of a conditional media download
+type MediaDownload struct {
+	Data        []byte          // File contents, empty when NotModified is set
+	Validators  CacheValidators // Validators to send on the next download of this file
+	NotModified bool            // True when the server reported the file is unchanged (304)
+}
+
 // InitialState represents the window.INITIAL_STATE object in person pages
 type InitialState struct {
 	Redux ReduxState `json:"redux"`
@@ -267,6 +335,12 @@ type PersonSourceDetail struct {
 	ViewRecordImageUrl    string `json:"ViewRecordImageUrl"`
 }
 
+// GetAssertionIDs returns the IDs of the facts the source supports, which match
+// PersonFactDetail.AssertionID
+func (s *PersonSourceDetail) GetAssertionIDs() []string {
+	return strings.Fields(s.AssertionIds)
+}
+
 // PersonFactDetail represents a single fact/event with complete details
 type PersonFactDetail struct {
 	Type              int                    `json:"Type"`
@@ -325,6 +399,17 @@ type FactEditData struct {
 	RecordImageUrl        string `json:"recordImageUrl,omitempty"`
 	RecordImagePreviewUrl string `json:"recordImagePreviewUrl,omitempty"`
 	LocalMediaFilePath    string `json:"localMediaFilePath,omitempty"`
+	// Facts the source supports, from the AssertionIds of each person's PersonSources
+	SupportedFacts []SupportedFact `json:"supportedFacts,omitempty"`
+}
+
+// SupportedFact is a person's fact that a source is cited for
+type SupportedFact struct {
+	PersonID    string      `json:"personId"`
+	AssertionID string      `json:"assertionId"`
+	Type        string      `json:"type"`
+	Date        interface{} `json:"date,omitempty"`
+	Place       string      `json:"place,omitempty"`
 }
 
 // DiscoveryDiscoveryRecordResponse represents the response from the record API
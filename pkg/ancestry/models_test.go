@@ -0,0 +1,56 @@
+package ancestry
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestGetMostRecentlyViewedTreeID(t *testing.T) {
+	tests := []struct {
+		body string
+		want string
+	}{
+		{body: `{"mostRecentlyViewedTreeId": "178123456"}`, want: "178123456"},
+		{body: `{"mostRecentlyViewedTreeId": 178123456}`, want: "178123456"},
+		{body: `{"mostRecentlyViewedTreeId": null}`, want: ""},
+		{body: `{"mostRecentlyViewedTreeId": 0}`, want: ""},
+		{body: `{}`, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.body, func(t *testing.T) {
+			var userData UserData
+			if err := json.Unmarshal([]byte(tt.body), &userData); err != nil {
+				t.Fatal(err)
+			}
+			if got := userData.GetMostRecentlyViewedTreeID(); got != tt.want {
+				t.Errorf("GetMostRecentlyViewedTreeID() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPersonSourceDetailGetAssertionIDs(t *testing.T) {
+	tests := []struct {
+		assertionIDs string
+		want         []string
+	}{
+		{"", nil},
+		{"123", []string{"123"}},
+		{" 123  456 789 ", []string{"123", "456", "789"}},
+	}
+	for _, tt := range tests {
+		source := PersonSourceDetail{AssertionIds: tt.assertionIDs}
+		got := source.GetAssertionIDs()
+		if len(got) != len(tt.want) {
+			t.Errorf("GetAssertionIDs(%q) = %v, want %v", tt.assertionIDs, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("GetAssertionIDs(%q) = %v, want %v", tt.assertionIDs, got, tt.want)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package ancestry
+
+import (
+	"context"
+	"fmt"
+	"strings"
+)
+
+// PersonNote is a private note or comment attached to a person
+type PersonNote struct {
+	ID     string `json:"id,omitempty"`
+	Text   string `json:"text"`
+	Author string `json:"author,omitempty"`
+	Date   string `json:"date,omitempty"`
+}
+
+// Candidate keys for each note field, tried in order. No captured comments response backs
+// them yet; once one is saved under testdata, these should shrink to the keys it uses.
+var (
+	noteIDKeys     = []string{"id", "commentId", "noteId"}
+	noteTextKeys   = []string{"text", "comment", "body", "note", "t"}
+	noteAuthorKeys = []string{"author", "authorName", "userName", "displayName"}
+	noteDateKeys   = []string{"date", "createdDate", "created", "cd"}
+)
+
+// GetPersonNotes retrieves the notes and comments attached to a person. They come from
+// the tree viewer comments endpoint, which returns comments keyed by person ID.
+// Note text can be sensitive, so callers should only store it when asked to.
+func (c *APIClient) GetPersonNotes(ctx context.Context, treeID, personID string) ([]PersonNote, error) {
+	// Extract just the person ID (first part before colon)
+	shortPersonID := personID
+	if parts := strings.Split(personID, ":"); len(parts) > 0 {
+		shortPersonID = parts[0]
+	}
+
+	comments, err := c.GetComments(ctx, treeID, []string{shortPersonID}, 1)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notes: %w", err)
+	}
+
+	return extractPersonNotes(comments, personID, shortPersonID), nil
+}
+
+// GetNotesForPersons retrieves the notes of many persons with batchSize person IDs per
+// request (see GetComments), keyed by the person IDs as given. Persons without notes are
+// left out.
+func (c *APIClient) GetNotesForPersons(ctx context.Context, treeID string, personIDs []string, batchSize int) (map[string][]PersonNote, error) {
+	shortIDs := make([]string, len(personIDs))
+	for i, personID := range personIDs {
+		shortIDs[i] = strings.Split(personID, ":")[0]
+	}
+
+	comments, err := c.GetComments(ctx, treeID, shortIDs, batchSize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notes: %w", err)
+	}
+
+	notes := make(map[string][]PersonNote)
+	for i, personID := range personIDs {
+		// Only notes keyed by person are used; a top-level list can't be told apart by person
+		if personNotes := notesFromItems(findCommentList(comments, []string{personID, shortIDs[i]})); len(personNotes) > 0 {
+			notes[personID] = personNotes
+		}
+	}
+	return notes, nil
+}
+
+// extractPersonNotes finds the person's notes in a comments response. The notes are
+// looked up under the full or short person ID, falling back to a top-level
+// "comments" or "notes" list.
+func extractPersonNotes(comments map[string]interface{}, ids ...string) []PersonNote {
+	return notesFromItems(findCommentList(comments, append(ids, "comments", "Comments", "notes", "Notes")))
+}
+
+// findCommentList returns the first list of comments found under keys
+func findCommentList(comments map[string]interface{}, keys []string) []interface{} {
+	for _, key := range keys {
+		if list, ok := comments[key].([]interface{}); ok {
+			return list
+		}
+	}
+	return nil
+}
+
+// notesFromItems converts comment objects to notes, skipping those without text
+func notesFromItems(items []interface{}) []PersonNote {
+	var notes []PersonNote
+	for _, item := range items {
+		itemMap, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		note := PersonNote{
+			ID:     firstStringValue(itemMap, noteIDKeys),
+			Text:   firstStringValue(itemMap, noteTextKeys),
+			Author: firstStringValue(itemMap, noteAuthorKeys),
+			Date:   firstStringValue(itemMap, noteDateKeys),
+		}
+		if note.Text == "" {
+			continue
+		}
+		notes = append(notes, note)
+	}
+
+	return notes
+}
+
+// firstStringValue returns the first non-empty value found under keys, formatting numbers as text
+func firstStringValue(m map[string]interface{}, keys []string) string {
+	for _, key := range keys {
+		switch v := m[key].(type) {
+		case string:
+			if v != "" {
+				return v
+			}
+		case float64:
+			return fmt.Sprintf("%.0f", v)
+		}
+	}
+	return ""
+}
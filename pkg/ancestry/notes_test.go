@@ -0,0 +1,129 @@
+package ancestry
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+)
+
+func TestExtractPersonNotes(t *testing.T) {
+	tests := []struct {
+		name     string
+		comments map[string]interface{}
+		want     []PersonNote
+	}{
+		{
+			name: "keyed by short person ID",
+			comments: map[string]interface{}{
+				"123": []interface{}{
+					map[string]interface{}{"id": float64(7), "text": "Check the 1880 census", "authorName": "Jo", "createdDate": "2023-01-02"},
+					map[string]interface{}{"id": "8", "text": ""},
+				},
+			},
+			want: []PersonNote{{ID: "7", Text: "Check the 1880 census", Author: "Jo", Date: "2023-01-02"}},
+		},
+		{
+			name: "top-level comments list",
+			comments: map[string]interface{}{
+				"comments": []interface{}{map[string]interface{}{"comment": "Possibly adopted"}},
+			},
+			want: []PersonNote{{Text: "Possibly adopted"}},
+		},
+		{
+			name:     "no notes",
+			comments: map[string]interface{}{"999": []interface{}{}},
+			want:     nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := extractPersonNotes(tt.comments, "123:1030:456", "123")
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("extractPersonNotes() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetPersonNotes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/treeviewer/comments/tree/t1" || r.URL.Query().Get("pid") != "123" {
+			http.Error(w, "unexpected URL "+r.URL.String(), http.StatusBadRequest)
+			return
+		}
+		_, _ = w.Write([]byte(`{"123":[{"text":"note"}]}`))
+	}))
+	defer server.Close()
+
+	notes, err := newTestClient(server).GetPersonNotes(context.Background(), "t1", "123:1030:456")
+	if err != nil {
+		t.Fatalf("GetPersonNotes() error = %v", err)
+	}
+	if len(notes) != 1 || notes[0].Text != "note" {
+		t.Errorf("GetPersonNotes() = %+v, want one note", notes)
+	}
+}
+
+// commentsServer answers comments requests with one note per requested pid, named after the
+// pid, plus a top-level list, and records the pids of each request
+func commentsServer(t *testing.T, requests *[][]string) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pids := r.URL.Query()["pid"]
+		*requests = append(*requests, pids)
+		body := map[string]interface{}{"comments": []interface{}{map[string]interface{}{"text": "unkeyed"}}}
+		for _, pid := range pids {
+			body[pid] = []interface{}{map[string]interface{}{"text": "note " + pid}}
+		}
+		_ = json.NewEncoder(w).Encode(body)
+	}))
+}
+
+func TestGetCommentsBatches(t *testing.T) {
+	var requests [][]string
+	server := commentsServer(t, &requests)
+	defer server.Close()
+
+	comments, err := newTestClient(server).GetComments(context.Background(), "t1", []string{"1", "2", "3", "4", "5"}, 2)
+	if err != nil {
+		t.Fatalf("GetComments() error = %v", err)
+	}
+
+	wantRequests := [][]string{{"1", "2"}, {"3", "4"}, {"5"}}
+	if !reflect.DeepEqual(requests, wantRequests) {
+		t.Errorf("requests = %v, want %v", requests, wantRequests)
+	}
+	for _, pid := range []string{"1", "2", "3", "4", "5"} {
+		if _, ok := comments[pid]; !ok {
+			t.Errorf("comments for %s missing from merged response", pid)
+		}
+	}
+	if list, _ := comments["comments"].([]interface{}); len(list) != 3 {
+		t.Errorf("top-level comments = %v, want the 3 batches' lists concatenated", comments["comments"])
+	}
+}
+
+func TestGetNotesForPersons(t *testing.T) {
+	var requests [][]string
+	server := commentsServer(t, &requests)
+	defer server.Close()
+
+	notes, err := newTestClient(server).GetNotesForPersons(context.Background(), "t1", []string{"1:1030:9", "2:1030:9"}, 0)
+	if err != nil {
+		t.Fatalf("GetNotesForPersons() error = %v", err)
+	}
+	if len(requests) != 1 {
+		t.Errorf("made %d requests, want 1 with the default batch size", len(requests))
+	}
+	want := map[string][]PersonNote{
+		"1:1030:9": {{Text: "note 1"}},
+		"2:1030:9": {{Text: "note 2"}},
+	}
+	if !reflect.DeepEqual(notes, want) {
+		t.Errorf("GetNotesForPersons() = %+v, want %+v", notes, want)
+	}
+}
@@ -1,7 +1,9 @@
 package ancestry
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -11,130 +13,133 @@ import (
 )
 
 // GetUserData retrieves user account information
-func (c *APIClient) GetUserData() (*UserData, error) {
-	endpoint := fmt.Sprintf("%s/api/navheaderdata/v1/header/data/user", c.baseURL)
-
-	req, err := http.NewRequest("GET", endpoint, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
-
-	req.Header.Set("Accept", "*/*")
-	req.Header.Set("Referer", "https://www.ancestry.com/")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to make request: %w", err)
-	}
-	defer func() {
-		if err := resp.Body.Close(); err != nil {
-			fmt.Printf("Error closing response body: %v\n", err)
-		}
-	}()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
-	}
-
+func (c *APIClient) GetUserData(ctx context.Context) (*UserData, error) {
 	var userData UserData
-	if err := json.NewDecoder(resp.Body).Decode(&userData); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	if err := c.getJSON(ctx, "/api/navheaderdata/v1/header/data/user", nil, &userData); err != nil {
+		return nil, err
 	}
 
 	return &userData, nil
 }
 
+// listOfAllPeopleReferer returns the Referer of the tree's "list of all people" page
+func listOfAllPeopleReferer(treeID string) string {
+	return fmt.Sprintf("https://www.ancestry.com/family-tree/tree/%s/listofallpeople", treeID)
+}
+
+// personPageReferer returns the Referer of a person's page in the tree, which the Ancestry UI
+// sends with the person's media requests
+func personPageReferer(treeID, personID string) string {
+	// Person IDs come as "232573524428:1030:197283789" but page URLs only use the first part
+	shortPersonID, _, _ := strings.Cut(personID, ":")
+	return fmt.Sprintf("https://www.ancestry.com/family-tree/person/tree/%s/person/%s", treeID, shortPersonID)
+}
+
+const (
+	// DefaultPageSize is the number of persons requested per page of GetAllPersons
+	DefaultPageSize = 100
+	// MaxPageSize is the largest page of persons GetAllPersons should request
+	MaxPageSize = 500
+)
+
 // GetAllPersons retrieves all persons in a tree with pagination support
-// Returns persons sorted by surname, given name, and ID
-func (c *APIClient) GetAllPersons(treeID string, page, limit int) ([]Person, error) {
-	endpoint := fmt.Sprintf("%s/api/treesui-list/trees/%s/persons", c.baseURL, treeID)
+// Returns persons sorted by surname, given name, and ID. With tags, the server only
+// returns persons with at least one of the tags, so pages stop short of GetPersonsCount.
+func (c *APIClient) GetAllPersons(ctx context.Context, treeID string, page, limit int, tags []string) ([]Person, error) {
+	return c.getPersonsPage(ctx, treeID, SearchOptions{Tags: tags}, page, limit)
+}
 
-	reqURL, err := url.Parse(endpoint)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse URL: %w", err)
-	}
+// SearchOptions filters the persons SearchPersons returns. The server matches the names;
+// empty fields don't filter.
+type SearchOptions struct {
+	FirstName string   // Given name
+	LastName  string   // Surname
+	Name      string   // Any part of the full name
+	Tags      []string // At least one of these tags
+	PageSize  int      // Persons per request, DefaultPageSize if 0
+}
 
-	query := reqURL.Query()
-	query.Set("expires", fmt.Sprintf("%d", time.Now().UnixMilli()))
-	query.Set("fn", "")
-	query.Set("ln", "")
-	query.Set("name", "")
-	query.Set("tags", "")
+// getPersonsPage retrieves one page of the tree's persons matching opts
+func (c *APIClient) getPersonsPage(ctx context.Context, treeID string, opts SearchOptions, page, limit int) ([]Person, error) {
+	query := url.Values{}
+	query.Set("expires", timestamp())
+	query.Set("fn", opts.FirstName)
+	query.Set("ln", opts.LastName)
+	query.Set("name", opts.Name)
+	query.Set("tags", strings.Join(opts.Tags, ","))
 	query.Set("sort", "sname,gname,id")
 	query.Set("page", fmt.Sprintf("%d", page))
 	query.Set("limit", fmt.Sprintf("%d", limit))
 	query.Set("fields", "NAMES,EVENTS")
-	reqURL.RawQuery = query.Encode()
 
-	req, err := http.NewRequest("GET", reqURL.String(), nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+	var persons []Person
+	path := fmt.Sprintf("/api/treesui-list/trees/%s/persons", treeID)
+	if err := c.getJSONWithReferer(ctx, path, query, listOfAllPeopleReferer(treeID), &persons); err != nil {
+		return nil, err
 	}
 
-	req.Header.Set("Accept", "*/*")
-	req.Header.Set("Referer", fmt.Sprintf("https://www.ancestry.com/family-tree/tree/%s/listofallpeople", treeID))
+	return persons, nil
+}
 
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to make request: %w", err)
+// SearchPersons retrieves every person in a tree matching opts, fetching page after page until
+// a short one. The server does the filtering, so only the matches are downloaded. With no
+// filters set it returns everyone in the tree.
+func (c *APIClient) SearchPersons(ctx context.Context, treeID string, opts SearchOptions) ([]Person, error) {
+	limit := opts.PageSize
+	if limit <= 0 {
+		limit = DefaultPageSize
 	}
-	defer func() {
-		if err := resp.Body.Close(); err != nil {
-			fmt.Printf("Error closing response body: %v\n", err)
-		}
-	}()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+	matches := []Person{}
+	for page := 1; ; page++ {
+		persons, err := c.getPersonsPage(ctx, treeID, opts, page, limit)
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch page %d: %w", page, err)
+		}
+		matches = append(matches, persons...)
+		if len(persons) < limit {
+			return matches, nil
+		}
 	}
+}
 
-	var persons []Person
-	if err := json.NewDecoder(resp.Body).Decode(&persons); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+// GetPersonsCount retrieves the total count of persons in a tree
+func (c *APIClient) GetPersonsCount(ctx context.Context, treeID string) (int, error) {
+	var count int
+	path := fmt.Sprintf("/api/treesui-list/trees/%s/persons/count", treeID)
+	if err := c.getJSONWithReferer(ctx, path, nil, listOfAllPeopleReferer(treeID), &count); err != nil {
+		return 0, err
 	}
 
-	return persons, nil
+	return count, nil
 }
 
-// GetPersonsCount retrieves the total count of persons in a tree
-func (c *APIClient) GetPersonsCount(treeID string) (int, error) {
-	endpoint := fmt.Sprintf("%s/api/treesui-list/trees/%s/persons/count", c.baseURL, treeID)
+// ErrPersonNotFound is returned by GetPerson when the tree has no person with the ID
+var ErrPersonNotFound = errors.New("person not found")
 
-	req, err := http.NewRequest("GET", endpoint, nil)
-	if err != nil {
-		return 0, fmt.Errorf("failed to create request: %w", err)
+// GetPerson retrieves one person of a tree, with their Names, Genders and Events, by their
+// short or composite ("<person>:1030:<tree>") ID. There is no single-person endpoint, so the
+// person is taken from a family view focused on them without any other generations.
+func (c *APIClient) GetPerson(ctx context.Context, treeID, personID string) (*Person, error) {
+	shortID := strings.Split(strings.TrimSpace(personID), ":")[0]
+	if shortID == "" {
+		return nil, fmt.Errorf("%w: empty person ID", ErrPersonNotFound)
 	}
 
-	req.Header.Set("Accept", "*/*")
-	req.Header.Set("Referer", fmt.Sprintf("https://www.ancestry.com/family-tree/tree/%s/listofallpeople", treeID))
-
-	resp, err := c.httpClient.Do(req)
+	familyView, err := c.GetFamilyView(ctx, treeID, shortID, 0, 0)
 	if err != nil {
-		return 0, fmt.Errorf("failed to make request: %w", err)
+		return nil, err
 	}
-	defer func() {
-		if err := resp.Body.Close(); err != nil {
-			fmt.Printf("Error closing response body: %v\n", err)
+	for i := range familyView.Persons {
+		if familyView.Persons[i].GetShortPersonID() == shortID {
+			return &familyView.Persons[i], nil
 		}
-	}()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return 0, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
-	}
-
-	var count int
-	if err := json.NewDecoder(resp.Body).Decode(&count); err != nil {
-		return 0, fmt.Errorf("failed to decode response: %w", err)
 	}
-
-	return count, nil
+	return nil, fmt.Errorf("%w: %s in tree %s", ErrPersonNotFound, shortID, treeID)
 }
 
 // GetPersonFactsFromHTML scrapes the "Facts" page for the researchData JSON
-func (c *APIClient) GetPersonFactsFromHTML(treeID, personID string) (*ResearchData, error) {
+func (c *APIClient) GetPersonFactsFromHTML(ctx context.Context, treeID, personID string) (*ResearchData, error) {
 
 	// Extract just the person ID (first part before colon)
 	shortPersonID := personID
@@ -145,66 +150,29 @@ func (c *APIClient) GetPersonFactsFromHTML(treeID, personID string) (*ResearchDa
 	endpoint := fmt.Sprintf("%s/family-tree/person/tree/%s/person/%s/facts", c.baseURL, treeID, shortPersonID)
 
 	// Try the request, with one retry on failure
-	html, err := c.factsHTMLReq(endpoint, treeID)
+	html, err := c.factsHTMLReq(ctx, endpoint, treeID)
 	if err != nil {
 		return nil, err
 	}
 
-	// Extract the window.researchData JSON from the HTML
-	htmlContent := string(html)
-	startMarker := "window.researchData = "
-	startIndex := strings.Index(htmlContent, startMarker)
-	if startIndex == -1 {
-		return nil, nil // Return nil if no research data found (not an error)
+	researchData, err := parseResearchData(html)
+	if researchData == nil {
+		c.keepFailedPage(treeID, shortPersonID, "researchData", html)
 	}
+	return researchData, err
+}
 
-	// Find the end of the JSON object by counting braces
-	jsonStartIndex := startIndex + len(startMarker)
-
-	// Count braces to find the matching closing brace
-	braceCount := 0
-	inString := false
-	escaped := false
-	jsonEndIndex := jsonStartIndex
-
-	for i := jsonStartIndex; i < len(htmlContent); i++ {
-		ch := htmlContent[i]
-
-		if escaped {
-			escaped = false
-			continue
-		}
-
-		if ch == '\\' {
-			escaped = true
-			continue
-		}
-
-		if ch == '"' {
-			inString = !inString
-			continue
-		}
-
-		if !inString {
-			if ch == '{' {
-				braceCount++
-			} else if ch == '}' {
-				braceCount--
-				if braceCount == 0 {
-					jsonEndIndex = i + 1
-					break
-				}
-			}
-		}
+// parseResearchData extracts the window.researchData JSON from a facts page, returning nil
+// without an error if the page has none
+func parseResearchData(html []byte) (*ResearchData, error) {
+	jsonStr, err := findEmbeddedJSON(html, "window.researchData")
+	if err != nil {
+		return nil, err
 	}
-
-	if braceCount != 0 {
-		return nil, fmt.Errorf("could not find matching closing brace for window.researchData")
+	if jsonStr == "" {
+		return nil, nil // Return nil if no research data found (not an error)
 	}
 
-	// Extract the JSON string
-	jsonStr := htmlContent[jsonStartIndex:jsonEndIndex]
-
 	// Parse the JSON (it's already valid JSON, not escaped)
 	var researchData ResearchData
 	if err := json.Unmarshal([]byte(jsonStr), &researchData); err != nil {
@@ -214,7 +182,12 @@ func (c *APIClient) GetPersonFactsFromHTML(treeID, personID string) (*ResearchDa
 	return &researchData, nil
 }
 
-func (c *APIClient) factsHTMLReq(endpoint, treeID string) ([]byte, error) {
+// factsRetryDelay is the pause before a failed Facts page request is tried again
+const factsRetryDelay = 30 * time.Second
+
+// factsHTMLReq fetches a Facts page, trying once more after factsRetryDelay if it fails.
+// It gives up at once when ctx is done, including during the pause.
+func (c *APIClient) factsHTMLReq(ctx context.Context, endpoint, treeID string) ([]byte, error) {
 	// Try the request, with one retry on failure
 	var html []byte
 	var err error
@@ -223,16 +196,27 @@ func (c *APIClient) factsHTMLReq(endpoint, treeID string) ([]byte, error) {
 		if attempt == 1 {
 			timeout = 30 * time.Second
 		} else {
-			// Second attempt: wait 30 seconds, then try with 35 second timeout
-			time.Sleep(30 * time.Second)
+			// Second attempt: wait, then try with 35 second timeout
+			timer := time.NewTimer(c.factsRetryDelay)
+			select {
+			case <-timer.C:
+			case <-ctx.Done():
+				timer.Stop()
+				return nil, ctx.Err()
+			}
 			timeout = 35 * time.Second
 		}
 
-		html, err = c.fetchFactsPageWithTimeout(endpoint, treeID, timeout)
+		html, err = c.fetchFactsPageWithTimeout(ctx, endpoint, treeID, timeout)
 		if err == nil {
 			break // Success!
 		}
 
+		// Don't retry a canceled request or spend a retry while the circuit breaker is rejecting requests
+		if ctx.Err() != nil || errors.Is(err, context.Canceled) || errors.Is(err, ErrCircuitOpen) {
+			return nil, err
+		}
+
 		// If this was the last attempt, return the error
 		if attempt == 2 {
 			return nil, fmt.Errorf("failed to fetch facts page after 2 attempts: %w", err)
@@ -243,7 +227,7 @@ func (c *APIClient) factsHTMLReq(endpoint, treeID string) ([]byte, error) {
 }
 
 // fetchFactsPageWithTimeout fetches the Facts page HTML with a specific timeout
-func (c *APIClient) fetchFactsPageWithTimeout(endpoint, treeID string, timeout time.Duration) ([]byte, error) {
+func (c *APIClient) fetchFactsPageWithTimeout(ctx context.Context, endpoint, treeID string, timeout time.Duration) ([]byte, error) {
 	// Create a new HTTP client with the specified timeout
 	client := &http.Client{
 		Jar:       c.httpClient.Jar,
@@ -251,12 +235,10 @@ func (c *APIClient) fetchFactsPageWithTimeout(endpoint, treeID string, timeout t
 		Transport: c.httpClient.Transport,
 	}
 
-	req, err := http.NewRequest("GET", endpoint, nil)
+	req, err := c.newRequest(ctx, "GET", endpoint, "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", fmt.Sprintf("https://www.ancestry.com/family-tree/tree/%s/family/familyview", treeID))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
-	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8")
-	req.Header.Set("Referer", fmt.Sprintf("https://www.ancestry.com/family-tree/tree/%s/family/familyview", treeID))
 
 	resp, err := client.Do(req)
 	if err != nil {
@@ -264,12 +246,12 @@ func (c *APIClient) fetchFactsPageWithTimeout(endpoint, treeID string, timeout t
 	}
 	defer func() {
 		if err := resp.Body.Close(); err != nil {
-			fmt.Printf("Error closing response body: %v\n", err)
+			c.log.Printf("Error closing response body: %v\n", err)
 		}
 	}()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("request failed with status %d", resp.StatusCode)
+		return nil, fmt.Errorf("request failed with %w", &StatusError{Code: resp.StatusCode})
 	}
 
 	html, err := io.ReadAll(resp.Body)
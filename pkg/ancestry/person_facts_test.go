@@ -0,0 +1,162 @@
+package ancestry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// factsPageServer serves a saved facts page fixture for every request
+func factsPageServer(t *testing.T, fixture string) *httptest.Server {
+	t.Helper()
+	page, err := os.ReadFile(filepath.Join("testdata", fixture))
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if want := "/family-tree/person/tree/42/person/1001/facts"; r.URL.Path != want {
+			t.Errorf("path = %q, want %q", r.URL.Path, want)
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write(page)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestGetPersonFactsFromHTML(t *testing.T) {
+	server := factsPageServer(t, "facts_page.html")
+
+	data, err := newTestClient(server).GetPersonFactsFromHTML(context.Background(), "42", "1001:1030:42")
+	if err != nil {
+		t.Fatalf("GetPersonFactsFromHTML() error = %v", err)
+	}
+	if data == nil || len(data.PersonFacts) != 2 || len(data.PersonSources) != 1 {
+		t.Fatalf("unexpected research data: %+v", data)
+	}
+
+	birth := data.PersonFacts[0]
+	if birth.TypeString != "Birth" || birth.Place != "London, Middlesex, England" || birth.Date != "10 Dec 1815" || birth.AssertionID != "a1" {
+		t.Errorf("unexpected birth fact: %+v", birth)
+	}
+	if want := `Baptised as "Augusta Ada" {see register}`; birth.Description != want {
+		t.Errorf("escaped quotes and braces: Description = %q, want %q", birth.Description, want)
+	}
+	if birth.SourceCitationIDs != "c1,c2" {
+		t.Errorf("SourceCitationIDs = %v, want c1,c2", birth.SourceCitationIDs)
+	}
+
+	lecture := data.PersonFacts[1]
+	if want := "Notes on the engine: } { unbalanced </script> and a real </script> tag"; lecture.Description != want {
+		t.Errorf("script tags in a string: Description = %q, want %q", lecture.Description, want)
+	}
+	if lecture.Title != "Lecture" || lecture.SourceCitationIDs != nil {
+		t.Errorf("unexpected custom fact: %+v", lecture)
+	}
+
+	source := data.PersonSources[0]
+	if source.CitationId != "c1" || source.AssertionIds != "a1 a2" || source.Title != `England, Select Births \ Christenings` {
+		t.Errorf("unexpected source: %+v", source)
+	}
+}
+
+func TestGetPersonFactsFromHTMLFallbacks(t *testing.T) {
+	tests := []struct {
+		name      string
+		fixture   string
+		wantFacts int
+		wantNil   bool
+	}{
+		{"assignment split across lines", "facts_page_spacing.html", 1, false},
+		{"page without research data", "facts_page_no_data.html", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := factsPageServer(t, tt.fixture)
+			data, err := newTestClient(server).GetPersonFactsFromHTML(context.Background(), "42", "1001")
+			if err != nil {
+				t.Fatalf("GetPersonFactsFromHTML() error = %v", err)
+			}
+			if tt.wantNil {
+				if data != nil {
+					t.Errorf("GetPersonFactsFromHTML() = %+v, want nil", data)
+				}
+				return
+			}
+			if data == nil || len(data.PersonFacts) != tt.wantFacts {
+				t.Fatalf("unexpected research data: %+v", data)
+			}
+		})
+	}
+}
+
+func TestGetPersonFactsFromHTMLKeepsFailedPages(t *testing.T) {
+	tests := []struct {
+		name     string
+		fixture  string
+		wantKept bool
+	}{
+		{"page without research data", "facts_page_no_data.html", true},
+		{"page with research data", "facts_page.html", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			debugDir := filepath.Join(t.TempDir(), "html-debug")
+			client := newTestClient(factsPageServer(t, tt.fixture))
+			client.SetHTMLDebugDir(debugDir)
+
+			if _, err := client.GetPersonFactsFromHTML(context.Background(), "42", "1001:1030:42"); err != nil {
+				t.Fatalf("GetPersonFactsFromHTML() error = %v", err)
+			}
+
+			saved, err := os.ReadFile(filepath.Join(debugDir, "42-1001-researchData.html"))
+			if kept := err == nil; kept != tt.wantKept {
+				t.Fatalf("page kept = %v, want %v (%v)", kept, tt.wantKept, err)
+			}
+			if tt.wantKept {
+				want, _ := os.ReadFile(filepath.Join("testdata", tt.fixture))
+				if string(saved) != string(want) {
+					t.Error("saved page differs from the page served")
+				}
+			}
+		})
+	}
+}
+
+func TestGetPersonFactsFromHTMLCanceledDuringRetryDelay(t *testing.T) {
+	var requests atomic.Int32
+	failed := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		failed <- struct{}{}
+	}))
+	defer server.Close()
+
+	client := newTestClient(server)
+	client.factsRetryDelay = time.Hour
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-failed
+		time.Sleep(50 * time.Millisecond)
+		cancel()
+	}()
+
+	start := time.Now()
+	_, err := client.GetPersonFactsFromHTML(ctx, "42", "1001")
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("error = %v, want %v", err, context.Canceled)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("returned %v after the first failure, want at once on cancellation", elapsed)
+	}
+	if requests.Load() != 1 {
+		t.Errorf("made %d requests, want no retry after cancellation", requests.Load())
+	}
+}
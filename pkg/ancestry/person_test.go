@@ -0,0 +1,114 @@
+package ancestry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGetAllPersonsTags(t *testing.T) {
+	tests := []struct {
+		name string
+		tags []string
+		want string
+	}{
+		{"no tags", nil, ""},
+		{"one tag", []string{"Research"}, "Research"},
+		{"several tags", []string{"Research", "Verified"}, "Research,Verified"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				query := r.URL.Query()
+				if !query.Has("tags") {
+					t.Error("tags query param missing")
+				}
+				if got := query.Get("tags"); got != tt.want {
+					t.Errorf("tags = %q, want %q", got, tt.want)
+				}
+				_, _ = w.Write([]byte(`[{"pid":"1:1030:99"}]`))
+			}))
+			defer server.Close()
+
+			persons, err := newTestClient(server).GetAllPersons(context.Background(), "t1", 1, 100, tt.tags)
+			if err != nil {
+				t.Fatalf("GetAllPersons() error = %v", err)
+			}
+			if len(persons) != 1 {
+				t.Errorf("GetAllPersons() returned %d persons, want 1", len(persons))
+			}
+		})
+	}
+}
+
+func TestSearchPersons(t *testing.T) {
+	var pages []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if got := query.Get("fn") + "|" + query.Get("ln") + "|" + query.Get("name"); got != "John|Smith|" {
+			t.Errorf("fn|ln|name = %q, want %q", got, "John|Smith|")
+		}
+		if query.Get("limit") != "2" {
+			t.Errorf("limit = %q, want 2", query.Get("limit"))
+		}
+		pages = append(pages, query.Get("page"))
+		if query.Get("page") == "1" {
+			_, _ = w.Write([]byte(`[{"pid":"1:1030:99"},{"pid":"2:1030:99"}]`))
+			return
+		}
+		_, _ = w.Write([]byte(`[{"pid":"3:1030:99"}]`))
+	}))
+	defer server.Close()
+
+	persons, err := newTestClient(server).SearchPersons(context.Background(), "t1", SearchOptions{FirstName: "John", LastName: "Smith", PageSize: 2})
+	if err != nil {
+		t.Fatalf("SearchPersons() error = %v", err)
+	}
+	if len(persons) != 3 {
+		t.Errorf("SearchPersons() returned %d persons, want 3", len(persons))
+	}
+	if len(pages) != 2 || pages[0] != "1" || pages[1] != "2" {
+		t.Errorf("fetched pages %v, want [1 2]", pages)
+	}
+}
+
+func TestGetPerson(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query := r.URL.Query()
+		if query.Get("genup") != "0" || query.Get("gendown") != "0" {
+			t.Errorf("requested generations %s up and %s down, want 0", query.Get("genup"), query.Get("gendown"))
+		}
+		focus := query.Get("focusPersonId")
+		if focus == "404" {
+			_, _ = w.Write([]byte(`{"v":"3.0","Persons":[{"gid":{"v":"5:1030:42"}}]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"v":"3.0","Persons":[
+			{"gid":{"v":"5:1030:42"},"Names":[{"g":"Mary","s":"Jones"}]},
+			{"gid":{"v":"` + focus + `:1030:42"},"Names":[{"g":"John","s":"Smith"}],"Genders":[{"g":"m"}],
+			 "Events":[{"t":"Birth","d":"12 Mar 1850"}]}
+		]}`))
+	}))
+	defer server.Close()
+	client := newTestClient(server)
+
+	for _, personID := range []string{"17", "17:1030:42", " 17 "} {
+		person, err := client.GetPerson(context.Background(), "42", personID)
+		if err != nil {
+			t.Fatalf("GetPerson(%q) error = %v", personID, err)
+		}
+		if person.GetShortPersonID() != "17" || person.GetDisplayName() != "John Smith" || len(person.Events) != 1 {
+			t.Errorf("GetPerson(%q) = %+v", personID, person)
+		}
+	}
+
+	if _, err := client.GetPerson(context.Background(), "42", "404"); !errors.Is(err, ErrPersonNotFound) {
+		t.Errorf("GetPerson() of a missing person error = %v, want %v", err, ErrPersonNotFound)
+	}
+	if _, err := client.GetPerson(context.Background(), "42", ""); !errors.Is(err, ErrPersonNotFound) {
+		t.Errorf("GetPerson() of an empty ID error = %v, want %v", err, ErrPersonNotFound)
+	}
+}
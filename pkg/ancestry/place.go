@@ -0,0 +1,124 @@
+package ancestry
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// openStreetMapSearchURL is the OpenStreetMap search page used for place map links
+const openStreetMapSearchURL = "https://www.openstreetmap.org/search"
+
+// Place is a structured place parsed from an event's nested place structure (NPS)
+type Place struct {
+	Name       string   `json:"name"`                 // Full place name, e.g. "Springfield, Sangamon, Illinois, USA"
+	Components []string `json:"components,omitempty"` // Place hierarchy from most to least specific
+	MapURL     string   `json:"mapUrl,omitempty"`     // OpenStreetMap search link for the place
+}
+
+// Keys tried for an NPS entry's form and its level in the place hierarchy. None of the
+// testdata pages include an NPS, so these are unconfirmed until a captured event is added.
+var (
+	npsTypeKeys  = []string{"t", "type"}
+	npsLevelKeys = []string{"l", "level"}
+)
+
+// isStandardizedNPS reports whether an NPS entry holds the standardized form of the place
+// (e.g. "Springfield, Sangamon, Illinois, USA") rather than the place as it was entered
+func isStandardizedNPS(entry map[string]interface{}) bool {
+	for _, key := range npsTypeKeys {
+		if value, ok := entry[key].(string); ok {
+			value = strings.ToLower(value)
+			return value == "s" || strings.HasPrefix(value, "std") || strings.HasPrefix(value, "standard")
+		}
+	}
+	return false
+}
+
+// npsLevel returns an NPS entry's level in the place hierarchy, and false if it has none
+func npsLevel(entry map[string]interface{}) (float64, bool) {
+	for _, key := range npsLevelKeys {
+		if level, ok := entry[key].(float64); ok {
+			return level, true
+		}
+	}
+	return 0, false
+}
+
+// placeComponents returns the trimmed, comma-separated parts of the entries' "v" values.
+// Entries with levels come first, lowest level first, followed by the entries without a
+// level in their original order.
+func placeComponents(entries []map[string]interface{}) []string {
+	sort.SliceStable(entries, func(i, j int) bool {
+		li, okI := npsLevel(entries[i])
+		lj, okJ := npsLevel(entries[j])
+		if okI != okJ {
+			return okI
+		}
+		return okI && li < lj
+	})
+
+	var components []string
+	for _, entry := range entries {
+		value, ok := entry["v"].(string)
+		if !ok {
+			continue
+		}
+		for _, part := range strings.Split(value, ",") {
+			if part = strings.TrimSpace(part); part != "" {
+				components = append(components, part)
+			}
+		}
+	}
+	return components
+}
+
+// splitPlaceForms separates the NPS entries holding the original place from those holding the standardized place
+func splitPlaceForms(nps []map[string]interface{}) (original, standardized []map[string]interface{}) {
+	for _, entry := range nps {
+		if isStandardizedNPS(entry) {
+			standardized = append(standardized, entry)
+		} else {
+			original = append(original, entry)
+		}
+	}
+	return original, standardized
+}
+
+// ParsePlaceForms returns an event's place as it was entered and in its standardized form.
+// Either is empty when the nested place structure (NPS) doesn't carry it.
+func ParsePlaceForms(nps []map[string]interface{}) (original, standardized string) {
+	originalEntries, standardizedEntries := splitPlaceForms(nps)
+	return strings.Join(placeComponents(originalEntries), ", "), strings.Join(placeComponents(standardizedEntries), ", ")
+}
+
+// ParseNPS parses a nested place structure into a structured place. The place as it was
+// entered is used, falling back to the standardized form. Each NPS entry's "v" value may
+// itself hold a comma-separated place, so all values are split into their components.
+// It returns false if there is no place.
+func ParseNPS(nps []map[string]interface{}) (Place, bool) {
+	originalEntries, standardizedEntries := splitPlaceForms(nps)
+	components := placeComponents(originalEntries)
+	if len(components) == 0 {
+		components = placeComponents(standardizedEntries)
+	}
+
+	if len(components) == 0 {
+		return Place{}, false
+	}
+
+	name := strings.Join(components, ", ")
+	return Place{
+		Name:       name,
+		Components: components,
+		MapURL:     openStreetMapSearchURL + "?query=" + url.QueryEscape(name),
+	}, true
+}
+
+// ResolvePlaces fills in the event's Place and PlaceStd from its nested place structure
+func (e *Event) ResolvePlaces() {
+	e.Place, e.PlaceStd = ParsePlaceForms(e.NPS)
+	if e.Place == "" {
+		e.Place = e.PlaceStd
+	}
+}
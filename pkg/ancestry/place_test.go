@@ -0,0 +1,105 @@
+package ancestry
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseNPS(t *testing.T) {
+	place, ok := ParseNPS([]map[string]interface{}{{"v": "Springfield, Sangamon,  Illinois, USA"}})
+	if !ok {
+		t.Fatal("expected a place")
+	}
+	want := []string{"Springfield", "Sangamon", "Illinois", "USA"}
+	if !reflect.DeepEqual(place.Components, want) {
+		t.Errorf("components = %q, want %q", place.Components, want)
+	}
+	if place.MapURL != "https://www.openstreetmap.org/search?query=Springfield%2C+Sangamon%2C+Illinois%2C+USA" {
+		t.Errorf("unexpected map URL %q", place.MapURL)
+	}
+
+	if _, ok := ParseNPS([]map[string]interface{}{{"id": 12}, {"v": " "}}); ok {
+		t.Error("expected no place when NPS has no names")
+	}
+}
+
+func TestParsePlaceForms(t *testing.T) {
+	tests := []struct {
+		name         string
+		nps          []map[string]interface{}
+		original     string
+		standardized string
+	}{
+		{
+			name:     "untyped entries are the original place",
+			nps:      []map[string]interface{}{{"v": "Springfield"}, {"v": "Ill."}},
+			original: "Springfield, Ill.",
+		},
+		{
+			name: "original and standardized entries",
+			nps: []map[string]interface{}{
+				{"t": "o", "v": "Springfield, Ill."},
+				{"t": "s", "v": "Springfield, Sangamon, Illinois, USA"},
+			},
+			original:     "Springfield, Ill.",
+			standardized: "Springfield, Sangamon, Illinois, USA",
+		},
+		{
+			name: "standardized levels are ordered",
+			nps: []map[string]interface{}{
+				{"type": "standardized", "level": float64(3), "v": "USA"},
+				{"type": "standardized", "level": float64(1), "v": "Springfield"},
+				{"type": "standardized", "level": float64(2), "v": "Illinois"},
+				{"type": "original", "v": "Springfield"},
+			},
+			original:     "Springfield",
+			standardized: "Springfield, Illinois, USA",
+		},
+		{
+			name: "unleveled entries follow the leveled ones",
+			nps: []map[string]interface{}{
+				{"t": "s", "v": "USA"},
+				{"t": "s", "l": float64(2), "v": "Illinois"},
+				{"t": "s", "v": "North America"},
+				{"t": "s", "l": float64(1), "v": "Springfield"},
+			},
+			standardized: "Springfield, Illinois, USA, North America",
+		},
+		{
+			name:         "only a standardized place",
+			nps:          []map[string]interface{}{{"t": "std", "v": "Boston, Suffolk, Massachusetts, USA"}},
+			standardized: "Boston, Suffolk, Massachusetts, USA",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			original, standardized := ParsePlaceForms(tt.nps)
+			if original != tt.original || standardized != tt.standardized {
+				t.Errorf("ParsePlaceForms() = %q, %q, want %q, %q", original, standardized, tt.original, tt.standardized)
+			}
+		})
+	}
+}
+
+func TestEventResolvePlaces(t *testing.T) {
+	event := Event{NPS: []map[string]interface{}{
+		{"t": "s", "v": "Springfield, Sangamon, Illinois, USA"},
+		{"t": "o", "v": "Springfield, Ill."},
+	}}
+	event.ResolvePlaces()
+	if event.Place != "Springfield, Ill." || event.PlaceStd != "Springfield, Sangamon, Illinois, USA" {
+		t.Errorf("got Place %q, PlaceStd %q", event.Place, event.PlaceStd)
+	}
+
+	place, ok := ParseNPS(event.NPS)
+	if !ok || place.Name != "Springfield, Ill." {
+		t.Errorf("ParseNPS should use the original place, got %q", place.Name)
+	}
+
+	stdOnly := Event{NPS: []map[string]interface{}{{"t": "s", "v": "Boston, Massachusetts, USA"}}}
+	stdOnly.ResolvePlaces()
+	if stdOnly.Place != "Boston, Massachusetts, USA" {
+		t.Errorf("Place should fall back to the standardized form, got %q", stdOnly.Place)
+	}
+}
@@ -0,0 +1,97 @@
+package ancestry
+
+import (
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultRequestsPerSecond is the request rate bulk downloads and exports are limited to.
+	// Faster downloads of large trees start getting 429s and the session throttled.
+	DefaultRequestsPerSecond = 3.0
+	// DefaultRequestBurst is the number of requests a new client may send at once after idling
+	DefaultRequestBurst = 1
+)
+
+// rateLimitTransport is an http.RoundTripper that spaces requests out to a steady rate
+// with a token bucket. Each request takes a token, waiting for one to be refilled if the
+// bucket is empty; waiting ends early if the request's context is canceled, which returns
+// the token.
+type rateLimitTransport struct {
+	transport http.RoundTripper
+
+	mu     sync.Mutex
+	rate   float64 // Tokens refilled per second; 0 or less disables the limit
+	burst  int     // Bucket capacity
+	tokens float64 // May go negative while requests wait for their reserved token
+	last   time.Time
+}
+
+// newRateLimitTransport creates a rateLimitTransport allowing rps requests per second
+func newRateLimitTransport(transport http.RoundTripper, rps float64, burst int) *rateLimitTransport {
+	t := &rateLimitTransport{transport: transport}
+	t.setLimit(rps, burst)
+	return t
+}
+
+// setLimit changes the rate and burst and refills the bucket
+func (t *rateLimitTransport) setLimit(rps float64, burst int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.rate = rps
+	t.burst = max(burst, 1)
+	t.tokens = float64(t.burst)
+	t.last = time.Now()
+}
+
+// reserve takes a token and returns how long to wait until it is available
+func (t *rateLimitTransport) reserve() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.rate <= 0 {
+		return 0
+	}
+	now := time.Now()
+	t.tokens = min(t.tokens+now.Sub(t.last).Seconds()*t.rate, float64(t.burst))
+	t.last = now
+	t.tokens--
+	if t.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-t.tokens / t.rate * float64(time.Second))
+}
+
+// release returns a reserved token whose request was never sent
+func (t *rateLimitTransport) release() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.rate <= 0 {
+		return
+	}
+	t.tokens = min(t.tokens+1, float64(t.burst))
+}
+
+// RoundTrip waits for the request's turn and then executes it
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if wait := t.reserve(); wait > 0 {
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-req.Context().Done():
+			timer.Stop()
+			t.release()
+			return nil, req.Context().Err()
+		}
+	}
+	return t.transport.RoundTrip(req)
+}
+
+// SetRateLimit limits the client to rps requests per second, allowing burst requests at once
+// after idling. Media downloads count toward the same limit. An rps of 0 removes the limit,
+// which is the default for a new client.
+func (c *APIClient) SetRateLimit(rps float64, burst int) {
+	c.limiter.setLimit(rps, burst)
+}
@@ -0,0 +1,77 @@
+package ancestry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestRateLimitTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	tests := []struct {
+		name     string
+		rps      float64
+		burst    int
+		requests int
+		min      time.Duration
+		max      time.Duration
+	}{
+		{"paced", 20, 1, 5, 200 * time.Millisecond, 2 * time.Second},
+		{"burst goes at once", 20, 3, 5, 100 * time.Millisecond, 2 * time.Second},
+		{"disabled", 0, 1, 5, 0, 150 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &http.Client{Transport: newRateLimitTransport(server.Client().Transport, tt.rps, tt.burst)}
+			start := time.Now()
+			for i := 0; i < tt.requests; i++ {
+				resp, err := client.Get(server.URL)
+				if err != nil {
+					t.Fatalf("request %d error = %v", i, err)
+				}
+				_ = resp.Body.Close()
+			}
+			if elapsed := time.Since(start); elapsed < tt.min || elapsed > tt.max {
+				t.Errorf("%d requests took %s, want between %s and %s", tt.requests, elapsed, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+func TestRateLimitTransportCanceled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	limiter := newRateLimitTransport(server.Client().Transport, 0.1, 1)
+	limiter.reserve() // Empty the bucket; the next token is 10s away
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", server.URL, nil)
+	if _, err := limiter.RoundTrip(req); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("RoundTrip() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestRateLimitTransportCanceledReleasesToken(t *testing.T) {
+	limiter := newRateLimitTransport(http.DefaultTransport, 0.1, 1)
+	limiter.reserve() // Empty the bucket; the next token is 10s away
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req, _ := http.NewRequestWithContext(ctx, "GET", "http://example.invalid", nil)
+	if _, err := limiter.RoundTrip(req); !errors.Is(err, context.Canceled) {
+		t.Fatalf("RoundTrip() error = %v, want %v", err, context.Canceled)
+	}
+
+	// Without the canceled request's token back, the next one would wait 20s
+	if wait := limiter.reserve(); wait > 11*time.Second {
+		t.Errorf("reserve() after a canceled request = %s, want at most 10s", wait)
+	}
+}
@@ -0,0 +1,113 @@
+package ancestry
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// EndpointTiming is how many requests went to one endpoint and how long they took
+type EndpointTiming struct {
+	Endpoint  string  `json:"endpoint"` // Method, host and path, with IDs replaced by {id}
+	Requests  int     `json:"requests"`
+	Failures  int     `json:"failures"` // Requests that errored or got a 5xx or 429 response
+	AverageMs float64 `json:"averageMs"`
+	MaxMs     float64 `json:"maxMs"`
+	TotalMs   float64 `json:"totalMs"`
+}
+
+// timingTransport is an http.RoundTripper that records the latency of each request per
+// endpoint once enabled. Nothing leaves the process; the timings are only read back
+// through RequestTimings.
+type timingTransport struct {
+	transport http.RoundTripper
+
+	mu        sync.Mutex
+	enabled   bool
+	endpoints map[string]*EndpointTiming
+}
+
+// newTimingTransport creates a new, disabled timingTransport.
+func newTimingTransport(transport http.RoundTripper) *timingTransport {
+	return &timingTransport{transport: transport, endpoints: make(map[string]*EndpointTiming)}
+}
+
+// RoundTrip executes a single HTTP transaction, timing it if timings are enabled.
+func (t *timingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	enabled := t.enabled
+	t.mu.Unlock()
+	if !enabled {
+		return t.transport.RoundTrip(req)
+	}
+
+	start := time.Now()
+	resp, err := t.transport.RoundTrip(req)
+	failed := err != nil || resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+	t.record(req.Method+" "+req.URL.Host+endpointPath(req.URL.Path), time.Since(start), failed)
+	return resp, err
+}
+
+// record adds one request to the endpoint's timing
+func (t *timingTransport) record(endpoint string, elapsed time.Duration, failed bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	timing, ok := t.endpoints[endpoint]
+	if !ok {
+		timing = &EndpointTiming{Endpoint: endpoint}
+		t.endpoints[endpoint] = timing
+	}
+	ms := float64(elapsed) / float64(time.Millisecond)
+	timing.Requests++
+	timing.TotalMs += ms
+	timing.AverageMs = timing.TotalMs / float64(timing.Requests)
+	if ms > timing.MaxMs {
+		timing.MaxMs = ms
+	}
+	if failed {
+		timing.Failures++
+	}
+}
+
+// endpointPath replaces the path segments holding IDs (any segment with a digit) with {id},
+// so requests for different persons or media are counted as the same endpoint
+func endpointPath(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		if strings.IndexFunc(segment, unicode.IsDigit) >= 0 {
+			segments[i] = "{id}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
+
+// EnableRequestTimings starts recording the latency of every request per endpoint, for
+// RequestTimings to report. The timings are kept in memory only.
+func (c *APIClient) EnableRequestTimings() {
+	c.timings.mu.Lock()
+	defer c.timings.mu.Unlock()
+	c.timings.enabled = true
+}
+
+// RequestTimings returns the recorded latency of each endpoint, the endpoints that took the
+// most time in total first. It is empty unless EnableRequestTimings was called.
+func (c *APIClient) RequestTimings() []EndpointTiming {
+	c.timings.mu.Lock()
+	defer c.timings.mu.Unlock()
+
+	timings := make([]EndpointTiming, 0, len(c.timings.endpoints))
+	for _, timing := range c.timings.endpoints {
+		timings = append(timings, *timing)
+	}
+	sort.Slice(timings, func(i, j int) bool {
+		if timings[i].TotalMs != timings[j].TotalMs {
+			return timings[i].TotalMs > timings[j].TotalMs
+		}
+		return timings[i].Endpoint < timings[j].Endpoint
+	})
+	return timings
+}
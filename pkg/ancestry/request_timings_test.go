@@ -0,0 +1,66 @@
+package ancestry
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestEndpointPath(t *testing.T) {
+	tests := map[string]string{
+		"/api/treeviewer/tree/123/persons":              "/api/treeviewer/tree/{id}/persons",
+		"/family-tree/person/tree/123/person/456/facts": "/family-tree/person/tree/{id}/person/{id}/facts",
+		"/api/media/abc1-def2.jpg":                      "/api/media/{id}",
+		"/":                                             "/",
+	}
+	for path, want := range tests {
+		if got := endpointPath(path); got != want {
+			t.Errorf("endpointPath(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestTimingTransport(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/tree/2/fail" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	timings := newTimingTransport(http.DefaultTransport)
+	client := &APIClient{httpClient: &http.Client{Transport: timings}, timings: timings}
+	get := func(path string) {
+		resp, err := client.httpClient.Get(server.URL + path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+	}
+
+	get("/tree/1/persons")
+	if got := client.RequestTimings(); len(got) != 0 {
+		t.Fatalf("timings recorded before EnableRequestTimings: %+v", got)
+	}
+
+	client.EnableRequestTimings()
+	get("/tree/1/persons")
+	get("/tree/2/persons")
+	get("/tree/2/fail")
+
+	got := client.RequestTimings()
+	if len(got) != 2 {
+		t.Fatalf("got %d endpoints, want 2: %+v", len(got), got)
+	}
+	byEndpoint := map[string]EndpointTiming{}
+	for _, timing := range got {
+		byEndpoint[timing.Endpoint] = timing
+	}
+	host := server.Listener.Addr().String()
+	if persons := byEndpoint["GET "+host+"/tree/{id}/persons"]; persons.Requests != 2 || persons.Failures != 0 {
+		t.Errorf("persons endpoint = %+v", persons)
+	}
+	if fail := byEndpoint["GET "+host+"/tree/{id}/fail"]; fail.Requests != 1 || fail.Failures != 1 {
+		t.Errorf("failing endpoint = %+v", fail)
+	}
+}
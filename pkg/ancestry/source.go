@@ -1,7 +1,9 @@
 package ancestry
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
@@ -12,8 +14,8 @@ import (
 
 // GetSource retrieves source record information by scraping the fact edit page
 // and extracting the window.getFactEditData JSON object.
-func (c *APIClient) GetSource(treeID, personID, sourceID, databaseID, recordID string) (*FactEditData, error) {
-	reqURL, err := c.buildSourceURL(treeID, personID, sourceID, databaseID, recordID)
+func (c *APIClient) GetSource(ctx context.Context, treeID, personID, sourceID, databaseID, recordID string) (*FactEditData, error) {
+	reqURL, err := c.buildSourceURL(ctx, treeID, personID, sourceID, databaseID, recordID)
 	if err != nil {
 		return nil, err
 	}
@@ -23,7 +25,7 @@ func (c *APIClient) GetSource(treeID, personID, sourceID, databaseID, recordID s
 
 	// Implement retry mechanism for transient server errors
 	for attempt := 1; attempt <= 3; attempt++ {
-		factEditData, shouldRetry, err := c.performSourceAttempt(reqURL, treeID, shortPersonID, attempt)
+		factEditData, shouldRetry, err := c.performSourceAttempt(ctx, reqURL, treeID, shortPersonID, attempt)
 		if err == nil {
 			return factEditData, nil
 		}
@@ -35,8 +37,8 @@ func (c *APIClient) GetSource(treeID, personID, sourceID, databaseID, recordID s
 	return nil, lastErr
 }
 
-func (c *APIClient) buildSourceURL(treeID, personID, sourceID, databaseID, recordID string) (*url.URL, error) {
-	userID, err := c.GetUserID()
+func (c *APIClient) buildSourceURL(ctx context.Context, treeID, personID, sourceID, databaseID, recordID string) (*url.URL, error) {
+	userID, err := c.GetUserID(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get authenticated user ID: %w", err)
 	}
@@ -65,20 +67,18 @@ func (c *APIClient) buildSourceURL(treeID, personID, sourceID, databaseID, recor
 	return reqURL, nil
 }
 
-func (c *APIClient) performSourceAttempt(reqURL *url.URL, treeID, shortPersonID string, attempt int) (*FactEditData, bool, error) {
-	req, err := http.NewRequest("GET", reqURL.String(), nil)
+func (c *APIClient) performSourceAttempt(ctx context.Context, reqURL *url.URL, treeID, shortPersonID string, attempt int) (*FactEditData, bool, error) {
+	req, err := c.newRequest(ctx, "GET", reqURL.String(), "text/html,application/xhtml+xml,application/xml;q=0.9,image/webp,*/*;q=0.8", fmt.Sprintf("https://www.ancestry.com/family-tree/person/tree/%s/person/%s/facts", treeID, shortPersonID))
 	if err != nil {
 		return nil, false, fmt.Errorf("failed to create request for source page: %w", err)
 	}
-	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,image/webp,*/*;q=0.8")
-	req.Header.Set("Referer", fmt.Sprintf("https://www.ancestry.com/family-tree/person/tree/%s/person/%s/facts", treeID, shortPersonID))
 	req.Header.Set("X-Requested-With", "XMLHttpRequest")
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		err = fmt.Errorf("failed to fetch source page: %w", err)
 		c.log.Printf("[DEBUG] Attempt %d: %v\n", attempt, err)
-		return nil, true, err
+		return nil, !errors.Is(err, ErrCircuitOpen), err
 	}
 	defer func() {
 		if err := resp.Body.Close(); err != nil {
@@ -88,7 +88,7 @@ func (c *APIClient) performSourceAttempt(reqURL *url.URL, treeID, shortPersonID
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		err = fmt.Errorf("source page request failed with status %d (URL: %s): %s", resp.StatusCode, reqURL.String(), string(body))
+		err = fmt.Errorf("source page request failed with %w (URL: %s): %s", &StatusError{Code: resp.StatusCode}, reqURL.String(), string(body))
 		c.log.Printf("[DEBUG] Attempt %d: %v\n", attempt, err)
 		if resp.StatusCode >= 500 && resp.StatusCode < 600 {
 			time.Sleep(time.Duration(attempt) * 2 * time.Second)
@@ -152,7 +152,7 @@ func (c *APIClient) extractFactEditDataFromHTML(htmlContent string) (*FactEditDa
 }
 
 // GetDiscoveryRecords retrieves records for a given database and person ID
-func (c *APIClient) GetDiscoveryRecords(dbID, pId string) (*DiscoveryDiscoveryRecordResponse, error) {
+func (c *APIClient) GetDiscoveryRecords(ctx context.Context, dbID, pId string) (*DiscoveryDiscoveryRecordResponse, error) {
 	endpoint := fmt.Sprintf("%s/discoveryui-contentservice/api/records", c.baseURL)
 	reqURL, err := url.Parse(endpoint)
 	if err != nil {
@@ -164,14 +164,11 @@ func (c *APIClient) GetDiscoveryRecords(dbID, pId string) (*DiscoveryDiscoveryRe
 	query.Set("r_idx", pId)
 	reqURL.RawQuery = query.Encode()
 
-	req, err := http.NewRequest("GET", reqURL.String(), nil)
+	req, err := c.newRequest(ctx, "GET", reqURL.String(), "application/json", "https://www.ancestry.com/")
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.Header.Set("Accept", "application/json")
-	req.Header.Set("Referer", "https://www.ancestry.com/")
-
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
 		return nil, fmt.Errorf("failed to make request: %w", err)
@@ -183,12 +180,16 @@ func (c *APIClient) GetDiscoveryRecords(dbID, pId string) (*DiscoveryDiscoveryRe
 	}()
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+		return nil, fmt.Errorf("API request failed with %w", statusError(resp))
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
 	}
 
 	var recordResponse DiscoveryDiscoveryRecordResponse
-	if err := json.NewDecoder(resp.Body).Decode(&recordResponse); err != nil {
+	if err := c.decodeJSON(body, reqURL.Path, &recordResponse); err != nil {
 		return nil, fmt.Errorf("failed to decode response: %w", err)
 	}
 
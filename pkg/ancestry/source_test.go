@@ -0,0 +1,101 @@
+package ancestry
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// checkSourceFixture checks the FactEditData extracted from the source page fixtures
+func checkSourceFixture(t *testing.T, data *FactEditData) {
+	t.Helper()
+	if data.CitationID != "c1" || data.SourceID != "s1" || data.DatabaseID != "1234" || data.RecordID != "5678" {
+		t.Errorf("unexpected IDs: %+v", data)
+	}
+	if want := "Page {12}; line 3"; data.CitationPage != want {
+		t.Errorf("braces and semicolon in a string: CitationPage = %q, want %q", data.CitationPage, want)
+	}
+	if want := `Clerk wrote "Byron"; see </script> note`; data.CitationNote != want {
+		t.Errorf("script tag in a string: CitationNote = %q, want %q", data.CitationNote, want)
+	}
+	if data.SourceTitle != "England, Select Births and Christenings, 1538-1975" || data.Name != "Ada Byron" || !data.CanEdit {
+		t.Errorf("unexpected source details: %+v", data)
+	}
+}
+
+func TestExtractFactEditDataFromHTML(t *testing.T) {
+	tests := []struct {
+		name    string
+		fixture string
+	}{
+		{"html fragment", "source_page.html"},
+		{"html wrapped in a JSON response", "source_page.json"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page, err := os.ReadFile(filepath.Join("testdata", tt.fixture))
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, err := (&APIClient{}).extractFactEditDataFromHTML(string(page))
+			if err != nil {
+				t.Fatalf("extractFactEditDataFromHTML() error = %v", err)
+			}
+			checkSourceFixture(t, data)
+		})
+	}
+}
+
+func TestExtractFactEditDataFromHTMLErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		page string
+	}{
+		{"no marker", "<div>Something went wrong</div>"},
+		{"no end", `<script>window.getFactEditData = {"CitationId":"c1"`},
+		{"invalid JSON", `<script>window.getFactEditData = {CitationId: c1};</script>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := (&APIClient{}).extractFactEditDataFromHTML(tt.page); err == nil {
+				t.Error("extractFactEditDataFromHTML() should fail")
+			}
+		})
+	}
+}
+
+func TestGetSource(t *testing.T) {
+	page, err := os.ReadFile(filepath.Join("testdata", "source_page.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if want := "/family-tree/person/factedit/user/u1/tree/42/person/1001/source/c1"; r.URL.Path != want {
+			t.Errorf("path = %q, want %q", r.URL.Path, want)
+		}
+		if got := r.URL.Query().Get("databaseId"); got != "1234" {
+			t.Errorf("databaseId = %q, want 1234", got)
+		}
+		if got := r.Header.Get("X-Requested-With"); got != "XMLHttpRequest" {
+			t.Errorf("X-Requested-With = %q", got)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(page)
+	}))
+	defer server.Close()
+
+	client := newTestClient(server)
+	client.userID = "u1"
+	client.log = log.New(io.Discard, "", 0)
+
+	data, err := client.GetSource(context.Background(), "42", "1001:1030:42", "c1", "1234", "5678")
+	if err != nil {
+		t.Fatalf("GetSource() error = %v", err)
+	}
+	checkSourceFixture(t, data)
+}
@@ -0,0 +1,108 @@
+package ancestry
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// TLSOptions configures certificate checking, for networks behind a TLS-inspecting proxy
+type TLSOptions struct {
+	CACertFile string // PEM file of extra root CAs to trust, e.g. a corporate proxy's CA
+	Insecure   bool   // Skip certificate verification entirely
+}
+
+// IsZero reports whether the options leave the default certificate checking in place
+func (o TLSOptions) IsZero() bool {
+	return o.CACertFile == "" && !o.Insecure
+}
+
+// loadCACerts reads the PEM certificates in the CA file
+func (o TLSOptions) loadCACerts() ([]*x509.Certificate, error) {
+	data, err := os.ReadFile(o.CACertFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA certificate: %w", err)
+	}
+
+	var certs []*x509.Certificate
+	for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse CA certificate %s: %w", o.CACertFile, err)
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no PEM certificates found in %s", o.CACertFile)
+	}
+	return certs, nil
+}
+
+// tlsConfig builds the TLS configuration for the options: the system roots plus
+// the CA file's certificates, with verification skipped when Insecure is set
+func (o TLSOptions) tlsConfig() (*tls.Config, error) {
+	config := &tls.Config{InsecureSkipVerify: o.Insecure} // #nosec G402 -- only with --insecure
+
+	if o.CACertFile != "" {
+		certs, err := o.loadCACerts()
+		if err != nil {
+			return nil, err
+		}
+		pool, err := x509.SystemCertPool()
+		if err != nil || pool == nil {
+			pool = x509.NewCertPool()
+		}
+		for _, cert := range certs {
+			pool.AddCert(cert)
+		}
+		config.RootCAs = pool
+	}
+
+	return config, nil
+}
+
+// browserFlags returns the Chrome command-line flags matching the options. Chrome keeps its
+// own certificate store, so the CA file is trusted by its public key hash instead.
+func (o TLSOptions) browserFlags() (map[string]string, error) {
+	flags := make(map[string]string)
+	if o.Insecure {
+		flags["ignore-certificate-errors"] = ""
+	}
+
+	if o.CACertFile != "" {
+		certs, err := o.loadCACerts()
+		if err != nil {
+			return nil, err
+		}
+		hashes := make([]string, 0, len(certs))
+		for _, cert := range certs {
+			sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+			hashes = append(hashes, base64.StdEncoding.EncodeToString(sum[:]))
+		}
+		flags["ignore-certificate-errors-spki-list"] = strings.Join(hashes, ",")
+	}
+
+	return flags, nil
+}
+
+// SetTLSOptions applies custom certificate checking to every request the client makes.
+// It must be called before the first request.
+func (c *APIClient) SetTLSOptions(opts TLSOptions) error {
+	if opts.IsZero() {
+		return nil
+	}
+	config, err := opts.tlsConfig()
+	if err != nil {
+		return err
+	}
+	c.transport.TLSClientConfig = config
+	return nil
+}
@@ -0,0 +1,70 @@
+package ancestry
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSetTLSOptions(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	caFile := filepath.Join(t.TempDir(), "proxy-ca.pem")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	if err := os.WriteFile(caFile, caPEM, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		opts    TLSOptions
+		wantErr bool
+	}{
+		{"default rejects unknown CA", TLSOptions{}, true},
+		{"custom CA", TLSOptions{CACertFile: caFile}, false},
+		{"insecure", TLSOptions{Insecure: true}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := NewAPIClient(nil, false)
+			if err != nil {
+				t.Fatal(err)
+			}
+			client.baseURL = server.URL
+			if err := client.SetTLSOptions(tt.opts); err != nil {
+				t.Fatalf("SetTLSOptions: %v", err)
+			}
+
+			_, err = client.GetUserData(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("GetUserData error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestTLSOptionsBadCAFile(t *testing.T) {
+	badFile := filepath.Join(t.TempDir(), "not-a-cert.pem")
+	if err := os.WriteFile(badFile, []byte("hello"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	client, err := NewAPIClient(nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := client.SetTLSOptions(TLSOptions{CACertFile: badFile}); err == nil {
+		t.Error("expected an error for a file without certificates")
+	}
+	if _, err := (TLSOptions{CACertFile: badFile}).browserFlags(); err == nil {
+		t.Error("expected browserFlags to reject a file without certificates")
+	}
+}
@@ -1,94 +1,102 @@
 package ancestry
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"strings"
+	"sync"
 	"time"
 )
 
+// timestamp returns the current time in milliseconds, used as a cache-busting query parameter
+func timestamp() string {
+	return fmt.Sprintf("%d", time.Now().UnixMilli())
+}
+
 // ListTrees retrieves all trees (owned and shared) for the authenticated user
-func (c *APIClient) ListTrees() ([]Tree, error) {
+func (c *APIClient) ListTrees(ctx context.Context) ([]Tree, error) {
 	// Use the media viewer API which returns ALL trees including shared ones
-	endpoint := fmt.Sprintf("%s/api/media/viewer/api/trees/list", c.baseURL)
-	reqURL, err := url.Parse(endpoint)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse URL: %w", err)
-	}
+	query := url.Values{}
+	query.Set("timestamp", timestamp())
 
-	// Add timestamp parameter
-	query := reqURL.Query()
-	query.Set("timestamp", fmt.Sprintf("%d", time.Now().UnixMilli()))
-	reqURL.RawQuery = query.Encode()
-
-	// Create request
-	req, err := http.NewRequest("GET", reqURL.String(), nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+	var body json.RawMessage
+	if err := c.getJSON(ctx, "/api/media/viewer/api/trees/list", query, &body); err != nil {
+		return nil, err
 	}
 
-	// Add headers
-	req.Header.Set("Accept", "*/*")
-	req.Header.Set("Referer", "https://www.ancestry.com/")
+	return decodeTreeList(body)
+}
 
-	// Make request
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to make request: %w", err)
+// decodeTreeList decodes a tree list response. The endpoint normally returns a bare
+// array, but some accounts still get the older {"trees": [...], "count": n} wrapper.
+// An object without the trees key is an error rather than an empty list.
+func decodeTreeList(body []byte) ([]Tree, error) {
+	var trees []Tree
+	arrayErr := json.Unmarshal(body, &trees)
+	if arrayErr == nil {
+		return trees, nil
 	}
-	defer func() {
-		if err := resp.Body.Close(); err != nil {
-			fmt.Printf("Error closing response body: %v\n", err)
-		}
-	}()
 
-	// Check status code
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+	var wrapped map[string]json.RawMessage
+	if err := json.Unmarshal(body, &wrapped); err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w", arrayErr)
 	}
-
-	// This endpoint returns an array directly, not wrapped in {trees: [...]}
-	var trees []Tree
-	if err := json.NewDecoder(resp.Body).Decode(&trees); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	rawTrees, ok := wrapped["trees"]
+	if !ok {
+		return nil, fmt.Errorf("failed to decode response: no \"trees\" key in the tree list")
+	}
+	if err := json.Unmarshal(rawTrees, &trees); err != nil {
+		return nil, fmt.Errorf("failed to decode trees: %w", err)
 	}
-
 	return trees, nil
 }
 
-// GetTreeInfo retrieves metadata about a specific tree
-func (c *APIClient) GetTreeInfo(treeID string) (*TreeInfo, error) {
-	endpoint := fmt.Sprintf("%s/api/treeviewer/tree/%s/info", c.baseURL, treeID)
-
-	req, err := http.NewRequest("GET", endpoint, nil)
+// ErrSharedTreeAccess is returned by OpenSharedTree when the user can no longer open a tree
+// that was shared with them
+var ErrSharedTreeAccess = errors.New("no access to this shared tree; the invitation may have been withdrawn or may have expired")
+
+// OpenSharedTree loads a shared tree's family view page the way the browser does when an
+// invited user opens the tree, so the site grants the session access to the tree before the
+// tree and person APIs are called. A 401, 403 or 404 means the invitation no longer grants
+// access and is reported as ErrSharedTreeAccess. The endpoint is unverified: it is the family
+// view URL the site links to, but no captured request shows the browser loading it to accept
+// a shared tree, so it may not grant access until it is checked against a capture.
+func (c *APIClient) OpenSharedTree(ctx context.Context, treeID string) error {
+	endpoint := fmt.Sprintf("%s/family-tree/tree/%s/family", c.baseURL, url.PathEscape(treeID))
+	req, err := c.newRequest(ctx, "GET", endpoint, "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", defaultReferer)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return fmt.Errorf("failed to create request: %w", err)
 	}
 
-	req.Header.Set("Accept", "*/*")
-	req.Header.Set("Referer", "https://www.ancestry.com/")
-
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("failed to make request: %w", err)
+		return fmt.Errorf("failed to open shared tree: %w", err)
 	}
 	defer func() {
-		if err := resp.Body.Close(); err != nil {
-			fmt.Printf("Error closing response body: %v\n", err)
-		}
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
 	}()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound:
+		return fmt.Errorf("tree %s: %w (%w)", treeID, ErrSharedTreeAccess, &StatusError{Code: resp.StatusCode})
+	default:
+		return fmt.Errorf("failed to open shared tree %s: %w", treeID, &StatusError{Code: resp.StatusCode})
 	}
+}
 
+// GetTreeInfo retrieves metadata about a specific tree
+func (c *APIClient) GetTreeInfo(ctx context.Context, treeID string) (*TreeInfo, error) {
 	var treeInfo TreeInfo
-	if err := json.NewDecoder(resp.Body).Decode(&treeInfo); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	if err := c.getJSON(ctx, fmt.Sprintf("/api/treeviewer/tree/%s/info", treeID), nil, &treeInfo); err != nil {
+		return nil, err
 	}
 
 	return &treeInfo, nil
@@ -96,186 +104,179 @@ func (c *APIClient) GetTreeInfo(treeID string) (*TreeInfo, error) {
 
 // GetFamilyView retrieves comprehensive tree data for multiple generations
 // This is the primary endpoint for downloading tree information
-func (c *APIClient) GetFamilyView(treeID, focusPersonID string, genUp, genDown int) (*FamilyViewResponse, error) {
-	endpoint := fmt.Sprintf("%s/api/treeviewer/tree/newfamilyview/%s", c.baseURL, treeID)
-
-	reqURL, err := url.Parse(endpoint)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse URL: %w", err)
-	}
-
-	query := reqURL.Query()
+func (c *APIClient) GetFamilyView(ctx context.Context, treeID, focusPersonID string, genUp, genDown int) (*FamilyViewResponse, error) {
+	query := url.Values{}
 	query.Set("focusPersonId", focusPersonID)
 	query.Set("isFocus", "true")
 	query.Set("view", "family")
 	query.Set("genup", fmt.Sprintf("%d", genUp))
 	query.Set("gendown", fmt.Sprintf("%d", genDown))
-	query.Set("ts", fmt.Sprintf("%d", time.Now().UnixMilli()))
-	reqURL.RawQuery = query.Encode()
+	query.Set("ts", timestamp())
 
-	req, err := http.NewRequest("GET", reqURL.String(), nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+	var body familyViewBody
+	if err := c.getJSON(ctx, fmt.Sprintf("/api/treeviewer/tree/newfamilyview/%s", treeID), query, &body); err != nil {
+		return nil, err
 	}
-
-	req.Header.Set("Accept", "*/*")
-	req.Header.Set("Referer", "https://www.ancestry.com/")
-
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to make request: %w", err)
+	if err := body.apiError(focusPersonID); err != nil {
+		return nil, err
 	}
-	defer func() {
-		if err := resp.Body.Close(); err != nil {
-			fmt.Printf("Error closing response body: %v\n", err)
-		}
-	}()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
-	}
+	return &body.FamilyViewResponse, nil
+}
 
-	var familyView FamilyViewResponse
-	if err := json.NewDecoder(resp.Body).Decode(&familyView); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
-	}
+// FamilyViewError is returned when the family view endpoint answers with an error object or an
+// empty object instead of a family view. Unlike a view without family it says nothing about the
+// person, and is usually worth retrying.
+type FamilyViewError struct {
+	FocusPersonID string
+	Message       string
+}
 
-	return &familyView, nil
+func (e *FamilyViewError) Error() string {
+	return fmt.Sprintf("family view for %s unavailable: %s", e.FocusPersonID, e.Message)
 }
 
-// GetRootPerson retrieves the root person of a tree
-func (c *APIClient) GetRootPerson(treeID string) (*Person, error) {
-	endpoint := fmt.Sprintf("%s/api/treesui-list/trees/%s/rootperson", c.baseURL, treeID)
+// familyViewBody is a family view response, which can also be an error object
+type familyViewBody struct {
+	FamilyViewResponse
+	ErrorValue json.RawMessage `json:"error,omitempty"`   // A message string or an error object
+	Message    string          `json:"message,omitempty"` // Set with some errors
+}
 
-	reqURL, err := url.Parse(endpoint)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse URL: %w", err)
+// apiError returns a FamilyViewError if the body is an error object, or an empty object with
+// neither a version nor any persons; a real view always includes at least the focus person
+func (b *familyViewBody) apiError(focusPersonID string) error {
+	if len(b.ErrorValue) > 0 && string(b.ErrorValue) != "null" {
+		message := b.Message
+		var text string
+		if json.Unmarshal(b.ErrorValue, &text) == nil {
+			message = strings.TrimSpace(text + " " + message)
+		} else if message == "" {
+			message = string(b.ErrorValue)
+		}
+		return &FamilyViewError{FocusPersonID: focusPersonID, Message: message}
 	}
-
-	query := reqURL.Query()
-	query.Set("expires", fmt.Sprintf("%d", time.Now().UnixMilli()))
-	query.Set("isGetFullPersonObject", "true")
-	reqURL.RawQuery = query.Encode()
-
-	req, err := http.NewRequest("GET", reqURL.String(), nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+	if b.V == "" && len(b.Persons) == 0 {
+		message := "empty response"
+		if b.Message != "" {
+			message = b.Message
+		}
+		return &FamilyViewError{FocusPersonID: focusPersonID, Message: message}
 	}
+	return nil
+}
 
-	req.Header.Set("Accept", "*/*")
-	req.Header.Set("Referer", "https://www.ancestry.com/")
+// FamilyViewResult is the family view of one focus person in a batch, or the error fetching it
+type FamilyViewResult struct {
+	FocusID    string
+	FamilyView *FamilyViewResponse
+	Err        error
+}
 
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to make request: %w", err)
+// GetFamilyViewBatch retrieves the family views of several focus persons. The newfamilyview
+// endpoint only takes a single focusPersonId, so the views are fetched in parallel, one request
+// per focus person and at most concurrency at a time (one at a time if concurrency is below 1).
+// The results are in the order of focusIDs.
+func (c *APIClient) GetFamilyViewBatch(ctx context.Context, treeID string, focusIDs []string, genUp, genDown, concurrency int) []FamilyViewResult {
+	results := make([]FamilyViewResult, len(focusIDs))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, max(concurrency, 1))
+
+	for i, focusID := range focusIDs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			familyView, err := c.GetFamilyView(ctx, treeID, focusID, genUp, genDown)
+			results[i] = FamilyViewResult{FocusID: focusID, FamilyView: familyView, Err: err}
+		}()
 	}
-	defer func() {
-		if err := resp.Body.Close(); err != nil {
-			fmt.Printf("Error closing response body: %v\n", err)
-		}
-	}()
+	wg.Wait()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
-	}
+	return results
+}
+
+// GetRootPerson retrieves the root person of a tree
+func (c *APIClient) GetRootPerson(ctx context.Context, treeID string) (*Person, error) {
+	query := url.Values{}
+	query.Set("expires", timestamp())
+	query.Set("isGetFullPersonObject", "true")
 
 	var person Person
-	if err := json.NewDecoder(resp.Body).Decode(&person); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	if err := c.getJSON(ctx, fmt.Sprintf("/api/treesui-list/trees/%s/rootperson", treeID), query, &person); err != nil {
+		return nil, err
 	}
 
 	return &person, nil
 }
 
 // GetFocusHistory retrieves the navigation history with person data
-func (c *APIClient) GetFocusHistory(treeID string) (*FocusHistoryResponse, error) {
-	endpoint := fmt.Sprintf("%s/api/treeviewer/getFocusHistory", c.baseURL)
-
-	reqURL, err := url.Parse(endpoint)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse URL: %w", err)
-	}
-
-	query := reqURL.Query()
+func (c *APIClient) GetFocusHistory(ctx context.Context, treeID string) (*FocusHistoryResponse, error) {
+	query := url.Values{}
 	query.Set("tid", treeID)
-	query.Set("ts", fmt.Sprintf("%d", time.Now().UnixMilli()))
-	reqURL.RawQuery = query.Encode()
+	query.Set("ts", timestamp())
 
-	req, err := http.NewRequest("GET", reqURL.String(), nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+	var history FocusHistoryResponse
+	if err := c.getJSON(ctx, "/api/treeviewer/getFocusHistory", query, &history); err != nil {
+		return nil, err
 	}
 
-	req.Header.Set("Accept", "*/*")
-	req.Header.Set("Referer", "https://www.ancestry.com/")
+	return &history, nil
+}
 
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to make request: %w", err)
-	}
-	defer func() {
-		if err := resp.Body.Close(); err != nil {
-			fmt.Printf("Error closing response body: %v\n", err)
-		}
-	}()
+// DefaultCommentsBatchSize is how many person IDs GetComments sends per request by default.
+// Each ID is a pid query parameter, so much larger batches risk a URL the server rejects.
+const DefaultCommentsBatchSize = 50
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
+// GetComments retrieves comments for multiple persons in a tree, keyed by person ID. The IDs
+// are sent batchSize per request (DefaultCommentsBatchSize if batchSize is less than 1) and
+// the responses merged; lists found under the same key in several responses are concatenated.
+func (c *APIClient) GetComments(ctx context.Context, treeID string, personIDs []string, batchSize int) (map[string]interface{}, error) {
+	if batchSize < 1 {
+		batchSize = DefaultCommentsBatchSize
 	}
 
-	var history FocusHistoryResponse
-	if err := json.NewDecoder(resp.Body).Decode(&history); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	comments := make(map[string]interface{})
+	for start := 0; start < len(personIDs); start += batchSize {
+		end := start + batchSize
+		if end > len(personIDs) {
+			end = len(personIDs)
+		}
+		batch, err := c.getCommentsBatch(ctx, treeID, personIDs[start:end])
+		if err != nil {
+			return nil, fmt.Errorf("failed to get comments for persons %d-%d of %d: %w", start+1, end, len(personIDs), err)
+		}
+		mergeComments(comments, batch)
 	}
 
-	return &history, nil
+	return comments, nil
 }
 
-// GetComments retrieves comments for multiple persons in a tree
-func (c *APIClient) GetComments(treeID string, personIDs []string) (map[string]interface{}, error) {
-	endpoint := fmt.Sprintf("%s/api/treeviewer/comments/tree/%s", c.baseURL, treeID)
-
-	reqURL, err := url.Parse(endpoint)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse URL: %w", err)
-	}
-
-	query := reqURL.Query()
+// getCommentsBatch retrieves comments for persons in a single request
+func (c *APIClient) getCommentsBatch(ctx context.Context, treeID string, personIDs []string) (map[string]interface{}, error) {
+	query := url.Values{}
 	for _, pid := range personIDs {
 		query.Add("pid", pid)
 	}
-	reqURL.RawQuery = query.Encode()
 
-	req, err := http.NewRequest("GET", reqURL.String(), nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+	var comments map[string]interface{}
+	if err := c.getJSON(ctx, fmt.Sprintf("/api/treeviewer/comments/tree/%s", treeID), query, &comments); err != nil {
+		return nil, err
 	}
 
-	req.Header.Set("Accept", "*/*")
-	req.Header.Set("Referer", "https://www.ancestry.com/")
+	return comments, nil
+}
 
-	resp, err := c.httpClient.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to make request: %w", err)
-	}
-	defer func() {
-		if err := resp.Body.Close(); err != nil {
-			fmt.Printf("Error closing response body: %v\n", err)
+// mergeComments adds a batch's comments to merged, concatenating lists under the same key
+func mergeComments(merged, batch map[string]interface{}) {
+	for key, value := range batch {
+		existing, hasExisting := merged[key].([]interface{})
+		list, isList := value.([]interface{})
+		if hasExisting && isList {
+			merged[key] = append(existing, list...)
+			continue
 		}
-	}()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(body))
-	}
-
-	var comments map[string]interface{}
-	if err := json.NewDecoder(resp.Body).Decode(&comments); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+		merged[key] = value
 	}
-
-	return comments, nil
 }
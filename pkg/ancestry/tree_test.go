@@ -0,0 +1,184 @@
+package ancestry
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestListTreesResponseShapes(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{"bare array", `[{"id":"111","name":"Smith Family"},{"id":"222","name":"Jones Family"}]`},
+		{"wrapped", `{"trees":[{"id":"111","name":"Smith Family"},{"id":"222","name":"Jones Family"}],"count":2}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			trees, err := newTestClient(server).ListTrees(context.Background())
+			if err != nil {
+				t.Fatalf("ListTrees() error: %v", err)
+			}
+			if len(trees) != 2 || trees[0].ID != "111" || trees[1].Name != "Jones Family" {
+				t.Errorf("unexpected trees: %+v", trees)
+			}
+		})
+	}
+}
+
+func TestDecodeTreeListInvalid(t *testing.T) {
+	for _, body := range []string{
+		`"not a tree list"`,
+		`{"error":"Unauthorized"}`,
+		`{"count":2}`,
+		`{"trees":"not a list"}`,
+	} {
+		if _, err := decodeTreeList([]byte(body)); err == nil {
+			t.Errorf("decodeTreeList(%s): expected an error", body)
+		}
+	}
+}
+
+func TestGetFamilyViewBatch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		focus := r.URL.Query().Get("focusPersonId")
+		if focus == "2" {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"v":"3.0","Persons":[{"gid":{"v":"` + focus + `:1030:1"}}]}`))
+	}))
+	defer server.Close()
+
+	focusIDs := []string{"1", "2", "3", "4", "5", "6"}
+	results := newTestClient(server).GetFamilyViewBatch(context.Background(), "1030", focusIDs, 1, 1, 2)
+	if len(results) != len(focusIDs) {
+		t.Fatalf("got %d results, want %d", len(results), len(focusIDs))
+	}
+	for i, result := range results {
+		if result.FocusID != focusIDs[i] {
+			t.Errorf("result %d is for %s, want %s", i, result.FocusID, focusIDs[i])
+		}
+		if focusIDs[i] == "2" {
+			if result.Err == nil {
+				t.Error("expected an error for person 2")
+			}
+			continue
+		}
+		if result.Err != nil || result.FamilyView.Persons[0].GetPersonID() != focusIDs[i]+":1030:1" {
+			t.Errorf("unexpected result for %s: %+v", focusIDs[i], result)
+		}
+	}
+}
+
+func TestGetFamilyViewBatchConcurrencyLimit(t *testing.T) {
+	var inFlight, maxInFlight atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			seen := maxInFlight.Load()
+			if n <= seen || maxInFlight.CompareAndSwap(seen, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		focus := r.URL.Query().Get("focusPersonId")
+		_, _ = w.Write([]byte(`{"v":"3.0","Persons":[{"gid":{"v":"` + focus + `:1030:1"}}]}`))
+	}))
+	defer server.Close()
+
+	focusIDs := []string{"1", "2", "3", "4", "5", "6", "7", "8", "9", "10"}
+	for _, concurrency := range []int{0, 1, 3} {
+		maxInFlight.Store(0)
+		results := newTestClient(server).GetFamilyViewBatch(context.Background(), "1030", focusIDs, 1, 1, concurrency)
+		for _, result := range results {
+			if result.Err != nil {
+				t.Fatalf("concurrency %d: %s: %v", concurrency, result.FocusID, result.Err)
+			}
+		}
+		if got, limit := maxInFlight.Load(), int32(max(concurrency, 1)); got > limit {
+			t.Errorf("concurrency %d: %d requests in flight at once, want at most %d", concurrency, got, limit)
+		}
+	}
+}
+
+func TestGetFamilyViewErrorBodies(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		wantErr     bool
+		wantMessage string
+	}{
+		{name: "family view", body: `{"v":"3.0","Persons":[{"gid":{"v":"1:1030:1"}}]}`},
+		{name: "view without persons", body: `{"v":"3.0","Persons":[]}`},
+		{name: "error string", body: `{"error":"Tree not available"}`, wantErr: true, wantMessage: "Tree not available"},
+		{name: "error object", body: `{"error":{"code":500},"message":"Internal error"}`, wantErr: true, wantMessage: "Internal error"},
+		{name: "empty object", body: `{}`, wantErr: true, wantMessage: "empty response"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			familyView, err := newTestClient(server).GetFamilyView(context.Background(), "1030", "1", 1, 1)
+			var viewErr *FamilyViewError
+			if !tt.wantErr {
+				if err != nil || familyView == nil {
+					t.Fatalf("GetFamilyView() = %v, %v; want a family view", familyView, err)
+				}
+				return
+			}
+			if !errors.As(err, &viewErr) {
+				t.Fatalf("GetFamilyView() error = %v, want a *FamilyViewError", err)
+			}
+			if viewErr.FocusPersonID != "1" || viewErr.Message != tt.wantMessage {
+				t.Errorf("FamilyViewError = %+v, want message %q", viewErr, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestOpenSharedTree(t *testing.T) {
+	tests := []struct {
+		status     int
+		wantErr    bool
+		wantAccess bool
+	}{
+		{http.StatusOK, false, false},
+		{http.StatusForbidden, true, true},
+		{http.StatusNotFound, true, true},
+		{http.StatusInternalServerError, true, false},
+	}
+	for _, tt := range tests {
+		var path string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			path = r.URL.Path
+			w.WriteHeader(tt.status)
+		}))
+
+		err := newTestClient(server).OpenSharedTree(context.Background(), "42")
+		server.Close()
+
+		if path != "/family-tree/tree/42/family" {
+			t.Errorf("requested %s", path)
+		}
+		if (err != nil) != tt.wantErr || errors.Is(err, ErrSharedTreeAccess) != tt.wantAccess {
+			t.Errorf("status %d: OpenSharedTree() error = %v", tt.status, err)
+		}
+	}
+}
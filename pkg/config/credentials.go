@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/zalando/go-keyring"
 )
@@ -23,6 +24,8 @@ const (
 	CookiesFileName = "cookies.json"
 	// ConfigFileName is the name of the config file
 	ConfigFileName = "config.json"
+	// CacheDirName is the name of the directory in the config directory holding cached tree data
+	CacheDirName = "cache"
 )
 
 var (
@@ -30,6 +33,9 @@ var (
 	ErrCredentialsNotFound = errors.New("credentials not found in keyring")
 	// ErrInvalidCredentials is returned when credentials are invalid
 	ErrInvalidCredentials = errors.New("invalid credentials provided")
+	// ErrKeyringFallback is returned by SaveCredentials when the keyring was unavailable and the
+	// credentials were saved to the encrypted file instead. The credentials were saved.
+	ErrKeyringFallback = errors.New("system keyring unavailable, credentials saved to file")
 )
 
 // Credentials represents a user's Ancestry.com login information
@@ -38,12 +44,39 @@ type Credentials struct {
 	Password string
 }
 
-// SaveCredentials stores the username and password in the system keyring
+// SaveCredentials stores the username and password in the system keyring. Without a usable
+// keyring (or after DisableKeyring) they are stored encrypted in ~/.ancestrydl/credentials.enc.
+// A keyring that can be reached but refuses the credentials is an error; falling back to the file
+// returns an error wrapping ErrKeyringFallback and the keyring's error so the caller can warn.
 func SaveCredentials(username, password string) error {
 	if username == "" || password == "" {
 		return ErrInvalidCredentials
 	}
 
+	if keyringDisabled {
+		return saveCredentialsFile(Credentials{Username: username, Password: password})
+	}
+	if err := saveKeyringCredentials(username, password); err != nil {
+		if keyringAvailable() {
+			return err
+		}
+		if fileErr := saveCredentialsFile(Credentials{Username: username, Password: password}); fileErr != nil {
+			return fmt.Errorf("%w (file fallback also failed: %v)", err, fileErr)
+		}
+		return fmt.Errorf("%w: %v", ErrKeyringFallback, err)
+	}
+	return nil
+}
+
+// keyringAvailable reports whether the system keyring can be reached, i.e. a lookup either
+// finds the stored username or reports that there is none
+func keyringAvailable() bool {
+	_, err := keyring.Get(ServiceName, UsernameKey)
+	return err == nil || errors.Is(err, keyring.ErrNotFound)
+}
+
+// saveKeyringCredentials stores the username and password in the system keyring
+func saveKeyringCredentials(username, password string) error {
 	// Store username
 	if err := keyring.Set(ServiceName, UsernameKey, username); err != nil {
 		return fmt.Errorf("failed to save username: %w", err)
@@ -57,8 +90,23 @@ func SaveCredentials(username, password string) error {
 	return nil
 }
 
-// GetCredentials retrieves the stored username and password from the system keyring
+// GetCredentials retrieves the stored username and password from the system keyring, or from
+// ~/.ancestrydl/credentials.enc when they aren't in the keyring
 func GetCredentials() (*Credentials, error) {
+	if keyringDisabled {
+		return readCredentialsFile()
+	}
+	if creds, err := getKeyringCredentials(); err == nil {
+		return creds, nil
+	}
+
+	// Not in the keyring, or the keyring is unusable: the credentials file is all that's left,
+	// and without one nothing was saved
+	return readCredentialsFile()
+}
+
+// getKeyringCredentials retrieves the stored username and password from the system keyring
+func getKeyringCredentials() (*Credentials, error) {
 	// Retrieve username
 	username, err := keyring.Get(ServiceName, UsernameKey)
 	if err != nil {
@@ -83,21 +131,21 @@ func GetCredentials() (*Credentials, error) {
 	}, nil
 }
 
-// DeleteCredentials removes the stored credentials from the system keyring and cookies file
+// DeleteCredentials removes the stored credentials from the system keyring, the encrypted
+// credentials file and the cookies file
 func DeleteCredentials() error {
 	var errs []error
 
-	// Delete username
-	if err := keyring.Delete(ServiceName, UsernameKey); err != nil {
-		if err != keyring.ErrNotFound {
-			errs = append(errs, fmt.Errorf("failed to delete username: %w", err))
-		}
+	fileExisted, err := deleteCredentialsFile()
+	if err != nil {
+		errs = append(errs, err)
 	}
 
-	// Delete password
-	if err := keyring.Delete(ServiceName, PasswordKey); err != nil {
-		if err != keyring.ErrNotFound {
-			errs = append(errs, fmt.Errorf("failed to delete password: %w", err))
+	if !keyringDisabled {
+		keyringErrs := deleteKeyringCredentials()
+		// Keyring errors are expected where the keyring was unusable and the file was used instead
+		if !fileExisted {
+			errs = append(errs, keyringErrs...)
 		}
 	}
 
@@ -119,6 +167,27 @@ func DeleteCredentials() error {
 	return nil
 }
 
+// deleteKeyringCredentials removes the stored credentials from the system keyring
+func deleteKeyringCredentials() []error {
+	var errs []error
+
+	// Delete username
+	if err := keyring.Delete(ServiceName, UsernameKey); err != nil {
+		if err != keyring.ErrNotFound {
+			errs = append(errs, fmt.Errorf("failed to delete username: %w", err))
+		}
+	}
+
+	// Delete password
+	if err := keyring.Delete(ServiceName, PasswordKey); err != nil {
+		if err != keyring.ErrNotFound {
+			errs = append(errs, fmt.Errorf("failed to delete password: %w", err))
+		}
+	}
+
+	return errs
+}
+
 // getConfigDir returns the path to the config directory (~/.ancestrydl)
 func getConfigDir() (string, error) {
 	homeDir, err := os.UserHomeDir()
@@ -155,6 +224,21 @@ func ensureConfigDir() error {
 	return nil
 }
 
+// GetTreeCacheDir returns the directory for a tree's cached data (~/.ancestrydl/cache/<tree-id>),
+// creating it if needed
+func GetTreeCacheDir(treeID string) (string, error) {
+	configDir, err := getConfigDir()
+	if err != nil {
+		return "", err
+	}
+
+	cacheDir := filepath.Join(configDir, CacheDirName, treeID)
+	if err := os.MkdirAll(cacheDir, 0700); err != nil {
+		return "", fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	return cacheDir, nil
+}
+
 // SaveCookies stores session cookies in ~/.ancestrydl/cookies.json
 func SaveCookies(cookiesJSON string) error {
 	if cookiesJSON == "" {
@@ -203,7 +287,8 @@ func GetCookies() (string, error) {
 
 // Config represents user configuration settings
 type Config struct {
-	DefaultTreeID string `json:"defaultTreeId,omitempty"`
+	DefaultTreeID string               `json:"defaultTreeId,omitempty"`
+	WatchedTrees  map[string]time.Time `json:"watchedTrees,omitempty"` // Last modified time downloaded by watch, keyed by tree ID
 }
 
 // getConfigFilePath returns the full path to the config file
@@ -293,3 +378,28 @@ func GetDefaultTreeID() (string, error) {
 
 	return cfg.DefaultTreeID, nil
 }
+
+// SetLastWatchedModified records the tree modification time that watch last downloaded
+func SetLastWatchedModified(treeID string, modified time.Time) error {
+	cfg, err := GetConfig()
+	if err != nil {
+		return err
+	}
+
+	if cfg.WatchedTrees == nil {
+		cfg.WatchedTrees = make(map[string]time.Time)
+	}
+	cfg.WatchedTrees[treeID] = modified
+	return SaveConfig(cfg)
+}
+
+// GetLastWatchedModified retrieves the tree modification time that watch last downloaded,
+// or the zero time if the tree hasn't been downloaded by watch yet
+func GetLastWatchedModified(treeID string) (time.Time, error) {
+	cfg, err := GetConfig()
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return cfg.WatchedTrees[treeID], nil
+}
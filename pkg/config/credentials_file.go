@@ -0,0 +1,165 @@
+package config
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const (
+	// CredentialsFileName is the name of the encrypted credentials file used when the keyring is unavailable
+	CredentialsFileName = "credentials.enc"
+	// CredentialsKeyFileName is the name of the file holding the key for the credentials file
+	CredentialsKeyFileName = "credentials.key"
+
+	// credentialsKeySize is the size of the credentials file key, for AES-256
+	credentialsKeySize = 32
+)
+
+// keyringDisabled makes credentials go to the encrypted file instead of the system keyring
+var keyringDisabled bool
+
+// DisableKeyring stores credentials in ~/.ancestrydl/credentials.enc instead of the system keyring
+func DisableKeyring() {
+	keyringDisabled = true
+}
+
+// getConfigFile returns the full path to a file in the config directory
+func getConfigFile(name string) (string, error) {
+	configDir, err := getConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(configDir, name), nil
+}
+
+// loadOrCreateCredentialsKey reads the credentials file key, creating a random one if there is none.
+// A key of the wrong size is an error rather than replaced, as that would make credentials.enc unreadable.
+func loadOrCreateCredentialsKey() ([]byte, error) {
+	keyPath, err := getConfigFile(CredentialsKeyFileName)
+	if err != nil {
+		return nil, err
+	}
+	key, err := os.ReadFile(keyPath)
+	if err == nil {
+		if len(key) != credentialsKeySize {
+			return nil, fmt.Errorf("credentials key %s is %d bytes, want %d\n\nDelete it and %s beside it, then log in again",
+				keyPath, len(key), credentialsKeySize, CredentialsFileName)
+		}
+		return key, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read credentials key: %w", err)
+	}
+
+	key = make([]byte, credentialsKeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate credentials key: %w", err)
+	}
+	if err := os.WriteFile(keyPath, key, 0600); err != nil {
+		return nil, fmt.Errorf("failed to write credentials key: %w", err)
+	}
+	return key, nil
+}
+
+// newCredentialsCipher returns an AES-GCM cipher using the credentials file key
+func newCredentialsCipher() (cipher.AEAD, error) {
+	key, err := loadOrCreateCredentialsKey()
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// saveCredentialsFile encrypts the credentials to ~/.ancestrydl/credentials.enc
+func saveCredentialsFile(creds Credentials) error {
+	if err := ensureConfigDir(); err != nil {
+		return err
+	}
+	gcm, err := newCredentialsCipher()
+	if err != nil {
+		return err
+	}
+
+	plaintext, err := json.Marshal(creds)
+	if err != nil {
+		return fmt.Errorf("failed to marshal credentials: %w", err)
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return fmt.Errorf("failed to generate nonce: %w", err)
+	}
+
+	credsPath, err := getConfigFile(CredentialsFileName)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(credsPath, gcm.Seal(nonce, nonce, plaintext, nil), 0600); err != nil {
+		return fmt.Errorf("failed to write credentials file: %w", err)
+	}
+	return nil
+}
+
+// readCredentialsFile decrypts ~/.ancestrydl/credentials.enc, returning ErrCredentialsNotFound if it doesn't exist
+func readCredentialsFile() (*Credentials, error) {
+	credsPath, err := getConfigFile(CredentialsFileName)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(credsPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrCredentialsNotFound
+		}
+		return nil, fmt.Errorf("failed to read credentials file: %w", err)
+	}
+
+	gcm, err := newCredentialsCipher()
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < gcm.NonceSize() {
+		return nil, errors.New("credentials file is corrupt")
+	}
+	plaintext, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt credentials file: %w", err)
+	}
+
+	var creds Credentials
+	if err := json.Unmarshal(plaintext, &creds); err != nil {
+		return nil, fmt.Errorf("failed to parse credentials file: %w", err)
+	}
+	return &creds, nil
+}
+
+// deleteCredentialsFile removes the encrypted credentials file and its key, reporting whether
+// there was a credentials file
+func deleteCredentialsFile() (bool, error) {
+	existed := false
+	for _, name := range []string{CredentialsFileName, CredentialsKeyFileName} {
+		path, err := getConfigFile(name)
+		if err != nil {
+			return existed, err
+		}
+		if err := os.Remove(path); err != nil {
+			if !os.IsNotExist(err) {
+				return existed, fmt.Errorf("failed to delete %s: %w", name, err)
+			}
+			continue
+		}
+		if name == CredentialsFileName {
+			existed = true
+		}
+	}
+	return existed, nil
+}
@@ -0,0 +1,109 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/zalando/go-keyring"
+)
+
+// useTempHome points the config directory at a temporary home for the test
+func useTempHome(t *testing.T) string {
+	t.Helper()
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	return home
+}
+
+// useCredentialsFile stores credentials in the encrypted file for the test
+func useCredentialsFile(t *testing.T) {
+	t.Helper()
+	DisableKeyring()
+	t.Cleanup(func() { keyringDisabled = false })
+}
+
+func TestCredentialsFileRoundTrip(t *testing.T) {
+	home := useTempHome(t)
+	useCredentialsFile(t)
+
+	if err := SaveCredentials("jane@example.com", "s3cret"); err != nil {
+		t.Fatalf("SaveCredentials() error = %v", err)
+	}
+	for _, name := range []string{CredentialsFileName, CredentialsKeyFileName} {
+		info, err := os.Stat(filepath.Join(home, ConfigDirName, name))
+		if err != nil {
+			t.Fatalf("%s not written: %v", name, err)
+		}
+		if perm := info.Mode().Perm(); perm != 0600 {
+			t.Errorf("%s permissions = %o, want 600", name, perm)
+		}
+	}
+
+	creds, err := GetCredentials()
+	if err != nil {
+		t.Fatalf("GetCredentials() error = %v", err)
+	}
+	if creds.Username != "jane@example.com" || creds.Password != "s3cret" {
+		t.Errorf("GetCredentials() = %+v", creds)
+	}
+
+	if err := DeleteCredentials(); err != nil {
+		t.Fatalf("DeleteCredentials() error = %v", err)
+	}
+	if _, err := GetCredentials(); !errors.Is(err, ErrCredentialsNotFound) {
+		t.Errorf("GetCredentials() after delete error = %v, want %v", err, ErrCredentialsNotFound)
+	}
+}
+
+func TestCredentialsKeyWrongSize(t *testing.T) {
+	home := useTempHome(t)
+	useCredentialsFile(t)
+
+	if err := SaveCredentials("jane@example.com", "s3cret"); err != nil {
+		t.Fatal(err)
+	}
+	keyPath := filepath.Join(home, ConfigDirName, CredentialsKeyFileName)
+	if err := os.WriteFile(keyPath, []byte("short"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := GetCredentials(); err == nil {
+		t.Fatal("GetCredentials() succeeded with a truncated key")
+	}
+	if err := SaveCredentials("jane@example.com", "s3cret"); err == nil {
+		t.Fatal("SaveCredentials() replaced a truncated key")
+	}
+	if key, _ := os.ReadFile(keyPath); string(key) != "short" {
+		t.Errorf("key file was overwritten with %d bytes", len(key))
+	}
+}
+
+func TestSaveCredentialsKeyringUnavailable(t *testing.T) {
+	useTempHome(t)
+	keyring.MockInitWithError(errors.New("no secret service"))
+	t.Cleanup(keyring.MockInit)
+
+	if keyringAvailable() {
+		t.Fatal("keyringAvailable() = true for a failing keyring")
+	}
+	if err := SaveCredentials("jane@example.com", "s3cret"); !errors.Is(err, ErrKeyringFallback) {
+		t.Fatalf("SaveCredentials() error = %v, want ErrKeyringFallback", err)
+	}
+	creds, err := GetCredentials()
+	if err != nil {
+		t.Fatalf("GetCredentials() error = %v", err)
+	}
+	if creds.Username != "jane@example.com" {
+		t.Errorf("GetCredentials() = %+v", creds)
+	}
+}
+
+func TestKeyringAvailable(t *testing.T) {
+	keyring.MockInit()
+	if !keyringAvailable() {
+		t.Error("keyringAvailable() = false for an empty keyring")
+	}
+}